@@ -0,0 +1,101 @@
+package ratelimited
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// =============================================================================
+// 自适应限制器 - 由下游信号驱动速率
+// =============================================================================
+
+// AdaptiveLimiter 根据外部控制信号调整速率的限制器
+//
+// 实现 Limiter 接口，可以直接作为链中的一层。控制信号通过 Observe 传入，
+// 表示下游期望的目标速率；限制器在信号越过死区时才调整实际速率，避免闭环控制中
+// 信号的微小波动导致速率来回振荡。下游只能给出成功或失败（如 429、超时）时，
+// 使用 ReportSuccess / ReportFailure 按 AIMD 规则调整速率。
+type AdaptiveLimiter struct {
+	limiter *rate.Limiter
+
+	mu   sync.Mutex
+	band float64 // 相对死区宽度，0 表示不设死区
+
+	// AIMD 反馈 (见 aimd.go)
+	increase      rate.Limit    // 每次成功增加的速率
+	decrease      float64       // 每次失败后速率乘以的系数
+	minLimit      rate.Limit    // 速率下限，0 表示不限制
+	maxLimit      rate.Limit    // 速率上限，0 表示不限制
+	latencyTarget time.Duration // 延迟超过该值视为失败，0 表示不按延迟判断
+}
+
+// AdaptiveOption 自适应限制器的配置选项
+type AdaptiveOption func(*AdaptiveLimiter)
+
+// WithHysteresis 设置速率调整的死区
+//
+// band 是相对于当前速率的比例：目标速率与当前速率之差不超过 band×当前速率时忽略该信号。
+// 例如 0.1 表示只有目标速率偏离当前速率超过 ±10% 时才调整。band <= 0 时每个信号都会生效。
+func WithHysteresis(band float64) AdaptiveOption {
+	return func(a *AdaptiveLimiter) {
+		a.band = max(band, 0)
+	}
+}
+
+// NewAdaptiveLimiter 创建初始速率为 initial、突发容量为 burst 的自适应限制器
+func NewAdaptiveLimiter(initial rate.Limit, burst int, opts ...AdaptiveOption) *AdaptiveLimiter {
+	a := &AdaptiveLimiter{
+		limiter:  rate.NewLimiter(initial, burst),
+		increase: initial / 10,
+		decrease: 0.5,
+	}
+	for _, opt := range opts {
+		opt(a)
+	}
+	return a
+}
+
+// WaitN 实现 Limiter 接口
+func (a *AdaptiveLimiter) WaitN(ctx context.Context, n int) error {
+	return a.limiter.WaitN(ctx, n)
+}
+
+// Limit 返回当前速率
+func (a *AdaptiveLimiter) Limit() rate.Limit {
+	return a.limiter.Limit()
+}
+
+// Burst 返回突发容量
+func (a *AdaptiveLimiter) Burst() int {
+	return a.limiter.Burst()
+}
+
+// Observe 传入控制信号，target 为下游期望的目标速率
+//
+// 目标速率落在当前速率的死区内时被忽略，否则速率立即调整为 target。
+// 返回本次信号是否改变了速率。
+func (a *AdaptiveLimiter) Observe(target rate.Limit) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.recompute(target)
+}
+
+// recompute 按死区判断是否采用新的目标速率，调用方必须持有锁
+func (a *AdaptiveLimiter) recompute(target rate.Limit) bool {
+	current := a.limiter.Limit()
+	if target == current {
+		return false
+	}
+	if current != rate.Inf && target != rate.Inf {
+		delta := math.Abs(float64(target - current))
+		if delta <= a.band*float64(current) {
+			return false
+		}
+	}
+	a.limiter.SetLimit(target)
+	return true
+}
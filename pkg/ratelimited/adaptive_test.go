@@ -0,0 +1,45 @@
+package ratelimited
+
+import (
+	"testing"
+
+	"golang.org/x/time/rate"
+)
+
+// TestAdaptiveLimiter_Hysteresis 测试死区内的信号不改变速率
+//
+// 测试目标：
+//   - 在 ±10% 死区内振荡的信号不改变速率
+//   - 越过死区的信号立即调整速率
+func TestAdaptiveLimiter_Hysteresis(t *testing.T) {
+	// Arrange
+	limiter := NewAdaptiveLimiter(1000, 1000, WithHysteresis(0.1))
+
+	// Act & Assert: 小幅振荡
+	for _, signal := range []rate.Limit{1050, 950, 1080, 920, 1000, 1099} {
+		assertEqual(t, false, limiter.Observe(signal), "死区内的信号不应该生效")
+	}
+	assertEqual(t, rate.Limit(1000), limiter.Limit(), "小幅振荡后速率应该保持不变")
+
+	// 越过死区
+	assertEqual(t, true, limiter.Observe(1500), "越过死区的信号应该生效")
+	assertEqual(t, rate.Limit(1500), limiter.Limit(), "速率应该调整为目标值")
+
+	// 死区以新速率为中心
+	assertEqual(t, false, limiter.Observe(1400), "新死区内的信号不应该生效")
+	assertEqual(t, true, limiter.Observe(1200), "越过新死区的信号应该生效")
+	assertEqual(t, rate.Limit(1200), limiter.Limit(), "速率应该向下调整")
+}
+
+// TestAdaptiveLimiter_NoHysteresis 测试未设置死区时每个信号都生效
+func TestAdaptiveLimiter_NoHysteresis(t *testing.T) {
+	// Arrange
+	limiter := NewAdaptiveLimiter(1000, 1000)
+
+	// Act
+	changed := limiter.Observe(1001)
+
+	// Assert
+	assertEqual(t, true, changed, "没有死区时微小变化也应该生效")
+	assertEqual(t, rate.Limit(1001), limiter.Limit(), "速率应该被调整")
+}
@@ -0,0 +1,87 @@
+package ratelimited
+
+import (
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// =============================================================================
+// AIMD 反馈 - 成功时加性增加、失败时乘性减少
+// =============================================================================
+
+// WithAIMD 设置 AIMD 调整的参数
+//
+// 每次 ReportSuccess 把速率增加 increase，每次 ReportFailure 把速率乘以 decrease
+// （取值在 (0, 1) 之间）。默认 increase 为初始速率的 10%，decrease 为 0.5。
+func WithAIMD(increase rate.Limit, decrease float64) AdaptiveOption {
+	return func(a *AdaptiveLimiter) {
+		if increase > 0 {
+			a.increase = increase
+		}
+		if decrease > 0 && decrease < 1 {
+			a.decrease = decrease
+		}
+	}
+}
+
+// WithRateBounds 限制 AIMD 调整后的速率范围，0 表示该方向不限制
+//
+// 设置下限可以避免连续失败把速率降到几乎为零、长时间无法恢复。
+func WithRateBounds(minLimit, maxLimit rate.Limit) AdaptiveOption {
+	return func(a *AdaptiveLimiter) {
+		a.minLimit = minLimit
+		a.maxLimit = maxLimit
+	}
+}
+
+// WithLatencyTarget 设置 ObserveLatency 的判断阈值，超过 target 的延迟视为失败
+func WithLatencyTarget(target time.Duration) AdaptiveOption {
+	return func(a *AdaptiveLimiter) {
+		a.latencyTarget = target
+	}
+}
+
+// ReportSuccess 报告一次成功的下游调用，速率加性增加，返回调整后的速率
+//
+// AIMD 调整不受死区限制。速率为 rate.Inf 时保持不变。
+func (a *AdaptiveLimiter) ReportSuccess() rate.Limit {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.adjust(a.limiter.Limit() + a.increase)
+}
+
+// ReportFailure 报告一次失败的下游调用（如 429 或超时），速率乘性减少，返回调整后的速率
+//
+// 并发的多个请求同时失败时每次报告都会减少速率，调用方可以只对每个时间窗口的
+// 第一次失败调用 ReportFailure，避免速率被一次拥塞压得过低。
+func (a *AdaptiveLimiter) ReportFailure() rate.Limit {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.adjust(a.limiter.Limit() * rate.Limit(a.decrease))
+}
+
+// ObserveLatency 按下游延迟报告一次调用：超过 WithLatencyTarget 设置的阈值视为失败，否则视为成功
+//
+// 未设置阈值时总是视为成功。返回调整后的速率。
+func (a *AdaptiveLimiter) ObserveLatency(d time.Duration) rate.Limit {
+	if a.latencyTarget > 0 && d > a.latencyTarget {
+		return a.ReportFailure()
+	}
+	return a.ReportSuccess()
+}
+
+// adjust 把速率设为限制在上下限内的 target，调用方必须持有锁
+func (a *AdaptiveLimiter) adjust(target rate.Limit) rate.Limit {
+	if a.limiter.Limit() == rate.Inf {
+		return rate.Inf
+	}
+	if a.maxLimit > 0 {
+		target = min(target, a.maxLimit)
+	}
+	if a.minLimit > 0 {
+		target = max(target, a.minLimit)
+	}
+	a.limiter.SetLimit(target)
+	return target
+}
@@ -0,0 +1,39 @@
+package ratelimited
+
+import (
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// TestAdaptiveLimiter_AIMD 测试成功时加性增加、失败时减半
+//
+// 测试目标：
+//   - 每次成功增加固定的速率
+//   - 每次失败把速率乘以减少系数
+//   - 调整不受死区限制，且被限制在上下限之内
+func TestAdaptiveLimiter_AIMD(t *testing.T) {
+	// Arrange
+	limiter := NewAdaptiveLimiter(1000, 1000,
+		WithHysteresis(0.5), WithAIMD(100, 0.5), WithRateBounds(300, 1250))
+
+	// Act & Assert
+	assertEqual(t, rate.Limit(1100), limiter.ReportSuccess(), "成功后应该加性增加")
+	assertEqual(t, rate.Limit(1200), limiter.ReportSuccess(), "死区不应该阻止 AIMD 调整")
+	assertEqual(t, rate.Limit(1250), limiter.ReportSuccess(), "不应该超过上限")
+	assertEqual(t, rate.Limit(625), limiter.ReportFailure(), "失败后应该减半")
+	assertEqual(t, rate.Limit(312.5), limiter.ReportFailure(), "再次失败继续减半")
+	assertEqual(t, rate.Limit(300), limiter.ReportFailure(), "不应该低于下限")
+	assertEqual(t, rate.Limit(300), limiter.Limit(), "底层限制器的速率应该同步调整")
+}
+
+// TestAdaptiveLimiter_ObserveLatency 测试按延迟阈值判断成功或失败
+func TestAdaptiveLimiter_ObserveLatency(t *testing.T) {
+	// Arrange: 默认每次成功增加初始速率的 10%
+	limiter := NewAdaptiveLimiter(1000, 1000, WithLatencyTarget(100*time.Millisecond))
+
+	// Act & Assert
+	assertEqual(t, rate.Limit(1100), limiter.ObserveLatency(50*time.Millisecond), "低于阈值视为成功")
+	assertEqual(t, rate.Limit(550), limiter.ObserveLatency(200*time.Millisecond), "超过阈值视为失败")
+}
@@ -0,0 +1,72 @@
+package ratelimited
+
+import (
+	"context"
+	"time"
+)
+
+// WithLimiterWaitAlert 设置单层限制器等待告警
+//
+// 链中任意一个限制器的单次 WaitN 耗时超过 threshold 时，以该层名称和等待时长调用 fn，
+// 用于定位配置不当、成为瓶颈的层级。名称来自 WithLimiterNames，未命名的层使用
+// "limiter[i]" 形式的位置名称。fn 在独立的 goroutine 中调用，不持有任何锁，
+// 也不会阻塞写入；调用方需要自行处理并发。
+func WithLimiterWaitAlert(threshold time.Duration, fn func(name string, waited time.Duration)) DiscardWriterOption {
+	return func(w *DiscardWriter) {
+		w.waitAlertThreshold = threshold
+		w.waitAlert = fn
+	}
+}
+
+// LayerWaitObserver 单层限制器等待的同步观察者
+//
+// 链中任意一个限制器的单次等待结束后，写入器在写入流程中同步调用所有观察者，
+// 可以从 ctx 中取得追踪 span 等请求范围的数据。同一次写入的多个不可预留层可能
+// 并发调用观察者，实现必须是并发安全的，且应尽量廉价。
+type LayerWaitObserver interface {
+	// ObserveLayerWait 记录一次单层等待：ctx 为本次写入的上下文，name 为层级名称
+	ObserveLayerWait(ctx context.Context, name string, waited time.Duration)
+}
+
+// LayerWaitObserverFunc 将普通函数适配为 LayerWaitObserver
+type LayerWaitObserverFunc func(ctx context.Context, name string, waited time.Duration)
+
+// ObserveLayerWait 实现 LayerWaitObserver 接口
+func (f LayerWaitObserverFunc) ObserveLayerWait(ctx context.Context, name string, waited time.Duration) {
+	f(ctx, name, waited)
+}
+
+// WithLayerWaitObservers 注册单层等待观察者，可多次调用追加，按注册顺序调用
+//
+// 每次发生了等待（waited > 0）的单层申请都会通知所有观察者。与 WithLimiterWaitAlert
+// 不同，观察者不占用告警的唯一位置，多个指标或追踪后端可以同时接入同一个写入器。
+func WithLayerWaitObservers(observers ...LayerWaitObserver) DiscardWriterOption {
+	return func(w *DiscardWriter) {
+		for _, o := range observers {
+			if o != nil {
+				w.waitObservers = append(w.waitObservers, layerWaitObserver{observer: o})
+			}
+		}
+	}
+}
+
+// WithLayerWaitObserver 注册只观察超过 threshold 的单层等待的同步观察函数，可多次调用追加
+//
+// 链中任意一个限制器的单次等待超过 threshold 时，以本次写入的上下文、层名称和等待时长调用 fn，
+// 调用约定与 LayerWaitObserver 相同；fn 返回时等待已经结束。
+func WithLayerWaitObserver(threshold time.Duration, fn func(ctx context.Context, name string, waited time.Duration)) DiscardWriterOption {
+	return func(w *DiscardWriter) {
+		if fn != nil {
+			w.waitObservers = append(w.waitObservers, layerWaitObserver{
+				observer:  LayerWaitObserverFunc(fn),
+				threshold: threshold,
+			})
+		}
+	}
+}
+
+// layerWaitObserver 带阈值的单层等待观察者
+type layerWaitObserver struct {
+	observer  LayerWaitObserver
+	threshold time.Duration
+}
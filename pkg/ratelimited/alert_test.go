@@ -0,0 +1,152 @@
+package ratelimited
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestWithLimiterWaitAlert_SlowLayer 测试慢速层触发告警并携带正确名称
+//
+// 测试目标：
+//   - 只有超过阈值的层触发告警
+//   - 告警携带 WithLimiterNames 设置的名称与等待时长
+func TestWithLimiterWaitAlert_SlowLayer(t *testing.T) {
+	// Arrange
+	clock := newFakeClock()
+	fastLayer := &advancingLimiter{clock: clock}
+	slowLayer := &advancingLimiter{clock: clock, delay: 200 * time.Millisecond}
+
+	type alert struct {
+		name   string
+		waited time.Duration
+	}
+	alerts := make(chan alert, 4)
+
+	writer := NewDiscardWriter([]Limiter{fastLayer, slowLayer},
+		WithClock(clock),
+		WithLimiterNames([]string{"global", "tenant"}),
+		WithLimiterWaitAlert(100*time.Millisecond, func(name string, waited time.Duration) {
+			alerts <- alert{name: name, waited: waited}
+		}),
+	)
+
+	// Act
+	_, err := writer.Write(createTestData(10))
+	assertNoError(t, err, "写入应该成功")
+
+	// Assert
+	select {
+	case got := <-alerts:
+		assertEqual(t, alert{name: "tenant", waited: 200 * time.Millisecond}, got, "告警应该指向慢速层")
+	case <-time.After(2 * time.Second):
+		t.Fatal("慢速层应该触发告警")
+	}
+	select {
+	case got := <-alerts:
+		t.Errorf("快速层不应该触发告警: %+v", got)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+// TestWithLimiterWaitAlert_UnnamedLayer 测试未命名层使用位置名称
+func TestWithLimiterWaitAlert_UnnamedLayer(t *testing.T) {
+	// Arrange
+	clock := newFakeClock()
+	names := make(chan string, 1)
+	writer := NewDiscardWriter([]Limiter{
+		&advancingLimiter{clock: clock},
+		&advancingLimiter{clock: clock, delay: time.Second},
+	},
+		WithClock(clock),
+		WithLimiterWaitAlert(time.Millisecond, func(name string, waited time.Duration) {
+			names <- name
+		}),
+	)
+
+	// Act
+	_, err := writer.Write(createTestData(10))
+	assertNoError(t, err, "写入应该成功")
+
+	// Assert
+	select {
+	case name := <-names:
+		assertEqual(t, "limiter[1]", name, "未命名层应该使用位置名称")
+	case <-time.After(2 * time.Second):
+		t.Fatal("慢速层应该触发告警")
+	}
+}
+
+// TestWithLayerWaitObserver 测试等待观察函数同步调用并收到写入的上下文
+//
+// 测试目标：
+//   - 只有超过阈值的层触发观察，写入返回前观察已经完成
+//   - 观察函数收到 WithContext 设置的上下文
+func TestWithLayerWaitObserver(t *testing.T) {
+	// Arrange
+	type ctxKey struct{}
+	clock := newFakeClock()
+	ctx := context.WithValue(context.Background(), ctxKey{}, "trace-1")
+
+	var observed []string
+	writer := NewDiscardWriter([]Limiter{
+		&advancingLimiter{clock: clock},
+		&advancingLimiter{clock: clock, delay: 200 * time.Millisecond},
+	},
+		WithClock(clock),
+		WithContext(ctx),
+		WithLimiterNames([]string{"global", "tenant"}),
+		WithLayerWaitObserver(100*time.Millisecond, func(ctx context.Context, name string, waited time.Duration) {
+			observed = append(observed, fmt.Sprintf("%v/%s/%s", ctx.Value(ctxKey{}), name, waited))
+		}),
+	)
+
+	// Act
+	_, err := writer.Write(createTestData(10))
+
+	// Assert
+	assertNoError(t, err, "写入应该成功")
+	assertEqual(t, "trace-1/tenant/200ms", strings.Join(observed, ","), "只有慢速层应该被同步观察")
+}
+
+// TestWithLayerWaitObservers_Append 测试多个等待观察者追加注册、各自按阈值触发
+//
+// 测试目标：
+//   - WithLayerWaitObservers 与 WithLayerWaitObserver 多次调用都是追加，不互相覆盖
+//   - 无阈值的观察者收到所有发生了等待的层，带阈值的只收到超过阈值的层
+func TestWithLayerWaitObservers_Append(t *testing.T) {
+	// Arrange
+	clock := newFakeClock()
+	// 不可预留的层并发等待，观察顺序不确定
+	var mu sync.Mutex
+	var all, slow []string
+	record := func(dst *[]string) LayerWaitObserverFunc {
+		return func(_ context.Context, name string, _ time.Duration) {
+			mu.Lock()
+			defer mu.Unlock()
+			*dst = append(*dst, name)
+		}
+	}
+	writer := NewDiscardWriter([]Limiter{
+		&advancingLimiter{clock: clock, delay: 50 * time.Millisecond},
+		&advancingLimiter{clock: clock, delay: 200 * time.Millisecond},
+	},
+		WithClock(clock),
+		WithLimiterNames([]string{"global", "tenant"}),
+		WithLayerWaitObservers(record(&all)),
+		WithLayerWaitObserver(100*time.Millisecond, record(&slow)),
+	)
+
+	// Act
+	_, err := writer.Write(createTestData(10))
+
+	// Assert
+	assertNoError(t, err, "写入应该成功")
+	sort.Strings(all)
+	assertEqual(t, "global,tenant", strings.Join(all, ","), "无阈值的观察者应该收到所有等待")
+	assertEqual(t, "tenant", strings.Join(slow, ","), "带阈值的观察者只收到慢速层")
+}
@@ -0,0 +1,108 @@
+package ratelimited
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// =============================================================================
+// 维护窗口 - 按挂钟时间硬性停止写入
+// =============================================================================
+
+// ErrBlackout 在维护窗口内写入且配置为快速失败时返回
+var ErrBlackout = errors.New("ratelimited: write rejected during blackout window")
+
+// Window 一天中的时间段
+//
+// Start 与 End 为距当天零点的偏移量，时区取自时钟返回时间的 Location。
+// Start 大于 End 时表示跨越午夜的时间段，例如 23:00 到次日 01:00。
+type Window struct {
+	Start time.Duration `json:"start"`
+	End   time.Duration `json:"end"`
+}
+
+// Contains 判断 t 是否落在时间段内（包含 Start，不包含 End）
+func (win Window) Contains(t time.Time) bool {
+	return win.remaining(t) > 0
+}
+
+// remaining 返回从 t 到时间段结束的时长，t 不在时间段内时返回 0
+func (win Window) remaining(t time.Time) time.Duration {
+	offset := t.Sub(startOfDay(t))
+
+	switch {
+	case win.Start <= win.End:
+		if offset >= win.Start && offset < win.End {
+			return win.End - offset
+		}
+	case offset >= win.Start:
+		// 跨午夜：位于前半段，结束于次日
+		return 24*time.Hour - offset + win.End
+	case offset < win.End:
+		// 跨午夜：位于后半段
+		return win.End - offset
+	}
+	return 0
+}
+
+// startOfDay 返回 t 所在日期的零点
+func startOfDay(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+}
+
+// WithBlackoutWindows 设置维护窗口
+//
+// 维护窗口内的写入不受速率影响地被硬性停止：默认阻塞到窗口结束（响应上下文取消），
+// 配合 WithBlackoutFailFast(true) 时立即返回 ErrBlackout。
+// 与按时间调整速率不同，窗口内不会放行任何数据。
+func WithBlackoutWindows(windows []Window) DiscardWriterOption {
+	return func(w *DiscardWriter) {
+		w.blackouts = windows
+	}
+}
+
+// WithBlackoutFailFast 设置维护窗口内是否立即返回 ErrBlackout 而不是阻塞
+func WithBlackoutFailFast(enabled bool) DiscardWriterOption {
+	return func(w *DiscardWriter) {
+		w.blackoutFailFast = enabled
+	}
+}
+
+// waitBlackout 在维护窗口内阻塞或失败，窗口外立即返回
+func (w *DiscardWriter) waitBlackout(ctx context.Context) error {
+	for {
+		d := w.blackoutRemaining(w.clock.Now())
+		if d <= 0 {
+			return nil
+		}
+		if w.blackoutFailFast {
+			return ErrBlackout
+		}
+		if isNonBlocking(ctx) {
+			return ErrWouldBlock
+		}
+		if err := w.exceedsMaxWait(d); err != nil {
+			return err
+		}
+
+		// 等待窗口结束后重新检查，以处理首尾相接的多个窗口
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-w.clock.After(d):
+		}
+	}
+}
+
+// blackoutRemaining 返回 t 所在的所有维护窗口中最晚结束的剩余时长
+func (w *DiscardWriter) blackoutRemaining(t time.Time) time.Duration {
+	var longest time.Duration
+	for _, win := range w.blackouts {
+		if d := win.remaining(t); d > longest {
+			longest = d
+		}
+	}
+	return longest
+}
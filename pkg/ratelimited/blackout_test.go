@@ -0,0 +1,125 @@
+package ratelimited
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// TestWindow_Contains 测试时间段判断，包括跨午夜的情况
+func TestWindow_Contains(t *testing.T) {
+	day := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name     string
+		window   Window
+		at       time.Duration
+		expected bool
+	}{
+		{"普通窗口内", Window{Start: time.Hour, End: 2 * time.Hour}, 90 * time.Minute, true},
+		{"普通窗口起点", Window{Start: time.Hour, End: 2 * time.Hour}, time.Hour, true},
+		{"普通窗口终点", Window{Start: time.Hour, End: 2 * time.Hour}, 2 * time.Hour, false},
+		{"跨午夜前半段", Window{Start: 23 * time.Hour, End: time.Hour}, 23*time.Hour + 30*time.Minute, true},
+		{"跨午夜后半段", Window{Start: 23 * time.Hour, End: time.Hour}, 30 * time.Minute, true},
+		{"跨午夜窗口外", Window{Start: 23 * time.Hour, End: time.Hour}, 12 * time.Hour, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assertEqual(t, tc.expected, tc.window.Contains(day.Add(tc.at)), "时间段判断应该正确")
+		})
+	}
+}
+
+// TestWithBlackoutWindows_FailFast 测试快速失败模式在窗口边界上的行为
+func TestWithBlackoutWindows_FailFast(t *testing.T) {
+	// Arrange: 虚拟时钟从零点开始，维护窗口为 01:00-02:00
+	clock := newFakeClock()
+	writer := NewDiscardWriter([]Limiter{&countingLimiter{}},
+		WithClock(clock),
+		WithBlackoutWindows([]Window{{Start: time.Hour, End: 2 * time.Hour}}),
+		WithBlackoutFailFast(true),
+	)
+	data := createTestData(10)
+
+	// Act & Assert: 窗口开始前
+	clock.Advance(time.Hour - time.Second)
+	_, err := writer.Write(data)
+	assertNoError(t, err, "窗口开始前写入应该成功")
+
+	// 进入窗口
+	clock.Advance(time.Second)
+	n, err := writer.Write(data)
+	assertEqual(t, ErrBlackout, err, "窗口起点应该返回 ErrBlackout")
+	assertEqual(t, 0, n, "窗口内不应该写入任何数据")
+
+	// 窗口结束
+	clock.Advance(time.Hour)
+	_, err = writer.Write(data)
+	assertNoError(t, err, "窗口结束后写入应该成功")
+}
+
+// TestWithBlackoutWindows_Blocking 测试阻塞模式等待窗口结束
+func TestWithBlackoutWindows_Blocking(t *testing.T) {
+	// Arrange
+	clock := newFakeClock()
+	clock.Advance(90 * time.Minute) // 位于 01:00-02:00 窗口内
+	writer := NewDiscardWriter([]Limiter{&countingLimiter{}},
+		WithClock(clock),
+		WithBlackoutWindows([]Window{{Start: time.Hour, End: 2 * time.Hour}}),
+	)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := writer.Write(createTestData(10))
+		done <- err
+	}()
+
+	// Act: 写入应该阻塞在窗口结束的等待上
+	clock.waitForWaiters(t, 1)
+	select {
+	case err := <-done:
+		t.Fatalf("窗口内写入不应该返回: %v", err)
+	default:
+	}
+
+	clock.Advance(30 * time.Minute)
+
+	// Assert
+	select {
+	case err := <-done:
+		assertNoError(t, err, "窗口结束后写入应该成功")
+	case <-time.After(2 * time.Second):
+		t.Fatal("窗口结束后写入应该被放行")
+	}
+}
+
+// TestWithBlackoutWindows_ContextCancel 测试阻塞期间响应上下文取消
+func TestWithBlackoutWindows_ContextCancel(t *testing.T) {
+	// Arrange
+	clock := newFakeClock()
+	ctx, cancel := context.WithCancel(context.Background())
+	writer := NewDiscardWriter([]Limiter{&countingLimiter{}},
+		WithContext(ctx),
+		WithClock(clock),
+		WithBlackoutWindows([]Window{{Start: 0, End: time.Hour}}),
+	)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := writer.Write(createTestData(10))
+		done <- err
+	}()
+
+	// Act
+	clock.waitForWaiters(t, 1)
+	cancel()
+
+	// Assert
+	select {
+	case err := <-done:
+		assertEqual(t, context.Canceled, err, "应该返回上下文取消错误")
+	case <-time.After(2 * time.Second):
+		t.Fatal("取消上下文后写入应该返回")
+	}
+}
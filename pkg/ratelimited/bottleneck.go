@@ -0,0 +1,66 @@
+package ratelimited
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// =============================================================================
+// 瓶颈识别 - 按层级累计等待时长
+// =============================================================================
+
+// recordLayerWait 把 waited 累计到名为 name 的层级
+func (w *DiscardWriter) recordLayerWait(name string, waited time.Duration) {
+	v, ok := w.layerWaits.Load(name)
+	if !ok {
+		v, _ = w.layerWaits.LoadOrStore(name, new(int64))
+	}
+	atomic.AddInt64(v.(*int64), int64(waited))
+}
+
+// LayerWaits 返回各层级累计等待时长的快照，没有发生过等待的层级不出现在结果中
+//
+// 层级名称来自 WithLimiterNames，未命名的层使用 "limiter[i]"，大写入专用链使用 "large[i]"，
+// 请求速率链使用 "request[i]"。
+func (w *DiscardWriter) LayerWaits() map[string]time.Duration {
+	out := make(map[string]time.Duration)
+	w.layerWaits.Range(func(key, value any) bool {
+		out[key.(string)] = time.Duration(atomic.LoadInt64(value.(*int64)))
+		return true
+	})
+	return out
+}
+
+// Bottleneck 返回累计等待时间最长的层级及其等待时长
+//
+// 该层级就是当前限制吞吐量的层级。从未发生等待时返回空名称和 0；
+// 等待时长相同时返回名称按字典序最小的层级，保证结果稳定。
+func (w *DiscardWriter) Bottleneck() (name string, waited time.Duration) {
+	return bottleneck(w.LayerWaits())
+}
+
+// Bottleneck 返回累计等待时间最长的层级及其等待时长，规则与 DiscardWriter.Bottleneck 相同
+func (m *LayerMetrics) Bottleneck() (name string, waited time.Duration) {
+	waits := make(map[string]time.Duration)
+	for layer, stats := range m.Snapshot() {
+		waits[layer] = stats.Waited
+	}
+	return bottleneck(waits)
+}
+
+// bottleneck 选出等待时长最长的层级
+func bottleneck(waits map[string]time.Duration) (string, time.Duration) {
+	var (
+		name   string
+		waited time.Duration
+	)
+	for layer, d := range waits {
+		if d <= 0 {
+			continue
+		}
+		if d > waited || (d == waited && layer < name) {
+			name, waited = layer, d
+		}
+	}
+	return name, waited
+}
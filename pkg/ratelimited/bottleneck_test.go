@@ -0,0 +1,83 @@
+package ratelimited
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// TestDiscardWriter_Bottleneck 测试按层级累计等待时长并识别瓶颈层级
+//
+// 测试目标：
+//   - 预留层以预留延迟计入等待，速率最低的层被识别为瓶颈
+//   - 不可预留的层以实际 WaitN 耗时计入等待
+//   - 没有发生等待时返回空名称
+func TestDiscardWriter_Bottleneck(t *testing.T) {
+	t.Run("预留层", func(t *testing.T) {
+		// Arrange: 两层突发容量相同，global 的速率只有 conn 的一半
+		clock := newSleepingClock()
+		w := NewDiscardWriter(
+			[]Limiter{rate.NewLimiter(1000, 1000), rate.NewLimiter(500, 1000)},
+			WithLimiterNames([]string{"conn", "global"}),
+			WithBatchSize(1000),
+			WithClock(clock),
+		)
+		name, waited := w.Bottleneck()
+		assertEqual(t, "", name, "没有写入时不应该有瓶颈")
+		assertEqual(t, time.Duration(0), waited, "没有写入时等待时长为 0")
+
+		// Act: 第一批消耗突发容量，第二批需要等待补充
+		_, err := w.Write(createTestData(2000))
+
+		// Assert
+		assertNoError(t, err, "写入")
+		waits := w.LayerWaits()
+		assertEqual(t, time.Second, waits["conn"], "conn 的累计等待")
+		assertEqual(t, 2*time.Second, waits["global"], "global 的累计等待")
+		name, waited = w.Bottleneck()
+		assertEqual(t, "global", name, "速率最低的层应该是瓶颈")
+		assertEqual(t, 2*time.Second, waited, "瓶颈的等待时长")
+	})
+
+	t.Run("不可预留的层", func(t *testing.T) {
+		// Arrange
+		clock := newFakeClock()
+		w := NewDiscardWriter(
+			[]Limiter{&advancingLimiter{clock: clock, delay: 100 * time.Millisecond}},
+			WithBatchSize(100),
+			WithClock(clock),
+		)
+
+		// Act
+		_, err := w.Write(createTestData(300))
+
+		// Assert
+		assertNoError(t, err, "写入")
+		name, waited := w.Bottleneck()
+		assertEqual(t, "limiter[0]", name, "未命名的层使用位置名称")
+		assertEqual(t, 300*time.Millisecond, waited, "应该累计每次 WaitN 的耗时")
+	})
+}
+
+// TestLayerMetrics_Bottleneck 测试指标装饰器的统计同样可以识别瓶颈
+func TestLayerMetrics_Bottleneck(t *testing.T) {
+	// Arrange
+	clock := newFakeClock()
+	metrics := NewLayerMetrics()
+	chain := InstrumentChain([]Limiter{
+		&advancingLimiter{clock: clock, delay: time.Second},
+		&advancingLimiter{clock: clock, delay: 3 * time.Second},
+	}, []string{"disk", "uplink"}, metrics, WithMetricsClock(clock))
+
+	// Act
+	for _, limiter := range chain {
+		assertNoError(t, limiter.WaitN(context.Background(), 1), "申请")
+	}
+
+	// Assert
+	name, waited := metrics.Bottleneck()
+	assertEqual(t, "uplink", name, "等待最久的层应该是瓶颈")
+	assertEqual(t, 3*time.Second, waited, "瓶颈的等待时长")
+}
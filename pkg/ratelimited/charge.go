@@ -0,0 +1,22 @@
+package ratelimited
+
+// Charge 为 size 字节执行限流和计费，但不写入任何数据
+//
+// 适用于只知道数据大小而没有 []byte 的场景，例如按行的序列化大小估算
+// 节流数据库查询结果。上下文、维护窗口、共享配额、令牌上限、批量令牌以及
+// 计数器与统计接收端的处理都与写入 size 字节的 Write 相同；没有数据可供检查，
+// 因此令牌成本始终等于 size，不经过成本函数、去重窗口和分类器。
+// 分阶段写入器（WithPhaseLimiters）的计费与写入一样计入阶段边界。
+//
+// 共享配额不足时与 Write 的截断行为一致：按剩余配额部分计费，并返回 ErrQuotaExhausted。
+func (w *DiscardWriter) Charge(size int) error {
+	n, err := w.admitCharged(nil, size)
+	w.countSize(n)
+	if err != nil {
+		return err
+	}
+	if n < size {
+		return ErrQuotaExhausted
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package ratelimited
+
+import (
+	"context"
+	"testing"
+)
+
+// TestDiscardWriter_ChargeMatchesWrite 测试 Charge 与同样大小的 Write 节流一致
+//
+// 测试目标：
+//   - 相同的大小序列产生相同的令牌申请模式
+//   - 字节和请求计数一致
+func TestDiscardWriter_ChargeMatchesWrite(t *testing.T) {
+	sizes := []int{100, 300, 50, 700, 20}
+
+	type outcome struct {
+		calls    int64
+		tokens   int64
+		bytes    int64
+		requests uint64
+	}
+	run := func(useCharge bool) outcome {
+		limiter := &countingLimiter{}
+		var bytesWritten int64
+		var requests uint64
+		writer := NewDiscardWriter([]Limiter{limiter},
+			WithBatchSize(256),
+			WithBytesCounter(&bytesWritten),
+			WithRequestCounter(&requests),
+		)
+		for _, size := range sizes {
+			if useCharge {
+				assertNoError(t, writer.Charge(size), "Charge 应该成功")
+			} else {
+				_, err := writer.Write(createTestData(size))
+				assertNoError(t, err, "Write 应该成功")
+			}
+		}
+		return outcome{limiter.callCount(), limiter.tokenCount(), bytesWritten, requests}
+	}
+
+	// Act
+	charged := run(true)
+	written := run(false)
+
+	// Assert
+	assertEqual(t, written, charged, "Charge 应该与等量的 Write 行为一致")
+}
+
+// TestDiscardWriter_ChargeQuotaAndContext 测试 Charge 遵守配额和上下文
+func TestDiscardWriter_ChargeQuotaAndContext(t *testing.T) {
+	// Arrange
+	quota := int64(150)
+	writer := NewDiscardWriter([]Limiter{&countingLimiter{}}, WithSharedQuota(&quota))
+
+	// Act & Assert: 配额部分覆盖时按剩余配额计费
+	assertNoError(t, writer.Charge(100), "配额内的计费应该成功")
+	assertEqual(t, ErrQuotaExhausted, writer.Charge(100), "配额不足应该返回 ErrQuotaExhausted")
+	assertAtomicEqual(t, 0, &quota, "剩余配额应该被耗尽")
+
+	// 上下文取消后拒绝计费
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	canceled := NewDiscardWriter([]Limiter{&countingLimiter{}}, WithContext(ctx))
+	assertEqual(t, context.Canceled, canceled.Charge(10), "上下文取消应该返回错误")
+}
@@ -0,0 +1,42 @@
+package ratelimited
+
+import "math"
+
+// =============================================================================
+// 内容分类计费 - 按负载类型放大或减免令牌成本
+// =============================================================================
+
+// WithClassifier 设置按内容分类的令牌成本倍数
+//
+// 每次写入调用 classify(p) 得到分类名，令牌成本乘以 multipliers 中该分类的倍数
+// 并向上取整，字节统计和配额仍按实际字节数计算。未出现在 multipliers 中的分类
+// 倍数为 1.0；倍数小于等于 0 的分类为免费写入，不申请令牌。
+// 倍数作用于成本函数（若设置）的结果之上，去重命中的写入仍然免费。
+//
+// classify 在每次写入的热路径上同步执行，调用方需要保证其足够廉价，
+// 例如只检查前缀或魔数而不是对整个负载做正则匹配。classify 为 nil 时禁用分类。
+// multipliers 在设置时被复制，之后修改原 map 不影响写入器。
+func WithClassifier(classify func(p []byte) string, multipliers map[string]float64) DiscardWriterOption {
+	return func(w *DiscardWriter) {
+		w.classify = classify
+		w.classMultipliers = nil
+		if len(multipliers) > 0 {
+			w.classMultipliers = make(map[string]float64, len(multipliers))
+			for class, m := range multipliers {
+				w.classMultipliers[class] = m
+			}
+		}
+	}
+}
+
+// classifiedCost 按 p 的分类倍数调整令牌成本
+func (w *DiscardWriter) classifiedCost(p []byte, cost int64) int64 {
+	m, ok := w.classMultipliers[w.classify(p)]
+	if !ok {
+		return cost
+	}
+	if m <= 0 {
+		return 0
+	}
+	return int64(math.Ceil(float64(cost) * m))
+}
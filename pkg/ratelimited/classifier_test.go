@@ -0,0 +1,66 @@
+package ratelimited
+
+import (
+	"bytes"
+	"testing"
+)
+
+// videoMagic 测试用的视频负载前缀
+var videoMagic = []byte{0xFF, 'V'}
+
+// classifyByMagic 根据魔数前缀区分负载类型
+func classifyByMagic(p []byte) string {
+	switch {
+	case bytes.HasPrefix(p, videoMagic):
+		return "video"
+	case bytes.HasPrefix(p, []byte("ping")):
+		return "heartbeat"
+	default:
+		return "other"
+	}
+}
+
+// TestWithClassifier_Multipliers 测试分类倍数调整令牌成本
+//
+// 测试目标：
+//   - 带魔数前缀的写入按两倍成本申请令牌
+//   - 未知分类按原成本申请，零倍数的分类免费
+//   - 字节统计始终按实际字节数
+func TestWithClassifier_Multipliers(t *testing.T) {
+	video := append(append([]byte{}, videoMagic...), createTestData(8)...)
+
+	testCases := []struct {
+		name           string
+		payload        []byte
+		expectedTokens int64
+		expectedCalls  int64
+	}{
+		{name: "视频负载双倍计费", payload: video, expectedTokens: 20, expectedCalls: 1},
+		{name: "未知分类按原成本", payload: createTestData(10), expectedTokens: 10, expectedCalls: 1},
+		{name: "零倍数免费", payload: []byte("ping-ping!"), expectedTokens: 0, expectedCalls: 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange: 设置共享配额时批次被限制为单次成本，令牌申请量等于计费量
+			limiter := &countingLimiter{}
+			quota := int64(1000)
+			var bytesWritten int64
+			writer := NewDiscardWriter([]Limiter{limiter},
+				WithSharedQuota(&quota),
+				WithClassifier(classifyByMagic, map[string]float64{"video": 2, "heartbeat": 0}),
+				WithBytesCounter(&bytesWritten),
+			)
+
+			// Act
+			n, err := writer.Write(tc.payload)
+
+			// Assert
+			assertNoError(t, err, "写入应该成功")
+			assertEqual(t, len(tc.payload), n, "应该接收全部字节")
+			assertEqual(t, tc.expectedTokens, limiter.tokenCount(), "令牌申请量应该按分类倍数调整")
+			assertEqual(t, tc.expectedCalls, limiter.callCount(), "免费写入不应该申请令牌")
+			assertAtomicEqual(t, int64(len(tc.payload)), &bytesWritten, "字节统计应该按实际字节数")
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package ratelimited
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// Clock 时间源抽象
+//
+// 写入器和包内所有限制器的时间判断与等待都通过 Clock 完成，
+// 测试和模拟中可以注入虚拟时钟，避免依赖真实的 sleep 和易抖动的超时。
+// 各构造函数通过 WithClock、WithGCRAClock 等选项接收时钟，默认使用系统时钟。
+//
+// 接收时钟的组件同样用它驱动 *rate.Limiter，包括写入器链中的层级和组件内部的令牌桶：
+// 预留、归还、查询和调整速率都通过 ReserveN、TokensAt、SetLimitAt 等带时间参数的方法
+// 传入时钟的当前时间，等待也按时钟进行。因此注入虚拟时钟时，同一个 *rate.Limiter
+// 不应再被按实际时间计算的代码访问，例如直接调用它的 WaitN、SetLimit，
+// 或放入 NewAdaptiveLimiter、Controller 等不接收时钟的组件。
+type Clock interface {
+	// Now 返回当前时间
+	Now() time.Time
+	// After 在经过 d 之后向返回的通道发送当前时间
+	After(d time.Duration) <-chan time.Time
+}
+
+// systemClock 基于 time 包的默认时钟
+type systemClock struct{}
+
+func (systemClock) Now() time.Time                         { return time.Now() }
+func (systemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// SystemClock 返回基于 time 包的系统时钟
+func SystemClock() Clock {
+	return systemClock{}
+}
+
+// Sleep 按时钟 c 等待 d，期间 ctx 结束时返回其错误
+//
+// d <= 0 时立即返回 nil。自定义限制器可以用它代替 time.Sleep，以便在虚拟时间中测试。
+func Sleep(ctx context.Context, c Clock, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	select {
+	case <-c.After(d):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// withClockTimeout 返回在时钟 c 上经过 d 后到期的上下文，与 context.WithTimeout 对应
+//
+// 上下文的 Deadline 按 c 的时间计算，与限制器用 c.Now() 判断"等待是否会超过期限"一致；
+// 到期时 Err 返回 context.DeadlineExceeded。父上下文的期限更早时沿用父上下文的期限。
+// 等待到期的 goroutine 在上下文结束时退出，调用方必须调用返回的 CancelFunc。
+func withClockTimeout(ctx context.Context, c Clock, d time.Duration) (context.Context, context.CancelFunc) {
+	deadline := c.Now().Add(d)
+	if parent, ok := ctx.Deadline(); ok && !parent.After(deadline) {
+		return context.WithCancel(ctx)
+	}
+	inner, cancel := context.WithCancelCause(ctx)
+	go func() {
+		select {
+		case <-c.After(d):
+			cancel(context.DeadlineExceeded)
+		case <-inner.Done():
+		}
+	}()
+	return &clockDeadlineContext{Context: inner, deadline: deadline}, func() { cancel(context.Canceled) }
+}
+
+// clockDeadlineContext 期限按时钟计算的上下文
+type clockDeadlineContext struct {
+	context.Context
+	deadline time.Time
+}
+
+func (c *clockDeadlineContext) Deadline() (time.Time, bool) {
+	return c.deadline, true
+}
+
+// Err 在按时钟到期时返回 context.DeadlineExceeded，而不是取消原因对应的 context.Canceled
+func (c *clockDeadlineContext) Err() error {
+	err := c.Context.Err()
+	if err != nil && context.Cause(c.Context) == context.DeadlineExceeded {
+		return context.DeadlineExceeded
+	}
+	return err
+}
+
+// waitRate 按时钟 c 在 l 上等待 n 个令牌
+//
+// 与 l.WaitN 的语义相同：n 超过突发容量或等待会超过 ctx 的 deadline 时不占用令牌并立即返回错误，
+// ctx 在等待期间结束时归还令牌；区别是预留和等待都使用 c 的时间。
+func waitRate(ctx context.Context, c Clock, l *rate.Limiter, n int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	now := c.Now()
+	r := l.ReserveN(now, n)
+	if !r.OK() {
+		return fmt.Errorf("rate: Wait(n=%d) exceeds limiter's burst %d", n, l.Burst())
+	}
+	delay := r.DelayFrom(now)
+	if deadline, ok := ctx.Deadline(); ok && delay > deadline.Sub(now) {
+		r.CancelAt(now)
+		return fmt.Errorf("rate: Wait(n=%d) would exceed context deadline: %w", n, context.DeadlineExceeded)
+	}
+	if err := Sleep(ctx, c, delay); err != nil {
+		r.CancelAt(c.Now())
+		return err
+	}
+	return nil
+}
+
+// WithClock 设置写入器使用的时钟，默认使用系统时钟
+func WithClock(c Clock) DiscardWriterOption {
+	return func(w *DiscardWriter) {
+		if c != nil {
+			w.clock = c
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package ratelimited
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// =============================================================================
+// 虚拟时钟 - 测试辅助工具
+// =============================================================================
+
+// fakeClock 只在 Advance 时推进的虚拟时钟
+type fakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []fakeWaiter
+}
+
+// fakeWaiter 等待虚拟时间到达 at 的 After 调用
+type fakeWaiter struct {
+	at time.Time
+	ch chan time.Time
+}
+
+// newFakeClock 创建起始于固定时间点的虚拟时钟
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- c.now
+		return ch
+	}
+	c.waiters = append(c.waiters, fakeWaiter{at: c.now.Add(d), ch: ch})
+	return ch
+}
+
+// Advance 推进虚拟时间并触发所有到期的等待者
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+	pending := c.waiters[:0]
+	for _, waiter := range c.waiters {
+		if !waiter.at.After(c.now) {
+			waiter.ch <- c.now
+			continue
+		}
+		pending = append(pending, waiter)
+	}
+	c.waiters = pending
+}
+
+// waiterCount 返回尚未到期的等待者数量
+func (c *fakeClock) waiterCount() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.waiters)
+}
+
+// waitForWaiters 阻塞直到至少有 n 个等待者注册，用于同步后台 goroutine
+func (c *fakeClock) waitForWaiters(t *testing.T, n int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for c.waiterCount() < n {
+		if time.Now().After(deadline) {
+			t.Fatalf("等待 %d 个时钟等待者超时", n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// TestFakeClock_After 验证虚拟时钟只在推进时触发等待者
+func TestFakeClock_After(t *testing.T) {
+	clock := newFakeClock()
+	ch := clock.After(time.Second)
+
+	clock.Advance(500 * time.Millisecond)
+	select {
+	case <-ch:
+		t.Fatal("未到期的等待者不应该被触发")
+	default:
+	}
+
+	clock.Advance(500 * time.Millisecond)
+	select {
+	case <-ch:
+	default:
+		t.Fatal("到期的等待者应该被触发")
+	}
+}
+
+// advancingLimiter 每次 WaitN 都把虚拟时钟推进 delay，用于模拟确定性的等待时间
+type advancingLimiter struct {
+	clock *fakeClock
+	delay time.Duration
+}
+
+func (l *advancingLimiter) WaitN(ctx context.Context, n int) error {
+	l.clock.Advance(l.delay)
+	return nil
+}
+
+// sleepingClock 在 After 调用时立即把虚拟时间推进 d 的时钟，模拟同步 sleep
+type sleepingClock struct {
+	fakeClock
+}
+
+// newSleepingClock 创建自动推进的虚拟时钟
+func newSleepingClock() *sleepingClock {
+	return &sleepingClock{fakeClock: *newFakeClock()}
+}
+
+func (c *sleepingClock) After(d time.Duration) <-chan time.Time {
+	c.Advance(d)
+	ch := make(chan time.Time, 1)
+	ch <- c.Now()
+	return ch
+}
+
+// TestSleep 测试 Sleep 按注入的时钟等待并响应上下文取消
+func TestSleep(t *testing.T) {
+	// Arrange
+	clock := newFakeClock()
+	done := make(chan error, 1)
+
+	// Act: 虚拟时间推进前不应该返回
+	go func() { done <- Sleep(context.Background(), clock, time.Second) }()
+	clock.waitForWaiters(t, 1)
+	clock.Advance(time.Second)
+
+	// Assert
+	assertNoError(t, <-done, "时间到达后应该正常返回")
+	assertNoError(t, Sleep(context.Background(), clock, 0), "非正时长应该立即返回")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assertEqual(t, context.Canceled, Sleep(ctx, clock, time.Hour), "上下文取消时应该返回其错误")
+}
+
+// TestClockOptions_VirtualTime 测试各限制器在注入虚拟时钟后按虚拟时间等待
+//
+// 测试目标：
+//   - 令牌耗尽后的等待在虚拟时间推进前不会结束
+//   - 装饰器按虚拟时钟测量等待时长
+func TestClockOptions_VirtualTime(t *testing.T) {
+	ctx := context.Background()
+
+	limiters := map[string]func(c Clock) Limiter{
+		"HTB": func(c Clock) Limiter {
+			return NewHTB(1000, 100, WithHTBClock(c))
+		},
+		"StoreLimiter": func(c Clock) Limiter {
+			return NewStoreLimiter(NewMemoryStore(WithStoreClock(c)), "k", 1000, 100, WithStoreClock(c))
+		},
+	}
+	for name, newLimiter := range limiters {
+		t.Run(name, func(t *testing.T) {
+			// Arrange: 耗尽突发容量
+			clock := newFakeClock()
+			limiter := newLimiter(clock)
+			assertNoError(t, limiter.WaitN(ctx, 100), "突发容量内的申请")
+			done := make(chan error, 1)
+
+			// Act: 再申请 100 个令牌需要虚拟时间 100ms
+			go func() { done <- limiter.WaitN(ctx, 100) }()
+			clock.waitForWaiters(t, 1)
+			select {
+			case <-done:
+				t.Fatal("虚拟时间推进前不应该获得令牌")
+			default:
+			}
+			clock.Advance(100 * time.Millisecond)
+
+			// Assert
+			assertNoError(t, <-done, "虚拟时间推进后应该获得令牌")
+		})
+	}
+
+	t.Run("MetricsLimiter", func(t *testing.T) {
+		// Arrange
+		clock := newFakeClock()
+		metrics := NewLayerMetrics()
+		limiter := NewMetricsLimiter("slow", &advancingLimiter{clock: clock, delay: time.Second}, metrics,
+			WithMetricsClock(clock))
+
+		// Act
+		assertNoError(t, limiter.WaitN(ctx, 1), "申请")
+
+		// Assert
+		assertEqual(t, time.Second, metrics.Layer("slow").Waited, "应该按虚拟时钟测量等待时长")
+	})
+}
@@ -0,0 +1,62 @@
+package ratelimited
+
+import "sync/atomic"
+
+// =============================================================================
+// 显式令牌提交 - 避免批次令牌在长时间空闲后被静默复用
+// =============================================================================
+
+// WithExplicitCommit 启用显式提交模式
+//
+// 默认模式下写入器按 WithBatchSize 批量申请令牌，未用完的令牌留在批次中，
+// 可能在长时间空闲后被用于突发写入。显式提交模式下每次 Write 只申请本次写入
+// 实际消耗的令牌（批次大小等于写入成本），令牌的生命周期完全由调用方控制：
+// 需要批量申请时调用 PreAcquire 暂存，用完后调用 CommitUnused 作废剩余部分。
+// 代价是每次写入都可能调用限制器的 WaitN，吞吐量低于批量模式。
+func WithExplicitCommit(enabled bool) DiscardWriterOption {
+	return func(w *DiscardWriter) {
+		w.explicitCommit = enabled
+	}
+}
+
+// PreAcquire 预先向所有限制器申请 n 个令牌并暂存到当前批次
+//
+// 后续写入优先消耗暂存的令牌，不足部分再向限制器申请。
+// 主要用于显式提交模式；默认模式下暂存的令牌同样可用，批量申请的令牌累加到
+// 批次余额上而不会覆盖暂存部分。暂存的令牌同样会被空闲作废、CommitUnused 或 Release 清空。
+func (w *DiscardWriter) PreAcquire(n int) error {
+	if n <= 0 {
+		return nil
+	}
+	if err := w.ctx.Err(); err != nil {
+		return err
+	}
+	if err := w.waitForTokens(w.ctx, n); err != nil {
+		return err
+	}
+	atomic.AddInt64(&w.remainingTokens, int64(n))
+	return nil
+}
+
+// CommitUnused 作废已申请但尚未使用的令牌，返回作废的数量
+//
+// 作废的令牌不归还给限制器，计入 DroppedTokens，之后的写入重新向限制器申请；
+// 需要把剩余令牌交还给限制器时使用 Release。
+func (w *DiscardWriter) CommitUnused() int64 {
+	return w.dropTokens()
+}
+
+// DroppedTokens 返回累计作废的令牌数（CommitUnused 与空闲作废）
+func (w *DiscardWriter) DroppedTokens() int64 {
+	return atomic.LoadInt64(&w.droppedTokens)
+}
+
+// dropTokens 清空当前批次剩余令牌并计入作废统计
+func (w *DiscardWriter) dropTokens() int64 {
+	dropped := atomic.SwapInt64(&w.remainingTokens, 0)
+	if dropped > 0 {
+		atomic.AddInt64(&w.droppedTokens, dropped)
+		return dropped
+	}
+	return 0
+}
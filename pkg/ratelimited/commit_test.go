@@ -0,0 +1,84 @@
+package ratelimited
+
+import (
+	"testing"
+	"time"
+)
+
+// TestWithExplicitCommit_NoStaleBurst 测试显式提交模式下空闲后没有陈旧令牌
+//
+// 测试目标：
+//   - 默认模式空闲后复用批次中的陈旧令牌
+//   - 显式提交模式每次写入都按写入大小申请，空闲后不会突发
+func TestWithExplicitCommit_NoStaleBurst(t *testing.T) {
+	testCases := []struct {
+		name          string
+		explicit      bool
+		expectedCalls int64
+		expectedTotal int64
+	}{
+		{name: "默认批量模式", explicit: false, expectedCalls: 1, expectedTotal: 1000},
+		{name: "显式提交模式", explicit: true, expectedCalls: 2, expectedTotal: 200},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			clock := newFakeClock()
+			limiter := &countingLimiter{}
+			writer := NewDiscardWriter([]Limiter{limiter},
+				WithClock(clock),
+				WithBatchSize(1000),
+				WithExplicitCommit(tc.explicit),
+			)
+
+			// Act: 写入后长时间空闲再写入
+			_, err := writer.Write(createTestData(100))
+			assertNoError(t, err, "首次写入应该成功")
+			clock.Advance(time.Hour)
+			_, err = writer.Write(createTestData(100))
+			assertNoError(t, err, "空闲后写入应该成功")
+
+			// Assert
+			assertEqual(t, tc.expectedCalls, limiter.callCount(), "令牌申请次数")
+			assertEqual(t, tc.expectedTotal, limiter.tokenCount(), "令牌申请总量")
+		})
+	}
+}
+
+// TestDiscardWriter_PreAcquireCommitUnused 测试暂存令牌与作废未使用的部分
+func TestDiscardWriter_PreAcquireCommitUnused(t *testing.T) {
+	// Arrange
+	limiter := &countingLimiter{}
+	writer := NewDiscardWriter([]Limiter{limiter}, WithExplicitCommit(true))
+
+	// Act
+	assertNoError(t, writer.PreAcquire(100), "暂存应该成功")
+	_, err := writer.Write(createTestData(30))
+	assertNoError(t, err, "写入应该成功")
+	dropped := writer.CommitUnused()
+	_, err = writer.Write(createTestData(30))
+	assertNoError(t, err, "写入应该成功")
+
+	// Assert
+	assertEqual(t, int64(70), dropped, "应该作废未使用的暂存令牌")
+	assertEqual(t, int64(70), writer.DroppedTokens(), "作废的令牌应该计入统计")
+	assertEqual(t, int64(2), limiter.callCount(), "暂存覆盖的写入不应该再次申请")
+	assertEqual(t, int64(130), limiter.tokenCount(), "作废后写入按实际大小申请")
+}
+
+// TestWithExplicitCommit_TopsUpStaged 测试暂存不足时只补足差额
+func TestWithExplicitCommit_TopsUpStaged(t *testing.T) {
+	// Arrange
+	limiter := &countingLimiter{}
+	writer := NewDiscardWriter([]Limiter{limiter}, WithExplicitCommit(true))
+	assertNoError(t, writer.PreAcquire(40), "暂存应该成功")
+
+	// Act
+	_, err := writer.Write(createTestData(100))
+
+	// Assert
+	assertNoError(t, err, "写入应该成功")
+	assertEqual(t, int64(100), limiter.tokenCount(), "应该只补足 60 个令牌")
+	assertEqual(t, int64(0), writer.CommitUnused(), "不应该剩余令牌")
+}
@@ -0,0 +1,32 @@
+package ratelimited_test
+
+import (
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"github.com/lwmacct/250918-go-pkg-ratelimited/pkg/ratelimited"
+	"github.com/lwmacct/250918-go-pkg-ratelimited/pkg/ratelimited/ratelimitedtest"
+)
+
+// TestRateCompliance_ConcurrentBatchAccounting 回归测试：并发写入不能超额消费批次令牌
+//
+// 多个 goroutine 共享同一写入器时，批次令牌的检查与扣除曾经不是原子的：
+// 多个写入可能同时通过检查、共同透支同一批令牌，随后的批量申请又会覆盖透支，
+// 导致准入速率超过限制器链的配置。
+func TestRateCompliance_ConcurrentBatchAccounting(t *testing.T) {
+	if testing.Short() {
+		t.Skip("真实时间的压力测试在 -short 模式下跳过")
+	}
+
+	// Arrange
+	const limit = 200000 // 200 KB/s
+	limiters := ratelimited.Chain(
+		rate.NewLimiter(limit, 20000),
+		rate.NewLimiter(limit*2, 20000),
+	)
+
+	// Act & Assert
+	ratelimitedtest.VerifyRateCompliance(t, limiters, limit, 500*time.Millisecond)
+}
@@ -0,0 +1,131 @@
+package ratelimited
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// =============================================================================
+// 并发限制器 - 限制同时进行中的写入数量
+// =============================================================================
+
+// ErrConcurrencyLimit 非阻塞写入时并发限制器没有空闲名额
+var ErrConcurrencyLimit = fmt.Errorf("%w: concurrency limit reached", ErrWouldBlock)
+
+// inFlightLimiter 按写入占用名额的限制器，写入器在每次写入前后获取和归还名额
+type inFlightLimiter interface {
+	Limiter
+	Acquire(ctx context.Context) error
+	TryAcquire() bool
+	Release()
+}
+
+// ConcurrencyLimiter 限制同时进行中的写入数量的限制器，可以和速率限制器放在同一条链中
+//
+// 写入器在每次写入开始时为链中的并发限制器占用一个名额，写入完成（包括转发到下游）后归还；
+// 名额用尽时写入阻塞，直到其他写入完成。并发限制器不参与令牌计费，WaitN 总是立即返回，
+// 因此批量令牌不会绕过它。与字节速率层组合即可在一条链中同时控制吞吐量和排队深度。
+// 在写入器之外使用时，调用方需自行配对调用 Acquire 和 Release。
+type ConcurrencyLimiter struct {
+	slots chan struct{}
+}
+
+// NewConcurrencyLimiter 创建最多允许 maxInFlight 个写入同时进行的并发限制器
+//
+// maxInFlight <= 0 时按 1 处理。
+func NewConcurrencyLimiter(maxInFlight int) *ConcurrencyLimiter {
+	return &ConcurrencyLimiter{slots: make(chan struct{}, max(maxInFlight, 1))}
+}
+
+// WaitN 实现 Limiter 接口，总是立即返回 nil
+//
+// 名额由写入器通过 Acquire / Release 按写入管理，与令牌数量无关。
+func (c *ConcurrencyLimiter) WaitN(ctx context.Context, n int) error {
+	return nil
+}
+
+// Acquire 占用一个名额，没有空闲名额时阻塞直到有写入完成或 ctx 结束
+func (c *ConcurrencyLimiter) Acquire(ctx context.Context) error {
+	select {
+	case c.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// TryAcquire 在有空闲名额时占用一个并返回 true，否则立即返回 false
+func (c *ConcurrencyLimiter) TryAcquire() bool {
+	select {
+	case c.slots <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+// Release 归还一个名额
+func (c *ConcurrencyLimiter) Release() {
+	<-c.slots
+}
+
+// InFlight 返回当前被占用的名额数
+func (c *ConcurrencyLimiter) InFlight() int {
+	return len(c.slots)
+}
+
+// MaxInFlight 返回名额总数
+func (c *ConcurrencyLimiter) MaxInFlight() int {
+	return cap(c.slots)
+}
+
+// acquireSlots 为链中的并发限制器各占用一个名额，返回归还全部名额的函数
+//
+// 非阻塞写入没有空闲名额时返回 ErrConcurrencyLimit；设置了 WithMaxWait 时
+// 等待名额最多 maxWait，超时返回 *MaxWaitError。失败时已占用的名额会被归还。
+func (w *DiscardWriter) acquireSlots(ctx context.Context) (func(), error) {
+	limiters, _ := w.chain()
+	var held []inFlightLimiter
+	release := func() {
+		for i := len(held) - 1; i >= 0; i-- {
+			held[i].Release()
+		}
+	}
+
+	waitCtx := ctx
+	for _, limiter := range limiters {
+		l, ok := writeScope(limiter).(inFlightLimiter)
+		if !ok {
+			continue
+		}
+		if isNonBlocking(ctx) {
+			if !l.TryAcquire() {
+				release()
+				return nil, ErrConcurrencyLimit
+			}
+			held = append(held, l)
+			continue
+		}
+		if w.maxWait > 0 && waitCtx == ctx {
+			var cancel context.CancelFunc
+			waitCtx, cancel = withClockTimeout(ctx, w.clock, w.maxWait)
+			defer cancel()
+		}
+		if err := l.Acquire(waitCtx); err != nil {
+			release()
+			if errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil {
+				return nil, &MaxWaitError{MaxWait: w.maxWait}
+			}
+			return nil, err
+		}
+		held = append(held, l)
+	}
+	if len(held) == 0 {
+		return noRelease, nil
+	}
+	return release, nil
+}
+
+// noRelease 链中没有并发限制器时使用的空归还函数
+func noRelease() {}
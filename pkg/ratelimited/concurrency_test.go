@@ -0,0 +1,84 @@
+package ratelimited
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// blockingSink 在写入转发到下游时阻塞，直到被放行
+type blockingSink struct {
+	entered chan struct{}
+	proceed chan struct{}
+}
+
+func (s *blockingSink) Write(p []byte) (int, error) {
+	s.entered <- struct{}{}
+	<-s.proceed
+	return len(p), nil
+}
+
+// TestConcurrencyLimiter_BlocksWhileInFlight 测试名额用尽时写入阻塞，写入完成后释放
+//
+// 测试目标：
+//   - 进行中的写入数量不超过上限
+//   - 写入完成（包括转发到下游）后名额被归还
+//   - 与速率限制器组合在同一条链中时令牌计费不受影响
+func TestConcurrencyLimiter_BlocksWhileInFlight(t *testing.T) {
+	// Arrange
+	limiter := NewConcurrencyLimiter(1)
+	sink := &blockingSink{entered: make(chan struct{}), proceed: make(chan struct{})}
+	writer := NewWriter(sink, []Limiter{limiter, rate.NewLimiter(rate.Inf, 0)})
+
+	// Act: 第一个写入停在下游
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() { defer wg.Done(); _, _ = writer.Write(createTestData(10)) }()
+	<-sink.entered
+	go func() { defer wg.Done(); _, _ = writer.Write(createTestData(10)) }()
+
+	// Assert
+	select {
+	case <-sink.entered:
+		t.Fatal("名额用尽时第二个写入不应该到达下游")
+	case <-time.After(20 * time.Millisecond):
+	}
+	assertEqual(t, 1, limiter.InFlight(), "进行中的写入数")
+
+	sink.proceed <- struct{}{}
+	<-sink.entered
+	sink.proceed <- struct{}{}
+	wg.Wait()
+	assertEqual(t, 0, limiter.InFlight(), "写入完成后名额应该全部归还")
+	assertEqual(t, int64(20), writer.Stats().Bytes, "两次写入都应该完成")
+}
+
+// TestConcurrencyLimiter_NonBlockingAndMaxWait 测试非阻塞写入和最长等待下的拒绝
+func TestConcurrencyLimiter_NonBlockingAndMaxWait(t *testing.T) {
+	// Arrange: 名额被外部占用
+	limiter := NewConcurrencyLimiter(1)
+	assertNoError(t, limiter.Acquire(context.Background()), "占用名额")
+	writer := NewDiscardWriter([]Limiter{limiter}, WithMaxWait(10*time.Millisecond))
+
+	// Act
+	_, tryErr := writer.TryWrite(createTestData(10))
+	_, waitErr := writer.Write(createTestData(10))
+
+	// Assert
+	if !errors.Is(tryErr, ErrWouldBlock) {
+		t.Errorf("非阻塞写入应该返回 ErrWouldBlock，实际 %v", tryErr)
+	}
+	var mwErr *MaxWaitError
+	if !errors.As(waitErr, &mwErr) {
+		t.Errorf("等待名额超过上限时应该返回 *MaxWaitError，实际 %v", waitErr)
+	}
+
+	limiter.Release()
+	_, err := writer.TryWrite(createTestData(10))
+	assertNoError(t, err, "名额归还后非阻塞写入应该成功")
+	assertEqual(t, 0, limiter.InFlight(), "失败的写入不应该占用名额")
+}
@@ -0,0 +1,143 @@
+package ratelimited
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"golang.org/x/time/rate"
+)
+
+// =============================================================================
+// 声明式配置 - 从配置文件构建限制器链
+// =============================================================================
+
+// LayerConfig 限制器链中单个层级的配置
+type LayerConfig struct {
+	Name  string `json:"name" yaml:"name"`                       // 层级名称，在链内唯一
+	Rate  string `json:"rate" yaml:"rate"`                       // ParseRate 格式的速率，如 "10MiB/s"
+	Burst int    `json:"burst,omitempty" yaml:"burst,omitempty"` // 突发容量，0 表示使用 ParseRate 建议的值
+}
+
+// Config 限制器链及写入器限额的声明式配置，可直接从 JSON 或 YAML 加载
+//
+// 示例 (YAML)：
+//
+//	layers:
+//	  - name: global
+//	    rate: 1Gbps
+//	  - name: user
+//	    rate: 10MiB/s
+//	    burst: 1048576
+//	quota: 1073741824
+//	batch_size: 65536
+//
+// 从 JSON 解码时会自动校验；YAML 等其他格式解码后应调用 Validate。
+type Config struct {
+	Layers    []LayerConfig `json:"layers" yaml:"layers"`
+	Quota     int64         `json:"quota,omitempty" yaml:"quota,omitempty"`           // 总字节配额，0 表示不限制
+	BatchSize int64         `json:"batch_size,omitempty" yaml:"batch_size,omitempty"` // 批量令牌大小，0 表示默认值
+}
+
+// UnmarshalJSON 解码 JSON 配置并校验
+func (c *Config) UnmarshalJSON(data []byte) error {
+	type plain Config // 去掉方法集，避免递归调用
+	var decoded plain
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	cfg := Config(decoded)
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	*c = cfg
+	return nil
+}
+
+// Validate 校验配置，错误信息中标明出错的层级
+//
+// 层级名称不能为空或重复，速率必须能被 ParseRate 解析，突发容量不能为负，
+// 且有限速率层级的突发容量不能小于批次大小，否则按批次申请令牌的写入永远无法满足。
+func (c *Config) Validate() error {
+	if c.Quota < 0 {
+		return fmt.Errorf("ratelimited: quota must not be negative, got %d", c.Quota)
+	}
+	if c.BatchSize < 0 {
+		return fmt.Errorf("ratelimited: batch_size must not be negative, got %d", c.BatchSize)
+	}
+
+	seen := make(map[string]bool, len(c.Layers))
+	for i, layer := range c.Layers {
+		if layer.Name == "" {
+			return fmt.Errorf("ratelimited: layer %d: name is required", i)
+		}
+		if seen[layer.Name] {
+			return fmt.Errorf("ratelimited: layer %q: duplicate name", layer.Name)
+		}
+		seen[layer.Name] = true
+
+		if _, _, err := layer.limit(c.batchSize()); err != nil {
+			return fmt.Errorf("ratelimited: layer %q: %w", layer.Name, err)
+		}
+	}
+	return nil
+}
+
+// Build 校验配置并按层级顺序创建限制器链
+func (c *Config) Build() ([]Limiter, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+
+	limiters := make([]Limiter, 0, len(c.Layers))
+	for _, layer := range c.Layers {
+		limit, burst, _ := layer.limit(c.batchSize())
+		limiters = append(limiters, rate.NewLimiter(limit, burst))
+	}
+	return limiters, nil
+}
+
+// WriterOptions 返回与配置对应的写入器选项：层级名称、批次大小和配额
+//
+// 配置了 Quota 时每次调用都分配一份新的共享配额，使用同一组选项创建的写入器共享这份配额。
+func (c *Config) WriterOptions() []DiscardWriterOption {
+	names := make([]string, len(c.Layers))
+	for i, layer := range c.Layers {
+		names[i] = layer.Name
+	}
+
+	opts := []DiscardWriterOption{
+		WithLimiterNames(names),
+		WithBatchSize(c.batchSize()),
+	}
+	if c.Quota > 0 {
+		quota := c.Quota
+		opts = append(opts, WithSharedQuota(&quota))
+	}
+	return opts
+}
+
+// batchSize 返回生效的批次大小
+func (c *Config) batchSize() int64 {
+	if c.BatchSize > 0 {
+		return c.BatchSize
+	}
+	return defaultBatchSize
+}
+
+// limit 解析层级的速率和突发容量，batchSize 用于检查突发容量是否足够
+func (l LayerConfig) limit(batchSize int64) (rate.Limit, int, error) {
+	limit, burst, err := ParseRate(l.Rate)
+	if err != nil {
+		return 0, 0, err
+	}
+	if l.Burst < 0 {
+		return 0, 0, fmt.Errorf("burst must not be negative, got %d", l.Burst)
+	}
+	if l.Burst > 0 {
+		burst = l.Burst
+	}
+	if limit != rate.Inf && int64(burst) < batchSize {
+		return 0, 0, fmt.Errorf("burst %d is smaller than batch size %d", burst, batchSize)
+	}
+	return limit, burst, nil
+}
@@ -0,0 +1,82 @@
+package ratelimited
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"golang.org/x/time/rate"
+)
+
+// TestConfig_LoadAndBuild 测试从 JSON 加载配置并构建限制器链
+//
+// 测试目标：
+//   - 层级按顺序构建，显式突发容量覆盖建议值
+//   - 写入器选项带上层级名称、批次大小和配额
+func TestConfig_LoadAndBuild(t *testing.T) {
+	// Arrange
+	data := `{
+		"layers": [
+			{"name": "global", "rate": "1Gbps"},
+			{"name": "user", "rate": "10MiB/s", "burst": 1048576}
+		],
+		"quota": 4096,
+		"batch_size": 1024
+	}`
+
+	// Act
+	var cfg Config
+	err := json.Unmarshal([]byte(data), &cfg)
+	assertNoError(t, err, "合法的配置应该解码成功")
+	limiters, err := cfg.Build()
+	assertNoError(t, err, "合法的配置应该构建成功")
+	writer := NewDiscardWriter(limiters, cfg.WriterOptions()...)
+
+	// Assert
+	assertEqual(t, 2, len(limiters), "应该构建两层")
+	user := limiters[1].(*rate.Limiter)
+	assertEqual(t, rate.Limit(10<<20), user.Limit(), "速率应该按 ParseRate 解析")
+	assertEqual(t, 1048576, user.Burst(), "显式突发容量应该覆盖建议值")
+	assertEqual(t, "user", limiterName(writer.names, 1, false), "层级名称应该传给写入器")
+	assertEqual(t, int64(1024), writer.batchSize, "批次大小应该传给写入器")
+	assertEqual(t, int64(4096), writer.Stats().QuotaRemaining, "配额应该传给写入器")
+}
+
+// TestConfig_Validate 测试配置校验错误标明出错的层级
+func TestConfig_Validate(t *testing.T) {
+	testCases := []struct {
+		name     string
+		cfg      Config
+		contains string
+	}{
+		{name: "缺少名称", cfg: Config{Layers: []LayerConfig{{Rate: "1MB/s"}}}, contains: "layer 0"},
+		{name: "重复名称", cfg: Config{Layers: []LayerConfig{{Name: "a", Rate: "1MB/s"}, {Name: "a", Rate: "2MB/s"}}}, contains: `layer "a"`},
+		{name: "非法速率", cfg: Config{Layers: []LayerConfig{{Name: "edge", Rate: "fast"}}}, contains: `layer "edge"`},
+		{name: "负突发容量", cfg: Config{Layers: []LayerConfig{{Name: "edge", Rate: "1MB/s", Burst: -1}}}, contains: `layer "edge"`},
+		{name: "突发容量小于批次", cfg: Config{Layers: []LayerConfig{{Name: "edge", Rate: "1MB/s", Burst: 100}}}, contains: "batch size"},
+		{name: "负配额", cfg: Config{Quota: -1}, contains: "quota"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Act
+			_, err := tc.cfg.Build()
+
+			// Assert
+			if err == nil || !strings.Contains(err.Error(), tc.contains) {
+				t.Errorf("错误应该包含 %q，实际 %v", tc.contains, err)
+			}
+		})
+	}
+
+	t.Run("JSON 解码时校验", func(t *testing.T) {
+		// Act
+		var cfg Config
+		err := json.Unmarshal([]byte(`{"layers": [{"name": "edge", "rate": "fast"}]}`), &cfg)
+
+		// Assert
+		if err == nil || !strings.Contains(err.Error(), `layer "edge"`) {
+			t.Errorf("解码非法配置应该返回标明层级的错误，实际 %v", err)
+		}
+	})
+}
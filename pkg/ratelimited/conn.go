@@ -0,0 +1,58 @@
+package ratelimited
+
+import (
+	"errors"
+	"net"
+)
+
+// =============================================================================
+// 网络连接 - 读写方向分别限流
+// =============================================================================
+
+// ThrottledConn 读写方向分别由独立限制器链限流的 net.Conn
+//
+// 读方向复用 ThrottledReader，写方向复用 ThrottledWriter，两个方向的字节数分别统计。
+// 其余 net.Conn 方法（地址、截止时间等）直接透传给底层连接。
+type ThrottledConn struct {
+	net.Conn
+	reader *ThrottledReader
+	writer *ThrottledWriter
+}
+
+// WrapConn 包装 c，读取经过 readChain 限流、写入经过 writeChain 限流
+//
+// opts 同时应用到两个方向：传入 WithBytesCounter 等外部计数器时会累计两个方向的总和，
+// 传入 WithSharedQuota 时两个方向共用同一配额。各方向的字节数可通过 BytesRead 和
+// BytesWritten 单独读取。
+func WrapConn(c net.Conn, readChain, writeChain []Limiter, opts ...Option) *ThrottledConn {
+	return &ThrottledConn{
+		Conn:   c,
+		reader: NewReader(c, readChain, opts...),
+		writer: NewWriter(c, writeChain, opts...),
+	}
+}
+
+// Read 实现 net.Conn 接口，按读方向的限制器链限流
+func (c *ThrottledConn) Read(p []byte) (int, error) {
+	return c.reader.Read(p)
+}
+
+// Write 实现 net.Conn 接口，按写方向的限制器链限流
+func (c *ThrottledConn) Write(p []byte) (int, error) {
+	return c.writer.Write(p)
+}
+
+// BytesRead 返回从连接读取并交给调用方的字节数
+func (c *ThrottledConn) BytesRead() int64 {
+	return c.reader.w.Stats().Bytes
+}
+
+// BytesWritten 返回写入连接的字节数
+func (c *ThrottledConn) BytesWritten() int64 {
+	return c.writer.Stats().Bytes
+}
+
+// Close 关闭两个方向的写入器和底层连接
+func (c *ThrottledConn) Close() error {
+	return errors.Join(c.reader.Close(), c.writer.Close(), c.Conn.Close())
+}
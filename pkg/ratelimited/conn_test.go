@@ -0,0 +1,42 @@
+package ratelimited
+
+import (
+	"io"
+	"net"
+	"testing"
+)
+
+// TestWrapConn_IndependentDirections 测试读写方向使用各自的限制器链和统计
+//
+// 测试目标：
+//   - 写入经过写方向的链，读取经过读方向的链
+//   - 两个方向的字节数分别统计
+func TestWrapConn_IndependentDirections(t *testing.T) {
+	// Arrange
+	client, server := net.Pipe()
+	readLimiter := &countingLimiter{}
+	writeLimiter := &countingLimiter{}
+	conn := WrapConn(client, []Limiter{readLimiter}, []Limiter{writeLimiter}, WithBatchSize(1))
+	defer conn.Close()
+
+	go func() {
+		buf := make([]byte, 100)
+		_, _ = io.ReadFull(server, buf)
+		_, _ = server.Write(buf[:30])
+		server.Close()
+	}()
+
+	// Act
+	written, werr := conn.Write(createTestData(100))
+	received, rerr := io.ReadAll(conn)
+
+	// Assert
+	assertNoError(t, werr, "写入应该成功")
+	assertNoError(t, rerr, "读取应该成功")
+	assertEqual(t, 100, written, "应该写入全部字节")
+	assertEqual(t, 30, len(received), "应该读到对端回写的字节")
+	assertEqual(t, int64(100), conn.BytesWritten(), "写方向统计应该独立")
+	assertEqual(t, int64(30), conn.BytesRead(), "读方向统计应该独立")
+	assertAtomicEqual(t, 100, &writeLimiter.tokens, "写入应该只经过写方向的链")
+	assertAtomicEqual(t, 30, &readLimiter.tokens, "读取应该只经过读方向的链")
+}
@@ -0,0 +1,91 @@
+package ratelimited
+
+import (
+	"log/slog"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// =============================================================================
+// 速率控制通道 - 控制面与数据面解耦
+// =============================================================================
+
+// RateUpdate 针对单个命名层级的速率更新
+type RateUpdate struct {
+	TierName string     // 层级名称，对应 WithLimiterNames 中的名称
+	Limit    rate.Limit // 新的速率
+	Burst    int        // 新的突发容量，小于等于 0 时保持不变
+}
+
+// mutableLimiter 可以在运行时调整速率的限制器，*rate.Limiter 实现了该接口
+type mutableLimiter interface {
+	SetLimit(newLimit rate.Limit)
+	SetBurst(newBurst int)
+}
+
+// WithRateControlChannel 从通道接收速率更新并应用到对应名称的层级
+//
+// 写入器创建时启动一个后台 goroutine 消费 ch，对名称匹配的层级调用 SetLimit / SetBurst，
+// 调用方无需持有限制器本身即可在运行时调整速率。层级名称来自 WithLimiterNames；
+// 名称未知或对应的限制器不支持调整时，更新以 Warn 级别记录到 WithLogger 设置的日志后忽略。
+// goroutine 在 Close 被调用或 ch 被关闭时退出，因此使用该选项的写入器必须调用 Close。
+func WithRateControlChannel(ch <-chan RateUpdate) DiscardWriterOption {
+	return func(w *DiscardWriter) {
+		w.rateUpdates = ch
+	}
+}
+
+// namedMutableLimiters 按名称索引链中可调整速率的限制器
+func namedMutableLimiters(limiters []Limiter, names []string) map[string]mutableLimiter {
+	tiers := make(map[string]mutableLimiter, len(names))
+	for i, name := range names {
+		if i >= len(limiters) || name == "" {
+			continue
+		}
+		if ml, ok := limiters[i].(mutableLimiter); ok {
+			if _, dup := tiers[name]; !dup {
+				tiers[name] = ml
+			}
+		}
+	}
+	return tiers
+}
+
+// applyRateUpdates 消费速率更新直到通道关闭或 done 被关闭
+//
+// 只持有限制器而不持有写入器，写入器本身可以被正常回收。logger 为 nil 时使用 slog.Default()。
+func applyRateUpdates(ch <-chan RateUpdate, tiers map[string]mutableLimiter, clock Clock, logger *slog.Logger, done <-chan struct{}) {
+	for {
+		select {
+		case <-done:
+			return
+		case update, ok := <-ch:
+			if !ok {
+				return
+			}
+			tier, found := tiers[update.TierName]
+			if !found {
+				loggerOrDefault(logger).Warn("ratelimited: rate update for unknown tier ignored",
+					slog.String("tier", update.TierName))
+				continue
+			}
+			setRate(tier, clock.Now(), update)
+		}
+	}
+}
+
+// setRate 把速率更新应用到 tier，*rate.Limiter 按写入器时钟的当前时间 now 调整
+func setRate(tier mutableLimiter, now time.Time, update RateUpdate) {
+	if l, ok := tier.(*rate.Limiter); ok {
+		l.SetLimitAt(now, update.Limit)
+		if update.Burst > 0 {
+			l.SetBurstAt(now, update.Burst)
+		}
+		return
+	}
+	tier.SetLimit(update.Limit)
+	if update.Burst > 0 {
+		tier.SetBurst(update.Burst)
+	}
+}
@@ -0,0 +1,85 @@
+package ratelimited
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// waitForLimit 轮询直到限制器的速率变为 expected 或超时
+func waitForLimit(t *testing.T, limiter *rate.Limiter, expected rate.Limit) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for limiter.Limit() != expected {
+		if time.Now().After(deadline) {
+			t.Fatalf("速率应该被更新为 %v，实际 %v", expected, limiter.Limit())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// TestWithRateControlChannel_AppliesUpdate 测试速率更新应用到对应名称的层级
+//
+// 测试目标：
+//   - 推送的更新改变命名层级的速率和突发容量
+//   - 未知层级的更新被忽略，不影响后续更新
+//   - Close 后不再消费通道
+func TestWithRateControlChannel_AppliesUpdate(t *testing.T) {
+	// Arrange
+	user := rate.NewLimiter(1000, 1000)
+	global := rate.NewLimiter(5000, 5000)
+	updates := make(chan RateUpdate)
+	writer := NewDiscardWriter([]Limiter{user, global},
+		WithLimiterNames([]string{"user", "global"}),
+		WithRateControlChannel(updates),
+	)
+
+	// Act
+	updates <- RateUpdate{TierName: "unknown", Limit: 1}
+	updates <- RateUpdate{TierName: "user", Limit: 200, Burst: 50}
+
+	// Assert
+	waitForLimit(t, user, 200)
+	assertEqual(t, 50, user.Burst(), "突发容量应该被更新")
+	assertEqual(t, rate.Limit(5000), global.Limit(), "其他层级不应该受影响")
+
+	// Close 后后台 goroutine 退出，不再接收更新
+	assertNoError(t, writer.Close(), "Close 应该成功")
+	assertNoError(t, writer.Close(), "重复 Close 应该是安全的")
+	select {
+	case updates <- RateUpdate{TierName: "user", Limit: 1}:
+		t.Error("Close 后不应该再消费速率更新")
+	case <-time.After(50 * time.Millisecond):
+	}
+	assertEqual(t, rate.Limit(200), user.Limit(), "Close 后速率不应该再变化")
+}
+
+// TestWithRateControlChannel_LogsUnknownTier 测试未知层级的更新记录到 WithLogger 设置的日志
+func TestWithRateControlChannel_LogsUnknownTier(t *testing.T) {
+	// Arrange
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+	user := rate.NewLimiter(1000, 1000)
+	updates := make(chan RateUpdate)
+	writer := NewDiscardWriter([]Limiter{user},
+		WithLimiterNames([]string{"user"}),
+		WithRateControlChannel(updates),
+		WithLogger(logger),
+	)
+	defer writer.Close()
+
+	// Act: 后续更新生效说明前一条已经处理完毕
+	updates <- RateUpdate{TierName: "unknown", Limit: 1}
+	updates <- RateUpdate{TierName: "user", Limit: 200}
+	waitForLimit(t, user, 200)
+
+	// Assert
+	out := buf.String()
+	if !strings.Contains(out, "level=WARN") || !strings.Contains(out, "tier=unknown") {
+		t.Errorf("未知层级的更新应该以 Warn 级别记录层级名称，实际:\n%s", out)
+	}
+}
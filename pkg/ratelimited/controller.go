@@ -0,0 +1,105 @@
+package ratelimited
+
+import (
+	"fmt"
+	"sync"
+
+	"golang.org/x/time/rate"
+)
+
+// =============================================================================
+// 速率控制器 - 按配置热更新限制器链
+// =============================================================================
+
+// Controller 持有按 Config 构建的限制器链，并支持在运行时按新配置原地调整速率
+//
+// 写入器通过 Limiters 和 WriterOptions 使用控制器的链；Apply 直接调用链中限制器的
+// SetLimit / SetBurst，长时间运行的传输无需重建写入器即可生效。并发安全。
+type Controller struct {
+	mu       sync.Mutex
+	cfg      Config
+	limiters []Limiter
+	names    []string // 与 limiters 一一对应，保持创建时的顺序
+	layers   map[string]*rate.Limiter
+}
+
+// NewController 按 cfg 构建限制器链并创建控制器
+func NewController(cfg Config) (*Controller, error) {
+	limiters, err := cfg.Build()
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, len(limiters))
+	layers := make(map[string]*rate.Limiter, len(limiters))
+	for i, layer := range cfg.Layers {
+		names[i] = layer.Name
+		layers[layer.Name] = limiters[i].(*rate.Limiter)
+	}
+	return &Controller{
+		cfg:      cloneConfig(cfg),
+		limiters: limiters,
+		names:    names,
+		layers:   layers,
+	}, nil
+}
+
+// Limiters 返回控制器持有的限制器链，链本身在控制器的生命周期内保持不变
+func (c *Controller) Limiters() []Limiter {
+	return c.limiters
+}
+
+// WriterOptions 返回当前配置对应的写入器选项，见 Config.WriterOptions
+//
+// 层级名称始终按 Limiters 中的顺序给出，即使 Apply 的配置调整了层级顺序。
+func (c *Controller) WriterOptions() []DiscardWriterOption {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append(c.cfg.WriterOptions(), WithLimiterNames(c.names))
+}
+
+// Config 返回当前生效的配置
+func (c *Controller) Config() Config {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return cloneConfig(c.cfg)
+}
+
+// Apply 按新配置原地调整各层的速率和突发容量
+//
+// 新配置必须包含与当前链完全相同的层级名称（顺序可以不同），因为已创建的写入器持有的
+// 链无法增删层级；需要增删层级时应使用 DynamicChain。配置先整体校验，
+// 校验失败或层级不匹配时返回标明层级的错误，不做任何调整。
+// Quota 和 BatchSize 的变化只影响之后通过 WriterOptions 创建的写入器。
+func (c *Controller) Apply(cfg Config) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(cfg.Layers) != len(c.layers) {
+		return fmt.Errorf("ratelimited: config has %d layers, controller has %d", len(cfg.Layers), len(c.layers))
+	}
+	for _, layer := range cfg.Layers {
+		if _, ok := c.layers[layer.Name]; !ok {
+			return fmt.Errorf("ratelimited: layer %q: not in controller chain", layer.Name)
+		}
+	}
+
+	for _, layer := range cfg.Layers {
+		limit, burst, _ := layer.limit(cfg.batchSize())
+		limiter := c.layers[layer.Name]
+		limiter.SetLimit(limit)
+		limiter.SetBurst(burst)
+	}
+	c.cfg = cloneConfig(cfg)
+	return nil
+}
+
+// cloneConfig 复制配置，避免与调用方共享层级切片
+func cloneConfig(cfg Config) Config {
+	cfg.Layers = append([]LayerConfig(nil), cfg.Layers...)
+	return cfg
+}
@@ -0,0 +1,67 @@
+package ratelimited
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/time/rate"
+)
+
+// TestController_Apply 测试按新配置原地调整速率
+//
+// 测试目标：
+//   - Apply 调整已有限制器的速率和突发容量，写入器持有的链随之生效
+//   - 层级名称不匹配或配置非法时返回错误且不做任何调整
+func TestController_Apply(t *testing.T) {
+	// Arrange
+	ctrl, err := NewController(Config{Layers: []LayerConfig{
+		{Name: "global", Rate: "1MB/s"},
+		{Name: "user", Rate: "100KB/s"},
+	}})
+	assertNoError(t, err, "合法的配置应该创建成功")
+	writer := NewDiscardWriter(ctrl.Limiters(), ctrl.WriterOptions()...)
+	user := writer.limiters[1].(*rate.Limiter)
+
+	// Act
+	err = ctrl.Apply(Config{Layers: []LayerConfig{
+		{Name: "user", Rate: "10MiB/s", Burst: 1 << 20},
+		{Name: "global", Rate: "unlimited"},
+	}})
+
+	// Assert
+	assertNoError(t, err, "层级相同的配置应该应用成功")
+	assertEqual(t, rate.Limit(10<<20), user.Limit(), "写入器持有的限制器应该被原地调整")
+	assertEqual(t, 1<<20, user.Burst(), "突发容量应该被原地调整")
+	assertEqual(t, rate.Inf, writer.limiters[0].(*rate.Limiter).Limit(), "所有层级都应该被调整")
+	assertEqual(t, "user", ctrl.Config().Layers[0].Name, "应该记录新配置")
+	later := NewDiscardWriter(ctrl.Limiters(), ctrl.WriterOptions()...)
+	assertEqual(t, "user", limiterName(later.names, 1, false), "层级名称应该保持链的顺序")
+
+	t.Run("层级不匹配", func(t *testing.T) {
+		// Act
+		err := ctrl.Apply(Config{Layers: []LayerConfig{
+			{Name: "global", Rate: "1MB/s"},
+			{Name: "tenant", Rate: "1MB/s"},
+		}})
+
+		// Assert
+		if err == nil || !strings.Contains(err.Error(), `layer "tenant"`) {
+			t.Errorf("未知层级应该返回标明层级的错误，实际 %v", err)
+		}
+		assertEqual(t, rate.Limit(10<<20), user.Limit(), "失败的 Apply 不应该调整任何层级")
+	})
+
+	t.Run("非法配置", func(t *testing.T) {
+		// Act
+		err := ctrl.Apply(Config{Layers: []LayerConfig{
+			{Name: "global", Rate: "1MB/s"},
+			{Name: "user", Rate: "fast"},
+		}})
+
+		// Assert
+		if err == nil {
+			t.Error("非法配置应该返回错误")
+		}
+		assertEqual(t, rate.Inf, writer.limiters[0].(*rate.Limiter).Limit(), "失败的 Apply 不应该调整任何层级")
+	})
+}
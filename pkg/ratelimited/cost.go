@@ -0,0 +1,82 @@
+package ratelimited
+
+import (
+	"errors"
+	"sync/atomic"
+)
+
+// =============================================================================
+// 令牌计费 - 令牌数与字节数解耦
+// =============================================================================
+
+// WithCostFunc 设置写入的令牌成本函数
+//
+// 默认每字节消耗一个令牌。设置成本函数后，每次写入向限制器申请 fn(p) 个令牌，
+// 而字节统计和配额仍按实际字节数计算。返回值小于等于 0 时视为免费写入：
+// 不调用任何限制器的 WaitN，但仍计入字节与请求统计。
+func WithCostFunc(fn func(p []byte) int64) DiscardWriterOption {
+	return func(w *DiscardWriter) {
+		w.costFunc = fn
+	}
+}
+
+// tokenCost 计算写入 p 需要向限制器申请的令牌数
+//
+// 去重命中优先于成本函数，命中的写入总是免费；分类倍数作用于成本函数的结果。
+// 启用去重且未命中时 novel 为 true，调用方在写入成功后用 sum 将 p 加入去重窗口。
+func (w *DiscardWriter) tokenCost(p []byte) (cost int64, sum uint64, novel bool) {
+	if w.dedup != nil {
+		sum = dedupHash(p)
+		if w.dedup.contains(sum) {
+			return 0, 0, false
+		}
+		novel = true
+	}
+
+	cost = int64(len(p))
+	if w.costFunc != nil {
+		cost = w.costFunc(p)
+	}
+	if cost > 0 && w.classify != nil {
+		cost = w.classifiedCost(p, cost)
+	}
+	return max(cost, 0), sum, novel
+}
+
+// ErrTokenCeilingReached 写入器累计计费的令牌达到上限
+var ErrTokenCeilingReached = errors.New("ratelimited: token ceiling reached")
+
+// WithTokenCeiling 设置写入器生命周期内累计令牌的硬上限
+//
+// 令牌即成本函数计算出的计费单位；当累计计费加上本次计费会超过 max 时，
+// 写入被整体拒绝并返回 ErrTokenCeilingReached。上限不会补充。
+// 令牌上限与共享配额（按字节）分别独立检查，两者中更严格的一方先生效。
+// max <= 0 表示不限制。
+func WithTokenCeiling(max int64) DiscardWriterOption {
+	return func(w *DiscardWriter) {
+		w.tokenCeiling = max
+	}
+}
+
+// reserveCeiling 原子地预留 cost 个令牌额度，超出上限时返回 false
+func (w *DiscardWriter) reserveCeiling(cost int64) bool {
+	if w.tokenCeiling <= 0 || cost <= 0 {
+		return true
+	}
+	for {
+		charged := atomic.LoadInt64(&w.tokensCharged)
+		if charged+cost > w.tokenCeiling {
+			return false
+		}
+		if atomic.CompareAndSwapInt64(&w.tokensCharged, charged, charged+cost) {
+			return true
+		}
+	}
+}
+
+// releaseCeiling 归还因写入失败而未使用的令牌额度
+func (w *DiscardWriter) releaseCeiling(cost int64) {
+	if w.tokenCeiling > 0 && cost > 0 {
+		atomic.AddInt64(&w.tokensCharged, -cost)
+	}
+}
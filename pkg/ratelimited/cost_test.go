@@ -0,0 +1,107 @@
+package ratelimited
+
+import (
+	"io"
+	"sync/atomic"
+	"testing"
+)
+
+// TestWithCostFunc_ZeroCost 测试零成本写入跳过令牌申请
+//
+// 测试目标：
+//   - 成本为 0 时不调用任何限制器的 WaitN
+//   - 字节统计、请求统计和统计接收端照常更新
+func TestWithCostFunc_ZeroCost(t *testing.T) {
+	// Arrange
+	limiter := &countingLimiter{}
+	sink := &recordingSink{}
+	var bytesWritten int64
+	var requestCount uint64
+
+	writer := NewDiscardWriter([]Limiter{limiter},
+		WithCostFunc(func(p []byte) int64 { return 0 }),
+		WithBytesCounter(&bytesWritten),
+		WithRequestCounter(&requestCount),
+		WithStatsSinks(sink),
+	)
+
+	// Act
+	for i := 0; i < 3; i++ {
+		n, err := writer.Write(createTestData(100))
+		assertNoError(t, err, "零成本写入应该成功")
+		assertEqual(t, 100, n, "零成本写入应该接收全部字节")
+	}
+
+	// Assert
+	assertEqual(t, int64(0), limiter.callCount(), "零成本写入不应该调用 WaitN")
+	assertAtomicEqual(t, 300, &bytesWritten, "字节统计应该照常累加")
+	assertEqual(t, uint64(3), atomic.LoadUint64(&requestCount), "请求统计应该照常累加")
+	assertEqual(t, 3, len(sink.snapshot()), "统计接收端应该收到每次写入")
+}
+
+// TestWithCostFunc_ScaledCost 测试成本函数放大令牌申请量
+func TestWithCostFunc_ScaledCost(t *testing.T) {
+	// Arrange: 批次大小等于单次成本，令牌申请量等于计费量
+	limiter := &countingLimiter{}
+	var bytesWritten int64
+	writer := NewDiscardWriter([]Limiter{limiter},
+		WithBatchSize(20),
+		WithCostFunc(func(p []byte) int64 { return int64(2 * len(p)) }),
+		WithBytesCounter(&bytesWritten),
+	)
+
+	// Act
+	for i := 0; i < 5; i++ {
+		_, err := writer.Write(createTestData(10))
+		assertNoError(t, err, "写入应该成功")
+	}
+
+	// Assert
+	assertEqual(t, int64(100), limiter.tokenCount(), "令牌申请量应该是字节数的两倍")
+	assertAtomicEqual(t, 50, &bytesWritten, "字节统计应该按实际字节计算")
+}
+
+// TestWithTokenCeiling_TripsBeforeBytes 测试令牌上限在成本放大时先于字节数触发
+//
+// 测试目标：
+//   - 2 倍成本下，100 个令牌的上限在写入 50 字节后触发
+//   - 触发后的写入返回 ErrTokenCeilingReached 且不扣除配额
+func TestWithTokenCeiling_TripsBeforeBytes(t *testing.T) {
+	// Arrange
+	quota := int64(1000)
+	var bytesWritten int64
+	writer := NewDiscardWriter([]Limiter{&countingLimiter{}},
+		WithCostFunc(func(p []byte) int64 { return int64(2 * len(p)) }),
+		WithTokenCeiling(100),
+		WithSharedQuota(&quota),
+		WithBytesCounter(&bytesWritten),
+	)
+	data := createTestData(10)
+
+	// Act: 每次写入计费 20 个令牌，前 5 次恰好用完上限
+	for i := 0; i < 5; i++ {
+		_, err := writer.Write(data)
+		assertNoError(t, err, "上限内的写入应该成功")
+	}
+	n, err := writer.Write(data)
+
+	// Assert
+	assertEqual(t, ErrTokenCeilingReached, err, "超过令牌上限应该返回 ErrTokenCeilingReached")
+	assertEqual(t, 0, n, "超过上限的写入不应该接收数据")
+	assertAtomicEqual(t, 50, &bytesWritten, "上限应该在写入 100 字节之前触发")
+	assertAtomicEqual(t, 950, &quota, "被拒绝的写入应该回滚配额")
+}
+
+// TestWithTokenCeiling_ReleasedOnFailure 测试令牌申请失败时归还上限额度
+func TestWithTokenCeiling_ReleasedOnFailure(t *testing.T) {
+	// Arrange
+	failing := &MockFailingLimiter{shouldFail: true, failError: io.ErrUnexpectedEOF}
+	writer := NewDiscardWriter([]Limiter{failing}, WithTokenCeiling(10))
+
+	// Act
+	_, err := writer.Write(createTestData(10))
+
+	// Assert
+	assertEqual(t, io.ErrUnexpectedEOF, err, "应该返回限制器错误")
+	assertEqual(t, int64(0), atomic.LoadInt64(&writer.tokensCharged), "失败的写入不应该占用上限额度")
+}
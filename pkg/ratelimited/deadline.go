@@ -0,0 +1,45 @@
+package ratelimited
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+)
+
+// ErrDeadline 复制在绝对截止时间到达时仍未完成
+var ErrDeadline = errors.New("ratelimited: copy deadline reached")
+
+// CopyNByDeadline 在截止时间 t 之前使用多层速率限制复制 n 字节到 Discard
+//
+// 复制要么在 t 之前完成，要么在 t 到达时返回已复制的字节数和 ErrDeadline，
+// 调用方可以据此从断点继续，而不必从 io.Copy 的错误中辨认 context.DeadlineExceeded。
+// 父上下文本身被取消或超时时，仍返回父上下文的错误。
+//
+// 截止时间通过到期取消上下文实现，而不是为上下文设置 deadline：
+// rate.Limiter 在预计等待会超过上下文 deadline 时会立即拒绝，
+// 这会让复制在 t 之前就提前失败，无法利用剩余时间继续复制。
+// t 按写入器的时钟（见 WithClock）判断，注入虚拟时钟时截止时间也在虚拟时间中到达。
+func CopyNByDeadline(ctx context.Context, r io.Reader, n int64, t time.Time, limiters []Limiter, opts ...DiscardWriterOption) (int64, error) {
+	dctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	allOpts := append([]DiscardWriterOption{WithContext(dctx), WithProgressTotal(n)}, opts...)
+	writer := NewDiscardWriter(limiters, allOpts...)
+	defer writer.Close()
+
+	expired := writer.clock.After(t.Sub(writer.clock.Now()))
+	go func() {
+		select {
+		case <-expired:
+			cancel(ErrDeadline)
+		case <-dctx.Done():
+		}
+	}()
+
+	copied, err := io.CopyN(writer, r, n)
+	if err != nil && ctx.Err() == nil && errors.Is(context.Cause(dctx), ErrDeadline) {
+		return copied, ErrDeadline
+	}
+	return copied, err
+}
@@ -0,0 +1,120 @@
+package ratelimited
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// TestCopyNByDeadline_PartialProgress 测试截止时间到达时返回部分进度
+//
+// 测试目标：
+//   - 速率不足以在截止时间前完成时返回 ErrDeadline
+//   - 返回值包含截止前已复制的字节数
+func TestCopyNByDeadline_PartialProgress(t *testing.T) {
+	// Arrange: 1000 B/s，突发 100 字节，截止时间 200ms，最多约 300 字节
+	limiter := rate.NewLimiter(1000, 100)
+	source := &chunkedReader{r: bytes.NewReader(make([]byte, 10000)), size: 50}
+	deadline := time.Now().Add(200 * time.Millisecond)
+
+	// Act
+	copied, err := CopyNByDeadline(context.Background(), source, 10000, deadline,
+		Chain(limiter), WithBatchSize(50))
+
+	// Assert
+	assertEqual(t, ErrDeadline, err, "截止时间到达时应该返回 ErrDeadline")
+	if copied <= 0 || copied >= 10000 {
+		t.Errorf("应该返回部分进度，实际复制 %d 字节", copied)
+	}
+	if time.Since(deadline) > time.Second {
+		t.Errorf("应该在截止时间附近返回，实际超出 %v", time.Since(deadline))
+	}
+}
+
+// TestCopyNByDeadline_CompletesInTime 测试截止时间前完成时正常返回
+func TestCopyNByDeadline_CompletesInTime(t *testing.T) {
+	// Arrange
+	limiter := rate.NewLimiter(rate.Inf, 0)
+	source := bytes.NewReader(make([]byte, 1000))
+
+	// Act
+	copied, err := CopyNByDeadline(context.Background(), source, 1000,
+		time.Now().Add(time.Second), Chain(limiter))
+
+	// Assert
+	assertNoError(t, err, "截止时间前完成应该成功")
+	assertEqual(t, int64(1000), copied, "应该复制全部字节")
+}
+
+// TestCopyNByDeadline_ParentCanceled 测试父上下文取消时返回原始错误
+func TestCopyNByDeadline_ParentCanceled(t *testing.T) {
+	// Arrange
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// Act
+	_, err := CopyNByDeadline(ctx, bytes.NewReader(make([]byte, 100)), 100,
+		time.Now().Add(time.Second), Chain(rate.NewLimiter(rate.Inf, 0)))
+
+	// Assert
+	assertEqual(t, context.Canceled, err, "父上下文取消应该返回 context.Canceled")
+}
+
+// TestCopyNByDeadline_Clock 测试截止时间按写入器的时钟到达
+func TestCopyNByDeadline_Clock(t *testing.T) {
+	// Arrange: 1000 B/s，突发 100 字节，虚拟截止时间 200ms，最多约 300 字节
+	clock := newFakeClock()
+	limiter := rate.NewLimiter(1000, 100)
+	source := &chunkedReader{r: bytes.NewReader(make([]byte, 10000)), size: 50}
+	deadline := clock.Now().Add(200 * time.Millisecond)
+
+	// Act: 有等待者时推进虚拟时间，直到复制返回
+	type result struct {
+		copied int64
+		err    error
+	}
+	done := make(chan result, 1)
+	go func() {
+		copied, err := CopyNByDeadline(context.Background(), source, 10000, deadline,
+			Chain(limiter), WithBatchSize(50), WithClock(clock))
+		done <- result{copied, err}
+	}()
+	var res result
+	timeout := time.After(5 * time.Second)
+	for finished := false; !finished; {
+		select {
+		case res = <-done:
+			finished = true
+		case <-timeout:
+			t.Fatal("复制应该在虚拟截止时间到达后返回")
+		default:
+			if clock.waiterCount() > 0 {
+				clock.Advance(50 * time.Millisecond)
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	// Assert
+	assertEqual(t, ErrDeadline, res.err, "虚拟截止时间到达时应该返回 ErrDeadline")
+	if res.copied <= 0 || res.copied >= 10000 {
+		t.Errorf("应该返回部分进度，实际复制 %d 字节", res.copied)
+	}
+}
+
+// chunkedReader 每次最多返回 size 字节的读取器，用于控制写入端看到的块大小
+type chunkedReader struct {
+	r    io.Reader
+	size int
+}
+
+func (c *chunkedReader) Read(p []byte) (int, error) {
+	if len(p) > c.size {
+		p = p[:c.size]
+	}
+	return c.r.Read(p)
+}
@@ -0,0 +1,82 @@
+package ratelimited
+
+import (
+	"hash/fnv"
+	"sync"
+)
+
+// =============================================================================
+// 去重计费 - 只为新内容申请令牌
+// =============================================================================
+
+// WithDedupCharge 启用去重感知的令牌计费
+//
+// 写入器维护最近 windowSize 个写入块的哈希值：内容与窗口内某个块相同的写入
+// 视为去重命中，不申请令牌；其余写入按字节数正常计费。字节统计和配额仍按
+// 实际字节数计算，只有速率限制的令牌受影响，用于模拟去重存储的真实带宽消耗。
+//
+// 块只在写入成功准入后才加入窗口：因限流、配额、令牌上限或下游错误而失败的写入
+// 重试时仍按新内容计费。窗口按先进先出淘汰，大小有上限；哈希碰撞会导致少量误判为命中，
+// 属于可接受的尽力而为。
+// windowSize <= 0 时不启用。
+func WithDedupCharge(windowSize int) DiscardWriterOption {
+	return func(w *DiscardWriter) {
+		if windowSize <= 0 {
+			w.dedup = nil
+			return
+		}
+		w.dedup = newDedupWindow(windowSize)
+	}
+}
+
+// dedupWindow 固定容量的最近块哈希集合
+type dedupWindow struct {
+	mu   sync.Mutex
+	set  map[uint64]struct{}
+	ring []uint64 // 按插入顺序保存哈希，用于淘汰最旧的条目
+	next int      // 下一个写入 ring 的位置
+	size int      // ring 中的有效条目数
+}
+
+// newDedupWindow 创建容量为 capacity 的去重窗口
+func newDedupWindow(capacity int) *dedupWindow {
+	return &dedupWindow{
+		set:  make(map[uint64]struct{}, capacity),
+		ring: make([]uint64, capacity),
+	}
+}
+
+// dedupHash 计算 p 在去重窗口中的哈希值
+func dedupHash(p []byte) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write(p)
+	return h.Sum64()
+}
+
+// contains 报告哈希 sum 是否在窗口内，不修改窗口
+func (d *dedupWindow) contains(sum uint64) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	_, ok := d.set[sum]
+	return ok
+}
+
+// add 将哈希 sum 加入窗口，已存在时不做任何事
+func (d *dedupWindow) add(sum uint64) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, ok := d.set[sum]; ok {
+		return
+	}
+
+	// 窗口已满时淘汰最旧的哈希
+	if d.size == len(d.ring) {
+		delete(d.set, d.ring[d.next])
+	} else {
+		d.size++
+	}
+	d.ring[d.next] = sum
+	d.next = (d.next + 1) % len(d.ring)
+	d.set[sum] = struct{}{}
+}
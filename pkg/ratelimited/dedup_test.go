@@ -0,0 +1,104 @@
+package ratelimited
+
+import (
+	"context"
+	"io"
+	"sync/atomic"
+	"testing"
+)
+
+// TestWithDedupCharge_RepeatedChunks 测试重复内容只计费一次
+//
+// 测试目标：
+//   - 窗口内重复出现的块不申请令牌
+//   - 字节统计仍然包含所有写入
+//   - 被淘汰出窗口的块再次出现时重新计费
+func TestWithDedupCharge_RepeatedChunks(t *testing.T) {
+	// Arrange: 批次大小等于块大小，使令牌申请量等于计费量
+	limiter := &countingLimiter{}
+	var bytesWritten int64
+	writer := NewDiscardWriter([]Limiter{limiter},
+		WithBatchSize(10),
+		WithBytesCounter(&bytesWritten),
+		WithDedupCharge(2),
+	)
+
+	chunkA := []byte("aaaaaaaaaa")
+	chunkB := []byte("bbbbbbbbbb")
+	chunkC := []byte("cccccccccc")
+
+	// Act & Assert: A、B 首次出现，正常计费
+	for _, chunk := range [][]byte{chunkA, chunkB, chunkA, chunkB} {
+		_, err := writer.Write(chunk)
+		assertNoError(t, err, "写入应该成功")
+	}
+	assertEqual(t, int64(20), limiter.tokenCount(), "窗口内重复的块不应该计费")
+	assertAtomicEqual(t, 40, &bytesWritten, "字节统计应该包含所有写入")
+
+	// C 挤出 A，A 再次出现时需要重新计费
+	_, err := writer.Write(chunkC)
+	assertNoError(t, err, "写入 C 应该成功")
+	_, err = writer.Write(chunkA)
+	assertNoError(t, err, "再次写入 A 应该成功")
+	assertEqual(t, int64(40), limiter.tokenCount(), "被淘汰的块应该重新计费")
+	assertAtomicEqual(t, 60, &bytesWritten, "字节统计应该包含所有写入")
+}
+
+// TestWithDedupCharge_QuotaCountsRawBytes 测试去重不影响配额扣除
+func TestWithDedupCharge_QuotaCountsRawBytes(t *testing.T) {
+	// Arrange
+	quota := int64(100)
+	writer := NewDiscardWriter([]Limiter{&countingLimiter{}},
+		WithSharedQuota(&quota),
+		WithDedupCharge(4),
+	)
+	chunk := []byte("0123456789")
+
+	// Act
+	for i := 0; i < 3; i++ {
+		_, err := writer.Write(chunk)
+		assertNoError(t, err, "写入应该成功")
+	}
+
+	// Assert
+	assertEqual(t, int64(70), atomic.LoadInt64(&quota), "配额应该按实际字节扣除")
+}
+
+// flakyLimiter 第一次调用失败、之后放行并计数的限制器
+type flakyLimiter struct {
+	countingLimiter
+	failed atomic.Bool
+}
+
+func (f *flakyLimiter) WaitN(ctx context.Context, n int) error {
+	if f.failed.CompareAndSwap(false, true) {
+		return io.ErrShortWrite
+	}
+	return f.countingLimiter.WaitN(ctx, n)
+}
+
+// TestWithDedupCharge_FailedWriteRetryCharged 测试失败写入的重试仍然计费
+//
+// 测试目标：
+//   - 令牌申请失败的块不加入去重窗口
+//   - 重试同一块时按新内容申请令牌，之后的重复才免费
+func TestWithDedupCharge_FailedWriteRetryCharged(t *testing.T) {
+	// Arrange
+	limiter := &flakyLimiter{}
+	writer := NewDiscardWriter([]Limiter{limiter},
+		WithBatchSize(10),
+		WithDedupCharge(4),
+	)
+	chunk := []byte("aaaaaaaaaa")
+
+	// Act
+	_, firstErr := writer.Write(chunk)
+	_, retryErr := writer.Write(chunk)
+	_, dupErr := writer.Write(chunk)
+
+	// Assert
+	assertEqual(t, io.ErrShortWrite, firstErr, "第一次写入应该因限制器失败")
+	assertNoError(t, retryErr, "重试应该成功")
+	assertNoError(t, dupErr, "重复写入应该成功")
+	assertEqual(t, int64(10), limiter.tokenCount(), "重试应该计费，之后的重复才免费")
+}
@@ -0,0 +1,74 @@
+package ratelimited
+
+import (
+	"bytes"
+	"context"
+	"io"
+)
+
+// drainDelimChunk DrainUntilDelim 每次从源读取的最大字节数
+const drainDelimChunk = 32 * 1024
+
+// DrainUntilDelim 限速排空 r 直到遇到分隔符 delim，返回包括分隔符在内消耗的字节数
+//
+// 数据按块读取并写入限速的丢弃写入器，分隔符可以跨越两次读取的边界。
+// 找到第一个分隔符后立即停止，只对分隔符及其之前的字节计费和计数。
+// 源在出现分隔符之前结束时，返回已消耗的字节数和 io.EOF（与 bufio.Reader.ReadBytes 一致）；
+// 读取、限速或配额错误原样返回；共享配额只够计费分隔符之前的一部分字节时，
+// 返回已计费的字节数和 ErrQuotaExhausted。delim 为空时立即返回 0 和 nil。
+//
+// 注意：按块读取会从 r 中多读出分隔符之后的数据，这部分数据不计费但也无法退回给 r。
+// 需要继续处理分隔符之后的数据时，应传入 *bufio.Reader 等可以按需读取的源，
+// 或者自行控制读取粒度。
+func DrainUntilDelim(ctx context.Context, r io.Reader, delim []byte, limiters []Limiter, opts ...DiscardWriterOption) (int64, error) {
+	if len(delim) == 0 {
+		return 0, nil
+	}
+
+	allOpts := append([]DiscardWriterOption{WithContext(ctx)}, opts...)
+	writer := NewDiscardWriter(limiters, allOpts...)
+	defer writer.Close()
+
+	buf := make([]byte, drainDelimChunk)
+	window := make([]byte, 0, len(delim)-1+drainDelimChunk) // 上一块的尾部 + 当前块
+	var total int64
+
+	for {
+		n, readErr := r.Read(buf)
+		if n > 0 {
+			tail := len(window)
+			window = append(window, buf[:n]...)
+
+			chunk := buf[:n]
+			found := false
+			if i := bytes.Index(window, delim); i >= 0 {
+				// 分隔符结束于当前块内的位置
+				chunk = buf[:i+len(delim)-tail]
+				found = true
+			}
+
+			written, err := writer.Write(chunk)
+			total += int64(written)
+			if err != nil {
+				return total, err
+			}
+			if written < len(chunk) {
+				// 丢弃写入器只会因配额不足而少接收数据
+				return total, ErrQuotaExhausted
+			}
+			if found {
+				return total, nil
+			}
+
+			// 只保留可能与下一块拼出分隔符的尾部
+			if keep := len(delim) - 1; len(window) > keep {
+				window = append(window[:0], window[len(window)-keep:]...)
+			}
+		}
+
+		if readErr != nil {
+			// 包括源结束前没有出现分隔符时的 io.EOF
+			return total, readErr
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package ratelimited
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"golang.org/x/time/rate"
+)
+
+// TestDrainUntilDelim_SplitAcrossChunks 测试分隔符跨越读取边界
+//
+// 测试目标：
+//   - 分隔符被拆分到两次读取中时仍能识别
+//   - 返回值包括分隔符在内，之后的数据不计入
+func TestDrainUntilDelim_SplitAcrossChunks(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		delim    string
+		expected int64
+	}{
+		{name: "分隔符跨越边界", input: "header\r\n\r\nbody", delim: "\r\n\r\n", expected: 10},
+		{name: "分隔符在开头", input: "--boundary--rest", delim: "--boundary--", expected: 12},
+		{name: "单字节分隔符", input: "abc;def", delim: ";", expected: 4},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange: 每次只读 3 字节，强制分隔符跨越多次读取
+			var bytesWritten int64
+			source := iotest.HalfReader(&chunkedReader{r: strings.NewReader(tc.input), size: 3})
+
+			// Act
+			consumed, err := DrainUntilDelim(context.Background(), source, []byte(tc.delim),
+				Chain(rate.NewLimiter(rate.Inf, 0)), WithBytesCounter(&bytesWritten))
+
+			// Assert
+			assertNoError(t, err, "找到分隔符时应该成功")
+			assertEqual(t, tc.expected, consumed, "消耗字节数应该包括分隔符")
+			assertAtomicEqual(t, tc.expected, &bytesWritten, "只应该对分隔符及之前的字节计数")
+		})
+	}
+}
+
+// TestDrainUntilDelim_MissingDelimiter 测试源结束前没有出现分隔符
+func TestDrainUntilDelim_MissingDelimiter(t *testing.T) {
+	// Arrange
+	source := strings.NewReader("no delimiter here")
+
+	// Act
+	consumed, err := DrainUntilDelim(context.Background(), source, []byte("\n"),
+		Chain(rate.NewLimiter(rate.Inf, 0)))
+
+	// Assert
+	assertEqual(t, io.EOF, err, "源结束时应该返回 io.EOF")
+	assertEqual(t, int64(17), consumed, "应该返回已消耗的全部字节")
+}
+
+// TestDrainUntilDelim_PartialQuota 测试配额只够计费一部分字节时返回 ErrQuotaExhausted
+func TestDrainUntilDelim_PartialQuota(t *testing.T) {
+	// Arrange
+	source := strings.NewReader("0123456789\n")
+	quota := int64(4)
+
+	// Act
+	consumed, err := DrainUntilDelim(context.Background(), source, []byte("\n"),
+		Chain(rate.NewLimiter(rate.Inf, 0)), WithSharedQuota(&quota))
+
+	// Assert
+	assertEqual(t, ErrQuotaExhausted, err, "配额不足时应该返回 ErrQuotaExhausted")
+	assertEqual(t, int64(4), consumed, "应该返回已计费的字节数")
+}
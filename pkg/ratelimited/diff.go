@@ -0,0 +1,203 @@
+package ratelimited
+
+import (
+	"fmt"
+	"reflect"
+
+	"golang.org/x/time/rate"
+)
+
+// =============================================================================
+// 链配置差异 - 审阅限流策略变更
+// =============================================================================
+
+// ChangeKind 层级变更的类型
+type ChangeKind int
+
+const (
+	// TierAdded 新链中新增的层级
+	TierAdded ChangeKind = iota
+	// TierRemoved 旧链中被移除的层级
+	TierRemoved
+	// TierModified 两条链中同名但配置不同的层级
+	TierModified
+)
+
+// String 返回变更类型的名称
+func (k ChangeKind) String() string {
+	switch k {
+	case TierAdded:
+		return "added"
+	case TierRemoved:
+		return "removed"
+	case TierModified:
+		return "modified"
+	default:
+		return "unknown"
+	}
+}
+
+// ChainChange 单个层级的变更
+//
+// 新增层级只有 New* 字段有效，移除层级只有 Old* 字段有效。
+// DetailsAvailable 为 false 时表示限制器无法内省速率和突发容量，
+// 只能确定其发生了变化，Limit/Burst 字段均为零值。
+type ChainChange struct {
+	Name             string
+	Kind             ChangeKind
+	OldLimit         rate.Limit
+	NewLimit         rate.Limit
+	OldBurst         int
+	NewBurst         int
+	DetailsAvailable bool
+}
+
+// String 返回便于日志输出的变更描述
+func (c ChainChange) String() string {
+	if !c.DetailsAvailable {
+		return fmt.Sprintf("%s %s (details unavailable)", c.Name, c.Kind)
+	}
+	switch c.Kind {
+	case TierAdded:
+		return fmt.Sprintf("%s added: limit=%v burst=%d", c.Name, c.NewLimit, c.NewBurst)
+	case TierRemoved:
+		return fmt.Sprintf("%s removed: limit=%v burst=%d", c.Name, c.OldLimit, c.OldBurst)
+	default:
+		return fmt.Sprintf("%s modified: limit %v -> %v, burst %d -> %d",
+			c.Name, c.OldLimit, c.NewLimit, c.OldBurst, c.NewBurst)
+	}
+}
+
+// limitIntrospector 可以报告自身速率和突发容量的限制器，*rate.Limiter 满足该接口
+type limitIntrospector interface {
+	Limit() rate.Limit
+	Burst() int
+}
+
+// introspect 返回限制器的速率和突发容量，无法内省时 ok 为 false
+func introspect(l Limiter) (limit rate.Limit, burst int, ok bool) {
+	li, ok := l.(limitIntrospector)
+	if !ok {
+		return 0, 0, false
+	}
+	return li.Limit(), li.Burst(), true
+}
+
+// DiffChains 比较两条命名限制器链，按层级名称报告新增、移除和修改
+//
+// 结果先按旧链顺序列出移除和修改的层级，再按新链顺序列出新增的层级。
+// 名称重复时只比较第一次出现的层级；nil 限制器被忽略。实现了 Limit() 与 Burst()
+// 的限制器（如 *rate.Limiter、GCRA、Pacer）报告新旧速率和突发容量，其他自定义限制器
+// 无法内省，只有换成了另一个实例时才报告修改，且 DetailsAvailable 为 false。
+// 这是纯函数，不会修改任何限制器，适合在热更新前审阅策略变更。
+func DiffChains(oldChain, newChain []NamedLimiter) []ChainChange {
+	oldByName := indexNamedLimiters(oldChain)
+	newByName := indexNamedLimiters(newChain)
+
+	var changes []ChainChange
+	seen := make(map[string]bool, len(oldChain))
+
+	for _, nl := range oldChain {
+		if isNilLimiter(nl.Limiter) || seen[nl.Name] {
+			continue
+		}
+		seen[nl.Name] = true
+
+		oldLimiter := oldByName[nl.Name]
+		newLimiter, exists := newByName[nl.Name]
+		if !exists {
+			changes = append(changes, tierChange(nl.Name, TierRemoved, oldLimiter, nil))
+			continue
+		}
+		if change, modified := compareTier(nl.Name, oldLimiter, newLimiter); modified {
+			changes = append(changes, change)
+		}
+	}
+
+	added := make(map[string]bool, len(newChain))
+	for _, nl := range newChain {
+		if isNilLimiter(nl.Limiter) || added[nl.Name] {
+			continue
+		}
+		added[nl.Name] = true
+
+		if _, exists := oldByName[nl.Name]; !exists {
+			changes = append(changes, tierChange(nl.Name, TierAdded, nil, newByName[nl.Name]))
+		}
+	}
+
+	return changes
+}
+
+// indexNamedLimiters 按名称索引非 nil 的限制器，名称重复时保留第一个
+func indexNamedLimiters(chain []NamedLimiter) map[string]Limiter {
+	index := make(map[string]Limiter, len(chain))
+	for _, nl := range chain {
+		if isNilLimiter(nl.Limiter) {
+			continue
+		}
+		if _, exists := index[nl.Name]; !exists {
+			index[nl.Name] = nl.Limiter
+		}
+	}
+	return index
+}
+
+// compareTier 比较同名层级，配置不同时返回修改记录
+func compareTier(name string, oldLimiter, newLimiter Limiter) (ChainChange, bool) {
+	oldLimit, oldBurst, oldOK := introspect(oldLimiter)
+	newLimit, newBurst, newOK := introspect(newLimiter)
+
+	if !oldOK || !newOK {
+		// 无法内省时只能比较是否为同一个限制器
+		if sameLimiter(oldLimiter, newLimiter) {
+			return ChainChange{}, false
+		}
+		return ChainChange{Name: name, Kind: TierModified}, true
+	}
+
+	if oldLimit == newLimit && oldBurst == newBurst {
+		return ChainChange{}, false
+	}
+	return ChainChange{
+		Name:             name,
+		Kind:             TierModified,
+		OldLimit:         oldLimit,
+		NewLimit:         newLimit,
+		OldBurst:         oldBurst,
+		NewBurst:         newBurst,
+		DetailsAvailable: true,
+	}, true
+}
+
+// sameLimiter 判断两个限制器是否为同一个实例
+//
+// 动态类型不可比较（如含切片或 map 字段的结构体值）时直接 == 会 panic，
+// 此时无法判断是否相同，按不同处理。
+func sameLimiter(a, b Limiter) bool {
+	ta, tb := reflect.TypeOf(a), reflect.TypeOf(b)
+	if ta != tb {
+		return false
+	}
+	if ta == nil {
+		return true
+	}
+	return ta.Comparable() && a == b
+}
+
+// tierChange 构造新增或移除层级的变更记录
+func tierChange(name string, kind ChangeKind, oldLimiter, newLimiter Limiter) ChainChange {
+	change := ChainChange{Name: name, Kind: kind, DetailsAvailable: true}
+
+	if oldLimiter != nil {
+		var ok bool
+		change.OldLimit, change.OldBurst, ok = introspect(oldLimiter)
+		change.DetailsAvailable = ok
+	}
+	if newLimiter != nil {
+		var ok bool
+		change.NewLimit, change.NewBurst, ok = introspect(newLimiter)
+		change.DetailsAvailable = ok
+	}
+	return change
+}
@@ -0,0 +1,141 @@
+package ratelimited
+
+import (
+	"context"
+	"testing"
+
+	"golang.org/x/time/rate"
+)
+
+// TestDiffChains 测试链配置差异
+//
+// 测试目标：
+//   - 新增、移除和修改的层级分别被报告
+//   - 未变化的层级不出现在结果中
+//   - 修改记录包含新旧速率与突发容量
+func TestDiffChains(t *testing.T) {
+	// Arrange
+	oldChain := []NamedLimiter{
+		{Name: "global", Limiter: rate.NewLimiter(1000, 1000)},
+		{Name: "service", Limiter: rate.NewLimiter(500, 500)},
+		{Name: "legacy", Limiter: rate.NewLimiter(100, 100)},
+	}
+	newChain := []NamedLimiter{
+		{Name: "global", Limiter: rate.NewLimiter(1000, 1000)},
+		{Name: "service", Limiter: rate.NewLimiter(800, 400)},
+		{Name: "user", Limiter: rate.NewLimiter(50, 50)},
+	}
+
+	// Act
+	changes := DiffChains(oldChain, newChain)
+
+	// Assert
+	expected := []ChainChange{
+		{Name: "service", Kind: TierModified, OldLimit: 500, NewLimit: 800, OldBurst: 500, NewBurst: 400, DetailsAvailable: true},
+		{Name: "legacy", Kind: TierRemoved, OldLimit: 100, OldBurst: 100, DetailsAvailable: true},
+		{Name: "user", Kind: TierAdded, NewLimit: 50, NewBurst: 50, DetailsAvailable: true},
+	}
+	assertEqual(t, len(expected), len(changes), "变更数量应该正确")
+	for i := range expected {
+		if i < len(changes) {
+			assertEqual(t, expected[i], changes[i], "变更记录应该正确")
+		}
+	}
+}
+
+// TestDiffChains_NoChanges 测试相同配置没有差异
+func TestDiffChains_NoChanges(t *testing.T) {
+	// Arrange
+	chain := []NamedLimiter{
+		{Name: "a", Limiter: rate.NewLimiter(10, 10)},
+		{Name: "nil", Limiter: nil},
+	}
+
+	// Act
+	changes := DiffChains(chain, chain)
+
+	// Assert
+	assertEqual(t, 0, len(changes), "相同配置不应该产生变更")
+}
+
+// TestCompareTier_Opaque 测试无法内省的限制器只报告发生变化
+func TestCompareTier_Opaque(t *testing.T) {
+	// Arrange
+	first, second := &countingLimiter{}, &countingLimiter{}
+
+	// Act
+	change, modified := compareTier("custom", first, second)
+	_, sameModified := compareTier("custom", first, first)
+
+	// Assert
+	assertEqual(t, true, modified, "不同的不透明限制器应该报告变化")
+	assertEqual(t, false, change.DetailsAvailable, "不透明限制器不应该提供细节")
+	assertEqual(t, "custom modified (details unavailable)", change.String(), "描述应该说明细节不可用")
+	assertEqual(t, false, sameModified, "同一个不透明限制器不应该报告变化")
+}
+
+// TestDiffChains_CustomLimiters 测试自定义限制器通过 DiffChains 比较
+//
+// 测试目标：
+//   - 可内省的自定义限制器报告速率和突发容量
+//   - 不透明的限制器只在换成另一个实例时报告修改，且没有细节
+func TestDiffChains_CustomLimiters(t *testing.T) {
+	// Arrange
+	opaque := &countingLimiter{}
+	oldChain := []NamedLimiter{
+		{Name: "gcra", Limiter: NewGCRAFromRate(100, 10)},
+		{Name: "same", Limiter: opaque},
+		{Name: "swapped", Limiter: &countingLimiter{}},
+	}
+	newChain := []NamedLimiter{
+		{Name: "gcra", Limiter: NewGCRAFromRate(200, 10)},
+		{Name: "same", Limiter: opaque},
+		{Name: "swapped", Limiter: &countingLimiter{}},
+		{Name: "pacer", Limiter: NewPacer(50, 5)},
+	}
+
+	// Act
+	changes := DiffChains(oldChain, newChain)
+
+	// Assert
+	expected := []ChainChange{
+		{Name: "gcra", Kind: TierModified, OldLimit: 100, NewLimit: 200, OldBurst: 10, NewBurst: 10, DetailsAvailable: true},
+		{Name: "swapped", Kind: TierModified},
+		{Name: "pacer", Kind: TierAdded, NewLimit: 50, NewBurst: 5, DetailsAvailable: true},
+	}
+	assertEqual(t, len(expected), len(changes), "变更数量应该正确")
+	for i := range expected {
+		if i < len(changes) {
+			assertEqual(t, expected[i], changes[i], "变更记录应该正确")
+		}
+	}
+}
+
+// sliceLimiter 含切片字段、动态类型不可比较的限制器
+type sliceLimiter struct {
+	tiers []int
+}
+
+func (s sliceLimiter) WaitN(ctx context.Context, n int) error {
+	return nil
+}
+
+// TestDiffChains_NonComparableLimiter 测试不可比较的限制器不会导致 panic
+func TestDiffChains_NonComparableLimiter(t *testing.T) {
+	// Arrange
+	oldChain := []NamedLimiter{
+		{Name: "custom", Limiter: sliceLimiter{tiers: []int{1}}},
+		{Name: "global", Limiter: NewGCRAFromRate(100, 10)},
+	}
+	newChain := []NamedLimiter{
+		{Name: "custom", Limiter: sliceLimiter{tiers: []int{1}}},
+		{Name: "global", Limiter: NewGCRAFromRate(100, 10)},
+	}
+
+	// Act
+	changes := DiffChains(oldChain, newChain)
+
+	// Assert
+	assertEqual(t, 1, len(changes), "只有不透明的层应该报告变更")
+	assertEqual(t, ChainChange{Name: "custom", Kind: TierModified}, changes[0], "不可比较的层应该按不同处理")
+}
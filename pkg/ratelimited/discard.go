@@ -37,8 +37,13 @@ package ratelimited
 
 import (
 	"context"
+	"fmt"
 	"io"
+	"log/slog"
+	"runtime"
+	"sync"
 	"sync/atomic"
+	"time"
 
 	"golang.org/x/time/rate"
 )
@@ -48,26 +53,156 @@ type Limiter interface {
 	WaitN(ctx context.Context, n int) error
 }
 
+// ErrQuotaExhausted 共享配额耗尽，写入器拒绝继续接收数据
+//
+// 为了兼容此前直接返回 io.EOF 的行为，errors.Is(ErrQuotaExhausted, io.EOF) 为 true；
+// 需要区分"源结束"与"配额用尽"的调用方应改为检查 ErrQuotaExhausted。
+var ErrQuotaExhausted error = quotaExhaustedError{}
+
+// quotaExhaustedError 匹配 io.EOF 的配额耗尽错误
+type quotaExhaustedError struct{}
+
+func (quotaExhaustedError) Error() string        { return "ratelimited: shared quota exhausted" }
+func (quotaExhaustedError) Is(target error) bool { return target == io.EOF }
+
 // DiscardWriter 支持多层速率限制的高效数据丢弃写入器
 type DiscardWriter struct {
 	// 速率限制器链 - 支持多层嵌套限制
 	limiters []Limiter
+	names    []string // 与 limiters 一一对应的名称 (可选)
 
 	// 上下文控制
 	ctx context.Context
 
-	// 统计信息 (可选)
-	bytesWritten *int64  // 写入字节统计
-	requestCount *uint64 // 请求次数统计
+	// 生命周期
+	done      chan struct{} // Close 时关闭，通知后台 goroutine 退出
+	closeOnce sync.Once
+
+	// 泄漏诊断 (可选)
+	captureStack  bool
+	creationStack string // 创建写入器时的调用栈
+
+	// 速率控制通道 (可选)
+	rateUpdates <-chan RateUpdate
+
+	// 后台告警使用的日志 (可选，为 nil 时使用 slog.Default())
+	logger *slog.Logger
+
+	// 下游写入端 (可选，为 nil 时丢弃数据)
+	dst             io.Writer
+	countAfterWrite bool // 下游确认交付后才更新计数器
+
+	// 时间源
+	clock Clock
+
+	// 单层等待告警 (可选)
+	waitAlert          func(name string, waited time.Duration)
+	waitAlertThreshold time.Duration
+
+	// 单层等待观察者 (可选)，在写入的 goroutine 中同步调用
+	waitObservers []layerWaitObserver
+
+	// 事件钩子 (可选)，见 WithHooks
+	hooks               []Hooks
+	quotaExhaustedFired atomic.Bool
+
+	// 统计信息 (可选)，字节与请求计数器也以记录器的形式保存
+	recorders []StatsRecorder
+
+	// 本写入器自身的统计，与外部计数器无关 (需要原子访问)
+	bytesTotal    int64
+	requestsTotal uint64
+	waitTotal     int64    // 等待令牌的累计时长 (纳秒)
+	batchesTotal  uint64   // 向限制器链申请令牌的次数
+	lostBytes     int64    // 因上下文错误未被接收的字节
+	layerWaits    sync.Map // 层级名称 -> 累计等待时长 (*int64 纳秒)，见 Bottleneck
+	throughput    throughputEMA
+	waitHist      waitHistogram // 每次写入的等待时长分布
+
+	progress *progressReporter // 进度回调 (可选)
+
+	lostCounter *int64 // 丢失字节统计 (可选)
+
+	// 请求计数粒度 (可选，用于降低共享计数器的竞争)
+	requestGranularity uint64 // 每累计多少次请求才更新一次共享计数器
+	requestPending     uint64 // 本写入器内尚未提交的请求次数 (需要原子访问)
 
 	// 配额管理 (可选，用于有限流)
-	sharedRemaining *int64 // 共享剩余配额指针
+	sharedRemaining *int64            // 共享剩余配额指针 (WithSharedQuota)
+	quota           Quota             // 写入时扣除的配额，共享配额指针、配额管理器或其他 Quota 实现
+	quotaBudget     int64             // 创建写入器时的剩余配额，软配额阈值以此为基准
+	quotaThresholds []*quotaThreshold // 软配额阈值 (可选)
+	quotaAwareWait  bool              // 配额不足本次写入时按剩余比例缩短等待
+	fullWrite       bool              // 循环写入直到 p 被完整接收或出错
+	maxWait         time.Duration     // 单次申请允许的最长等待，超过时快速失败
 
 	// 批量令牌处理
 	batchSize       int64 // 批量申请令牌大小
 	remainingTokens int64 // 当前批次剩余令牌 (需要原子访问)
+	explicitCommit  bool  // 显式提交模式，按写入大小申请令牌
+	droppedTokens   int64 // 已申请但未使用即作废的令牌 (需要原子访问)
+
+	// 令牌计费 (可选)
+	costFunc         func(p []byte) int64  // 自定义成本函数
+	dedup            *dedupWindow          // 去重窗口
+	classify         func(p []byte) string // 内容分类器
+	classMultipliers map[string]float64    // 分类对应的成本倍数
+	errorFeedback    *errorFeedback        // 按下游错误率缩放成本
+
+	// 统计接收端 (可选)
+	sinks []StatsSink
+
+	// 饱和检测 (可选)
+	saturationFn        func(saturated bool)
+	saturationThreshold time.Duration
+	saturationDebounce  int
+	saturation          *saturationTracker
+
+	// 令牌上限 (可选，写入器生命周期内累计)
+	tokenCeiling  int64 // 累计令牌上限，0 表示不限制
+	tokensCharged int64 // 已计费的令牌数 (需要原子访问)
+
+	// 分阶段限制 (可选)
+	phaseMu        sync.Mutex                // 串行化初始阶段的准入，保护 phaseWritten
+	phaseBoundary  int64                     // 前 phaseBoundary 字节使用初始链
+	phaseWritten   int64                     // 初始阶段已准入的字节数
+	steadyLimiters []Limiter                 // 越过边界后切换到的链，构造后不再修改
+	phaseChain     atomic.Pointer[[]Limiter] // 当前阶段的链，nil 表示未启用分阶段
+	phaseSteady    atomic.Bool               // 是否已经切换到稳定阶段
+
+	// 大写入分流 (可选)
+	largeThreshold int       // 超过该字节数的写入视为大写入，0 表示不区分
+	largeLimiters  []Limiter // 大写入专用的限制器链
+	largeWrites    uint64    // 大写入次数 (需要原子访问)
+	smallWrites    uint64    // 普通写入次数 (需要原子访问)
+
+	// 维护窗口 (可选)
+	blackouts        []Window
+	blackoutFailFast bool
+
+	// 空闲令牌作废 (可选)
+	idleRefund time.Duration // 空闲超过该时长后作废剩余批次令牌
+	lastActive int64         // 上次写入的时间戳 (UnixNano，需要原子访问)
+
+	// 暂停控制 (Pause / Resume)
+	pauseMu sync.Mutex
+	resumed chan struct{} // 暂停时非 nil，Resume 时关闭
+
+	// 动态限制器链 (可选，设置后替代 limiters 和 names)
+	dynamicChain *DynamicChain
+
+	// 请求速率链 (可选，每次写入申请 1 个令牌)
+	requestLimiters []Limiter
+
+	// 估算计费 (WriteEstimated / Reconcile)
+	estMu      sync.Mutex
+	estWritten int64 // 当前流已写入的实际字节数
+	estCharged int64 // 当前流已按估算从配额中扣除的字节数
 }
 
+// defaultBatchSize 默认的批量令牌大小 (64KB)
+const defaultBatchSize = 64 * 1024
+
 // DiscardWriterOption 配置选项
 type DiscardWriterOption func(*DiscardWriter)
 
@@ -78,17 +213,80 @@ func WithContext(ctx context.Context) DiscardWriterOption {
 	}
 }
 
-// WithBytesCounter 设置字节统计计数器
+// WithBytesCounter 设置字节统计计数器，替换之前设置的所有字节计数器
+//
+// 计数器是只记录字节数的 StatsRecorder，不影响通过 WithRecorder 注册的其他记录器。
 func WithBytesCounter(counter *int64) DiscardWriterOption {
 	return func(w *DiscardWriter) {
-		w.bytesWritten = counter
+		w.recorders = withoutRecorders[bytesCounter](w.recorders)
+		if counter != nil {
+			w.recorders = append(w.recorders, bytesCounter{counter})
+		}
+	}
+}
+
+// WithBytesCounters 追加字节统计计数器，每次写入原子地累加到所有计数器
+//
+// 适用于同一写入同时计入连接级和全局计数器的场景，无需包装写入器。
+// 计数器保存在固定的切片中，热路径上不产生内存分配。nil 计数器被忽略。
+func WithBytesCounters(counters ...*int64) DiscardWriterOption {
+	return func(w *DiscardWriter) {
+		for _, c := range counters {
+			if c != nil {
+				w.recorders = append(w.recorders, bytesCounter{c})
+			}
+		}
 	}
 }
 
-// WithRequestCounter 设置请求计数器
+// WithRequestCounter 设置请求计数器，替换之前设置的所有请求计数器
+//
+// 计数器是只记录请求次数的 StatsRecorder，不影响通过 WithRecorder 注册的其他记录器。
 func WithRequestCounter(counter *uint64) DiscardWriterOption {
 	return func(w *DiscardWriter) {
-		w.requestCount = counter
+		w.recorders = withoutRecorders[requestCounter](w.recorders)
+		if counter != nil {
+			w.recorders = append(w.recorders, requestCounter{counter})
+		}
+	}
+}
+
+// WithRequestCounters 追加请求计数器，每次请求原子地累加到所有计数器
+//
+// 计数粒度（WithRequestCounterGranularity）对所有请求计数器同样生效。nil 计数器被忽略。
+func WithRequestCounters(counters ...*uint64) DiscardWriterOption {
+	return func(w *DiscardWriter) {
+		for _, c := range counters {
+			if c != nil {
+				w.recorders = append(w.recorders, requestCounter{c})
+			}
+		}
+	}
+}
+
+// WithRequestCounterGranularity 设置请求计数器的更新粒度
+//
+// 启用后，写入器在本地累计请求次数，每满 n 次才对共享计数器执行一次 +n，
+// 以降低高写入频率下多个写入器竞争同一计数器的开销。
+// 这是以精确度换取吞吐量：共享计数器的值总是 n 的整数倍，
+// 相对真实请求次数向下取整，误差小于 n。n <= 1 时等价于逐次精确计数。
+// 粒度同样适用于 WithRecorder 注册的记录器：AddRequests 每次收到的是 n。
+func WithRequestCounterGranularity(n uint64) DiscardWriterOption {
+	return func(w *DiscardWriter) {
+		w.requestGranularity = n
+	}
+}
+
+// WithLimiterNames 设置限制器链中各层的名称，用于告警和统计
+//
+// names 与传入 NewDiscardWriter 的限制器按位置一一对应，
+// 通常直接使用 Builder.BuildWithNames 的返回值：
+//
+//	limiters, names := builder.BuildWithNames()
+//	writer := NewDiscardWriter(limiters, WithLimiterNames(names))
+func WithLimiterNames(names []string) DiscardWriterOption {
+	return func(w *DiscardWriter) {
+		w.names = names
 	}
 }
 
@@ -96,6 +294,10 @@ func WithRequestCounter(counter *uint64) DiscardWriterOption {
 func WithSharedQuota(quota *int64) DiscardWriterOption {
 	return func(w *DiscardWriter) {
 		w.sharedRemaining = quota
+		w.quota = nil
+		if quota != nil {
+			w.quota = sharedQuota{quota}
+		}
 	}
 }
 
@@ -106,127 +308,568 @@ func WithBatchSize(size int64) DiscardWriterOption {
 	}
 }
 
+// WithIdleRefund 设置空闲令牌作废时长
+//
+// 写入器空闲超过 after 后，批量申请但尚未消费的令牌会被作废，
+// 下一次写入将重新向限制器申请。令牌无法归还给令牌桶，但作废可以避免
+// 长时间空闲后用陈旧的批次令牌突发写入，提高多个写入器共享限制器链时的公平性。
+// 检查在每次写入开始时惰性进行，不需要额外的定时器 goroutine。作废的令牌计入 DroppedTokens。
+func WithIdleRefund(after time.Duration) DiscardWriterOption {
+	return func(w *DiscardWriter) {
+		w.idleRefund = after
+	}
+}
+
 // NewDiscardWriter 创建支持多层速率限制的数据丢弃写入器
 func NewDiscardWriter(limiters []Limiter, opts ...DiscardWriterOption) *DiscardWriter {
 	w := &DiscardWriter{
 		limiters:  limiters,
 		ctx:       context.Background(),
-		batchSize: 64 * 1024, // 默认64KB批次
+		clock:     systemClock{},
+		done:      make(chan struct{}),
+		batchSize: defaultBatchSize,
 	}
 
-	// 应用选项
 	for _, opt := range opts {
 		opt(w)
 	}
 
+	if len(w.quotaThresholds) > 0 && w.quota != nil {
+		w.quotaBudget = w.quota.Remaining()
+	}
+	if w.progress != nil {
+		w.progress.start = w.clock.Now()
+		w.progress.last = w.progress.start.UnixNano()
+	}
+	if w.saturationFn != nil {
+		w.saturation = newSaturationTracker(w.saturationFn, w.saturationThreshold, w.saturationDebounce)
+	}
+	if w.rateUpdates != nil {
+		go applyRateUpdates(w.rateUpdates, namedMutableLimiters(w.limiters, w.names), w.clock, w.logger, w.done)
+	}
+	if w.captureStack {
+		w.creationStack = captureCreationStack(2)
+	}
+	if w.needsClose() {
+		// 后台 goroutine 不持有写入器，未关闭的写入器被回收时由终结器告警并兜底关闭
+		runtime.SetFinalizer(w, finalizeUnclosed)
+	}
+
 	return w
 }
 
 // Write 实现 io.Writer 接口，支持多层速率限制的数据丢弃
 func (w *DiscardWriter) Write(p []byte) (int, error) {
-	n := len(p)
-	if n == 0 {
+	var n int
+	var err error
+	if w.fullWrite {
+		n, err = w.writeFull(p)
+	} else {
+		n, err = w.writeOnce(p)
+	}
+	w.countSize(n)
+	return n, err
+}
+
+// writeOnce 执行一次写入，配额不足时可能只接收 p 的前一部分
+func (w *DiscardWriter) writeOnce(p []byte) (int, error) {
+	n, err := w.admitCharged(p, len(p))
+	if err != nil {
+		w.recordLost(p, n, err)
+	}
+	return n, err
+}
+
+// admitCharged 为写入或纯计费执行准入，分阶段写入器在初始阶段按边界拆分
+//
+// 不做大小计数，由 Write、Charge 等用户层入口按整次调用计数。
+func (w *DiscardWriter) admitCharged(p []byte, size int) (int, error) {
+	if w.inReadPhase() {
+		return w.admitPhased(p, size, true)
+	}
+	return w.admit(p, size, true)
+}
+
+// write 执行一次写入，chargeQuota 为 false 时不从共享配额中扣除字节
+func (w *DiscardWriter) write(p []byte, chargeQuota bool) (int, error) {
+	n, err := w.admit(p, len(p), chargeQuota)
+	w.countSize(n)
+	return n, err
+}
+
+// admit 为 size 字节执行限流、计费和统计，是 Write 与 Charge 的共同实现
+//
+// p 为 nil 时表示没有数据的纯计费：令牌成本按字节数计算，不经过成本函数、
+// 去重和分类器，也不转发到下游。chargeQuota 为 false 时不从共享配额中扣除字节。
+func (w *DiscardWriter) admit(p []byte, size int, chargeQuota bool) (int, error) {
+	return w.admitContext(w.ctx, p, size, chargeQuota)
+}
+
+// admitContext 与 admit 相同，但在 ctx 而不是写入器的上下文中等待
+func (w *DiscardWriter) admitContext(ctx context.Context, p []byte, size int, chargeQuota bool) (int, error) {
+	n := size
+	if n <= 0 {
 		return 0, nil
 	}
 
 	// 检查上下文是否被取消
 	select {
-	case <-w.ctx.Done():
-		return 0, w.ctx.Err()
+	case <-ctx.Done():
+		return 0, ctx.Err()
 	default:
 	}
 
-	// 有限流：使用原子操作安全地检查和预留配额
-	if w.sharedRemaining != nil {
-		for {
-			current := atomic.LoadInt64(w.sharedRemaining)
-			if current <= 0 {
-				return 0, io.EOF // 配额耗尽
-			}
+	// 暂停期间阻塞直到恢复
+	if err := w.waitResumed(ctx); err != nil {
+		return 0, err
+	}
+
+	// 维护窗口内阻塞或失败
+	if len(w.blackouts) > 0 {
+		if err := w.waitBlackout(ctx); err != nil {
+			return 0, err
+		}
+	}
+
+	// 为链中的并发限制器占用名额，写入完成后归还
+	release, slotErr := w.acquireSlots(ctx)
+	if slotErr != nil {
+		return 0, slotErr
+	}
+	defer release()
+
+	// 按请求计费的层每次写入申请 1 个令牌，之后的准入失败时归还
+	refundRequests, err := w.chargeRequests(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	quota := w.quota
+	if !chargeQuota {
+		quota = nil
+	}
 
-			// 确定实际可用的字节数
-			available := int(current)
-			if n > available {
-				n = available // 调整到剩余配额
+	// 有限流：原子地预留配额，剩余不足时调整到剩余配额
+	var quotaBefore int64 // 预留前的剩余配额
+	if quota != nil {
+		taken, before := quota.AcquireUpTo(int64(n))
+		if taken <= 0 {
+			refundRequests()
+			w.quotaExhausted()
+			return 0, ErrQuotaExhausted
+		}
+		n = int(taken)
+		quotaBefore = before
+		if w.quotaThresholds != nil {
+			w.checkQuotaThresholds(before - taken)
+		}
+		if before-taken <= 0 {
+			w.quotaExhausted()
+		}
+	}
+
+	// 空闲过久的批次令牌作废
+	if w.idleRefund > 0 {
+		w.dropIdleTokens()
+	}
+
+	// 计算本次写入需要的令牌数（默认等于字节数）
+	cost := int64(n)
+	var dedupSum uint64
+	var dedupNovel bool
+	if p != nil {
+		cost, dedupSum, dedupNovel = w.tokenCost(p[:n])
+	}
+	if cost > 0 && w.errorFeedback != nil {
+		cost = w.errorFeedback.scaledCost(w.clock.Now(), cost)
+	}
+
+	// 令牌上限：预留本次计费，超出时拒绝写入
+	if !w.reserveCeiling(cost) {
+		if quota != nil {
+			quota.Release(int64(n)) // 回滚配额
+		}
+		refundRequests()
+		return 0, ErrTokenCeilingReached
+	}
+
+	// 配额所剩无几时缩短本次等待的期限
+	if w.quotaAwareWait && quota != nil && quotaBefore < int64(size) {
+		var cancel context.CancelFunc
+		ctx, cancel = quotaWaitContext(ctx, w.clock, quotaBefore, size)
+		defer cancel()
+	}
+
+	// 批量令牌管理
+	// 计费为零的写入（如去重命中、成本函数返回 0）完全跳过令牌申请，
+	// 除开头的上下文检查外不会在限制器上等待，但仍然更新统计并通知接收端
+	var waited time.Duration
+	useLarge := w.isLargeWrite(n) && len(w.largeLimiters) > 0
+	if cost > 0 && useLarge {
+		// 大写入走专用链，按实际成本申请，不使用也不影响普通链的批次令牌
+		if waited, err = w.acquireLarge(ctx, cost); err != nil {
+			if quota != nil {
+				quota.Release(int64(n)) // 回滚配额
 			}
-			if n <= 0 {
-				return 0, io.EOF
+			w.releaseCeiling(cost)
+			refundRequests()
+			return 0, err
+		}
+	} else if cost > 0 {
+		if waited, err = w.consumeTokens(ctx, cost); err != nil {
+			// 如果令牌申请失败且我们已经预留了配额，需要回滚配额
+			if quota != nil {
+				quota.Release(int64(n)) // 回滚配额
 			}
+			w.releaseCeiling(cost)
+			refundRequests()
+			return 0, err
+		}
+	}
 
-			// 原子地预留配额，避免竞态条件
-			newRemaining := current - int64(n)
-			if atomic.CompareAndSwapInt64(w.sharedRemaining, current, newRemaining) {
-				// 成功预留配额，跳出循环
-				break
-			}
-			// 如果CAS失败，说明其他goroutine修改了配额，重试
+	// 更新统计；计数后置时推迟到下游确认交付之后
+	forwarding := w.dst != nil && p != nil
+	countAfter := w.countAfterWrite && forwarding
+	if !countAfter {
+		w.recordCounts(n)
+	}
+
+	// 配额已在前面通过CAS操作预留，这里不需要再次扣除
+
+	// 转发到下游；未设置下游时数据直接丢弃，不做任何存储
+	if forwarding {
+		total := n
+		var undelivered int64
+		n, undelivered, err = w.forward(p[:n])
+		if undelivered > 0 {
+			w.refund(undelivered, total, cost, !useLarge, quota)
+		}
+		if countAfter && err == nil {
+			w.recordCounts(n)
 		}
 	}
 
-	// 批量令牌管理
-	if atomic.LoadInt64(&w.remainingTokens) < int64(n) {
-		batchSize := w.batchSize
+	// 只有成功准入并交付的块才加入去重窗口，失败的写入重试时仍按新内容计费
+	if dedupNovel && err == nil {
+		w.dedup.add(dedupSum)
+	}
 
-		// 注意：配额检查已在前面完成，这里不再重复检查
-		// 如果有配额限制，batchSize可能需要调整以适应剩余配额
-		if w.sharedRemaining != nil && batchSize > int64(n) {
-			// 在有配额限制的情况下，避免申请过多令牌
-			batchSize = int64(n)
+	w.waitHist.record(waited)
+	if waited > 0 {
+		for _, r := range w.recorders {
+			r.AddWait(waited)
 		}
+	}
 
-		if batchSize <= 0 {
-			return 0, io.EOF
+	// 只有成功的写入才通知统计接收端，转发失败的写入不计入
+	if err == nil {
+		for _, sink := range w.sinks {
+			sink.RecordWrite(n, waited)
 		}
+	}
 
-		// 为所有速率限制器申请令牌
-		if err := w.waitForTokens(int(batchSize)); err != nil {
-			// 如果令牌申请失败且我们已经预留了配额，需要回滚配额
-			if w.sharedRemaining != nil {
-				atomic.AddInt64(w.sharedRemaining, int64(n)) // 回滚配额
-			}
+	return n, err
+}
+
+// consumeTokens 从批次中消费 cost 个令牌，不足部分向限制器申请，返回等待时间
+//
+// 批次令牌的取出和补充都是原子的增减：并发写入不会同时透支同一批令牌，
+// 补充也不会覆盖其他写入留下的余额。申请失败时已取出和已申请的令牌留在批次中。
+func (w *DiscardWriter) consumeTokens(ctx context.Context, cost int64) (time.Duration, error) {
+	taken := w.takeTokens(cost)
+	need := cost - taken
+	if need == 0 {
+		return 0, nil
+	}
+
+	// 为所有速率限制器申请令牌
+	start := w.clock.Now()
+	want := w.acquireSize(need)
+	if isNonBlocking(ctx) {
+		want = need // 非阻塞写入不为批次余量等待
+	}
+	acquired, err := w.acquireTokens(ctx, want)
+	if err != nil {
+		atomic.AddInt64(&w.remainingTokens, taken+acquired)
+		return 0, err
+	}
+	if surplus := want - need; surplus > 0 {
+		atomic.AddInt64(&w.remainingTokens, surplus)
+	}
+
+	waited := w.clock.Now().Sub(start)
+	w.recordWait(waited)
+	if w.saturation != nil {
+		w.saturation.observe(waited)
+	}
+	return waited, nil
+}
+
+// takeTokens 从当前批次中原子地取出至多 cost 个令牌，返回取出的数量
+func (w *DiscardWriter) takeTokens(cost int64) int64 {
+	for {
+		remaining := atomic.LoadInt64(&w.remainingTokens)
+		if remaining <= 0 {
+			return 0
+		}
+		take := min(remaining, cost)
+		if atomic.CompareAndSwapInt64(&w.remainingTokens, remaining, remaining-take) {
+			return take
+		}
+	}
+}
+
+// acquireSize 返回批次缺少 need 个令牌时需要向限制器申请的令牌数
+//
+// 默认申请一个完整批次，多出的部分留给后续写入；不会少于 need。
+func (w *DiscardWriter) acquireSize(need int64) int64 {
+	// 显式提交模式：只补足本次写入缺少的令牌
+	if w.explicitCommit {
+		return need
+	}
+
+	// 注意：配额检查已在写入时完成，这里不再重复检查
+	// 如果有配额限制，避免申请超出本次写入的令牌
+	if w.quota != nil {
+		return need
+	}
+	return max(w.batchSize, need)
+}
+
+// acquireTokens 分批向限制器申请 total 个令牌，每次不超过批次大小
+//
+// 返回已成功申请的令牌数；失败时其中可能包含此前已完成的部分。
+func (w *DiscardWriter) acquireTokens(ctx context.Context, total int64) (int64, error) {
+	chunk := w.batchSize
+	if chunk <= 0 || chunk > total {
+		chunk = total
+	}
+
+	if w.maxWait > 0 && total > chunk {
+		// 分段申请时按整次申请的总延迟预估，避免逐段等待累计超过上限
+		if err := w.exceedsMaxWait(w.peekDelay(w.clock.Now(), total)); err != nil {
 			return 0, err
 		}
-		atomic.StoreInt64(&w.remainingTokens, batchSize)
+	}
+	if budget, ok := quotaWaitLimit(ctx); ok && total > chunk {
+		// 配额感知期限同样按总延迟预估
+		if d := w.peekDelay(w.clock.Now(), total); d > budget {
+			return 0, &QuotaWaitError{Estimated: d, Budget: budget}
+		}
 	}
 
-	// 更新统计
-	if w.requestCount != nil {
-		atomic.AddUint64(w.requestCount, 1)
+	var acquired int64
+	for acquired < total {
+		n := min(chunk, total-acquired)
+		if err := w.waitForTokens(ctx, int(n)); err != nil {
+			return acquired, err
+		}
+		acquired += n
 	}
-	if w.bytesWritten != nil {
-		atomic.AddInt64(w.bytesWritten, int64(n))
+	return acquired, nil
+}
+
+// Close 归还未使用的批次令牌（见 Release），并停止写入器的后台 goroutine（如速率控制通道的消费者）
+//
+// Close 是幂等的，总是返回 nil。关闭后写入器仍可继续写入，只是不再响应后台控制。
+// 需要关闭的写入器未关闭就被回收时，终结器会记录告警（附带 WithCreationStack 记录的创建栈）并代为关闭。
+func (w *DiscardWriter) Close() error {
+	w.Release()
+	w.closeOnce.Do(func() {
+		w.reportProgress(true)
+		close(w.done)
+		runtime.SetFinalizer(w, nil)
+	})
+	return nil
+}
+
+// CanFit 判断 size 字节是否能容纳在当前剩余的共享配额内
+//
+// 未设置共享配额时总是返回 true。该方法只做一次原子读取，不预留配额，
+// 因此结果只反映调用时刻的可用量：并发写入可能在之后消耗配额，
+// 对于会周期性补充的配额也不计入未来的补充量。
+func (w *DiscardWriter) CanFit(size int64) bool {
+	if w.quota == nil {
+		return true
 	}
+	return size <= w.quota.Remaining()
+}
 
-	// 配额已在前面通过CAS操作预留，这里不需要再次扣除
+// dropIdleTokens 记录本次活动时间，若距上次写入超过空闲时长则作废剩余批次令牌
+func (w *DiscardWriter) dropIdleTokens() {
+	now := w.clock.Now().UnixNano()
+	last := atomic.SwapInt64(&w.lastActive, now)
+	if last != 0 && now-last >= int64(w.idleRefund) {
+		w.dropTokens()
+	}
+}
+
+// recordCounts 更新写入器自身的统计以及统计记录器中的请求次数和字节数
+func (w *DiscardWriter) recordCounts(n int) {
+	atomic.AddInt64(&w.bytesTotal, int64(n))
+	atomic.AddUint64(&w.requestsTotal, 1)
+	w.throughput.add(w.clock.Now(), n)
+	if len(w.recorders) == 0 {
+		return
+	}
+	w.countRequest()
+	for _, r := range w.recorders {
+		r.AddBytes(int64(n))
+	}
+}
 
-	// 消费令牌
-	atomic.AddInt64(&w.remainingTokens, -int64(n))
+// countRequest 按配置的粒度向统计记录器提交请求次数
+func (w *DiscardWriter) countRequest() {
+	g := w.requestGranularity
+	if g <= 1 {
+		g = 1
+	} else if atomic.AddUint64(&w.requestPending, 1)%g != 0 {
+		// 本地累计，每满 g 次才提交到共享计数器
+		return
+	}
 
-	// 数据直接丢弃，不做任何存储
-	return n, nil
+	for _, r := range w.recorders {
+		r.AddRequests(g)
+	}
 }
 
 // waitForTokens 为所有速率限制器等待令牌
-// 对于上下文相关错误（取消、超时）立即返回，对于其他错误则跳过该限制器继续处理
-func (w *DiscardWriter) waitForTokens(n int) error {
-	var lastErr error
-	successCount := 0
+func (w *DiscardWriter) waitForTokens(ctx context.Context, n int) error {
+	limiters, names := w.chain()
+	return w.waitForChain(ctx, limiters, names, false, n)
+}
 
-	for _, limiter := range w.limiters {
-		if limiter != nil {
-			if err := limiter.WaitN(w.ctx, n); err != nil {
-				// 检查是否为上下文相关的致命错误
-				if w.ctx.Err() != nil {
-					// 上下文被取消或超时，立即返回
-					return err
-				}
-
-				// 非致命错误，记录并继续处理下一个限制器
-				lastErr = err
-				continue
+// waitForChain 为链中所有速率限制器等待令牌，large 表示这是大写入专用链
+//
+// 支持预留的层（如 *rate.Limiter）先全部通过 ReserveN 预留，再统一等待其中最长的延迟；
+// 不支持预留的层并发调用 WaitN。总等待时间取决于最慢的一层而不是各层之和。
+// 上下文在等待期间结束时，所有预留都会被取消，令牌归还给各层，取消不会消耗令牌
+// （不支持预留的层上已完成的 WaitN 无法撤销）。
+// 上下文相关错误（取消、超时）直接返回；其他错误（超过突发容量、等待会超过上下文的
+// deadline、限制器自身的错误）只使该层被跳过，全部失败时返回最后一个错误。
+func (w *DiscardWriter) waitForChain(ctx context.Context, limiters []Limiter, names []string, large bool, n int) error {
+	now := w.clock.Now()
+	deadline, hasDeadline := ctx.Deadline()
+
+	errs := make([]error, len(limiters))
+	delays := make([]time.Duration, len(limiters))
+	var reservations []*rate.Reservation
+	var blocking []int // 不支持预留、需要调用 WaitN 的层
+	var delay time.Duration
+	active := 0
+
+	// 第一阶段：在支持预留的层上预留令牌
+	nonBlocking := isNonBlocking(ctx)
+	budget, hasBudget := quotaWaitLimit(ctx)
+	for i, limiter := range limiters {
+		if limiter == nil {
+			continue
+		}
+		if perWriteLayer(limiter) {
+			continue // 并发和按请求计费的层按写入处理，不参与字节令牌的申请
+		}
+		active++
+		rl, ok := limiter.(reserver)
+		if !ok {
+			if nonBlocking {
+				cancelReservations(reservations, now)
+				return ErrNotReservable
+			}
+			blocking = append(blocking, i)
+			continue
+		}
+		r := rl.ReserveN(now, n)
+		if nonBlocking && (!r.OK() || r.DelayFrom(now) > 0) {
+			r.CancelAt(now)
+			cancelReservations(reservations, now)
+			return ErrWouldBlock
+		}
+		if !r.OK() {
+			errs[i] = fmt.Errorf("ratelimited: %s: WaitN(n=%d) exceeds burst", limiterName(names, i, large), n)
+			continue
+		}
+		d := r.DelayFrom(now)
+		if hasBudget && d > budget {
+			// 配额感知期限对整条链生效，不能跳过这一层用其余层的令牌放行
+			r.CancelAt(now)
+			cancelReservations(reservations, now)
+			return &QuotaWaitError{Estimated: d, Budget: budget}
+		}
+		if hasDeadline && now.Add(d).After(deadline) {
+			r.CancelAt(now)
+			errs[i] = fmt.Errorf("ratelimited: %s: WaitN(n=%d) would exceed context deadline: %w", limiterName(names, i, large), n, context.DeadlineExceeded)
+			continue
+		}
+		reservations = append(reservations, r)
+		delays[i] = d
+		delay = max(delay, d)
+	}
+	if active == 0 {
+		return nil
+	}
+	if err := w.exceedsMaxWait(delay); err != nil {
+		cancelReservations(reservations, now)
+		return err
+	}
+
+	// 不支持预留的层无法预估等待，最多等待 maxWait
+	waitCtx := ctx
+	if w.maxWait > 0 && len(blocking) > 0 {
+		var cancel context.CancelFunc
+		waitCtx, cancel = withClockTimeout(ctx, w.clock, w.maxWait)
+		defer cancel()
+	}
+
+	// 第二阶段：等待最长的预留延迟，同时在其余层上并发 WaitN
+	var sleepErr error
+	if delay == 0 && len(blocking) == 1 {
+		i := blocking[0]
+		errs[i] = w.waitLimiter(waitCtx, names, i, large, limiters[i], n)
+	} else {
+		var wg sync.WaitGroup
+		for _, i := range blocking {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs[i] = w.waitLimiter(waitCtx, names, i, large, limiters[i], n)
+			}()
+		}
+		sleepErr = w.sleepContext(ctx, delay)
+		wg.Wait()
+	}
+
+	// 上下文结束：取消全部预留
+	if sleepErr != nil || ctx.Err() != nil {
+		cancelReservations(reservations, now)
+		if sleepErr != nil {
+			return sleepErr
+		}
+		for _, err := range errs {
+			if err != nil {
+				return err
 			}
-			successCount++
 		}
+		return ctx.Err()
+	}
+	if waitCtx.Err() != nil {
+		cancelReservations(reservations, now)
+		return &MaxWaitError{MaxWait: w.maxWait}
+	}
+
+	var lastErr error
+	successCount := 0
+	for i, limiter := range limiters {
+		if limiter == nil {
+			continue
+		}
+		if err := errs[i]; err != nil {
+			// 非致命错误，记录并继续检查下一个限制器
+			lastErr = err
+			continue
+		}
+		successCount++
+		// 预留层的等待时间即其预留延迟
+		w.observeLayerWait(ctx, names, i, large, delays[i])
 	}
 
 	// 如果所有限制器都失败了，返回最后一个错误
@@ -237,6 +880,63 @@ func (w *DiscardWriter) waitForTokens(n int) error {
 	return nil
 }
 
+// cancelReservations 取消在 at 时刻完成的全部预留，按预留的逆序取消令牌桶才能完整恢复
+func cancelReservations(reservations []*rate.Reservation, at time.Time) {
+	for i := len(reservations) - 1; i >= 0; i-- {
+		reservations[i].CancelAt(at)
+	}
+}
+
+// sleepContext 按写入器的时钟等待 d，期间上下文结束时返回其错误
+func (w *DiscardWriter) sleepContext(ctx context.Context, d time.Duration) error {
+	return Sleep(ctx, w.clock, d)
+}
+
+// waitLimiter 在单个限制器上等待令牌并测量等待时间
+func (w *DiscardWriter) waitLimiter(ctx context.Context, names []string, i int, large bool, limiter Limiter, n int) error {
+	start := w.clock.Now()
+	err := limiter.WaitN(ctx, n)
+	w.observeLayerWait(ctx, names, i, large, w.clock.Now().Sub(start))
+	return err
+}
+
+// observeLayerWait 累计第 i 层的等待时长，超过阈值时触发等待告警和等待观察
+func (w *DiscardWriter) observeLayerWait(ctx context.Context, names []string, i int, large bool, waited time.Duration) {
+	alert := w.waitAlert != nil && waited > w.waitAlertThreshold
+	if waited <= 0 && !alert {
+		return
+	}
+	name := limiterName(names, i, large)
+	if waited > 0 {
+		w.recordLayerWait(name, waited)
+	}
+	if alert {
+		// 异步回调，告警处理不阻塞写入
+		go w.waitAlert(name, waited)
+	}
+	for _, o := range w.waitObservers {
+		if waited > o.threshold {
+			o.observer.ObserveLayerWait(ctx, name, waited)
+		}
+	}
+	if len(w.hooks) > 0 {
+		w.throttleHooks(name, waited)
+	}
+}
+
+// limiterName 返回第 i 个限制器的名称，未命名时使用其在链中的位置
+//
+// 大写入专用链没有名称，使用 "large[i]" 形式。
+func limiterName(names []string, i int, large bool) string {
+	if large {
+		return fmt.Sprintf("large[%d]", i)
+	}
+	if i < len(names) && names[i] != "" {
+		return names[i]
+	}
+	return fmt.Sprintf("limiter[%d]", i)
+}
+
 // CopyWithRateLimit 使用多层速率限制从 reader 复制数据到 Discard
 // 这是最常用的便利函数
 func CopyWithRateLimit(ctx context.Context, reader io.Reader, limiters []Limiter, opts ...DiscardWriterOption) (int64, error) {
@@ -244,15 +944,17 @@ func CopyWithRateLimit(ctx context.Context, reader io.Reader, limiters []Limiter
 	allOpts := append([]DiscardWriterOption{WithContext(ctx)}, opts...)
 
 	writer := NewDiscardWriter(limiters, allOpts...)
+	defer writer.Close()
 	return io.Copy(writer, reader)
 }
 
 // CopyNWithRateLimit 使用多层速率限制复制指定字节数到 Discard
 func CopyNWithRateLimit(ctx context.Context, reader io.Reader, n int64, limiters []Limiter, opts ...DiscardWriterOption) (int64, error) {
-	// 添加上下文选项
-	allOpts := append([]DiscardWriterOption{WithContext(ctx)}, opts...)
+	// 添加上下文选项，进度报告的总量默认为 n
+	allOpts := append([]DiscardWriterOption{WithContext(ctx), WithProgressTotal(n)}, opts...)
 
 	writer := NewDiscardWriter(limiters, allOpts...)
+	defer writer.Close()
 	return io.CopyN(writer, reader, n)
 }
 
@@ -281,11 +983,36 @@ func Chain(limiters ...*rate.Limiter) []Limiter {
 	return result
 }
 
+// ChainAny 使用任意 Limiter 实现创建多层限制器链，层级顺序与 Chain 相同
+//
+// 第三方或自定义的限制器（如分布式限制器、测试替身）可以与 *rate.Limiter 混合组合。
+// 与 Chain 一样过滤 nil，包括以 Limiter 接口传入的 nil *rate.Limiter。
+func ChainAny(limiters ...Limiter) []Limiter {
+	result := make([]Limiter, 0, len(limiters))
+	for _, limiter := range limiters {
+		if !isNilLimiter(limiter) {
+			result = append(result, limiter)
+		}
+	}
+	return result
+}
+
+// isNilLimiter 判断限制器是否为 nil 或包装了 nil 的 *rate.Limiter
+func isNilLimiter(limiter Limiter) bool {
+	if limiter == nil {
+		return true
+	}
+	rl, ok := limiter.(*rate.Limiter)
+	return ok && rl == nil
+}
+
 // =============================================================================
 // 调试支持 - 带名称的限制器
 // =============================================================================
 
 // NamedLimiter 带名称的限制器，便于调试和日志记录
+//
+// Limiter 可以是 *rate.Limiter 或任意 Limiter 实现。
 // 使用示例：
 //
 //	limiters := ChainWithNames(
@@ -294,14 +1021,16 @@ func Chain(limiters ...*rate.Limiter) []Limiter {
 //	)
 type NamedLimiter struct {
 	Name    string
-	Limiter *rate.Limiter
+	Limiter Limiter
 }
 
 // ChainWithNames 创建带名称的多层限制器链
+//
+// 与 ChainAny 一样过滤 nil，包括以 Limiter 接口传入的 nil *rate.Limiter。
 func ChainWithNames(namedLimiters ...NamedLimiter) []Limiter {
 	result := make([]Limiter, 0, len(namedLimiters))
 	for _, nl := range namedLimiters {
-		if nl.Limiter != nil {
+		if !isNilLimiter(nl.Limiter) {
 			result = append(result, nl.Limiter)
 		}
 	}
@@ -320,7 +1049,13 @@ func ChainWithNames(namedLimiters ...NamedLimiter) []Limiter {
 //	    Add("secondary", secondaryLimiter).
 //	    Build()
 type Builder struct {
-	limiters []NamedLimiter
+	layers []builderLayer
+}
+
+// builderLayer 建造者中的一个命名层级
+type builderLayer struct {
+	name    string
+	limiter Limiter
 }
 
 // NewBuilder 创建限制器建造者
@@ -331,27 +1066,54 @@ func NewBuilder() *Builder {
 // Add 添加命名限制器
 func (b *Builder) Add(name string, limiter *rate.Limiter) *Builder {
 	if limiter != nil {
-		b.limiters = append(b.limiters, NamedLimiter{Name: name, Limiter: limiter})
+		b.layers = append(b.layers, builderLayer{name: name, limiter: limiter})
+	}
+	return b
+}
+
+// AddLimiter 添加任意 Limiter 实现的命名限制器
+//
+// 用于让装饰过的或自定义的限制器（测试替身、分布式限制器等）参与命名链和 BuildWithNames。
+// 与 Add 一样忽略 nil，包括以 Limiter 接口传入的 nil *rate.Limiter。
+func (b *Builder) AddLimiter(name string, limiter Limiter) *Builder {
+	if !isNilLimiter(limiter) {
+		b.layers = append(b.layers, builderLayer{name: name, limiter: limiter})
 	}
 	return b
 }
 
 // Build 构建限制器链
 func (b *Builder) Build() []Limiter {
-	return ChainWithNames(b.limiters...)
+	limiters, _ := b.BuildWithNames()
+	return limiters
 }
 
 // BuildWithNames 构建限制器链并返回名称信息
 func (b *Builder) BuildWithNames() ([]Limiter, []string) {
-	limiters := make([]Limiter, 0, len(b.limiters))
-	names := make([]string, 0, len(b.limiters))
+	limiters := make([]Limiter, 0, len(b.layers))
+	names := make([]string, 0, len(b.layers))
 
-	for _, nl := range b.limiters {
-		if nl.Limiter != nil {
-			limiters = append(limiters, nl.Limiter)
-			names = append(names, nl.Name)
-		}
+	for _, layer := range b.layers {
+		limiters = append(limiters, layer.limiter)
+		names = append(names, layer.name)
 	}
 
 	return limiters, names
 }
+
+// WriterFactory 返回一个写入器工厂，每次调用都创建共享同一限制器链的新写入器
+//
+// 适用于接受 func() io.Writer 等工厂函数、按请求创建写入器的框架。链在调用
+// WriterFactory 时构建一次，之后对 Builder 的修改不影响工厂；层级名称自动通过
+// WithLimiterNames 传入，显式传入的 opts 在其后应用。
+//
+// 每个写入器拥有独立的批次令牌和自身统计；共享配额与外部计数器通过指针传入，
+// 因此 opts 中的 WithSharedQuota、WithBytesCounter 等会被所有写入器共享——
+// 除非显式传入共享指针，否则写入器之间的配额和计数互不影响。
+func (b *Builder) WriterFactory(opts ...DiscardWriterOption) func() *DiscardWriter {
+	limiters, names := b.BuildWithNames()
+	allOpts := append([]DiscardWriterOption{WithLimiterNames(names)}, opts...)
+	return func() *DiscardWriter {
+		return NewDiscardWriter(limiters, allOpts...)
+	}
+}
@@ -22,6 +22,8 @@ package ratelimited
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"io"
 	"strings"
 	"sync"
@@ -97,6 +99,28 @@ func assertAtomicEqual(t *testing.T, expected int64, actual *int64, message stri
 	}
 }
 
+// countingLimiter 记录 WaitN 调用次数与申请令牌总量的限制器，总是立即放行
+type countingLimiter struct {
+	calls  int64
+	tokens int64
+}
+
+func (c *countingLimiter) WaitN(ctx context.Context, n int) error {
+	atomic.AddInt64(&c.calls, 1)
+	atomic.AddInt64(&c.tokens, int64(n))
+	return nil
+}
+
+// callCount 返回 WaitN 的调用次数
+func (c *countingLimiter) callCount() int64 {
+	return atomic.LoadInt64(&c.calls)
+}
+
+// tokenCount 返回累计申请的令牌数
+func (c *countingLimiter) tokenCount() int64 {
+	return atomic.LoadInt64(&c.tokens)
+}
+
 // =============================================================================
 // 核心功能测试 - DiscardWriter
 // =============================================================================
@@ -289,6 +313,76 @@ func TestDiscardWriter_LayerOrdering(t *testing.T) {
 	}
 }
 
+// rendezvousLimiter 等到所有层都进入 WaitN 后才放行，串行等待会在超时后失败
+type rendezvousLimiter struct {
+	arrived *sync.WaitGroup
+}
+
+func (l rendezvousLimiter) WaitN(ctx context.Context, n int) error {
+	l.arrived.Done()
+	done := make(chan struct{})
+	go func() {
+		l.arrived.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-time.After(2 * time.Second):
+		return errors.New("其他层没有同时等待")
+	}
+}
+
+// TestDiscardWriter_ConcurrentLayerWaits 测试各层并发等待令牌
+//
+// 测试目标：
+//   - 所有层同时处于等待中，总等待时间取最慢一层而不是各层之和
+func TestDiscardWriter_ConcurrentLayerWaits(t *testing.T) {
+	// Arrange
+	var arrived sync.WaitGroup
+	arrived.Add(3)
+	layer := rendezvousLimiter{arrived: &arrived}
+	writer := NewDiscardWriter([]Limiter{layer, nil, layer, layer})
+
+	// Act
+	n, err := writer.Write(createTestData(10))
+
+	// Assert
+	assertNoError(t, err, "各层应该并发等待")
+	assertEqual(t, 10, n, "写入字节数应该正确")
+}
+
+// TestDiscardWriter_CancelRestoresReservations 测试等待中取消时各层令牌被完整归还
+//
+// 测试目标：
+//   - 前两层可以立即放行、第三层需要长时间等待时，取消上下文使写入失败
+//   - 已在前两层预留的令牌全部归还，取消没有副作用
+func TestDiscardWriter_CancelRestoresReservations(t *testing.T) {
+	// Arrange: 第三层的令牌桶已被清空，补充 10 个令牌需要 10 秒
+	first := rate.NewLimiter(1, 100)
+	second := rate.NewLimiter(1, 100)
+	third := rate.NewLimiter(1, 100)
+	third.AllowN(time.Now(), 100)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+	writer := NewDiscardWriter(Chain(first, second, third), WithContext(ctx), WithBatchSize(10))
+
+	// Act
+	n, err := writer.Write(createTestData(10))
+
+	// Assert
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("期望 context.Canceled，实际为 %v", err)
+	}
+	assertEqual(t, 0, n, "取消的写入不应该接收字节")
+	for i, l := range []*rate.Limiter{first, second} {
+		if tokens := l.Tokens(); tokens < 99.9 {
+			t.Errorf("第 %d 层的令牌应该被归还，实际剩余 %.1f", i+1, tokens)
+		}
+	}
+}
+
 // =============================================================================
 // 配额管理测试
 // =============================================================================
@@ -355,11 +449,92 @@ func TestDiscardWriter_QuotaManagement(t *testing.T) {
 		n, err := writer.Write(testData)
 
 		// Assert
-		assertEqual(t, io.EOF, err, "配额耗尽时应该返回 EOF")
+		assertEqual(t, ErrQuotaExhausted, err, "配额耗尽时应该返回 ErrQuotaExhausted")
+		if !errors.Is(err, io.EOF) {
+			t.Error("ErrQuotaExhausted 应该兼容 io.EOF")
+		}
 		assertEqual(t, 0, n, "配额耗尽时不应该写入任何数据")
 	})
 }
 
+// TestDiscardWriter_CanFit 测试写入前的配额预检
+func TestDiscardWriter_CanFit(t *testing.T) {
+	// Arrange
+	quota := int64(1000)
+	limiter := rate.NewLimiter(100000, 100000)
+	withQuota := NewDiscardWriter(Chain(limiter), WithSharedQuota(&quota))
+	withoutQuota := NewDiscardWriter(Chain(limiter))
+
+	// Act & Assert
+	assertEqual(t, true, withQuota.CanFit(1000), "恰好等于剩余配额时应该能容纳")
+	assertEqual(t, false, withQuota.CanFit(1001), "超过剩余配额时不应该能容纳")
+	assertEqual(t, true, withoutQuota.CanFit(1<<40), "未设置配额时应该总能容纳")
+
+	_, err := withQuota.Write(createTestData(600))
+	assertNoError(t, err, "配额内写入应该成功")
+	assertEqual(t, false, withQuota.CanFit(500), "写入后剩余配额不足时应该返回 false")
+	assertEqual(t, int64(400), atomic.LoadInt64(&quota), "CanFit 不应该消耗配额")
+}
+
+// =============================================================================
+// 空闲令牌作废测试
+// =============================================================================
+
+// TestDiscardWriter_IdleRefund 测试空闲后作废剩余批次令牌
+//
+// 测试目标：
+//   - 空闲时间未超过阈值时继续使用剩余批次令牌
+//   - 空闲超过阈值后剩余令牌被作废，下一次写入重新申请
+func TestDiscardWriter_IdleRefund(t *testing.T) {
+	// Arrange
+	clock := newFakeClock()
+	limiter := &countingLimiter{}
+	writer := NewDiscardWriter([]Limiter{limiter},
+		WithClock(clock),
+		WithBatchSize(1000),
+		WithIdleRefund(time.Second),
+	)
+	data := createTestData(100)
+
+	// Act & Assert: 首次写入申请一个批次
+	_, err := writer.Write(data)
+	assertNoError(t, err, "首次写入应该成功")
+	assertEqual(t, int64(1), limiter.callCount(), "首次写入应该申请一次令牌")
+
+	// 短暂间隔后继续消费同一批次
+	clock.Advance(100 * time.Millisecond)
+	_, err = writer.Write(data)
+	assertNoError(t, err, "第二次写入应该成功")
+	assertEqual(t, int64(1), limiter.callCount(), "未空闲时应该复用批次令牌")
+
+	// 空闲超过阈值后剩余令牌被作废
+	clock.Advance(2 * time.Second)
+	_, err = writer.Write(data)
+	assertNoError(t, err, "空闲后写入应该成功")
+	assertEqual(t, int64(2), limiter.callCount(), "空闲后应该重新申请令牌")
+}
+
+// TestDiscardWriter_IdleRefundDisabled 测试未启用时保留批次令牌
+func TestDiscardWriter_IdleRefundDisabled(t *testing.T) {
+	// Arrange
+	clock := newFakeClock()
+	limiter := &countingLimiter{}
+	writer := NewDiscardWriter([]Limiter{limiter},
+		WithClock(clock),
+		WithBatchSize(1000),
+	)
+	data := createTestData(100)
+
+	// Act
+	_, _ = writer.Write(data)
+	clock.Advance(time.Hour)
+	_, err := writer.Write(data)
+
+	// Assert
+	assertNoError(t, err, "写入应该成功")
+	assertEqual(t, int64(1), limiter.callCount(), "未启用空闲作废时应该复用批次令牌")
+}
+
 // =============================================================================
 // 上下文控制测试
 // =============================================================================
@@ -546,6 +721,22 @@ func TestChain_VariousConfigurations(t *testing.T) {
 	}
 }
 
+// TestChainAny 测试使用任意 Limiter 实现创建链
+func TestChainAny(t *testing.T) {
+	// Arrange
+	var nilRate *rate.Limiter
+	custom := &countingLimiter{}
+	primary := rate.NewLimiter(1000, 1000)
+
+	// Act
+	result := ChainAny(primary, nil, custom, nilRate)
+
+	// Assert
+	assertEqual(t, 2, len(result), "nil 和包装了 nil 的 *rate.Limiter 应该被过滤掉")
+	assertEqual(t, Limiter(primary), result[0], "应该保持传入顺序")
+	assertEqual(t, Limiter(custom), result[1], "自定义限制器应该被保留")
+}
+
 // TestBuilder_ChainConstruction 测试建造者模式
 func TestBuilder_ChainConstruction(t *testing.T) {
 	// Arrange
@@ -575,6 +766,31 @@ func TestBuilder_ChainConstruction(t *testing.T) {
 	assertEqual(t, "secondary", names[1], "第二个名称应该正确")
 }
 
+// TestBuilder_WriterFactory 测试工厂创建的写入器共享链但统计独立
+func TestBuilder_WriterFactory(t *testing.T) {
+	// Arrange
+	limiter := rate.NewLimiter(rate.Inf, 0)
+	newWriter := NewBuilder().Add("global", limiter).WriterFactory(WithBatchSize(128))
+
+	// Act
+	first := newWriter()
+	second := newWriter()
+	_, err := first.Write(createTestData(100))
+	assertNoError(t, err, "写入应该成功")
+	_, err = second.Write(createTestData(30))
+	assertNoError(t, err, "写入应该成功")
+
+	// Assert
+	if first == second {
+		t.Fatal("每次调用应该创建新的写入器")
+	}
+	assertEqual(t, first.limiters[0], second.limiters[0], "写入器应该共享同一限制器链")
+	assertEqual(t, "global", limiterName(second.names, 0, false), "层级名称应该被传入")
+	assertEqual(t, int64(128), second.batchSize, "选项应该应用到每个写入器")
+	assertAtomicEqual(t, 100, &first.bytesTotal, "第一个写入器的统计应该独立")
+	assertAtomicEqual(t, 30, &second.bytesTotal, "第二个写入器的统计应该独立")
+}
+
 // TestBuilder_NilHandling 测试建造者模式对nil的处理
 func TestBuilder_NilHandling(t *testing.T) {
 	// Act
@@ -588,6 +804,29 @@ func TestBuilder_NilHandling(t *testing.T) {
 	assertEqual(t, 2, len(limiters), "nil限制器应该被过滤掉")
 }
 
+// TestBuilder_AddLimiter 测试建造者添加任意 Limiter 实现
+func TestBuilder_AddLimiter(t *testing.T) {
+	// Arrange
+	var nilRate *rate.Limiter
+	custom := &countingLimiter{}
+
+	// Act
+	limiters, names := NewBuilder().
+		Add("global", rate.NewLimiter(rate.Inf, 0)).
+		AddLimiter("custom", custom).
+		AddLimiter("nil", nil).
+		AddLimiter("nil_rate", nilRate).
+		BuildWithNames()
+	writer := NewDiscardWriter(limiters, WithLimiterNames(names), WithBatchSize(1))
+	_, err := writer.Write(createTestData(10))
+
+	// Assert
+	assertNoError(t, err, "写入应该成功")
+	assertEqual(t, 2, len(limiters), "nil 限制器应该被过滤掉")
+	assertEqual(t, "custom", names[1], "自定义限制器应该保留名称")
+	assertAtomicEqual(t, 10, &custom.tokens, "自定义限制器应该参与令牌申请")
+}
+
 // TestChainWithNames_Functionality 测试带名称的链构造
 func TestChainWithNames_Functionality(t *testing.T) {
 	// Arrange
@@ -807,6 +1046,88 @@ func TestDiscardWriter_ConcurrentWrite(t *testing.T) {
 	assertEqual(t, expectedRequests, actualRequests, "并发写入的总请求数应该正确")
 }
 
+// TestDiscardWriter_RequestCounterGranularity 测试请求计数粒度
+//
+// 测试目标：
+//   - 验证共享计数器只以粒度的整数倍增长
+//   - 确保近似计数与真实请求次数的误差小于粒度
+func TestDiscardWriter_RequestCounterGranularity(t *testing.T) {
+	// Arrange
+	setup := newTestSetup()
+	defer setup.cleanup()
+
+	const granularity = 16
+	const goroutineCount = 8
+	const writesPerGoroutine = 125
+
+	limiter := rate.NewLimiter(rate.Inf, 0)
+	writer := NewDiscardWriter(Chain(limiter),
+		WithContext(setup.ctx),
+		WithRequestCounter(&setup.requestCount),
+		WithRequestCounterGranularity(granularity),
+	)
+
+	var wg sync.WaitGroup
+	wg.Add(goroutineCount)
+
+	// Act
+	for i := 0; i < goroutineCount; i++ {
+		go func() {
+			defer wg.Done()
+			testData := createTestData(10)
+			for j := 0; j < writesPerGoroutine; j++ {
+				if _, err := writer.Write(testData); err != nil {
+					t.Errorf("写入失败: %v", err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Assert
+	trueCount := uint64(goroutineCount * writesPerGoroutine)
+	approxCount := atomic.LoadUint64(&setup.requestCount)
+
+	assertEqual(t, uint64(0), approxCount%granularity, "近似计数应该是粒度的整数倍")
+	if approxCount > trueCount || trueCount-approxCount >= granularity {
+		t.Errorf("近似计数 %d 与真实计数 %d 的误差应该小于 %d", approxCount, trueCount, granularity)
+	}
+}
+
+// TestDiscardWriter_MultipleCounters 测试同一写入累加到多个计数器
+//
+// 测试目标：
+//   - 连接级与全局字节计数器都反映写入总量
+//   - 全局计数器可以被多个写入器共享
+//   - 请求计数器同样支持多个
+func TestDiscardWriter_MultipleCounters(t *testing.T) {
+	// Arrange
+	var connA, connB, global int64
+	var connRequests, globalRequests uint64
+	limiters := Chain(rate.NewLimiter(rate.Inf, 0))
+	writerA := NewDiscardWriter(limiters,
+		WithBytesCounters(&connA, &global),
+		WithRequestCounters(&connRequests, &globalRequests),
+	)
+	writerB := NewDiscardWriter(limiters, WithBytesCounters(&connB, &global))
+
+	// Act
+	for i := 0; i < 3; i++ {
+		_, err := writerA.Write(createTestData(100))
+		assertNoError(t, err, "写入应该成功")
+	}
+	_, err := writerB.Write(createTestData(50))
+	assertNoError(t, err, "写入应该成功")
+
+	// Assert
+	assertAtomicEqual(t, 300, &connA, "连接 A 的计数器应该反映其写入总量")
+	assertAtomicEqual(t, 50, &connB, "连接 B 的计数器应该反映其写入总量")
+	assertAtomicEqual(t, 350, &global, "全局计数器应该反映所有写入")
+	assertEqual(t, uint64(3), atomic.LoadUint64(&connRequests), "连接请求计数")
+	assertEqual(t, uint64(3), atomic.LoadUint64(&globalRequests), "全局请求计数")
+}
+
 // =============================================================================
 // 性能基准测试
 // =============================================================================
@@ -849,6 +1170,31 @@ func BenchmarkDiscardWriter_MultiLayer(b *testing.B) {
 	}
 }
 
+// BenchmarkDiscardWriter_SharedRequestCounter 多个写入器共享请求计数器时的竞争开销
+func BenchmarkDiscardWriter_SharedRequestCounter(b *testing.B) {
+	for _, granularity := range []uint64{1, 64} {
+		b.Run(fmt.Sprintf("granularity=%d", granularity), func(b *testing.B) {
+			limiter := rate.NewLimiter(rate.Inf, 0)
+			var requests uint64
+			data := createTestData(64)
+
+			b.ReportAllocs()
+			b.RunParallel(func(pb *testing.PB) {
+				// 每个 goroutine 使用独立的写入器，只共享计数器
+				writer := NewDiscardWriter(Chain(limiter),
+					WithRequestCounter(&requests),
+					WithRequestCounterGranularity(granularity),
+				)
+				for pb.Next() {
+					if _, err := writer.Write(data); err != nil {
+						b.Fatalf("写入失败: %v", err)
+					}
+				}
+			})
+		})
+	}
+}
+
 // BenchmarkCopyWithRateLimit 便利函数的性能基准
 func BenchmarkCopyWithRateLimit(b *testing.B) {
 	limiter := rate.NewLimiter(1000000, 1000000)
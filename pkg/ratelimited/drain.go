@@ -0,0 +1,148 @@
+package ratelimited
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// =============================================================================
+// 排空结果分类 - 区分源结束与写入端失败
+// =============================================================================
+
+// DrainOutcome 排空操作的结束原因
+//
+// CopyWithRateLimit 直接返回 io.Copy 的错误：源结束被折叠为 nil，配额耗尽表现为
+// ErrQuotaExhausted，而上下文与限制器的错误需要调用方自行辨认，难以据此决定是否重试。
+// Drain / DrainN 在复制的同时记录读写两端的错误，并给出明确的分类。
+type DrainOutcome int
+
+const (
+	// CompletedEOF 源正常结束，或已复制到 DrainN 指定的字节数
+	CompletedEOF DrainOutcome = iota
+	// QuotaExhausted 共享配额耗尽，写入端拒绝继续接收数据
+	QuotaExhausted
+	// ContextCanceled 上下文被取消或超时
+	ContextCanceled
+	// ReaderError 源在中途返回了非 io.EOF 的错误
+	ReaderError
+	// LimiterError 速率限制器返回了非上下文相关的错误
+	LimiterError
+)
+
+// String 返回结束原因的名称
+func (o DrainOutcome) String() string {
+	switch o {
+	case CompletedEOF:
+		return "CompletedEOF"
+	case QuotaExhausted:
+		return "QuotaExhausted"
+	case ContextCanceled:
+		return "ContextCanceled"
+	case ReaderError:
+		return "ReaderError"
+	case LimiterError:
+		return "LimiterError"
+	default:
+		return "DrainOutcome(unknown)"
+	}
+}
+
+// DrainResult 排空操作的结果
+type DrainResult struct {
+	Copied  int64        // 已被写入端接收的字节数
+	Outcome DrainOutcome // 结束原因
+	Err     error        // 原始错误，CompletedEOF 时通常为 nil
+}
+
+// Drain 与 CopyWithRateLimit 相同，但返回区分结束原因的结果
+func Drain(ctx context.Context, reader io.Reader, limiters []Limiter, opts ...DiscardWriterOption) DrainResult {
+	allOpts := append([]DiscardWriterOption{WithContext(ctx)}, opts...)
+
+	tr := &drainReader{r: reader}
+	tw := &drainWriter{w: NewDiscardWriter(limiters, allOpts...)}
+	defer tw.w.Close()
+	copied, err := io.Copy(tw, tr)
+
+	return classifyDrain(ctx, copied, err, tr, tw)
+}
+
+// DrainN 与 CopyNWithRateLimit 相同，但返回区分结束原因的结果
+//
+// 源在复制满 n 字节之前结束时，Outcome 为 CompletedEOF，Err 为 io.EOF（与 io.CopyN 一致）。
+func DrainN(ctx context.Context, reader io.Reader, n int64, limiters []Limiter, opts ...DiscardWriterOption) DrainResult {
+	allOpts := append([]DiscardWriterOption{WithContext(ctx)}, opts...)
+
+	tr := &drainReader{r: reader}
+	tw := &drainWriter{w: NewDiscardWriter(limiters, allOpts...)}
+	defer tw.w.Close()
+	copied, err := io.CopyN(tw, tr, n)
+
+	return classifyDrain(ctx, copied, err, tr, tw)
+}
+
+// classifyDrain 根据读写两端记录的错误给出结束原因
+func classifyDrain(ctx context.Context, copied int64, err error, tr *drainReader, tw *drainWriter) DrainResult {
+	result := DrainResult{Copied: copied, Err: err}
+
+	switch {
+	case err == nil:
+		result.Outcome = CompletedEOF
+	case tw.err != nil:
+		// 写入端的错误优先于读取端，与 io.Copy 的处理顺序一致
+		result.Outcome = classifyWriteError(ctx, tw.err)
+	case tw.short:
+		// 写入器只在配额不足时返回短写
+		result.Outcome = QuotaExhausted
+	case tr.err != nil && tr.err != io.EOF:
+		result.Outcome = ReaderError
+	default:
+		result.Outcome = CompletedEOF
+	}
+
+	return result
+}
+
+// classifyWriteError 对写入端返回的错误进行分类
+func classifyWriteError(ctx context.Context, err error) DrainOutcome {
+	switch {
+	case errors.Is(err, ErrQuotaExhausted), err == io.EOF:
+		return QuotaExhausted
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded), ctx.Err() != nil:
+		return ContextCanceled
+	default:
+		return LimiterError
+	}
+}
+
+// drainReader 记录源返回的最后一个错误
+type drainReader struct {
+	r   io.Reader
+	err error
+}
+
+func (r *drainReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if err != nil {
+		r.err = err
+	}
+	return n, err
+}
+
+// drainWriter 记录写入端返回的第一个错误以及是否发生过短写
+type drainWriter struct {
+	w     *DiscardWriter
+	err   error
+	short bool
+}
+
+func (w *drainWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	if err != nil && w.err == nil {
+		w.err = err
+	}
+	if err == nil && n < len(p) {
+		w.short = true
+	}
+	return n, err
+}
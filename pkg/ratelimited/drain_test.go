@@ -0,0 +1,132 @@
+package ratelimited
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"golang.org/x/time/rate"
+)
+
+// TestDrain_OutcomeClassification 测试排空结果的分类
+//
+// 测试目标：
+//   - 源正常结束、配额耗尽、上下文取消、源错误、限制器错误各自得到不同的分类
+//   - Copied 反映写入端实际接收的字节数
+func TestDrain_OutcomeClassification(t *testing.T) {
+	errBoom := errors.New("boom")
+
+	testCases := []struct {
+		name           string
+		reader         func() io.Reader
+		limiters       func() []Limiter
+		ctx            func() context.Context
+		quota          int64
+		expectedCopied int64
+		expected       DrainOutcome
+	}{
+		{
+			name:           "源正常结束",
+			reader:         func() io.Reader { return strings.NewReader("hello world") },
+			expectedCopied: 11,
+			expected:       CompletedEOF,
+		},
+		{
+			name:           "配额耗尽",
+			reader:         func() io.Reader { return strings.NewReader(strings.Repeat("x", 100)) },
+			quota:          10,
+			expectedCopied: 10,
+			expected:       QuotaExhausted,
+		},
+		{
+			name:   "上下文取消",
+			reader: func() io.Reader { return strings.NewReader("hello") },
+			ctx: func() context.Context {
+				ctx, cancel := context.WithCancel(context.Background())
+				cancel()
+				return ctx
+			},
+			expectedCopied: 0,
+			expected:       ContextCanceled,
+		},
+		{
+			name: "源中途出错",
+			reader: func() io.Reader {
+				return io.MultiReader(strings.NewReader("abc"), iotest.ErrReader(errBoom))
+			},
+			expectedCopied: 3,
+			expected:       ReaderError,
+		},
+		{
+			name:   "限制器全部失败",
+			reader: func() io.Reader { return strings.NewReader("hello") },
+			limiters: func() []Limiter {
+				return []Limiter{&MockFailingLimiter{shouldFail: true, failError: io.ErrUnexpectedEOF}}
+			},
+			expectedCopied: 0,
+			expected:       LimiterError,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			ctx := context.Background()
+			if tc.ctx != nil {
+				ctx = tc.ctx()
+			}
+			limiters := Chain(rate.NewLimiter(100000, 100000))
+			if tc.limiters != nil {
+				limiters = tc.limiters()
+			}
+			var opts []DiscardWriterOption
+			if tc.quota > 0 {
+				quota := tc.quota
+				opts = append(opts, WithSharedQuota(&quota))
+			}
+
+			// Act
+			result := Drain(ctx, tc.reader(), limiters, opts...)
+
+			// Assert
+			assertEqual(t, tc.expected, result.Outcome, "结束原因分类应该正确")
+			assertEqual(t, tc.expectedCopied, result.Copied, "复制字节数应该正确")
+			if tc.expected != CompletedEOF && result.Err == nil {
+				t.Errorf("%s 应该携带原始错误", tc.expected)
+			}
+		})
+	}
+}
+
+// TestDrainN_EarlyEOF 测试 DrainN 在源提前结束时的分类
+func TestDrainN_EarlyEOF(t *testing.T) {
+	// Arrange
+	reader := strings.NewReader("short")
+	limiters := Chain(rate.NewLimiter(100000, 100000))
+
+	// Act
+	result := DrainN(context.Background(), reader, 100, limiters)
+
+	// Assert
+	assertEqual(t, CompletedEOF, result.Outcome, "源提前结束应该归类为 CompletedEOF")
+	assertEqual(t, int64(5), result.Copied, "复制字节数应该等于源长度")
+	assertEqual(t, io.EOF, result.Err, "应该保留 io.CopyN 的 io.EOF")
+}
+
+// TestDrainN_QuotaVersusEOF 测试配额耗尽与源结束不再混淆
+func TestDrainN_QuotaVersusEOF(t *testing.T) {
+	// Arrange: 配额恰好在源结束前耗尽
+	quota := int64(4)
+	reader := strings.NewReader("0123456789")
+	limiters := Chain(rate.NewLimiter(100000, 100000))
+
+	// Act
+	result := DrainN(context.Background(), reader, 10, limiters, WithSharedQuota(&quota))
+
+	// Assert
+	assertEqual(t, QuotaExhausted, result.Outcome, "配额耗尽应该与源结束区分")
+	assertEqual(t, int64(4), result.Copied, "只应该复制配额内的字节")
+}
@@ -0,0 +1,163 @@
+package ratelimited
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// =============================================================================
+// 双窗口限制器 - 同时满足短期速率和长期总量
+// =============================================================================
+
+// DualWindowLimiter 同时执行短期令牌桶和长期滑动窗口上限的限制器
+//
+// 对应云服务常见的"每秒不超过 X、每小时不超过 Y"的配额形态：短期速率由令牌桶控制，
+// 长期上限统计最近 longWindow 内已放行的令牌总数。WaitN 只有在两者都满足时才返回，
+// 可以直接作为链中的一层，无需手动组合两种不同的限制器。
+type DualWindowLimiter struct {
+	short      *rate.Limiter
+	longCap    int64
+	longWindow time.Duration
+	clock      Clock
+
+	mu     sync.Mutex
+	grants []windowGrant // 按放行时间排序的长期窗口内的放行记录
+	used   int64         // grants 中的令牌总数
+}
+
+// windowGrant 一次放行记录
+type windowGrant struct {
+	at time.Time
+	n  int64
+}
+
+// NewDualWindowLimiter 创建短期速率为 shortRate（每秒）、突发容量为 shortBurst，
+// 且任意 longWindow 时长内累计不超过 longCap 个令牌的限制器
+func NewDualWindowLimiter(shortRate, shortBurst int, longCap int64, longWindow time.Duration) *DualWindowLimiter {
+	return &DualWindowLimiter{
+		short:      rate.NewLimiter(rate.Limit(shortRate), shortBurst),
+		longCap:    longCap,
+		longWindow: longWindow,
+		clock:      systemClock{},
+	}
+}
+
+// WaitN 实现 Limiter 接口，阻塞直到 n 个令牌同时满足短期速率和长期上限
+//
+// 长期窗口已满时先等待最早的放行记录滑出窗口，再向令牌桶申请令牌；
+// 放行记录按令牌桶给出的实际放行时间计入长期窗口。等待期间上下文结束时
+// 撤销本次预留并返回上下文的错误。n 超过突发容量或长期上限时永远无法满足，立即返回错误。
+func (l *DualWindowLimiter) WaitN(ctx context.Context, n int) error {
+	if n <= 0 {
+		return nil
+	}
+	if n > l.short.Burst() && l.short.Limit() != rate.Inf {
+		return fmt.Errorf("ratelimited: WaitN(n=%d) exceeds short-term burst %d", n, l.short.Burst())
+	}
+	if int64(n) > l.longCap {
+		return fmt.Errorf("ratelimited: WaitN(n=%d) exceeds long-window cap %d", n, l.longCap)
+	}
+
+	for {
+		l.mu.Lock()
+		now := l.clock.Now()
+		l.expire(now)
+		if wait := l.longWait(now, int64(n)); wait > 0 {
+			l.mu.Unlock()
+			if err := l.sleep(ctx, wait); err != nil {
+				return err
+			}
+			continue
+		}
+
+		r := l.short.ReserveN(now, n)
+		delay := r.DelayFrom(now)
+		grant := windowGrant{at: now.Add(delay), n: int64(n)}
+		l.record(grant)
+		l.mu.Unlock()
+
+		if err := l.sleep(ctx, delay); err != nil {
+			l.mu.Lock()
+			r.CancelAt(now)
+			l.remove(grant)
+			l.mu.Unlock()
+			return err
+		}
+		return nil
+	}
+}
+
+// Remaining 返回此刻短期令牌桶中可用的令牌数和长期窗口内剩余的额度
+func (l *DualWindowLimiter) Remaining() (short float64, long int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.clock.Now()
+	l.expire(now)
+	return l.short.TokensAt(now), l.longCap - l.used
+}
+
+// expire 移除已滑出长期窗口的放行记录，调用方必须持有锁
+func (l *DualWindowLimiter) expire(now time.Time) {
+	cutoff := now.Add(-l.longWindow)
+	i := 0
+	for i < len(l.grants) && !l.grants[i].at.After(cutoff) {
+		l.used -= l.grants[i].n
+		i++
+	}
+	l.grants = l.grants[i:]
+}
+
+// longWait 返回长期窗口能再容纳 n 个令牌之前需要等待的时间，调用方必须持有锁
+func (l *DualWindowLimiter) longWait(now time.Time, n int64) time.Duration {
+	excess := l.used + n - l.longCap
+	for _, g := range l.grants {
+		if excess <= 0 {
+			break
+		}
+		excess -= g.n
+		if excess <= 0 {
+			return g.at.Add(l.longWindow).Sub(now)
+		}
+	}
+	return 0
+}
+
+// record 按放行时间顺序插入一条记录，调用方必须持有锁
+func (l *DualWindowLimiter) record(g windowGrant) {
+	i := len(l.grants)
+	for i > 0 && l.grants[i-1].at.After(g.at) {
+		i--
+	}
+	l.grants = append(l.grants, windowGrant{})
+	copy(l.grants[i+1:], l.grants[i:])
+	l.grants[i] = g
+	l.used += g.n
+}
+
+// remove 撤销一条尚未滑出窗口的记录，调用方必须持有锁
+func (l *DualWindowLimiter) remove(g windowGrant) {
+	for i, existing := range l.grants {
+		if existing == g {
+			l.grants = append(l.grants[:i], l.grants[i+1:]...)
+			l.used -= g.n
+			return
+		}
+	}
+}
+
+// sleep 按时钟等待 d，期间上下文结束时返回其错误
+func (l *DualWindowLimiter) sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	select {
+	case <-l.clock.After(d):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,72 @@
+package ratelimited
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// newTestDualWindow 创建使用自动推进虚拟时钟的双窗口限制器
+func newTestDualWindow(shortRate, shortBurst int, longCap int64, longWindow time.Duration) (*DualWindowLimiter, *sleepingClock) {
+	clock := newSleepingClock()
+	l := NewDualWindowLimiter(shortRate, shortBurst, longCap, longWindow)
+	l.clock = clock
+	return l, clock
+}
+
+// TestDualWindowLimiter_LongCapTrips 测试短期速率满足但长期上限触发
+//
+// 测试目标：
+//   - 长期额度耗尽前只受短期速率约束
+//   - 长期额度耗尽后等待最早的放行滑出窗口
+func TestDualWindowLimiter_LongCapTrips(t *testing.T) {
+	// Arrange: 每秒 100、突发 100，每小时最多 500
+	limiter, clock := newTestDualWindow(100, 100, 500, time.Hour)
+	ctx := context.Background()
+	start := clock.Now()
+
+	// Act: 5 次申请按短期速率在约 4 秒内完成
+	for range 5 {
+		assertNoError(t, limiter.WaitN(ctx, 100), "长期额度内的申请应该成功")
+	}
+	shortElapsed := clock.Now().Sub(start)
+	_, long := limiter.Remaining()
+
+	assertNoError(t, limiter.WaitN(ctx, 100), "等待之后的申请应该成功")
+	longElapsed := clock.Now().Sub(start)
+
+	// Assert
+	assertEqual(t, 4*time.Second, shortElapsed, "长期额度内只应该受短期速率约束")
+	assertEqual(t, int64(0), long, "长期额度应该耗尽")
+	assertEqual(t, time.Hour, longElapsed, "应该等待第一次放行滑出一小时窗口")
+}
+
+// TestDualWindowLimiter_ShortRateTrips 测试长期额度充足但短期速率触发
+func TestDualWindowLimiter_ShortRateTrips(t *testing.T) {
+	// Arrange: 每秒 10、突发 10，每小时最多 1000000
+	limiter, clock := newTestDualWindow(10, 10, 1000000, time.Hour)
+	ctx := context.Background()
+	start := clock.Now()
+
+	// Act
+	assertNoError(t, limiter.WaitN(ctx, 10), "突发内的申请应该成功")
+	assertNoError(t, limiter.WaitN(ctx, 10), "第二次申请应该成功")
+
+	// Assert
+	short, long := limiter.Remaining()
+	assertEqual(t, time.Second, clock.Now().Sub(start), "第二次申请应该等待令牌桶补充")
+	assertEqual(t, 0.0, short, "令牌桶应该为空")
+	assertEqual(t, int64(999980), long, "长期额度应该扣除两次申请")
+}
+
+// TestDualWindowLimiter_ExceedsCapacity 测试超过容量的申请立即失败
+func TestDualWindowLimiter_ExceedsCapacity(t *testing.T) {
+	limiter, _ := newTestDualWindow(10, 10, 5, time.Hour)
+
+	if err := limiter.WaitN(context.Background(), 11); err == nil {
+		t.Error("超过突发容量的申请应该失败")
+	}
+	if err := limiter.WaitN(context.Background(), 6); err == nil {
+		t.Error("超过长期上限的申请应该失败")
+	}
+}
@@ -0,0 +1,92 @@
+package ratelimited
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// =============================================================================
+// 动态限制器 - 速率与突发容量来自外部配置
+// =============================================================================
+
+// DynamicLimiter 定期从提供者函数读取速率和突发容量的限制器
+//
+// 实现 Limiter 接口，可以直接作为链中的一层，适合限额保存在配置中心等外部位置、
+// 且会频繁变化的场景。刷新在后台 goroutine 中进行，不再使用时必须调用 Close。
+type DynamicLimiter struct {
+	limiter *rate.Limiter
+
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewDynamicLimiter 创建每隔 refresh 从 provider 重新读取限额的限制器
+//
+// 创建时同步调用一次 provider 作为初始限额，之后按 clock 每隔 refresh 刷新一次；
+// provider 返回的突发容量小于等于 0 时保持原突发容量不变。
+// refresh <= 0 时只在创建时读取一次，不启动后台 goroutine。clock 为 nil 时使用系统时钟。
+// provider 在后台 goroutine 中调用，需要是并发安全的。
+func NewDynamicLimiter(provider func() (rate.Limit, int), refresh time.Duration, clock Clock) *DynamicLimiter {
+	if clock == nil {
+		clock = systemClock{}
+	}
+	limit, burst := provider()
+	d := &DynamicLimiter{
+		limiter: rate.NewLimiter(limit, max(burst, 0)),
+		done:    make(chan struct{}),
+	}
+	if refresh > 0 {
+		go refreshLimits(d.limiter, provider, refresh, clock, d.done)
+	}
+	return d
+}
+
+// WaitN 实现 Limiter 接口
+func (d *DynamicLimiter) WaitN(ctx context.Context, n int) error {
+	return d.limiter.WaitN(ctx, n)
+}
+
+// Limit 返回当前速率
+func (d *DynamicLimiter) Limit() rate.Limit {
+	return d.limiter.Limit()
+}
+
+// Burst 返回当前突发容量
+func (d *DynamicLimiter) Burst() int {
+	return d.limiter.Burst()
+}
+
+// Close 停止后台刷新，之后限额保持最后一次读取的值
+//
+// Close 是幂等的，总是返回 nil。
+func (d *DynamicLimiter) Close() error {
+	d.closeOnce.Do(func() {
+		close(d.done)
+	})
+	return nil
+}
+
+// refreshLimits 每隔 refresh 将 provider 返回的限额应用到 limiter，直到 done 被关闭
+//
+// 只持有内部限制器而不持有 DynamicLimiter，便于与 Close 的生命周期解耦。
+func refreshLimits(limiter *rate.Limiter, provider func() (rate.Limit, int), refresh time.Duration, clock Clock, done <-chan struct{}) {
+	for {
+		select {
+		case <-done:
+			return
+		case <-clock.After(refresh):
+		}
+
+		limit, burst := provider()
+		now := clock.Now()
+		if limit != limiter.Limit() {
+			limiter.SetLimitAt(now, limit)
+		}
+		if burst > 0 && burst != limiter.Burst() {
+			limiter.SetBurstAt(now, burst)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package ratelimited
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// TestDynamicLimiter_TracksProvider 测试动态限制器在刷新边界后跟随提供者的限额
+//
+// 测试目标：
+//   - 创建时立即读取初始限额
+//   - 刷新之前提供者的变化不生效，越过刷新边界后生效
+//   - 突发容量小于等于 0 时保持不变
+func TestDynamicLimiter_TracksProvider(t *testing.T) {
+	// Arrange
+	clock := newFakeClock()
+	var limit atomic.Int64
+	var burst atomic.Int64
+	limit.Store(100)
+	burst.Store(10)
+	provider := func() (rate.Limit, int) {
+		return rate.Limit(limit.Load()), int(burst.Load())
+	}
+	limiter := NewDynamicLimiter(provider, time.Second, clock)
+	defer limiter.Close()
+
+	assertEqual(t, rate.Limit(100), limiter.Limit(), "应该使用初始速率")
+	assertEqual(t, 10, limiter.Burst(), "应该使用初始突发容量")
+
+	// Act: 刷新之前修改提供者的返回值
+	limit.Store(500)
+	burst.Store(0)
+	clock.waitForWaiters(t, 1)
+	clock.Advance(500 * time.Millisecond)
+	assertEqual(t, rate.Limit(100), limiter.Limit(), "刷新之前速率不应该变化")
+
+	clock.Advance(500 * time.Millisecond)
+	deadline := time.Now().Add(2 * time.Second)
+	for limiter.Limit() != 500 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+
+	// Assert
+	assertEqual(t, rate.Limit(500), limiter.Limit(), "刷新之后应该跟随新的速率")
+	assertEqual(t, 10, limiter.Burst(), "突发容量为 0 时应该保持不变")
+}
+
+// TestDynamicLimiter_Close 测试 Close 停止后台刷新
+func TestDynamicLimiter_Close(t *testing.T) {
+	// Arrange
+	clock := newFakeClock()
+	var calls atomic.Int64
+	limiter := NewDynamicLimiter(func() (rate.Limit, int) {
+		calls.Add(1)
+		return 100, 10
+	}, time.Second, clock)
+	clock.waitForWaiters(t, 1)
+
+	// Act
+	assertNoError(t, limiter.Close(), "关闭应该成功")
+	assertNoError(t, limiter.Close(), "重复关闭应该是安全的")
+	time.Sleep(10 * time.Millisecond) // 让后台 goroutine 观察到关闭
+	clock.Advance(time.Second)
+	time.Sleep(10 * time.Millisecond)
+
+	// Assert
+	assertEqual(t, int64(1), calls.Load(), "关闭后不应该再调用提供者")
+}
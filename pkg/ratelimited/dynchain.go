@@ -0,0 +1,117 @@
+package ratelimited
+
+import "sync"
+
+// =============================================================================
+// 动态限制器链 - 运行时增删和替换层级
+// =============================================================================
+
+// DynamicChain 可在运行时修改的命名限制器链，并发安全
+//
+// 通过 WithDynamicChain 交给写入器后，每次向限制器链申请令牌时都读取链的当前快照，
+// 增删或替换层级无需重建写入器，计数器和批次状态得以保留。
+// 修改采用写时复制：已经开始的申请继续使用修改前的快照，之后的申请才看到新的层级。
+type DynamicChain struct {
+	mu       sync.RWMutex
+	limiters []Limiter
+	names    []string
+}
+
+// NewDynamicChain 创建空的动态限制器链
+func NewDynamicChain() *DynamicChain {
+	return &DynamicChain{}
+}
+
+// Add 在链尾追加名为 name 的层级，名称已存在或 l 为 nil（包括 nil 的 *rate.Limiter）时返回 false
+func (c *DynamicChain) Add(name string, l Limiter) bool {
+	if isNilLimiter(l) {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.index(name) >= 0 {
+		return false
+	}
+
+	limiters := make([]Limiter, len(c.limiters), len(c.limiters)+1)
+	copy(limiters, c.limiters)
+	names := make([]string, len(c.names), len(c.names)+1)
+	copy(names, c.names)
+	c.limiters = append(limiters, l)
+	c.names = append(names, name)
+	return true
+}
+
+// Remove 移除名为 name 的层级，名称不存在时返回 false
+func (c *DynamicChain) Remove(name string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	i := c.index(name)
+	if i < 0 {
+		return false
+	}
+
+	limiters := make([]Limiter, 0, len(c.limiters)-1)
+	c.limiters = append(append(limiters, c.limiters[:i]...), c.limiters[i+1:]...)
+	names := make([]string, 0, len(c.names)-1)
+	c.names = append(append(names, c.names[:i]...), c.names[i+1:]...)
+	return true
+}
+
+// Replace 把名为 name 的层级替换为 l，位置保持不变；名称不存在或 l 为 nil（包括 nil 的 *rate.Limiter）时返回 false
+func (c *DynamicChain) Replace(name string, l Limiter) bool {
+	if isNilLimiter(l) {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	i := c.index(name)
+	if i < 0 {
+		return false
+	}
+
+	limiters := make([]Limiter, len(c.limiters))
+	copy(limiters, c.limiters)
+	limiters[i] = l
+	c.limiters = limiters
+	return true
+}
+
+// Snapshot 返回链的当前层级及其名称，两个切片按位置一一对应，调用方不得修改
+func (c *DynamicChain) Snapshot() ([]Limiter, []string) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.limiters, c.names
+}
+
+// index 返回名为 name 的层级位置，不存在时返回 -1，调用方需持有锁
+func (c *DynamicChain) index(name string) int {
+	for i, n := range c.names {
+		if n == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// WithDynamicChain 使用动态限制器链替换写入器的静态限制器链（包括 WithPhaseLimiters 设置的链）和层级名称
+//
+// 层级名称用于告警和统计。链中新增的层级只对之后向链申请的令牌生效，
+// 已申请的批次剩余令牌不会向新层级补扣。速率控制通道（WithRateControlChannel）
+// 只作用于创建写入器时的静态链，不会调整动态链中的层级。
+func WithDynamicChain(c *DynamicChain) DiscardWriterOption {
+	return func(w *DiscardWriter) {
+		w.dynamicChain = c
+	}
+}
+
+// chain 返回写入器当前使用的限制器链及其名称
+func (w *DiscardWriter) chain() ([]Limiter, []string) {
+	if w.dynamicChain != nil {
+		return w.dynamicChain.Snapshot()
+	}
+	if p := w.phaseChain.Load(); p != nil {
+		return *p, w.names
+	}
+	return w.limiters, w.names
+}
@@ -0,0 +1,105 @@
+package ratelimited
+
+import (
+	"sync"
+	"testing"
+
+	"golang.org/x/time/rate"
+)
+
+// TestDynamicChain 测试运行时修改限制器链
+//
+// 测试目标：
+//   - Add/Remove/Replace 按名称修改层级，重复或不存在的名称返回 false
+//   - 写入器在后续写入中使用修改后的链，统计保持累计
+//   - 并发修改与写入是安全的
+func TestDynamicChain(t *testing.T) {
+	t.Run("增删替换", func(t *testing.T) {
+		// Arrange
+		chain := NewDynamicChain()
+		first, second, third := &countingLimiter{}, &countingLimiter{}, &countingLimiter{}
+
+		// Act & Assert
+		assertEqual(t, true, chain.Add("a", first), "新名称应该添加成功")
+		assertEqual(t, true, chain.Add("b", second), "新名称应该添加成功")
+		assertEqual(t, false, chain.Add("a", third), "重复名称应该添加失败")
+		assertEqual(t, true, chain.Replace("a", third), "已存在的名称应该替换成功")
+		assertEqual(t, false, chain.Replace("c", first), "不存在的名称应该替换失败")
+		assertEqual(t, true, chain.Remove("b"), "已存在的名称应该移除成功")
+		assertEqual(t, false, chain.Remove("b"), "不存在的名称应该移除失败")
+
+		limiters, names := chain.Snapshot()
+		assertEqual(t, 1, len(limiters), "应该只剩一层")
+		assertEqual(t, "a", names[0], "名称应该保留")
+		assertEqual(t, Limiter(third), limiters[0], "应该是替换后的限制器")
+	})
+
+	t.Run("写入器使用修改后的链", func(t *testing.T) {
+		// Arrange
+		chain := NewDynamicChain()
+		first, second := &countingLimiter{}, &countingLimiter{}
+		chain.Add("first", first)
+		writer := NewDiscardWriter(nil, WithDynamicChain(chain), WithBatchSize(1))
+
+		// Act
+		_, err := writer.Write(createTestData(10))
+		assertNoError(t, err, "第一次写入应该成功")
+		chain.Add("second", second)
+		chain.Remove("first")
+		_, err = writer.Write(createTestData(5))
+		assertNoError(t, err, "修改链后的写入应该成功")
+
+		// Assert
+		assertAtomicEqual(t, 10, &first.tokens, "移除前的层级应该只计入第一次写入")
+		assertAtomicEqual(t, 5, &second.tokens, "新增的层级应该只计入之后的写入")
+		assertEqual(t, int64(15), writer.Stats().Bytes, "统计应该跨修改累计")
+	})
+
+	t.Run("并发修改", func(t *testing.T) {
+		// Arrange
+		chain := NewDynamicChain()
+		chain.Add("base", &countingLimiter{})
+		writer := NewDiscardWriter(nil, WithDynamicChain(chain))
+
+		// Act
+		var wg sync.WaitGroup
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for range 100 {
+				chain.Add("extra", &countingLimiter{})
+				chain.Replace("base", &countingLimiter{})
+				chain.Remove("extra")
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for range 100 {
+				_, _ = writer.Write(createTestData(16))
+			}
+		}()
+		wg.Wait()
+
+		// Assert
+		assertEqual(t, int64(1600), writer.Stats().Bytes, "所有写入都应该被接收")
+	})
+}
+
+// TestDynamicChain_RejectsTypedNil 测试 nil 的 *rate.Limiter 不会被加入链中
+func TestDynamicChain_RejectsTypedNil(t *testing.T) {
+	// Arrange
+	var typedNil *rate.Limiter
+	chain := NewDynamicChain()
+	chain.Add("global", &countingLimiter{})
+	writer := NewDiscardWriter(nil, WithDynamicChain(chain))
+
+	// Act
+	added := chain.Add("user", typedNil)
+	replaced := chain.Replace("global", typedNil)
+	_, err := writer.Write(createTestData(100))
+
+	// Assert
+	assertEqual(t, false, added, "Add 应该拒绝 nil 的 *rate.Limiter")
+	assertEqual(t, false, replaced, "Replace 应该拒绝 nil 的 *rate.Limiter")
+	assertNoError(t, err, "链中不应该出现 nil 层，写入应该成功")
+}
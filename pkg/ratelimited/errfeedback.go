@@ -0,0 +1,86 @@
+package ratelimited
+
+import (
+	"math"
+	"sync/atomic"
+	"time"
+)
+
+// =============================================================================
+// 错误反馈 - 下游错误率上升时收缩有效速率
+// =============================================================================
+
+const (
+	// defaultErrorSampleInterval 两次采样错误率之间的最短间隔
+	defaultErrorSampleInterval = 100 * time.Millisecond
+	// minErrorScale 缩放系数的下限，避免单次写入的成本无限放大
+	minErrorScale = 0.01
+)
+
+// WithErrorFeedback 根据下游错误率按比例收缩写入器的有效速率
+//
+// 写入器定期调用 report 获取当前错误率，再由 scale 换算为 (0, 1] 之间的缩放系数，
+// 此后每次写入的令牌成本除以该系数（向上取整）：系数为 0.5 时同样的令牌只能放行
+// 一半的字节，从而在下游失败时主动减载。scale 的结果大于 1 按 1 处理，
+// 小于 0.01 按 0.01 处理。scale 为 nil 时系数取 1-错误率。
+//
+// 采样有界且廉价：两次采样至少间隔 100ms（按写入器的时钟），只在写入时惰性触发，
+// 间隔内的写入只读取一次原子变量。系数作用于成本函数和分类倍数之后，
+// 免费写入保持免费。report 为 nil 时禁用错误反馈。
+func WithErrorFeedback(report func() float64, scale func(errRate float64) float64) DiscardWriterOption {
+	return func(w *DiscardWriter) {
+		if report == nil {
+			w.errorFeedback = nil
+			return
+		}
+		if scale == nil {
+			scale = func(errRate float64) float64 { return 1 - errRate }
+		}
+		w.errorFeedback = &errorFeedback{
+			report:   report,
+			scale:    scale,
+			interval: defaultErrorSampleInterval,
+			factor:   math.Float64bits(1),
+		}
+	}
+}
+
+// errorFeedback 按采样到的错误率缩放令牌成本
+type errorFeedback struct {
+	report   func() float64
+	scale    func(errRate float64) float64
+	interval time.Duration
+
+	nextSample int64  // 下次允许采样的时间戳 (UnixNano，需要原子访问)
+	factor     uint64 // 当前缩放系数的 float64 位模式 (需要原子访问)
+}
+
+// scaledCost 返回按当前缩放系数调整后的令牌成本，必要时先重新采样
+func (f *errorFeedback) scaledCost(now time.Time, cost int64) int64 {
+	f.sample(now)
+	factor := math.Float64frombits(atomic.LoadUint64(&f.factor))
+	if factor >= 1 {
+		return cost
+	}
+	return int64(math.Ceil(float64(cost) / factor))
+}
+
+// sample 在采样间隔到期时刷新缩放系数，并发写入中只有一个会真正调用 report
+func (f *errorFeedback) sample(now time.Time) {
+	next := atomic.LoadInt64(&f.nextSample)
+	if now.UnixNano() < next {
+		return
+	}
+	if !atomic.CompareAndSwapInt64(&f.nextSample, next, now.Add(f.interval).UnixNano()) {
+		return
+	}
+
+	factor := f.scale(f.report())
+	switch {
+	case math.IsNaN(factor) || factor < minErrorScale:
+		factor = minErrorScale
+	case factor > 1:
+		factor = 1
+	}
+	atomic.StoreUint64(&f.factor, math.Float64bits(factor))
+}
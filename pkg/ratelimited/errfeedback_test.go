@@ -0,0 +1,74 @@
+package ratelimited
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestWithErrorFeedback_RisingErrorRate 测试错误率上升时放行的字节减少
+//
+// 测试目标：
+//   - 错误率为 0 时每字节一个令牌
+//   - 错误率上升后同样的令牌只能放行更少的字节
+//   - 字节统计仍按实际字节数
+func TestWithErrorFeedback_RisingErrorRate(t *testing.T) {
+	// Arrange: 设置共享配额时批次被限制为单次成本，令牌申请量等于计费量
+	clock := newFakeClock()
+	limiter := &countingLimiter{}
+	quota := int64(10000)
+	var errRate atomic.Value
+	errRate.Store(0.0)
+	writer := NewDiscardWriter([]Limiter{limiter},
+		WithClock(clock),
+		WithSharedQuota(&quota),
+		WithErrorFeedback(func() float64 { return errRate.Load().(float64) }, nil),
+	)
+
+	// Act & Assert: 每个采样间隔写入 100 字节，比较每个令牌放行的字节数
+	var previous float64
+	for i, rate := range []float64{0, 0.5, 0.75, 0.875} {
+		errRate.Store(rate)
+		clock.Advance(defaultErrorSampleInterval)
+		before := atomic.LoadInt64(&limiter.tokens)
+
+		_, err := writer.Write(createTestData(100))
+		assertNoError(t, err, "写入应该成功")
+
+		tokens := atomic.LoadInt64(&limiter.tokens) - before
+		admitted := 100 / float64(tokens)
+		if i > 0 && admitted >= previous {
+			t.Errorf("错误率 %.1f 时每令牌放行 %.3f 字节，应该少于上一轮的 %.3f", rate, admitted, previous)
+		}
+		previous = admitted
+	}
+	assertAtomicEqual(t, 1500, &limiter.tokens, "令牌总数应该为 100+200+400+800")
+}
+
+// TestWithErrorFeedback_SamplingBounded 测试采样间隔内只调用一次 report
+func TestWithErrorFeedback_SamplingBounded(t *testing.T) {
+	// Arrange
+	clock := newFakeClock()
+	var reports int64
+	writer := NewDiscardWriter([]Limiter{&countingLimiter{}},
+		WithClock(clock),
+		WithErrorFeedback(func() float64 {
+			atomic.AddInt64(&reports, 1)
+			return 0.5
+		}, func(errRate float64) float64 { return 2 }), // 超过 1 的系数按 1 处理
+	)
+
+	// Act
+	for range 10 {
+		_, err := writer.Write(createTestData(10))
+		assertNoError(t, err, "写入应该成功")
+	}
+	clock.Advance(defaultErrorSampleInterval - time.Nanosecond)
+	_, _ = writer.Write(createTestData(10))
+	clock.Advance(time.Nanosecond)
+	_, _ = writer.Write(createTestData(10))
+
+	// Assert
+	assertAtomicEqual(t, 2, &reports, "每个采样间隔应该只采样一次")
+	assertEqual(t, int64(10), writer.errorFeedback.scaledCost(clock.Now(), 10), "系数应该被限制在 1 以内")
+}
@@ -0,0 +1,71 @@
+package ratelimited
+
+// =============================================================================
+// 估算计费 - 总大小未知的流式写入
+// =============================================================================
+
+// WriteEstimated 按流的估算总大小从共享配额中扣除，按实际字节数申请令牌
+//
+// 适用于分块上传等事先不知道总大小的场景。每次调用时，当前流的配额扣除量被提升到
+// max(estTotal, 已写入字节数)：第一次调用即按估算值整体扣除，估算值增大或实际写入
+// 超过估算时补扣差额；估算值变小时不会在流中途退还。速率限制器的令牌始终按 p 的实际
+// 字节数申请，因为已发放的令牌无法归还。配额不足以覆盖补扣的差额时返回 0 和 ErrQuotaExhausted，
+// 本次调用不产生任何扣除。
+//
+// 流结束后必须调用 Reconcile 按真实总大小结算。结算前配额处于估算状态：
+// 估算偏高会让其他共享配额的写入器提前耗尽（过度计费），估算偏低则允许流的
+// 尾部在结算前越过配额（计费不足），结算时会把配额扣为负数。
+// 同一写入器同一时间只支持一个估算流，WriteEstimated 与 Reconcile 互斥执行。
+func (w *DiscardWriter) WriteEstimated(p []byte, estTotal int64) (int, error) {
+	w.estMu.Lock()
+	defer w.estMu.Unlock()
+
+	target := w.estWritten + int64(len(p))
+	if estTotal > target {
+		target = estTotal
+	}
+
+	delta := target - w.estCharged
+	if delta < 0 {
+		delta = 0
+	}
+	if delta > 0 && w.quota != nil {
+		if !w.quota.Acquire(delta) {
+			w.quotaExhausted()
+			return 0, ErrQuotaExhausted
+		}
+		if w.quotaThresholds != nil {
+			w.checkQuotaThresholds(w.quota.Remaining())
+		}
+	}
+
+	n, err := w.write(p, false)
+	if err != nil {
+		if delta > 0 && w.quota != nil {
+			w.quota.Release(delta) // 回滚配额
+		}
+		return n, err
+	}
+
+	w.estWritten += int64(n)
+	w.estCharged += delta
+	return n, nil
+}
+
+// Reconcile 按真实总大小结算当前估算流，并开始新的估算流
+//
+// 已扣除量大于 actualTotal 时把差额退还给共享配额，小于时补扣差额；
+// 补扣不检查剩余配额，可能使配额变为负数，此后的写入会立即返回 ErrQuotaExhausted。
+// 未设置共享配额时只重置估算状态。
+func (w *DiscardWriter) Reconcile(actualTotal int64) {
+	w.estMu.Lock()
+	defer w.estMu.Unlock()
+
+	if w.quota != nil {
+		if diff := w.estCharged - actualTotal; diff != 0 {
+			w.quota.Release(diff)
+		}
+	}
+	w.estWritten = 0
+	w.estCharged = 0
+}
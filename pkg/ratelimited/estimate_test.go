@@ -0,0 +1,59 @@
+package ratelimited
+
+import "testing"
+
+// TestDiscardWriter_WriteEstimated 测试按估算计费并在结束时结算
+//
+// 测试目标：
+//   - 第一次写入即按估算总大小扣除配额，令牌按实际字节申请
+//   - 实际写入超过估算时补扣差额
+//   - Reconcile 按真实总大小退还或补扣
+func TestDiscardWriter_WriteEstimated(t *testing.T) {
+	testCases := []struct {
+		name            string
+		estTotal        int64
+		actualTotal     int64
+		expectedCharged int64 // 四个 10 字节分块之后的配额扣除量
+	}{
+		{name: "估算偏高后退还", estTotal: 100, actualTotal: 40, expectedCharged: 100},
+		{name: "估算偏低后补扣", estTotal: 20, actualTotal: 60, expectedCharged: 40},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			quota := int64(1000)
+			limiter := &countingLimiter{}
+			writer := NewDiscardWriter([]Limiter{limiter}, WithSharedQuota(&quota), WithBatchSize(10))
+
+			// Act
+			for i := 0; i < 4; i++ {
+				n, err := writer.WriteEstimated(createTestData(10), tc.estTotal)
+				assertNoError(t, err, "估算写入应该成功")
+				assertEqual(t, 10, n, "应该写入完整分块")
+			}
+			charged := 1000 - quota
+			writer.Reconcile(tc.actualTotal)
+
+			// Assert
+			assertEqual(t, tc.expectedCharged, charged, "流中途应该按估算扣除配额")
+			assertAtomicEqual(t, 1000-tc.actualTotal, &quota, "结算后应该按真实总大小扣除")
+			assertEqual(t, int64(40), limiter.tokenCount(), "令牌应该按实际字节申请")
+		})
+	}
+}
+
+// TestDiscardWriter_WriteEstimatedExceedsQuota 测试估算超出剩余配额时拒绝
+func TestDiscardWriter_WriteEstimatedExceedsQuota(t *testing.T) {
+	// Arrange
+	quota := int64(50)
+	writer := NewDiscardWriter([]Limiter{&countingLimiter{}}, WithSharedQuota(&quota))
+
+	// Act
+	n, err := writer.WriteEstimated(createTestData(10), 100)
+
+	// Assert
+	assertEqual(t, 0, n, "不应该写入任何字节")
+	assertEqual(t, ErrQuotaExhausted, err, "估算超出配额应该返回 ErrQuotaExhausted")
+	assertAtomicEqual(t, int64(50), &quota, "拒绝的写入不应该扣除配额")
+}
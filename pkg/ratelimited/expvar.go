@@ -0,0 +1,99 @@
+package ratelimited
+
+import (
+	"expvar"
+
+	"golang.org/x/time/rate"
+)
+
+// =============================================================================
+// expvar 发布 - 通过 /debug/vars 暴露写入器与限制器链的实时状态
+// =============================================================================
+
+// PublishExpvar 以 name 在 expvar 中发布写入器的实时统计
+//
+// 每次读取 /debug/vars 时按需计算，不产生额外开销。发布的字段：
+//   - bytes、writes、wait_ns、batches：对应 Stats 的字节数、写入次数、累计等待和批次数
+//   - quota_remaining：共享配额的剩余字节数，未设置配额时为 -1
+//   - layer_wait_ns：各层级的累计等待（见 LayerWaits）
+//   - bottleneck：累计等待最长的层级，从未等待时为空
+//   - throughput：当前吞吐量（字节/秒）的移动平均（见 Throughput）
+//
+// 与 expvar.Publish 相同，name 已被使用时会 panic；发布的写入器在进程生命周期内不会被回收。
+func PublishExpvar(name string, w *DiscardWriter) {
+	expvar.Publish(name, expvar.Func(func() any {
+		return writerVars(w)
+	}))
+}
+
+// PublishRegistryExpvar 以 name 在 expvar 中发布注册表 r 中所有限制器链的实时状态
+//
+// 发布的对象包含 keys、created、evicted（对应 RegistryStats）和 chains（按键索引的链），
+// 每条链按顺序列出各层的 limit、burst 和当前可用的 tokens；无法内省的层只有 index，
+// 不限速的层 unlimited 为 true。每次读取时重新获取注册表，之后创建的链同样可见，
+// 读取与 Len 一样会顺带回收过期的键。
+//
+// 与 expvar.Publish 相同，name 已被使用时会 panic；发布的注册表在进程生命周期内不会被回收。
+func PublishRegistryExpvar(name string, r *Registry) {
+	expvar.Publish(name, expvar.Func(func() any {
+		chains := make(map[string]any)
+		for key, limiters := range r.chains() {
+			chains[key] = chainVars(limiters)
+		}
+		stats := r.Stats()
+		return map[string]any{
+			"keys":    stats.Keys,
+			"created": stats.Created,
+			"evicted": stats.Evicted,
+			"chains":  chains,
+		}
+	}))
+}
+
+// writerVars 返回写入器统计的 JSON 友好快照
+func writerVars(w *DiscardWriter) map[string]any {
+	stats := w.Stats()
+	layerWaits := make(map[string]int64)
+	for name, waited := range w.LayerWaits() {
+		layerWaits[name] = int64(waited)
+	}
+	bottleneck, _ := w.Bottleneck()
+	return map[string]any{
+		"bytes":           stats.Bytes,
+		"writes":          stats.Requests,
+		"wait_ns":         int64(stats.WaitTime),
+		"batches":         stats.Batches,
+		"quota_remaining": stats.QuotaRemaining,
+		"layer_wait_ns":   layerWaits,
+		"bottleneck":      bottleneck,
+		"throughput":      w.Throughput(),
+	}
+}
+
+// tokenReporter 可以报告当前可用令牌数的限制器，*rate.Limiter 满足该接口
+type tokenReporter interface {
+	Tokens() float64
+}
+
+// chainVars 返回链中各层状态的 JSON 友好快照
+//
+// 不限速的层以 unlimited 标记，不输出没有意义的 limit 和 tokens。
+func chainVars(limiters []Limiter) []map[string]any {
+	out := make([]map[string]any, 0, len(limiters))
+	for i, limiter := range limiters {
+		layer := map[string]any{"index": i}
+		if limit, burst, ok := introspect(limiter); ok {
+			layer["burst"] = burst
+			if limit == rate.Inf {
+				layer["unlimited"] = true
+			} else {
+				layer["limit"] = float64(limit)
+				if tr, ok := limiter.(tokenReporter); ok {
+					layer["tokens"] = tr.Tokens()
+				}
+			}
+		}
+		out = append(out, layer)
+	}
+	return out
+}
@@ -0,0 +1,109 @@
+package ratelimited
+
+import (
+	"encoding/json"
+	"expvar"
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// readExpvar 读取并解析已发布的 expvar 变量
+func readExpvar(t *testing.T, name string, v any) {
+	t.Helper()
+	published := expvar.Get(name)
+	if published == nil {
+		t.Fatalf("变量 %s 没有被发布", name)
+	}
+	if err := json.Unmarshal([]byte(published.String()), v); err != nil {
+		t.Fatalf("解析变量 %s 失败: %v", name, err)
+	}
+}
+
+// TestPublishExpvar 测试写入器的实时统计通过 expvar 发布
+//
+// 测试目标：
+//   - 统计在每次读取时实时计算
+//   - 分层等待与瓶颈层级被一并发布
+func TestPublishExpvar(t *testing.T) {
+	// Arrange
+	clock := newSleepingClock()
+	quota := int64(10000)
+	w := NewDiscardWriter([]Limiter{rate.NewLimiter(1000, 1000)},
+		WithLimiterNames([]string{"global"}),
+		WithBatchSize(1000),
+		WithSharedQuota(&quota),
+		WithClock(clock),
+	)
+	PublishExpvar("ratelimited_test_writer", w)
+
+	var before struct {
+		Bytes int64 `json:"bytes"`
+	}
+	readExpvar(t, "ratelimited_test_writer", &before)
+
+	// Act
+	_, err := w.Write(createTestData(2000))
+	assertNoError(t, err, "写入")
+
+	// Assert
+	var after struct {
+		Bytes          int64            `json:"bytes"`
+		Writes         uint64           `json:"writes"`
+		QuotaRemaining int64            `json:"quota_remaining"`
+		LayerWaitNs    map[string]int64 `json:"layer_wait_ns"`
+		Bottleneck     string           `json:"bottleneck"`
+	}
+	readExpvar(t, "ratelimited_test_writer", &after)
+	assertEqual(t, int64(0), before.Bytes, "写入前的字节数")
+	assertEqual(t, int64(2000), after.Bytes, "写入后的字节数")
+	assertEqual(t, uint64(1), after.Writes, "写入次数")
+	assertEqual(t, int64(8000), after.QuotaRemaining, "剩余配额")
+	assertEqual(t, int64(time.Second), after.LayerWaitNs["global"], "分层等待")
+	assertEqual(t, "global", after.Bottleneck, "瓶颈层级")
+}
+
+// TestPublishRegistryExpvar 测试注册表中的链通过 expvar 发布
+func TestPublishRegistryExpvar(t *testing.T) {
+	// Arrange
+	registry := NewRegistry(func(key string) []Limiter {
+		if key == "tenant" {
+			return []Limiter{rate.NewLimiter(500, 100), &advancingLimiter{}}
+		}
+		return []Limiter{rate.NewLimiter(rate.Inf, 0)}
+	})
+	registry.Get("free")
+	PublishRegistryExpvar("ratelimited_test_registry", registry)
+
+	// Act: 发布之后创建的链同样可见
+	registry.Get("tenant")
+
+	// Assert
+	type layer struct {
+		Index     int      `json:"index"`
+		Limit     *float64 `json:"limit"`
+		Burst     int      `json:"burst"`
+		Tokens    *float64 `json:"tokens"`
+		Unlimited bool     `json:"unlimited"`
+	}
+	var got struct {
+		Keys    int                `json:"keys"`
+		Created uint64             `json:"created"`
+		Chains  map[string][]layer `json:"chains"`
+	}
+	readExpvar(t, "ratelimited_test_registry", &got)
+	assertEqual(t, 2, got.Keys, "键数量")
+	assertEqual(t, uint64(2), got.Created, "创建的链数")
+	free := got.Chains["free"]
+	assertEqual(t, 1, len(free), "不限速链层数")
+	assertEqual(t, true, free[0].Unlimited, "不限速的层应该被标记")
+	tenant := got.Chains["tenant"]
+	assertEqual(t, 2, len(tenant), "租户链层数")
+	if tenant[0].Limit == nil || *tenant[0].Limit != 500 || tenant[0].Burst != 100 || tenant[0].Tokens == nil {
+		t.Errorf("可内省的层应该发布速率、突发容量和令牌数: %+v", tenant[0])
+	}
+	if tenant[1].Index != 1 || tenant[1].Limit != nil {
+		t.Errorf("无法内省的层只应该发布位置: %+v", tenant[1])
+	}
+}
@@ -0,0 +1,86 @@
+package ratelimited
+
+import (
+	"io"
+	"sync/atomic"
+)
+
+// =============================================================================
+// 转发写入 - 将限流后的数据写入真实的下游
+// =============================================================================
+
+// CoalescingWriter 会合并或去重部分字节的下游写入端
+//
+// 转发写入器在每次 Write 前后各调用一次 Written，两者之差即本次实际交付的字节数；
+// 接受但没有交付的字节（被合并进此前的数据或被去重丢弃）对应的令牌和配额会被退还。
+// 实现需要遵守以下约定：
+//   - Write 返回的 n 表示接受的字节数，合并不属于短写，不应返回错误
+//   - Written 返回自创建以来实际交付的累计字节数，单调不减，且在 Write 返回前同步更新
+//   - 同一实例只被一个转发写入器串行写入，否则交付增量无法归属到单次写入
+type CoalescingWriter interface {
+	io.Writer
+	Written() int64
+}
+
+// WithCountAfterWrite 设置转发写入器在下游确认交付之后才更新计数器
+//
+// 默认（计数前置）在申请到令牌后、写入下游之前更新字节和请求计数器：
+// 下游写入失败或进程在两者之间崩溃时，计数器会多于实际交付的数据。
+// 启用后只有下游写入成功才更新计数器，失败的写入不会抬高计数，
+// 代价是计数器相对令牌消耗有短暂滞后。对于没有下游的丢弃写入器该选项不起作用。
+func WithCountAfterWrite(enabled bool) DiscardWriterOption {
+	return func(w *DiscardWriter) {
+		w.countAfterWrite = enabled
+	}
+}
+
+// newForwardingWriter 创建把数据转发到 dst 的限流写入器
+func newForwardingWriter(dst io.Writer, limiters []Limiter, opts ...DiscardWriterOption) *DiscardWriter {
+	w := NewDiscardWriter(limiters, opts...)
+	w.dst = dst
+	return w
+}
+
+// forward 把 p 写入下游，返回接受的字节数和未交付的字节数
+//
+// 未交付的字节包括下游没有接受的部分（短写或出错）和 CoalescingWriter 合并掉的部分。
+func (w *DiscardWriter) forward(p []byte) (int, int64, error) {
+	cw, coalescing := w.dst.(CoalescingWriter)
+	var before int64
+	if coalescing {
+		before = cw.Written()
+	}
+
+	n, err := w.dst.Write(p)
+	if n < 0 || n > len(p) {
+		n = 0
+	}
+	if err == nil && n < len(p) {
+		err = io.ErrShortWrite
+	}
+
+	undelivered := int64(len(p) - n)
+	if coalescing && n > 0 {
+		if delivered := cw.Written() - before; delivered >= 0 && delivered < int64(n) {
+			undelivered += int64(n) - delivered
+		}
+	}
+	return n, undelivered, err
+}
+
+// refund 按比例退还 total 字节中 undelivered 字节对应的令牌和配额
+//
+// 令牌无法归还给限制器，toBatch 为 true 时退还的令牌回到本写入器的当前批次，
+// 供后续写入使用；大写入专用链上的令牌没有批次，只归还令牌上限的额度。
+func (w *DiscardWriter) refund(undelivered int64, total int, cost int64, toBatch bool, quota Quota) {
+	if cost > 0 {
+		tokens := cost * undelivered / int64(total)
+		if toBatch {
+			atomic.AddInt64(&w.remainingTokens, tokens)
+		}
+		w.releaseCeiling(tokens)
+	}
+	if quota != nil {
+		quota.Release(undelivered)
+	}
+}
@@ -0,0 +1,121 @@
+package ratelimited
+
+import (
+	"bytes"
+	"errors"
+	"sync/atomic"
+	"testing"
+)
+
+// dedupSink 丢弃与上一次写入相同的数据块的下游，实现 CoalescingWriter
+type dedupSink struct {
+	buf     bytes.Buffer
+	last    []byte
+	written int64
+}
+
+func (s *dedupSink) Write(p []byte) (int, error) {
+	if !bytes.Equal(p, s.last) {
+		s.buf.Write(p)
+		atomic.AddInt64(&s.written, int64(len(p)))
+	}
+	s.last = append(s.last[:0], p...)
+	return len(p), nil
+}
+
+func (s *dedupSink) Written() int64 {
+	return atomic.LoadInt64(&s.written)
+}
+
+// TestForwardingWriter_CoalescingRefund 测试下游去重的字节退还令牌和配额
+//
+// 测试目标：
+//   - 重复的数据块被下游丢弃后，对应的配额被退还
+//   - 退还的令牌回到批次，后续写入不再申请
+func TestForwardingWriter_CoalescingRefund(t *testing.T) {
+	// Arrange: 批次大小等于单次写入，每次写入都会耗尽批次
+	sink := &dedupSink{}
+	limiter := &countingLimiter{}
+	quota := int64(1000)
+	writer := newForwardingWriter(sink, []Limiter{limiter}, WithSharedQuota(&quota))
+	chunk := createTestData(10)
+
+	// Act: 第二次写入被下游去重
+	for i := 0; i < 2; i++ {
+		n, err := writer.Write(chunk)
+		assertNoError(t, err, "写入应该成功")
+		assertEqual(t, 10, n, "下游接受了全部字节")
+	}
+	callsAfterDedup := limiter.callCount()
+	_, err := writer.Write(bytes.Repeat([]byte("z"), 10))
+	assertNoError(t, err, "写入应该成功")
+
+	// Assert
+	assertEqual(t, 20, sink.buf.Len(), "重复的第二块不应该被交付")
+	assertEqual(t, int64(2), callsAfterDedup, "前两次写入各申请一次令牌")
+	assertEqual(t, int64(2), limiter.callCount(), "去重退还的令牌应该供下一次写入使用")
+	assertAtomicEqual(t, int64(1000-10-10), &quota, "去重部分的配额应该被退还")
+}
+
+// TestForwardingWriter_PlainDestination 测试普通下游的转发
+func TestForwardingWriter_PlainDestination(t *testing.T) {
+	// Arrange
+	var dst bytes.Buffer
+	writer := newForwardingWriter(&dst, []Limiter{&countingLimiter{}})
+
+	// Act
+	n, err := writer.Write([]byte("hello"))
+
+	// Assert
+	assertNoError(t, err, "写入应该成功")
+	assertEqual(t, 5, n, "应该写入全部字节")
+	assertEqual(t, "hello", dst.String(), "数据应该被转发到下游")
+}
+
+// failingDestination 总是写入失败的下游
+type failingDestination struct{}
+
+func (failingDestination) Write(p []byte) (int, error) {
+	return 0, errors.New("downstream unavailable")
+}
+
+// TestWithCountAfterWrite 测试下游失败时计数器的行为
+//
+// 测试目标：
+//   - 计数后置时，失败的下游写入不推进计数器
+//   - 默认计数前置时，计数器在下游写入之前已经推进
+func TestWithCountAfterWrite(t *testing.T) {
+	testCases := []struct {
+		name          string
+		countAfter    bool
+		expectedBytes int64
+		expectedReqs  uint64
+	}{
+		{name: "计数后置", countAfter: true, expectedBytes: 0, expectedReqs: 0},
+		{name: "默认计数前置", countAfter: false, expectedBytes: 10, expectedReqs: 1},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			var bytesWritten int64
+			var requests uint64
+			writer := newForwardingWriter(failingDestination{}, []Limiter{&countingLimiter{}},
+				WithBytesCounter(&bytesWritten),
+				WithRequestCounter(&requests),
+				WithCountAfterWrite(tc.countAfter),
+			)
+
+			// Act
+			n, err := writer.Write(createTestData(10))
+
+			// Assert
+			if err == nil {
+				t.Fatal("下游失败时写入应该返回错误")
+			}
+			assertEqual(t, 0, n, "下游没有接受任何字节")
+			assertAtomicEqual(t, tc.expectedBytes, &bytesWritten, "字节计数器")
+			assertEqual(t, tc.expectedReqs, atomic.LoadUint64(&requests), "请求计数器")
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package ratelimited
+
+import "io"
+
+// =============================================================================
+// 完整写入 - Write 不返回无错误的短写
+// =============================================================================
+
+// WithFullWrite 设置 Write 是否循环直到 p 被完整接收
+//
+// 默认情况下，共享配额只够容纳 p 的一部分时 Write 接收这一部分并返回 n < len(p)。
+// 启用后 Write 在内部循环写入剩余部分，直到全部接收或遇到真正的错误
+// （上下文结束、配额耗尽的 ErrQuotaExhausted、限制器错误等），保证不会返回没有错误的短写，
+// 适合假定写入总是完整的调用方。每轮循环都会检查上下文。
+//
+// 注意：剩余部分需要继续等待令牌，单次 Write 可能比默认模式阻塞更久；
+// 对会周期性补充的配额，Write 会一直持有调用方直到补充足够的额度。
+func WithFullWrite(enabled bool) DiscardWriterOption {
+	return func(w *DiscardWriter) {
+		w.fullWrite = enabled
+	}
+}
+
+// writeFull 循环写入直到 p 被完整接收或出错
+func (w *DiscardWriter) writeFull(p []byte) (int, error) {
+	written := 0
+	for written < len(p) {
+		n, err := w.writeOnce(p[written:])
+		written += n
+		if err != nil {
+			return written, err
+		}
+		if n == 0 {
+			return written, io.ErrShortWrite
+		}
+	}
+	return written, nil
+}
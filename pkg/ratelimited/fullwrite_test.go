@@ -0,0 +1,74 @@
+package ratelimited
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+
+	"golang.org/x/time/rate"
+)
+
+// refillingLimiter 每次放行后向共享配额补充 refill 字节，模拟周期性补充的配额
+type refillingLimiter struct {
+	quota  *int64
+	refill int64
+}
+
+func (l *refillingLimiter) WaitN(ctx context.Context, n int) error {
+	atomic.AddInt64(l.quota, l.refill)
+	return nil
+}
+
+// TestWithFullWrite 测试完整写入模式下 Write 一次返回 len(p)
+//
+// 测试目标：
+//   - 无配额的链上大缓冲区一次写完
+//   - 配额每次只够一部分时，内部循环直到写完
+//   - 未启用时返回短写
+func TestWithFullWrite(t *testing.T) {
+	t.Run("无配额的大缓冲区", func(t *testing.T) {
+		// Arrange
+		writer := NewDiscardWriter(Chain(rate.NewLimiter(rate.Inf, 0)),
+			WithBatchSize(1024),
+			WithFullWrite(true),
+		)
+
+		// Act
+		n, err := writer.Write(createTestData(1 << 20))
+
+		// Assert
+		assertNoError(t, err, "写入应该成功")
+		assertEqual(t, 1<<20, n, "应该一次接收全部字节")
+	})
+
+	t.Run("配额逐步补充", func(t *testing.T) {
+		// Arrange
+		quota := int64(100)
+		writer := NewDiscardWriter([]Limiter{&refillingLimiter{quota: &quota, refill: 100}},
+			WithSharedQuota(&quota),
+			WithFullWrite(true),
+		)
+
+		// Act
+		n, err := writer.Write(createTestData(300))
+
+		// Assert
+		assertNoError(t, err, "写入应该成功")
+		assertEqual(t, 300, n, "应该循环直到接收全部字节")
+	})
+
+	t.Run("未启用时短写", func(t *testing.T) {
+		// Arrange
+		quota := int64(100)
+		writer := NewDiscardWriter([]Limiter{&refillingLimiter{quota: &quota, refill: 100}},
+			WithSharedQuota(&quota),
+		)
+
+		// Act
+		n, err := writer.Write(createTestData(300))
+
+		// Assert
+		assertNoError(t, err, "短写不应该返回错误")
+		assertEqual(t, 100, n, "应该只接收配额内的字节")
+	})
+}
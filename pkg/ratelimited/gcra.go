@@ -0,0 +1,201 @@
+package ratelimited
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// =============================================================================
+// GCRA 限制器 - 通用信元速率算法
+// =============================================================================
+
+// GCRA 基于通用信元速率算法（Generic Cell Rate Algorithm）的限制器，实现 Limiter 接口
+//
+// 只维护一个理论到达时间（TAT），每次申请的判断和更新都是常数时间。
+// 令牌以 1/limit 秒为间隔匀速发放，突发容量 burst 允许申请比理论时间提前
+// (burst-1)/limit 秒到达，与同参数的令牌桶等价。
+//
+// 发射间隔按浮点秒计算，理论到达时间以纳秒小数精度推进，不会因为间隔
+// 不是整数纳秒而产生累积误差，速率超过每秒 1e9 个令牌时同样准确。
+type GCRA struct {
+	clock Clock
+
+	mu    sync.Mutex
+	limit float64     // 速率（令牌/秒），0 表示不限制
+	burst int         // 突发容量（令牌数）
+	tat   virtualTime // 理论到达时间
+}
+
+// GCRAOption GCRA 限制器的配置选项
+type GCRAOption func(*GCRA)
+
+// WithGCRAClock 设置限制器使用的时钟，默认使用系统时钟
+func WithGCRAClock(c Clock) GCRAOption {
+	return func(g *GCRA) {
+		if c != nil {
+			g.clock = c
+		}
+	}
+}
+
+// NewGCRA 创建发射间隔为 interval、突发容忍为 tolerance 的 GCRA 限制器
+//
+// 例如 1 MB/s、允许 64KB 突发：NewGCRA(time.Second/(1<<20), 64*1024*time.Second/(1<<20))。
+// 突发容量为 tolerance/interval + 1 个令牌。interval 只能表示整数纳秒，
+// 间隔不是整数纳秒的速率应使用 NewGCRAFromRate。interval <= 0 表示不限制。
+func NewGCRA(interval, tolerance time.Duration, opts ...GCRAOption) *GCRA {
+	g := &GCRA{clock: systemClock{}}
+	if interval > 0 {
+		g.limit = float64(time.Second) / float64(interval)
+		g.burst = int(max(tolerance, 0)/interval) + 1
+	}
+	for _, opt := range opts {
+		opt(g)
+	}
+	return g
+}
+
+// NewGCRAFromRate 创建速率为 limit、突发容量为 burst 个令牌的 GCRA 限制器
+//
+// limit 为 rate.Inf 或 <= 0 时不限制；burst 小于 1 时按 1 处理。
+func NewGCRAFromRate(limit rate.Limit, burst int, opts ...GCRAOption) *GCRA {
+	g := &GCRA{clock: systemClock{}}
+	if limit != rate.Inf && limit > 0 {
+		g.limit = float64(limit)
+		g.burst = max(burst, 1)
+	}
+	for _, opt := range opts {
+		opt(g)
+	}
+	return g
+}
+
+// WaitN 实现 Limiter 接口，阻塞直到 n 个令牌符合发放时间
+//
+// n 超过突发容量或等待会超过 ctx 的 deadline 时不占用令牌并立即返回错误，
+// 与 *rate.Limiter 的行为一致；ctx 在等待期间结束时归还已占用的令牌。
+func (g *GCRA) WaitN(ctx context.Context, n int) error {
+	g.mu.Lock()
+	if g.limit <= 0 {
+		g.mu.Unlock()
+		return nil
+	}
+	if n > g.burst {
+		g.mu.Unlock()
+		return fmt.Errorf("ratelimited: WaitN(n=%d) exceeds limiter's burst %d", n, g.burst)
+	}
+
+	now := g.clock.Now()
+	newTat := g.tat
+	newTat.clampTo(now)
+	newTat.add(tokenNanos(n, g.limit))
+	wait := newTat.t.Sub(now) - time.Duration(tokenNanos(g.burst, g.limit))
+	if deadline, ok := ctx.Deadline(); ok && wait > deadline.Sub(now) {
+		g.mu.Unlock()
+		return fmt.Errorf("ratelimited: WaitN(n=%d) would exceed context deadline: %w", n, context.DeadlineExceeded)
+	}
+	g.tat = newTat
+	g.mu.Unlock()
+
+	if wait <= 0 {
+		return nil
+	}
+	select {
+	case <-ctx.Done():
+		g.ReturnN(n)
+		return ctx.Err()
+	case <-g.clock.After(wait):
+		return nil
+	}
+}
+
+// ReturnN 实现 TokenReturner 接口，把理论到达时间提前 n 个令牌的间隔，但不早于当前时间
+func (g *GCRA) ReturnN(n int) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.limit <= 0 || n <= 0 {
+		return
+	}
+	g.tat.add(-tokenNanos(n, g.limit))
+	g.tat.clampTo(g.clock.Now())
+}
+
+// Limit 返回限制器的速率（令牌/秒）
+func (g *GCRA) Limit() rate.Limit {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.limit <= 0 {
+		return rate.Inf
+	}
+	return rate.Limit(g.limit)
+}
+
+// Burst 返回限制器的突发容量（令牌数），不限制时为 0
+func (g *GCRA) Burst() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.limit <= 0 {
+		return 0
+	}
+	return g.burst
+}
+
+// SetLimit 修改速率，突发容量按令牌数保持不变，可用于 WithRateControlChannel
+//
+// 从不限制切换为限速时突发容量为 1。
+func (g *GCRA) SetLimit(newLimit rate.Limit) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if newLimit == rate.Inf || newLimit <= 0 {
+		g.limit = 0
+		return
+	}
+	g.limit = float64(newLimit)
+	g.burst = max(g.burst, 1)
+}
+
+// SetBurst 修改突发容量（令牌数），可用于 WithRateControlChannel
+func (g *GCRA) SetBurst(newBurst int) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.burst = max(newBurst, 1)
+}
+
+// =============================================================================
+// 小数纳秒时间点
+// =============================================================================
+
+// virtualTime 以小数纳秒精度推进的时间点
+//
+// 按令牌间隔推进时若每次都截断到整数纳秒，间隔不是整数纳秒的速率会系统性偏快，
+// 速率超过每秒 1e9 个令牌时间隔甚至为 0；不足 1 纳秒的部分保留在 frac 中累加。
+type virtualTime struct {
+	t    time.Time
+	frac float64 // 不足 1 纳秒的部分，范围 [0, 1)
+}
+
+// add 推进 ns 纳秒，ns 可以为负
+func (v *virtualTime) add(ns float64) {
+	total := v.frac + ns
+	whole := math.Floor(total)
+	v.t = v.t.Add(time.Duration(whole))
+	v.frac = total - whole
+}
+
+// clampTo 早于 now 时移动到 now
+func (v *virtualTime) clampTo(now time.Time) {
+	if v.t.Before(now) {
+		v.t = now
+		v.frac = 0
+	}
+}
+
+// tokenNanos 返回速率为 limit（令牌/秒）时 n 个令牌对应的纳秒数
+func tokenNanos(n int, limit float64) float64 {
+	return float64(n) * float64(time.Second) / limit
+}
@@ -0,0 +1,114 @@
+package ratelimited
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// TestGCRA_BurstThenPacing 测试突发容忍内立即放行、超出后按发射间隔等待
+//
+// 测试目标：
+//   - 突发容量等于 tolerance/interval + 1
+//   - 突发耗尽后下一个令牌需要等待一个发射间隔
+//   - 超过突发容量的申请立即失败
+func TestGCRA_BurstThenPacing(t *testing.T) {
+	// Arrange: 每 10ms 一个令牌，容忍 30ms，即突发 4 个令牌
+	clock := newFakeClock()
+	g := NewGCRA(10*time.Millisecond, 30*time.Millisecond, WithGCRAClock(clock))
+	ctx := context.Background()
+
+	// Act & Assert
+	assertEqual(t, 4, g.Burst(), "突发容量")
+	assertEqual(t, rate.Limit(100), g.Limit(), "速率")
+	assertNoError(t, g.WaitN(ctx, 4), "突发容量内应该立即放行")
+
+	done := make(chan error, 1)
+	go func() { done <- g.WaitN(ctx, 1) }()
+	clock.waitForWaiters(t, 1)
+	clock.Advance(10 * time.Millisecond)
+	assertNoError(t, <-done, "等待一个发射间隔后应该放行")
+
+	if err := g.WaitN(ctx, 5); err == nil {
+		t.Error("超过突发容量的申请应该返回错误")
+	}
+}
+
+// TestGCRA_DeadlineAndCancel 测试等待超过 deadline 时不占用令牌、取消时归还令牌
+func TestGCRA_DeadlineAndCancel(t *testing.T) {
+	// Arrange: 每 10ms 一个令牌，没有突发容忍
+	clock := newFakeClock()
+	g := NewGCRA(10*time.Millisecond, 0, WithGCRAClock(clock))
+	assertNoError(t, g.WaitN(context.Background(), 1), "第一个令牌")
+
+	// Act: deadline 不足以等到下一个令牌
+	short, cancel := context.WithDeadline(context.Background(), clock.Now().Add(5*time.Millisecond))
+	defer cancel()
+	err := g.WaitN(short, 1)
+
+	// Assert
+	if err == nil {
+		t.Error("等待会超过 deadline 时应该立即返回错误")
+	}
+
+	// Act: 等待期间取消
+	ctx, cancelWait := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- g.WaitN(ctx, 1) }()
+	clock.waitForWaiters(t, 1)
+	cancelWait()
+
+	// Assert
+	if err := <-done; err != context.Canceled {
+		t.Errorf("取消后应该返回 context.Canceled，实际 %v", err)
+	}
+	clock.Advance(10 * time.Millisecond)
+	fast, cancelFast := context.WithDeadline(context.Background(), clock.Now())
+	defer cancelFast()
+	assertNoError(t, g.WaitN(fast, 1), "取消的申请应该归还令牌，间隔过后立即放行")
+}
+
+// TestGCRA_FromRate 测试按速率和突发容量创建，以及作为写入器链中的一层
+func TestGCRA_FromRate(t *testing.T) {
+	// Arrange
+	g := NewGCRAFromRate(1<<20, 64*1024)
+	writer := NewDiscardWriter([]Limiter{g})
+
+	// Act
+	n, err := writer.Write(createTestData(4096))
+
+	// Assert
+	assertNoError(t, err, "写入")
+	assertEqual(t, 4096, n, "写入字节数")
+	assertEqual(t, 64*1024, g.Burst(), "突发容量")
+	assertEqual(t, rate.Inf, NewGCRAFromRate(rate.Inf, 0).Limit(), "无限速率")
+}
+
+// TestGCRA_FractionalInterval 测试发射间隔不是整数纳秒的速率没有截断误差
+//
+// 测试目标：
+//   - 187.5e6 令牌/秒（间隔 5.33ns）逐个发放 1000 个令牌，理论到达时间推进 5333ns 而不是 5000ns
+//   - 超过每秒 1e9 个令牌的速率不会退化为不限制
+func TestGCRA_FractionalInterval(t *testing.T) {
+	// Arrange: 突发容量足够大，所有申请都立即放行
+	clock := newFakeClock()
+	g := NewGCRAFromRate(187.5e6, 1000, WithGCRAClock(clock))
+	fast := NewGCRAFromRate(2e9, 1000, WithGCRAClock(clock))
+	start := clock.Now()
+
+	// Act
+	for i := 0; i < 1000; i++ {
+		assertNoError(t, g.WaitN(context.Background(), 1), "突发容量内应该立即放行")
+		assertNoError(t, fast.WaitN(context.Background(), 1), "突发容量内应该立即放行")
+	}
+
+	// Assert
+	assertEqual(t, 5333*time.Nanosecond, g.tat.t.Sub(start), "理论到达时间应该按小数间隔累加")
+	assertEqual(t, 500*time.Nanosecond, fast.tat.t.Sub(start), "每个令牌 0.5ns 也应该累加")
+	assertEqual(t, rate.Limit(2e9), fast.Limit(), "超过 1e9 的速率应该保持原值")
+	if err := fast.WaitN(context.Background(), 1001); err == nil {
+		t.Error("超过 1e9 的速率仍然应该检查突发容量")
+	}
+}
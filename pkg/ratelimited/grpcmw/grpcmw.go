@@ -0,0 +1,133 @@
+// Package grpcmw 提供按消息大小限制 gRPC 带宽的服务端拦截器
+//
+// 请求和响应消息的序列化大小从限制器链中扣除，链可以按方法或按对端选择：
+//
+//	chains := grpcmw.ByMethod(map[string][]ratelimited.Limiter{
+//	    "/files.Store/Upload": ratelimited.Chain(uploadLimiter),
+//	}, ratelimited.Chain(defaultLimiter))
+//	server := grpc.NewServer(grpc.UnaryInterceptor(
+//	    grpcmw.UnaryServerInterceptor(chains, ratelimited.WithMaxWait(time.Second))))
+//
+// 流式调用使用 StreamServerInterceptor / StreamClientInterceptor，发送和接收可以使用不同的链。
+// 等待超过 WithMaxWait 或配额耗尽时返回 codes.ResourceExhausted。
+package grpcmw
+
+import (
+	"context"
+	"errors"
+	"net"
+
+	"github.com/lwmacct/250918-go-pkg-ratelimited/pkg/ratelimited"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// ChainFunc 为一次调用选择限制器链，返回空链时不限速
+type ChainFunc func(ctx context.Context, fullMethod string) []ratelimited.Limiter
+
+// ByMethod 按完整方法名（如 "/pkg.Service/Method"）选择链，未列出的方法使用 fallback
+func ByMethod(chains map[string][]ratelimited.Limiter, fallback []ratelimited.Limiter) ChainFunc {
+	return func(_ context.Context, fullMethod string) []ratelimited.Limiter {
+		if limiters, ok := chains[fullMethod]; ok {
+			return limiters
+		}
+		return fallback
+	}
+}
+
+// ByPeer 按对端地址的主机部分从 registry 取得各自的链，global 中的层排在前面并被所有对端共享
+//
+// 上下文中没有对端信息时只使用 global。
+func ByPeer(registry *ratelimited.Registry, global []ratelimited.Limiter) ChainFunc {
+	return func(ctx context.Context, _ string) []ratelimited.Limiter {
+		p, ok := peer.FromContext(ctx)
+		if !ok || p.Addr == nil {
+			return global
+		}
+		host := p.Addr.String()
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		perPeer := registry.Get(host)
+		limiters := make([]ratelimited.Limiter, 0, len(global)+len(perPeer))
+		return append(append(limiters, global...), perPeer...)
+	}
+}
+
+// UnaryServerInterceptor 返回按消息大小限速的一元服务端拦截器
+//
+// 调用处理器之前扣除请求消息的大小，处理器成功返回后扣除响应消息的大小；
+// 消息不是 proto.Message 时按 0 字节计。每次调用使用一个只申请实际所需令牌的写入器，
+// opts 应用于该写入器，可用于设置 WithMaxWait、WithQuota 等，等待使用调用的上下文。
+// 限速失败时返回的状态码见 Code。
+func UnaryServerInterceptor(chainFor ChainFunc, opts ...ratelimited.DiscardWriterOption) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		limiters := chainFor(ctx, info.FullMethod)
+		if len(limiters) == 0 {
+			return handler(ctx, req)
+		}
+
+		w := newCallWriter(ctx, limiters, opts)
+		defer w.Close()
+
+		if err := charge(w, req); err != nil {
+			return nil, err
+		}
+		resp, err := handler(ctx, req)
+		if err != nil {
+			return resp, err
+		}
+		if err := charge(w, resp); err != nil {
+			return nil, err
+		}
+		return resp, nil
+	}
+}
+
+// Code 返回限速错误对应的 gRPC 状态码
+//
+// 超过最长等待（*ratelimited.MaxWaitError）、超过配额感知期限（*ratelimited.QuotaWaitError）、
+// 配额耗尽和非阻塞模式下的 ErrWouldBlock 对应 codes.ResourceExhausted；上下文取消和超时对应 codes.Canceled 和
+// codes.DeadlineExceeded，预计等待会超过调用截止时间而提前拒绝的错误包装了
+// context.DeadlineExceeded，同样对应 codes.DeadlineExceeded，避免客户端按 UNAVAILABLE 立即重试；
+// 其他错误对应 codes.Unavailable。
+func Code(err error) codes.Code {
+	var mwErr *ratelimited.MaxWaitError
+	var qwErr *ratelimited.QuotaWaitError
+	switch {
+	case errors.As(err, &mwErr),
+		errors.As(err, &qwErr),
+		errors.Is(err, ratelimited.ErrQuotaExhausted),
+		errors.Is(err, ratelimited.ErrWouldBlock):
+		return codes.ResourceExhausted
+	case errors.Is(err, context.Canceled):
+		return codes.Canceled
+	case errors.Is(err, context.DeadlineExceeded):
+		return codes.DeadlineExceeded
+	default:
+		return codes.Unavailable
+	}
+}
+
+// newCallWriter 创建一次调用（或流的一个方向）使用的写入器，只申请实际所需的令牌，等待使用 ctx
+func newCallWriter(ctx context.Context, limiters []ratelimited.Limiter, opts []ratelimited.DiscardWriterOption) *ratelimited.DiscardWriter {
+	writerOpts := append([]ratelimited.DiscardWriterOption{
+		ratelimited.WithExplicitCommit(true),
+	}, opts...)
+	writerOpts = append(writerOpts, ratelimited.WithContext(ctx))
+	return ratelimited.NewDiscardWriter(limiters, writerOpts...)
+}
+
+// charge 扣除消息的序列化大小，失败时转换为 gRPC 状态错误；w 为 nil 时不限速
+func charge(w *ratelimited.DiscardWriter, msg any) error {
+	size := messageSize(msg)
+	if w == nil || size == 0 {
+		return nil
+	}
+	if err := w.Charge(size); err != nil {
+		return status.Error(Code(err), err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package grpcmw
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lwmacct/250918-go-pkg-ratelimited/pkg/ratelimited"
+	"golang.org/x/time/rate"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// countingLimiter 记录申请令牌总数的限制器
+type countingLimiter struct {
+	tokens int64
+}
+
+func (c *countingLimiter) WaitN(ctx context.Context, n int) error {
+	atomic.AddInt64(&c.tokens, int64(n))
+	return nil
+}
+
+// echoHandler 原样返回请求的处理器
+func echoHandler(ctx context.Context, req any) (any, error) {
+	return req, nil
+}
+
+// TestUnaryServerInterceptor_ChargesMessageSizes 测试请求和响应的大小都计入按方法选择的链
+func TestUnaryServerInterceptor_ChargesMessageSizes(t *testing.T) {
+	// Arrange
+	upload := &countingLimiter{}
+	fallback := &countingLimiter{}
+	interceptor := UnaryServerInterceptor(ByMethod(map[string][]ratelimited.Limiter{
+		"/files.Store/Upload": {upload},
+	}, []ratelimited.Limiter{fallback}))
+	req := wrapperspb.String("hello, world")
+	info := &grpc.UnaryServerInfo{FullMethod: "/files.Store/Upload"}
+
+	// Act
+	resp, err := interceptor(context.Background(), req, info, echoHandler)
+
+	// Assert
+	if err != nil || resp != req {
+		t.Fatalf("调用应该成功并返回处理器的响应，实际 resp=%v err=%v", resp, err)
+	}
+	if got, want := atomic.LoadInt64(&upload.tokens), int64(2*proto.Size(req)); got != want {
+		t.Errorf("应该按请求和响应的大小申请 %d 个令牌，实际 %d", want, got)
+	}
+	if got := atomic.LoadInt64(&fallback.tokens); got != 0 {
+		t.Errorf("列出的方法不应该使用默认链，实际申请 %d", got)
+	}
+}
+
+// TestUnaryServerInterceptor_MaxWait 测试等待超过上限时返回 ResourceExhausted 且不调用处理器
+func TestUnaryServerInterceptor_MaxWait(t *testing.T) {
+	// Arrange: 突发容量已耗尽，512 字节的请求需要等待约 0.5 秒
+	limiter := rate.NewLimiter(1024, 1024)
+	limiter.AllowN(time.Now(), 1024)
+	interceptor := UnaryServerInterceptor(ByMethod(nil, ratelimited.Chain(limiter)),
+		ratelimited.WithMaxWait(10*time.Millisecond))
+	called := false
+	handler := func(ctx context.Context, req any) (any, error) {
+		called = true
+		return req, nil
+	}
+
+	// Act
+	_, err := interceptor(context.Background(), wrapperspb.String(strings.Repeat("x", 512)),
+		&grpc.UnaryServerInfo{FullMethod: "/svc/M"}, handler)
+
+	// Assert
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("应该返回 ResourceExhausted，实际 %v", err)
+	}
+	if called {
+		t.Error("请求被拒绝时不应该调用处理器")
+	}
+}
+
+// TestUnaryServerInterceptor_ClientDeadline 测试等待会超过调用截止时间时返回 DeadlineExceeded
+func TestUnaryServerInterceptor_ClientDeadline(t *testing.T) {
+	// Arrange: 突发容量已耗尽，512 字节的请求需要等待约 0.5 秒，调用只剩 20 毫秒
+	limiter := rate.NewLimiter(1024, 1024)
+	limiter.AllowN(time.Now(), 1024)
+	interceptor := UnaryServerInterceptor(ByMethod(nil, ratelimited.Chain(limiter)))
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	// Act
+	_, err := interceptor(ctx, wrapperspb.String(strings.Repeat("x", 512)),
+		&grpc.UnaryServerInfo{FullMethod: "/svc/M"}, echoHandler)
+
+	// Assert
+	if status.Code(err) != codes.DeadlineExceeded {
+		t.Errorf("应该返回 DeadlineExceeded 而不是可重试的 Unavailable，实际 %v", err)
+	}
+}
+
+// TestByPeer 测试按对端主机选择各自的链并共享全局层
+func TestByPeer(t *testing.T) {
+	// Arrange
+	global := &countingLimiter{}
+	created := 0
+	registry := ratelimited.NewRegistry(func(string) []ratelimited.Limiter {
+		created++
+		return []ratelimited.Limiter{&countingLimiter{}}
+	})
+	chainFor := ByPeer(registry, []ratelimited.Limiter{global})
+	peerCtx := func(addr string) context.Context {
+		tcp, _ := net.ResolveTCPAddr("tcp", addr)
+		return peer.NewContext(context.Background(), &peer.Peer{Addr: tcp})
+	}
+
+	// Act
+	first := chainFor(peerCtx("192.0.2.1:1000"), "/svc/M")
+	sameHost := chainFor(peerCtx("192.0.2.1:2000"), "/svc/M")
+	anonymous := chainFor(context.Background(), "/svc/M")
+
+	// Assert
+	if created != 1 || len(first) != 2 || first[1] != sameHost[1] {
+		t.Errorf("同一主机的不同端口应该共享一条链，创建了 %d 条", created)
+	}
+	if first[0] != global {
+		t.Error("全局层应该排在每条链的最前面")
+	}
+	if len(anonymous) != 1 {
+		t.Errorf("没有对端信息时只应使用全局层，实际 %d 层", len(anonymous))
+	}
+}
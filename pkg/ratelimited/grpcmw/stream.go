@@ -0,0 +1,215 @@
+package grpcmw
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/lwmacct/250918-go-pkg-ratelimited/pkg/ratelimited"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+)
+
+// =============================================================================
+// 流式拦截器 - 按消息限速 SendMsg / RecvMsg
+// =============================================================================
+
+// StreamChainFunc 为一个流分别选择发送和接收方向的限制器链，返回空链的方向不限速
+type StreamChainFunc func(ctx context.Context, fullMethod string) (send, recv []ratelimited.Limiter)
+
+// SameChain 把一元调用的 ChainFunc 用于流的两个方向，两个方向共享同一条链
+func SameChain(chainFor ChainFunc) StreamChainFunc {
+	return func(ctx context.Context, fullMethod string) ([]ratelimited.Limiter, []ratelimited.Limiter) {
+		limiters := chainFor(ctx, fullMethod)
+		return limiters, limiters
+	}
+}
+
+// StreamCounters 一个流已发送和已接收的消息字节数与消息数，并发安全
+type StreamCounters struct {
+	sentBytes    atomic.Int64
+	sentMessages atomic.Int64
+	recvBytes    atomic.Int64
+	recvMessages atomic.Int64
+}
+
+// Sent 返回已发送的消息字节数和消息数
+func (c *StreamCounters) Sent() (bytes, messages int64) {
+	return c.sentBytes.Load(), c.sentMessages.Load()
+}
+
+// Received 返回已接收的消息字节数和消息数
+func (c *StreamCounters) Received() (bytes, messages int64) {
+	return c.recvBytes.Load(), c.recvMessages.Load()
+}
+
+// countersKey 流计数器在上下文中的键
+type countersKey struct{}
+
+// CountersFromContext 返回流拦截器为当前流记录的计数器
+//
+// 服务端处理器传入 stream.Context()，客户端传入返回的流的 Context()；
+// 流没有经过本包的拦截器时返回 nil。
+func CountersFromContext(ctx context.Context) *StreamCounters {
+	c, _ := ctx.Value(countersKey{}).(*StreamCounters)
+	return c
+}
+
+// StreamServerInterceptor 返回按消息大小限速的流式服务端拦截器
+//
+// SendMsg 在发送前扣除消息大小，RecvMsg 在接收后扣除；每个流的每个方向
+// 使用一个只申请实际所需令牌的写入器，opts 应用于这些写入器，等待使用流的上下文。
+// 限速失败时 SendMsg / RecvMsg 返回的状态码见 Code。
+func StreamServerInterceptor(chainFor StreamChainFunc, opts ...ratelimited.DiscardWriterOption) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		counters := &StreamCounters{}
+		ctx := context.WithValue(ss.Context(), countersKey{}, counters)
+		send, recv := chainFor(ctx, info.FullMethod)
+		stream := &serverStream{
+			ServerStream: ss,
+			ctx:          ctx,
+			counted:      newCounted(ctx, counters, send, recv, opts),
+		}
+		defer stream.close()
+		return handler(srv, stream)
+	}
+}
+
+// StreamClientInterceptor 返回按消息大小限速的流式客户端拦截器
+//
+// 行为与 StreamServerInterceptor 相同。流结束后（RecvMsg 返回错误）写入器不会被显式关闭，
+// 写入器只申请实际所需的令牌，没有需要归还的批次余量。
+func StreamClientInterceptor(chainFor StreamChainFunc, opts ...ratelimited.DiscardWriterOption) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, callOpts ...grpc.CallOption) (grpc.ClientStream, error) {
+		cs, err := streamer(ctx, desc, cc, method, callOpts...)
+		if err != nil {
+			return nil, err
+		}
+		counters := &StreamCounters{}
+		ctx = context.WithValue(cs.Context(), countersKey{}, counters)
+		send, recv := chainFor(ctx, method)
+		return &clientStream{
+			ClientStream: cs,
+			ctx:          ctx,
+			counted:      newCounted(ctx, counters, send, recv, opts),
+		}, nil
+	}
+}
+
+// counted 流两个方向共用的限速和计数逻辑
+type counted struct {
+	counters *StreamCounters
+	send     *ratelimited.DiscardWriter // 为 nil 时发送方向不限速
+	recv     *ratelimited.DiscardWriter // 为 nil 时接收方向不限速
+}
+
+// newCounted 为非空的链创建按流上下文等待的写入器
+func newCounted(ctx context.Context, counters *StreamCounters, send, recv []ratelimited.Limiter, opts []ratelimited.DiscardWriterOption) counted {
+	c := counted{counters: counters}
+	if len(send) > 0 {
+		c.send = newCallWriter(ctx, send, opts)
+	}
+	if len(recv) > 0 {
+		c.recv = newCallWriter(ctx, recv, opts)
+	}
+	return c
+}
+
+// beforeSend 扣除发送消息的大小
+func (c *counted) beforeSend(m any) error {
+	return charge(c.send, m)
+}
+
+// afterSend 记录已发送的消息
+func (c *counted) afterSend(m any) {
+	c.counters.sentBytes.Add(int64(messageSize(m)))
+	c.counters.sentMessages.Add(1)
+}
+
+// afterRecv 记录并扣除已接收消息的大小
+func (c *counted) afterRecv(m any) error {
+	c.counters.recvBytes.Add(int64(messageSize(m)))
+	c.counters.recvMessages.Add(1)
+	return charge(c.recv, m)
+}
+
+// close 关闭两个方向的写入器
+func (c *counted) close() {
+	if c.send != nil {
+		c.send.Close()
+	}
+	if c.recv != nil {
+		c.recv.Close()
+	}
+}
+
+// serverStream 限速的 grpc.ServerStream
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+	counted
+}
+
+// Context 返回带有流计数器的上下文
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+// SendMsg 扣除消息大小后发送
+func (s *serverStream) SendMsg(m any) error {
+	if err := s.beforeSend(m); err != nil {
+		return err
+	}
+	if err := s.ServerStream.SendMsg(m); err != nil {
+		return err
+	}
+	s.afterSend(m)
+	return nil
+}
+
+// RecvMsg 接收消息后扣除其大小
+func (s *serverStream) RecvMsg(m any) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	return s.afterRecv(m)
+}
+
+// clientStream 限速的 grpc.ClientStream
+type clientStream struct {
+	grpc.ClientStream
+	ctx context.Context
+	counted
+}
+
+// Context 返回带有流计数器的上下文
+func (s *clientStream) Context() context.Context {
+	return s.ctx
+}
+
+// SendMsg 扣除消息大小后发送
+func (s *clientStream) SendMsg(m any) error {
+	if err := s.beforeSend(m); err != nil {
+		return err
+	}
+	if err := s.ClientStream.SendMsg(m); err != nil {
+		return err
+	}
+	s.afterSend(m)
+	return nil
+}
+
+// RecvMsg 接收消息后扣除其大小
+func (s *clientStream) RecvMsg(m any) error {
+	if err := s.ClientStream.RecvMsg(m); err != nil {
+		return err
+	}
+	return s.afterRecv(m)
+}
+
+// messageSize 返回消息的序列化大小，不是 proto.Message 时为 0
+func messageSize(msg any) int {
+	if m, ok := msg.(proto.Message); ok {
+		return proto.Size(m)
+	}
+	return 0
+}
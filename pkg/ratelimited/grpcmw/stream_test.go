@@ -0,0 +1,133 @@
+package grpcmw
+
+import (
+	"context"
+	"io"
+	"sync/atomic"
+	"testing"
+
+	"github.com/lwmacct/250918-go-pkg-ratelimited/pkg/ratelimited"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// fakeServerStream 从队列接收消息、记录发送消息的服务端流
+type fakeServerStream struct {
+	grpc.ServerStream
+	incoming []proto.Message
+	sent     int
+}
+
+func (s *fakeServerStream) Context() context.Context { return context.Background() }
+
+func (s *fakeServerStream) SendMsg(m any) error {
+	s.sent++
+	return nil
+}
+
+func (s *fakeServerStream) RecvMsg(m any) error {
+	if len(s.incoming) == 0 {
+		return io.EOF
+	}
+	proto.Merge(m.(proto.Message), s.incoming[0])
+	s.incoming = s.incoming[1:]
+	return nil
+}
+
+// fakeClientStream 不做任何事的客户端流
+type fakeClientStream struct {
+	grpc.ClientStream
+}
+
+func (fakeClientStream) Context() context.Context { return context.Background() }
+func (fakeClientStream) SendMsg(m any) error      { return nil }
+func (fakeClientStream) RecvMsg(m any) error {
+	proto.Merge(m.(proto.Message), wrapperspb.String("reply"))
+	return nil
+}
+
+// TestStreamServerInterceptor 测试服务端流按方向扣除消息大小并记录计数
+//
+// 测试目标：
+//   - 发送的消息计入发送链，接收的消息计入接收链
+//   - 处理器可以通过 CountersFromContext 读取流的计数
+func TestStreamServerInterceptor(t *testing.T) {
+	// Arrange
+	send := &countingLimiter{}
+	recv := &countingLimiter{}
+	interceptor := StreamServerInterceptor(func(context.Context, string) ([]ratelimited.Limiter, []ratelimited.Limiter) {
+		return []ratelimited.Limiter{send}, []ratelimited.Limiter{recv}
+	})
+	in := wrapperspb.String("request payload")
+	out := wrapperspb.String("reply")
+	ss := &fakeServerStream{incoming: []proto.Message{in, in}}
+	var counters *StreamCounters
+
+	// Act
+	err := interceptor(nil, ss, &grpc.StreamServerInfo{FullMethod: "/svc/Stream"}, func(_ any, stream grpc.ServerStream) error {
+		counters = CountersFromContext(stream.Context())
+		for {
+			msg := &wrapperspb.StringValue{}
+			if err := stream.RecvMsg(msg); err != nil {
+				return nil
+			}
+			if err := stream.SendMsg(out); err != nil {
+				return err
+			}
+		}
+	})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("流应该正常结束: %v", err)
+	}
+	if got, want := atomic.LoadInt64(&recv.tokens), int64(2*proto.Size(in)); got != want {
+		t.Errorf("接收链应该申请 %d 个令牌，实际 %d", want, got)
+	}
+	if got, want := atomic.LoadInt64(&send.tokens), int64(2*proto.Size(out)); got != want {
+		t.Errorf("发送链应该申请 %d 个令牌，实际 %d", want, got)
+	}
+	if counters == nil {
+		t.Fatal("处理器应该能取得流计数器")
+	}
+	if bytes, messages := counters.Received(); bytes != int64(2*proto.Size(in)) || messages != 2 {
+		t.Errorf("接收计数错误: bytes=%d messages=%d", bytes, messages)
+	}
+	if bytes, messages := counters.Sent(); bytes != int64(2*proto.Size(out)) || messages != 2 {
+		t.Errorf("发送计数错误: bytes=%d messages=%d", bytes, messages)
+	}
+}
+
+// TestStreamClientInterceptor 测试客户端流共享同一条链并通过流的上下文暴露计数
+func TestStreamClientInterceptor(t *testing.T) {
+	// Arrange
+	limiter := &countingLimiter{}
+	interceptor := StreamClientInterceptor(SameChain(ByMethod(nil, []ratelimited.Limiter{limiter})))
+	streamer := func(context.Context, *grpc.StreamDesc, *grpc.ClientConn, string, ...grpc.CallOption) (grpc.ClientStream, error) {
+		return fakeClientStream{}, nil
+	}
+	req := wrapperspb.String("hello")
+
+	// Act
+	cs, err := interceptor(context.Background(), &grpc.StreamDesc{}, nil, "/svc/Stream", streamer)
+	if err != nil {
+		t.Fatalf("创建流失败: %v", err)
+	}
+	_ = cs.SendMsg(req)
+	reply := &wrapperspb.StringValue{}
+	_ = cs.RecvMsg(reply)
+
+	// Assert
+	want := int64(proto.Size(req) + proto.Size(reply))
+	if got := atomic.LoadInt64(&limiter.tokens); got != want {
+		t.Errorf("两个方向都应该计入同一条链，期望 %d，实际 %d", want, got)
+	}
+	counters := CountersFromContext(cs.Context())
+	if _, sent := counters.Sent(); sent != 1 {
+		t.Errorf("应该记录 1 条发送消息，实际 %d", sent)
+	}
+	if _, received := counters.Received(); received != 1 {
+		t.Errorf("应该记录 1 条接收消息，实际 %d", received)
+	}
+}
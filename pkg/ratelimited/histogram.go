@@ -0,0 +1,126 @@
+package ratelimited
+
+import (
+	"math/bits"
+	"sync/atomic"
+	"time"
+)
+
+// =============================================================================
+// 等待时长分布 - HDR 风格的对数线性直方图
+// =============================================================================
+
+const (
+	// histSubBuckets 每个 2 的幂区间内的线性子桶数，相对误差不超过 1/histSubBuckets
+	histSubBuckets = 4
+	// histMaxExp 最大的 2 的幂指数（微秒），2^40µs 约 12.7 天，更长的等待计入最后一个桶
+	histMaxExp = 40
+	// histBuckets 桶的总数：前 histSubBuckets 个桶分别对应 0~3µs，之后每个 2 的幂区间 histSubBuckets 个桶
+	histBuckets = histSubBuckets + (histMaxExp-1)*histSubBuckets
+)
+
+// WaitHistogram 每次写入等待令牌时长的分布快照
+//
+// 桶的边界按 HDR 直方图的方式划分：分辨率为 1µs，每个 2 的幂区间再线性等分为 4 个子桶，
+// 因此分位数的相对误差不超过 25%，而整个直方图只需要固定的 160 个计数器。
+// 不足 1µs 的等待（通常是没有等待）计入第一个桶。快照是普通的值，可以自由复制和比较。
+type WaitHistogram struct {
+	counts [histBuckets]uint64
+}
+
+// WaitBucket 直方图中的一个桶：等待时长不超过 UpperBound 且大于前一个桶上界的写入次数
+type WaitBucket struct {
+	UpperBound time.Duration
+	Count      uint64
+}
+
+// Count 返回记录的写入总次数
+func (h WaitHistogram) Count() uint64 {
+	var total uint64
+	for _, c := range h.counts {
+		total += c
+	}
+	return total
+}
+
+// Quantile 返回 q 分位（0~1）的等待时长，例如 0.99 返回 P99
+//
+// 返回值是分位所在桶的上界，即不低估的保守估计。没有记录时返回 0。
+func (h WaitHistogram) Quantile(q float64) time.Duration {
+	total := h.Count()
+	if total == 0 {
+		return 0
+	}
+	q = min(max(q, 0), 1)
+	rank := uint64(q * float64(total))
+	if rank == 0 {
+		rank = 1
+	}
+	var seen uint64
+	for i, c := range h.counts {
+		seen += c
+		if seen >= rank {
+			return histUpperBound(i)
+		}
+	}
+	return histUpperBound(len(h.counts) - 1)
+}
+
+// Buckets 返回所有非空的桶，按上界升序排列
+func (h WaitHistogram) Buckets() []WaitBucket {
+	var out []WaitBucket
+	for i, c := range h.counts {
+		if c > 0 {
+			out = append(out, WaitBucket{UpperBound: histUpperBound(i), Count: c})
+		}
+	}
+	return out
+}
+
+// waitHistogram 写入器内部的并发安全直方图
+type waitHistogram struct {
+	counts [histBuckets]uint64 // 需要原子访问
+}
+
+// record 记录一次等待
+func (h *waitHistogram) record(waited time.Duration) {
+	atomic.AddUint64(&h.counts[histIndex(waited)], 1)
+}
+
+// snapshot 返回当前分布的快照
+func (h *waitHistogram) snapshot() WaitHistogram {
+	var snap WaitHistogram
+	for i := range snap.counts {
+		snap.counts[i] = atomic.LoadUint64(&h.counts[i])
+	}
+	return snap
+}
+
+// histIndex 返回等待时长所在桶的下标
+func histIndex(waited time.Duration) int {
+	if waited <= 0 {
+		return 0
+	}
+	v := uint64(waited / time.Microsecond)
+	if v < histSubBuckets {
+		return int(v)
+	}
+	exp := bits.Len64(v) - 1 // v 位于 [2^exp, 2^(exp+1))
+	if exp > histMaxExp {
+		return histBuckets - 1
+	}
+	sub := int(v>>(exp-2)) & (histSubBuckets - 1)
+	return histSubBuckets + (exp-2)*histSubBuckets + sub
+}
+
+// histUpperBound 返回第 i 个桶的上界
+func histUpperBound(i int) time.Duration {
+	if i < histSubBuckets {
+		return time.Duration(i+1)*time.Microsecond - 1
+	}
+	exp := (i-histSubBuckets)/histSubBuckets + 2
+	sub := (i - histSubBuckets) % histSubBuckets
+	width := uint64(1) << (exp - 2)
+	upper := (histSubBuckets+uint64(sub))*width + width
+	return time.Duration(upper)*time.Microsecond - 1
+}
@@ -0,0 +1,62 @@
+package ratelimited
+
+import (
+	"testing"
+	"time"
+)
+
+// TestWaitHistogram_Buckets 测试桶的划分覆盖所有时长且相对误差有界
+//
+// 测试目标：
+//   - 每个时长都落在上界不小于自身的桶中
+//   - 桶上界与时长的相对误差不超过 1/histSubBuckets
+//   - 超出范围的时长计入最后一个桶
+func TestWaitHistogram_Buckets(t *testing.T) {
+	for _, d := range []time.Duration{
+		0, 500 * time.Nanosecond, time.Microsecond, 3 * time.Microsecond, 5 * time.Microsecond,
+		time.Millisecond, 1234567 * time.Microsecond, time.Minute, time.Hour,
+	} {
+		i := histIndex(d)
+		upper := histUpperBound(i)
+		if upper < d {
+			t.Errorf("%s 所在桶的上界 %s 小于自身", d, upper)
+		}
+		if d >= 4*time.Microsecond && float64(upper-d) > float64(d)/histSubBuckets {
+			t.Errorf("%s 所在桶的上界 %s 误差过大", d, upper)
+		}
+		if i > 0 && histUpperBound(i-1) >= d {
+			t.Errorf("%s 应该落在更低的桶中", d)
+		}
+	}
+	assertEqual(t, histBuckets-1, histIndex(1000*time.Hour*24), "超出范围的时长应该计入最后一个桶")
+}
+
+// TestWaitHistogram_Quantile 测试分位数和非空桶列表
+func TestWaitHistogram_Quantile(t *testing.T) {
+	// Arrange: 90 次无等待，9 次 10ms，1 次 1s
+	var h waitHistogram
+	for range 90 {
+		h.record(0)
+	}
+	for range 9 {
+		h.record(10 * time.Millisecond)
+	}
+	h.record(time.Second)
+
+	// Act
+	snap := h.snapshot()
+
+	// Assert
+	assertEqual(t, uint64(100), snap.Count(), "总次数")
+	if p50 := snap.Quantile(0.5); p50 >= time.Microsecond {
+		t.Errorf("P50 应该是无等待，实际 %s", p50)
+	}
+	if p99 := snap.Quantile(0.99); p99 < 10*time.Millisecond || p99 > 12500*time.Microsecond {
+		t.Errorf("P99 应该在 10ms 的桶内，实际 %s", p99)
+	}
+	if p100 := snap.Quantile(1); p100 < time.Second || p100 > 1250*time.Millisecond {
+		t.Errorf("P100 应该在 1s 的桶内，实际 %s", p100)
+	}
+	assertEqual(t, 3, len(snap.Buckets()), "应该有 3 个非空桶")
+	assertEqual(t, time.Duration(0), WaitHistogram{}.Quantile(0.99), "空直方图的分位数为 0")
+}
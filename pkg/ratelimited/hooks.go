@@ -0,0 +1,69 @@
+package ratelimited
+
+import "time"
+
+// =============================================================================
+// 事件钩子 - 写入、限流等待与配额耗尽的回调
+// =============================================================================
+
+// Hooks 写入器事件的回调集合，未设置的回调被忽略
+//
+// 所有回调都在写入的 goroutine 中同步调用，不持有写入器的锁，但应尽快返回；
+// 多个写入 goroutine 共享写入器时回调可能被并发调用。
+type Hooks struct {
+	// OnWrite 每次成功写入后调用，n 为接收的字节数，waited 为本次写入等待令牌的时间
+	OnWrite func(n int, waited time.Duration)
+
+	// OnThrottle 链中任意一层的单次等待超过 ThrottleThreshold 时调用，name 为层级名称
+	OnThrottle func(name string, waited time.Duration)
+	// ThrottleThreshold 触发 OnThrottle 的等待时长阈值，0 表示任何等待都触发
+	ThrottleThreshold time.Duration
+
+	// OnQuotaExhausted 写入器第一次发现共享配额耗尽时调用：扣除使剩余配额归零，
+	// 或写入因配额耗尽被拒绝。每个写入器最多调用一次。
+	OnQuotaExhausted func()
+}
+
+// WithHooks 注册事件钩子，可多次调用追加，按注册顺序调用
+//
+// 使用示例：
+//
+//	w := ratelimited.NewDiscardWriter(limiters,
+//	    ratelimited.WithHooks(ratelimited.Hooks{
+//	        OnWrite: func(n int, _ time.Duration) { bar.Add(n) },
+//	        OnThrottle: func(name string, d time.Duration) { log.Printf("%s throttled %s", name, d) },
+//	        ThrottleThreshold: time.Second,
+//	        OnQuotaExhausted: func() { notify("quota exhausted") },
+//	    }),
+//	)
+func WithHooks(h Hooks) DiscardWriterOption {
+	return func(w *DiscardWriter) {
+		if h.OnWrite != nil {
+			w.sinks = append(w.sinks, StatsSinkFunc(h.OnWrite))
+		}
+		if h.OnThrottle != nil || h.OnQuotaExhausted != nil {
+			w.hooks = append(w.hooks, h)
+		}
+	}
+}
+
+// throttleHooks 调用等待超过阈值的 OnThrottle 钩子
+func (w *DiscardWriter) throttleHooks(name string, waited time.Duration) {
+	for _, h := range w.hooks {
+		if h.OnThrottle != nil && waited > h.ThrottleThreshold {
+			h.OnThrottle(name, waited)
+		}
+	}
+}
+
+// quotaExhausted 第一次发现配额耗尽时调用 OnQuotaExhausted 钩子
+func (w *DiscardWriter) quotaExhausted() {
+	if len(w.hooks) == 0 || !w.quotaExhaustedFired.CompareAndSwap(false, true) {
+		return
+	}
+	for _, h := range w.hooks {
+		if h.OnQuotaExhausted != nil {
+			h.OnQuotaExhausted()
+		}
+	}
+}
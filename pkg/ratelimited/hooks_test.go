@@ -0,0 +1,86 @@
+package ratelimited
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestWithHooks 测试写入、限流等待和配额耗尽钩子
+//
+// 测试目标：
+//   - OnWrite 在每次成功写入后以接收的字节数调用
+//   - OnThrottle 只在等待超过阈值时以层级名称调用
+//   - OnQuotaExhausted 在配额归零时调用一次，此后的拒绝不再重复调用
+func TestWithHooks(t *testing.T) {
+	// Arrange
+	clock := newFakeClock()
+	quota := int64(150)
+	var (
+		written   int64
+		throttled []string
+		exhausted int64
+	)
+	w := NewDiscardWriter([]Limiter{
+		&advancingLimiter{clock: clock, delay: 10 * time.Millisecond},
+		&advancingLimiter{clock: clock},
+	},
+		WithClock(clock),
+		WithBatchSize(100),
+		WithSharedQuota(&quota),
+		WithLimiterNames([]string{"global", "tenant"}),
+		WithHooks(Hooks{
+			OnWrite: func(n int, waited time.Duration) {
+				atomic.AddInt64(&written, int64(n))
+			},
+			OnThrottle: func(name string, waited time.Duration) {
+				throttled = append(throttled, name)
+			},
+			ThrottleThreshold: 5 * time.Millisecond,
+			OnQuotaExhausted: func() {
+				atomic.AddInt64(&exhausted, 1)
+			},
+		}),
+	)
+
+	// Act
+	_, err := w.Write(createTestData(100))
+	assertNoError(t, err, "第一次写入")
+	assertAtomicEqual(t, int64(0), &exhausted, "配额未耗尽时不应该调用")
+	n, _ := w.Write(createTestData(100))
+	_, rejectErr := w.Write(createTestData(100))
+
+	// Assert
+	assertEqual(t, 50, n, "第二次写入应该截断到剩余配额")
+	if !errors.Is(rejectErr, ErrQuotaExhausted) {
+		t.Errorf("配额耗尽后应该返回 ErrQuotaExhausted，实际 %v", rejectErr)
+	}
+	assertAtomicEqual(t, int64(150), &written, "OnWrite 应该累计所有接收的字节")
+	assertAtomicEqual(t, int64(1), &exhausted, "OnQuotaExhausted 应该只调用一次")
+	if len(throttled) == 0 {
+		t.Fatal("超过阈值的等待应该触发 OnThrottle")
+	}
+	for _, name := range throttled {
+		assertEqual(t, "global", name, "只有超过阈值的层应该触发 OnThrottle")
+	}
+}
+
+// TestWithHooks_Multiple 测试多次注册的钩子按顺序全部调用
+func TestWithHooks_Multiple(t *testing.T) {
+	// Arrange
+	var order []string
+	w := NewDiscardWriter(nil,
+		WithHooks(Hooks{OnWrite: func(int, time.Duration) { order = append(order, "first") }}),
+		WithHooks(Hooks{OnWrite: func(int, time.Duration) { order = append(order, "second") }}),
+	)
+
+	// Act
+	_, err := w.Write(createTestData(10))
+
+	// Assert
+	assertNoError(t, err, "写入")
+	assertEqual(t, 2, len(order), "两个钩子都应该被调用")
+	assertEqual(t, "first", order[0], "按注册顺序调用")
+	assertEqual(t, "second", order[1], "按注册顺序调用")
+}
@@ -0,0 +1,177 @@
+package ratelimited
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// =============================================================================
+// 分层令牌桶 (HTB) - 保证速率与向父类借用
+// =============================================================================
+
+// HTBClass 分层令牌桶树中的一个类，实现 Limiter 接口
+//
+// 每个类有保证速率 assured 和上限速率 ceil：类自己的令牌桶按保证速率补充，
+// 令牌不足时可以向祖先借用其空闲的令牌，但类的总速率不超过 ceil。
+// 令牌由哪个类提供，就计入该类及其所有祖先的用量，因此父类的速率是子类共享的总带宽。
+// 通常把叶子类作为链中的一层，例如每个租户一个叶子、全部挂在表示出口带宽的根下。
+// 同一棵树的所有类共用一把锁。
+type HTBClass struct {
+	name     string
+	parent   *HTBClass
+	mu       *sync.Mutex   // 整棵树共用
+	clock    Clock         // 整棵树共用
+	assured  *rate.Limiter // 保证速率
+	ceil     *rate.Limiter // 上限速率，nil 表示只受祖先限制
+	children map[string]*HTBClass
+}
+
+// HTBOption 分层令牌桶的配置选项
+type HTBOption func(*HTBClass)
+
+// WithHTBClock 设置整棵树使用的时钟，默认使用系统时钟
+func WithHTBClock(c Clock) HTBOption {
+	return func(h *HTBClass) {
+		if c != nil {
+			h.clock = c
+		}
+	}
+}
+
+// NewHTB 创建速率为 limit、突发容量为 burst 的根类
+func NewHTB(limit rate.Limit, burst int, opts ...HTBOption) *HTBClass {
+	root := &HTBClass{
+		mu:      &sync.Mutex{},
+		clock:   systemClock{},
+		assured: rate.NewLimiter(limit, burst),
+	}
+	for _, opt := range opts {
+		opt(root)
+	}
+	return root
+}
+
+// Class 返回名为 name 的子类，保证速率为 assured、上限速率为 ceil、突发容量为 burst
+//
+// ceil 为 0 时不单独设上限，子类最多借用到祖先的全部空闲带宽。
+// 已存在同名子类时直接返回该子类，其余参数被忽略。
+func (c *HTBClass) Class(name string, assured, ceil rate.Limit, burst int) *HTBClass {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if child, ok := c.children[name]; ok {
+		return child
+	}
+	child := &HTBClass{
+		name:    name,
+		parent:  c,
+		mu:      c.mu,
+		clock:   c.clock,
+		assured: rate.NewLimiter(assured, burst),
+	}
+	if ceil > 0 {
+		child.ceil = rate.NewLimiter(ceil, burst)
+	}
+	if c.children == nil {
+		c.children = make(map[string]*HTBClass)
+	}
+	c.children[name] = child
+	return child
+}
+
+// Name 返回类的名称，根类的名称为空
+func (c *HTBClass) Name() string {
+	return c.name
+}
+
+// Parent 返回父类，根类返回 nil
+func (c *HTBClass) Parent() *HTBClass {
+	return c.parent
+}
+
+// Limit 返回类的保证速率
+func (c *HTBClass) Limit() rate.Limit {
+	return c.assured.Limit()
+}
+
+// Burst 返回类的突发容量
+func (c *HTBClass) Burst() int {
+	return c.assured.Burst()
+}
+
+// WaitN 实现 Limiter 接口，阻塞直到本类或某个祖先能够提供 n 个令牌
+//
+// 依次尝试本类的令牌和各级祖先的空闲令牌，路径上任何一级的上限都不能被突破；
+// 都不可用时在本类的令牌桶上排队。n 超过突发容量或等待会超过 ctx 的 deadline 时
+// 不占用令牌并立即返回错误；ctx 在等待期间结束时归还占用的令牌。
+func (c *HTBClass) WaitN(ctx context.Context, n int) error {
+	now := c.clock.Now()
+	delay, cancel, err := c.reserve(now, n)
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok && delay > deadline.Sub(now) {
+		cancel()
+		return fmt.Errorf("ratelimited: WaitN(n=%d) would exceed context deadline: %w", n, context.DeadlineExceeded)
+	}
+	if err := Sleep(ctx, c.clock, delay); err != nil {
+		cancel()
+		return err
+	}
+	return nil
+}
+
+// reserve 在 now 时刻为 n 个令牌选择提供者并占用令牌，返回需要等待的时长和撤销函数
+func (c *HTBClass) reserve(now time.Time, n int) (time.Duration, func(), error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if n > c.assured.Burst() {
+		return 0, nil, fmt.Errorf("ratelimited: WaitN(n=%d) exceeds limiter's burst %d", n, c.assured.Burst())
+	}
+
+	// 选择提供令牌的类：本类或最近的有空闲令牌的祖先，路径上的上限都必须允许
+	lender := c
+	for class := c; class != nil; class = class.parent {
+		if class.ceil != nil && class.ceil.TokensAt(now) < float64(n) {
+			break
+		}
+		if class.assured.TokensAt(now) >= float64(n) {
+			lender = class
+			break
+		}
+	}
+
+	// 提供者及其祖先计入用量，路径上的上限计入总速率。祖先因此欠下的令牌
+	// 不让本次申请等待，只有提供者自己（没有空闲令牌时即本类）和上限需要等待
+	var reservations []*rate.Reservation
+	var delay time.Duration
+	charge := func(l *rate.Limiter, wait bool) {
+		r := l.ReserveN(now, n)
+		reservations = append(reservations, r)
+		if wait {
+			delay = max(delay, r.DelayFrom(now))
+		}
+	}
+	for class := lender; class != nil; class = class.parent {
+		charge(class.assured, class == lender)
+	}
+	for class := c; class != nil; class = class.parent {
+		if class.ceil != nil {
+			charge(class.ceil, true)
+		}
+	}
+
+	cancel := func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		for i := len(reservations) - 1; i >= 0; i-- {
+			reservations[i].CancelAt(now)
+		}
+	}
+	return delay, cancel, nil
+}
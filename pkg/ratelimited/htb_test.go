@@ -0,0 +1,75 @@
+package ratelimited
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// TestHTB_Borrowing 测试子类令牌不足时向父类借用，且借用受上限约束
+//
+// 测试目标：
+//   - 保证速率内的申请使用本类令牌
+//   - 本类令牌不足时从父类的空闲令牌借用，不消耗本类令牌
+//   - 上限不足时不能借用，只能在本类排队
+func TestHTB_Borrowing(t *testing.T) {
+	// Arrange: 根 1000/s；a 保证 100/s、上限 1000/s；b 保证 100/s、上限 200/s
+	root := NewHTB(1000, 1000)
+	a := root.Class("a", 100, 1000, 100)
+	b := root.Class("b", 100, 200, 100)
+	t0 := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	later := t0.Add(100 * time.Millisecond)
+
+	// Act & Assert: 保证速率内
+	delay, _, err := a.reserve(t0, 100)
+	assertNoError(t, err, "a 的第一次申请")
+	assertEqual(t, time.Duration(0), delay, "保证速率内应该立即放行")
+	delay, _, _ = b.reserve(t0, 100)
+	assertEqual(t, time.Duration(0), delay, "b 的保证速率内应该立即放行")
+
+	// Act & Assert: a 本类只补充了 10 个令牌，从根借用
+	delay, _, _ = a.reserve(later, 100)
+	assertEqual(t, time.Duration(0), delay, "根有空闲令牌时应该借用")
+	assertEqual(t, 10.0, a.assured.TokensAt(later), "借用不应该消耗本类令牌")
+
+	// Act & Assert: b 的上限只补充了 20 个令牌，不能借用，在本类排队
+	delay, _, _ = b.reserve(later, 100)
+	assertEqual(t, 900*time.Millisecond, delay, "上限不足时应该在本类等待补充")
+}
+
+// TestHTB_Guarantee 测试父类被其他子类用尽时，子类仍能使用自己的保证速率
+func TestHTB_Guarantee(t *testing.T) {
+	// Arrange: 根 100/s；a、b 各保证 50/s
+	root := NewHTB(100, 100)
+	a := root.Class("a", 50, 0, 100)
+	b := root.Class("b", 50, 0, 100)
+	t0 := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// Act
+	_, _, _ = a.reserve(t0, 100)
+	own, _, _ := b.reserve(t0, 100)
+	queued, _, _ := b.reserve(t0, 100)
+
+	// Assert
+	assertEqual(t, time.Duration(0), own, "根被 a 用尽时 b 仍应使用自己的令牌")
+	assertEqual(t, 2*time.Second, queued, "无处借用时应该按保证速率排队")
+	if root.Class("a", 1, 1, 1) != a {
+		t.Error("同名子类应该返回已有的类")
+	}
+}
+
+// TestHTB_WaitN 测试作为限制器使用时的突发容量和 deadline 检查
+func TestHTB_WaitN(t *testing.T) {
+	// Arrange
+	leaf := NewHTB(1000, 1000).Class("leaf", 10, 0, 10)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	// Act & Assert
+	assertNoError(t, leaf.WaitN(ctx, 10), "保证速率内应该立即放行")
+	if err := leaf.WaitN(ctx, 11); err == nil {
+		t.Error("超过突发容量的申请应该返回错误")
+	}
+	assertNoError(t, leaf.WaitN(ctx, 10), "根有空闲令牌时应该借用")
+	assertEqual(t, "leaf", leaf.Name(), "类名称")
+}
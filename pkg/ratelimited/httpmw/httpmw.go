@@ -0,0 +1,69 @@
+// Package httpmw 提供限制 HTTP 带宽的服务端中间件和客户端 RoundTripper
+//
+// 服务端的响应体经过 ratelimited 的限制器链写出，适用于下载限速、按租户分配带宽等场景：
+//
+//	handler := httpmw.Limit(mux, func(r *http.Request) []ratelimited.Limiter {
+//	    return ratelimited.Chain(globalLimiter, tenantLimiter(r))
+//	})
+//
+// 按客户端 IP 分配带宽时，每个 IP 从 Registry 取得各自的链，并共享全局层：
+//
+//	registry := ratelimited.NewRegistry(perIPTemplate, ratelimited.WithTTL(10*time.Minute))
+//	handler := httpmw.LimitPerIP(mux, registry, ratelimited.Chain(globalLimiter))
+//
+// 客户端读取的响应体同样可以限速：
+//
+//	client := &http.Client{Transport: httpmw.NewTransport(nil, ratelimited.Chain(limiter))}
+package httpmw
+
+import (
+	"net/http"
+
+	"github.com/lwmacct/250918-go-pkg-ratelimited/pkg/ratelimited"
+)
+
+// Limit 包装 next，使其响应体经过 chainFactory 为每个请求返回的限制器链
+//
+// 等待令牌使用请求的上下文，客户端断开后写入立即返回错误。
+// chainFactory 返回空链时响应不限速，但仍统计字节数。
+// next 收到的 ResponseWriter 是 *ResponseWriter，可通过类型断言读取本次请求已写出的字节数。
+func Limit(next http.Handler, chainFactory func(*http.Request) []ratelimited.Limiter) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rw := &ResponseWriter{ResponseWriter: w}
+		rw.body = ratelimited.NewWriter(w, chainFactory(r), ratelimited.WithContext(r.Context()))
+		defer rw.body.Close()
+
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// ResponseWriter 限速写出响应体的 http.ResponseWriter
+//
+// 实现 http.Flusher：底层 ResponseWriter 支持时透传 Flush，否则 Flush 是空操作。
+// Unwrap 返回底层 ResponseWriter，供 http.ResponseController 使用。
+type ResponseWriter struct {
+	http.ResponseWriter
+	body *ratelimited.ThrottledWriter
+}
+
+// Write 按限制器链限速写出响应体
+func (w *ResponseWriter) Write(p []byte) (int, error) {
+	return w.body.Write(p)
+}
+
+// Flush 实现 http.Flusher 接口
+func (w *ResponseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap 返回底层的 http.ResponseWriter
+func (w *ResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// BytesWritten 返回本次请求已写出的响应体字节数
+func (w *ResponseWriter) BytesWritten() int64 {
+	return w.body.Stats().Bytes
+}
@@ -0,0 +1,59 @@
+package httpmw
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/lwmacct/250918-go-pkg-ratelimited/pkg/ratelimited"
+)
+
+// countingLimiter 记录申请令牌总数的限制器
+type countingLimiter struct {
+	tokens int64
+}
+
+func (c *countingLimiter) WaitN(ctx context.Context, n int) error {
+	atomic.AddInt64(&c.tokens, int64(n))
+	return nil
+}
+
+// TestLimit_ThrottlesResponseBody 测试响应体经过限制器链并保留 Flusher
+//
+// 测试目标：
+//   - 响应体原样写出，且令牌按字节数申请
+//   - Flush 透传到底层 ResponseWriter
+//   - 处理器可以读取本次请求写出的字节数
+func TestLimit_ThrottlesResponseBody(t *testing.T) {
+	// Arrange
+	limiter := &countingLimiter{}
+	var reported int64
+	handler := Limit(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("hello, "))
+		w.(http.Flusher).Flush()
+		_, _ = w.Write([]byte("world"))
+		reported = w.(*ResponseWriter).BytesWritten()
+	}), func(*http.Request) []ratelimited.Limiter {
+		return []ratelimited.Limiter{limiter}
+	})
+	rec := httptest.NewRecorder()
+
+	// Act
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	// Assert
+	if got := rec.Body.String(); got != "hello, world" {
+		t.Errorf("响应体应该原样写出，实际为 %q", got)
+	}
+	if !rec.Flushed {
+		t.Error("Flush 应该透传到底层 ResponseWriter")
+	}
+	if reported != 12 {
+		t.Errorf("应该报告写出 12 字节，实际为 %d", reported)
+	}
+	if tokens := atomic.LoadInt64(&limiter.tokens); tokens < 12 {
+		t.Errorf("令牌申请量应该覆盖响应体，实际为 %d", tokens)
+	}
+}
@@ -0,0 +1,99 @@
+package httpmw
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+
+	"github.com/lwmacct/250918-go-pkg-ratelimited/pkg/ratelimited"
+)
+
+// ipConfig 按客户端 IP 限速的配置
+type ipConfig struct {
+	trusted []netip.Prefix
+}
+
+// IPOption 按客户端 IP 限速的配置选项
+type IPOption func(*ipConfig)
+
+// WithTrustedProxies 信任来自 prefixes 中地址的 X-Forwarded-For 头
+//
+// 默认不信任任何代理，客户端 IP 直接取自连接的远端地址，避免客户端伪造请求头逃避限速。
+// 部署在负载均衡或反向代理之后时，应传入这些代理的网段。
+func WithTrustedProxies(prefixes ...netip.Prefix) IPOption {
+	return func(c *ipConfig) {
+		c.trusted = append(c.trusted, prefixes...)
+	}
+}
+
+// ClientIP 返回请求的客户端 IP
+//
+// 连接的远端地址属于受信任的代理时，从右向左查找 X-Forwarded-For 中第一个不受信任的地址，
+// 即最后一个受信任代理看到的客户端；全部受信任时取最左边的地址。
+// 远端地址无法解析时原样返回 r.RemoteAddr。
+func ClientIP(r *http.Request, trusted ...netip.Prefix) string {
+	remote, err := parseAddr(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	if !isTrusted(remote, trusted) {
+		return remote.String()
+	}
+
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	client := remote
+	for i := len(hops) - 1; i >= 0; i-- {
+		addr, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			break
+		}
+		client = addr.Unmap()
+		if !isTrusted(client, trusted) {
+			break
+		}
+	}
+	return client.String()
+}
+
+// PerIP 返回按客户端 IP 选择限制器链的 chainFactory，供 Limit 使用
+//
+// 每个客户端 IP 从 registry 取得各自的链（由 registry 的模板创建，并按其容量和 TTL 回收），
+// global 中的层排在前面并被所有客户端共享，用于表达整体带宽上限。
+func PerIP(registry *ratelimited.Registry, global []ratelimited.Limiter, opts ...IPOption) func(*http.Request) []ratelimited.Limiter {
+	cfg := &ipConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return func(r *http.Request) []ratelimited.Limiter {
+		perIP := registry.Get(ClientIP(r, cfg.trusted...))
+		limiters := make([]ratelimited.Limiter, 0, len(global)+len(perIP))
+		return append(append(limiters, global...), perIP...)
+	}
+}
+
+// LimitPerIP 包装 next，使每个客户端 IP 的响应体经过各自的限制器链和共享的 global 层
+//
+// 等同于 Limit(next, PerIP(registry, global, opts...))。
+func LimitPerIP(next http.Handler, registry *ratelimited.Registry, global []ratelimited.Limiter, opts ...IPOption) http.Handler {
+	return Limit(next, PerIP(registry, global, opts...))
+}
+
+// parseAddr 解析 "host:port" 或不带端口的地址
+func parseAddr(s string) (netip.Addr, error) {
+	if host, _, err := net.SplitHostPort(s); err == nil {
+		s = host
+	}
+	addr, err := netip.ParseAddr(s)
+	return addr.Unmap(), err
+}
+
+// isTrusted 判断地址是否属于受信任的代理网段
+func isTrusted(addr netip.Addr, trusted []netip.Prefix) bool {
+	for _, p := range trusted {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
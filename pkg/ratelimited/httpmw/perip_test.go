@@ -0,0 +1,83 @@
+package httpmw
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"sync/atomic"
+	"testing"
+
+	"github.com/lwmacct/250918-go-pkg-ratelimited/pkg/ratelimited"
+)
+
+// TestClientIP 测试客户端 IP 的解析和 X-Forwarded-For 的信任规则
+func TestClientIP(t *testing.T) {
+	proxies := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}
+	tests := []struct {
+		name    string
+		remote  string
+		xff     string
+		trusted []netip.Prefix
+		want    string
+	}{
+		{"无代理", "203.0.113.7:5000", "", nil, "203.0.113.7"},
+		{"不受信任的远端忽略请求头", "203.0.113.7:5000", "198.51.100.1", proxies, "203.0.113.7"},
+		{"受信任的代理", "10.0.0.1:5000", "198.51.100.1", proxies, "198.51.100.1"},
+		{"多级代理取最右的不受信任地址", "10.0.0.1:5000", "1.1.1.1, 198.51.100.1, 10.0.0.2", proxies, "198.51.100.1"},
+		{"全部受信任取最左", "10.0.0.1:5000", "10.0.0.3, 10.0.0.2", proxies, "10.0.0.3"},
+		{"无法解析的跳停止查找", "10.0.0.1:5000", "garbage", proxies, "10.0.0.1"},
+		{"IPv6", "[2001:db8::1]:443", "", nil, "2001:db8::1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = tt.remote
+			if tt.xff != "" {
+				r.Header.Set("X-Forwarded-For", tt.xff)
+			}
+
+			// Act
+			got := ClientIP(r, tt.trusted...)
+
+			// Assert
+			if got != tt.want {
+				t.Errorf("ClientIP = %q，期望 %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestLimitPerIP 测试每个客户端 IP 使用各自的链，并共享全局层
+func TestLimitPerIP(t *testing.T) {
+	// Arrange
+	global := &countingLimiter{}
+	perIP := map[string]*countingLimiter{}
+	registry := ratelimited.NewRegistry(func(key string) []ratelimited.Limiter {
+		perIP[key] = &countingLimiter{}
+		return []ratelimited.Limiter{perIP[key]}
+	})
+	handler := LimitPerIP(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("0123456789"))
+	}), registry, []ratelimited.Limiter{global}, WithTrustedProxies(netip.MustParsePrefix("10.0.0.0/8")))
+
+	// Act
+	for _, client := range []string{"198.51.100.1", "198.51.100.2", "198.51.100.1"} {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.RemoteAddr = "10.0.0.1:5000"
+		r.Header.Set("X-Forwarded-For", client)
+		handler.ServeHTTP(httptest.NewRecorder(), r)
+	}
+
+	// Assert
+	if len(perIP) != 2 {
+		t.Fatalf("应该为两个客户端各创建一条链，实际 %d 条", len(perIP))
+	}
+	if got := atomic.LoadInt64(&perIP["198.51.100.1"].tokens); got < 20 {
+		t.Errorf("同一客户端的两次请求应该计入同一条链，实际 %d", got)
+	}
+	if got := atomic.LoadInt64(&global.tokens); got < 30 {
+		t.Errorf("全局层应该被所有客户端共享，实际 %d", got)
+	}
+}
@@ -0,0 +1,60 @@
+package httpmw
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/lwmacct/250918-go-pkg-ratelimited/pkg/ratelimited"
+)
+
+// transport 限速读取响应体的 http.RoundTripper
+type transport struct {
+	base     http.RoundTripper
+	limiters []ratelimited.Limiter
+	opts     []ratelimited.Option
+}
+
+// NewTransport 包装 base，使 http.Client 读取的响应体经过限制器链限速
+//
+// 每个响应体包装为 ratelimited.NewReader，限制器链和 opts 中的共享配额、计数器
+// 在所有响应之间共享。等待令牌默认使用请求的上下文，opts 中的 WithContext 会覆盖它。
+// 关闭响应体时归还读取器预取但未使用的令牌，因此调用方应像往常一样关闭响应体。
+// base 为 nil 时使用 http.DefaultTransport。
+func NewTransport(base http.RoundTripper, limiters []ratelimited.Limiter, opts ...ratelimited.Option) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &transport{base: base, limiters: limiters, opts: opts}
+}
+
+// RoundTrip 实现 http.RoundTripper 接口
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || resp.Body == nil {
+		return resp, err
+	}
+
+	opts := append([]ratelimited.Option{ratelimited.WithContext(req.Context())}, t.opts...)
+	resp.Body = &throttledBody{
+		reader: ratelimited.NewReader(resp.Body, t.limiters, opts...),
+		body:   resp.Body,
+	}
+	return resp, nil
+}
+
+// throttledBody 限速读取、关闭时关闭读取器和原始响应体
+type throttledBody struct {
+	reader *ratelimited.ThrottledReader
+	body   io.Closer
+}
+
+// Read 实现 io.Reader 接口
+func (b *throttledBody) Read(p []byte) (int, error) {
+	return b.reader.Read(p)
+}
+
+// Close 归还读取器未使用的令牌并关闭原始响应体
+func (b *throttledBody) Close() error {
+	return errors.Join(b.reader.Close(), b.body.Close())
+}
@@ -0,0 +1,89 @@
+package httpmw
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/lwmacct/250918-go-pkg-ratelimited/pkg/ratelimited"
+	"golang.org/x/time/rate"
+)
+
+// TestNewTransport_ThrottlesDownloads 测试客户端下载经过限制器链和共享配额
+//
+// 测试目标：
+//   - 响应体的令牌按读取的字节数申请
+//   - 共享配额在多个响应之间共享，耗尽后响应体提前结束
+func TestNewTransport_ThrottlesDownloads(t *testing.T) {
+	// Arrange
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, strings.Repeat("x", 100))
+	}))
+	defer server.Close()
+
+	limiter := &countingLimiter{}
+	quota := int64(150)
+	client := &http.Client{Transport: NewTransport(nil, []ratelimited.Limiter{limiter},
+		ratelimited.WithSharedQuota(&quota),
+	)}
+
+	// Act
+	var sizes []int
+	for range 2 {
+		resp, err := client.Get(server.URL)
+		if err != nil {
+			t.Fatalf("请求失败: %v", err)
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatalf("读取响应体失败: %v", err)
+		}
+		sizes = append(sizes, len(body))
+	}
+
+	// Assert
+	if sizes[0] != 100 || sizes[1] != 50 {
+		t.Errorf("两个响应体应该分别读到 100 和 50 字节，实际为 %v", sizes)
+	}
+	if tokens := atomic.LoadInt64(&limiter.tokens); tokens != 150 {
+		t.Errorf("令牌申请量应该等于读取的字节数 150，实际为 %d", tokens)
+	}
+}
+
+// TestNewTransport_CloseReturnsTokens 测试关闭响应体时预取的批次令牌归还给共享限制器
+func TestNewTransport_CloseReturnsTokens(t *testing.T) {
+	// Arrange
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, strings.Repeat("x", 100))
+	}))
+	defer server.Close()
+
+	const burst = 1 << 20
+	limiter := rate.NewLimiter(1, burst)
+	client := &http.Client{Transport: NewTransport(nil, []ratelimited.Limiter{limiter})}
+
+	// Act
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("请求失败: %v", err)
+	}
+	if _, err := io.ReadAll(resp.Body); err != nil {
+		t.Fatalf("读取响应体失败: %v", err)
+	}
+	prefetched := limiter.Tokens()
+	if err := resp.Body.Close(); err != nil {
+		t.Fatalf("关闭响应体失败: %v", err)
+	}
+
+	// Assert
+	if prefetched > burst-1000 {
+		t.Fatalf("读取时应该按批次预取令牌，剩余 %.0f", prefetched)
+	}
+	if tokens := limiter.Tokens(); tokens < burst-200 {
+		t.Errorf("关闭后只应该消耗读取的 100 字节，剩余令牌 %.0f", tokens)
+	}
+}
@@ -0,0 +1,71 @@
+package ratelimited
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+)
+
+// =============================================================================
+// 抖动装饰器 - 打散同时补充令牌后的同步突发
+// =============================================================================
+
+// JitterLimiter 在内部限制器的等待结束后追加随机延迟的装饰器，实现 Limiter 接口
+//
+// 大量写入器共享同一个令牌桶时，它们在令牌补充的同一时刻被唤醒并同时突发。
+// 装饰器在内部限制器发生等待时追加 [0, frac×等待时长) 的随机延迟，把唤醒时间打散；
+// 没有等待的申请不受影响，因此不会降低未受限时的吞吐量。
+type JitterLimiter struct {
+	inner Limiter
+	frac  float64
+	clock Clock
+}
+
+// JitterOption 抖动装饰器的配置选项
+type JitterOption func(*JitterLimiter)
+
+// WithJitterClock 设置测量等待和随机延迟使用的时钟，默认使用系统时钟
+func WithJitterClock(c Clock) JitterOption {
+	return func(j *JitterLimiter) {
+		if c != nil {
+			j.clock = c
+		}
+	}
+}
+
+// WithJitter 包装 l，在其等待之后追加至多 frac 倍等待时长的随机延迟
+//
+// 例如 frac 为 0.1 时，等待了 100ms 的申请额外随机等待 0~10ms。frac <= 0 时不追加延迟。
+func WithJitter(l Limiter, frac float64, opts ...JitterOption) *JitterLimiter {
+	j := &JitterLimiter{inner: l, frac: max(frac, 0), clock: systemClock{}}
+	for _, opt := range opts {
+		opt(j)
+	}
+	return j
+}
+
+// WaitN 实现 Limiter 接口，先向内部限制器申请，发生等待时再随机延迟
+//
+// 随机延迟期间 ctx 结束时返回其错误，已申请的令牌不会归还。
+func (j *JitterLimiter) WaitN(ctx context.Context, n int) error {
+	start := j.clock.Now()
+	if err := j.inner.WaitN(ctx, n); err != nil {
+		return err
+	}
+	waited := j.clock.Now().Sub(start)
+	if j.frac <= 0 || waited <= 0 {
+		return nil
+	}
+	extra := time.Duration(rand.Float64() * j.frac * float64(waited))
+	return Sleep(ctx, j.clock, extra)
+}
+
+// Unwrap 返回内部的限制器
+func (j *JitterLimiter) Unwrap() Limiter {
+	return j.inner
+}
+
+// WriteScope 返回内部限制器包装的按写入计费的层，内部限制器按字节计费时返回 nil
+func (j *JitterLimiter) WriteScope() Limiter {
+	return writeScope(j.inner)
+}
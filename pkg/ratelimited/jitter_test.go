@@ -0,0 +1,51 @@
+package ratelimited
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// sleepyWaitLimiter 每次申请固定等待一段时间的限制器
+type sleepyWaitLimiter struct {
+	d time.Duration
+}
+
+func (s sleepyWaitLimiter) WaitN(ctx context.Context, n int) error {
+	time.Sleep(s.d)
+	return nil
+}
+
+// TestJitterLimiter 测试只在内部限制器等待后追加有上限的随机延迟
+//
+// 测试目标：
+//   - 追加的延迟不超过 frac 倍的等待时长
+//   - 多次申请的总等待不全相同，即确实引入了随机性
+//   - frac 为 0 时不追加延迟
+func TestJitterLimiter(t *testing.T) {
+	// Arrange
+	jittered := WithJitter(sleepyWaitLimiter{d: 20 * time.Millisecond}, 1)
+	ctx := context.Background()
+
+	// Act
+	seen := map[time.Duration]bool{}
+	for range 5 {
+		start := time.Now()
+		assertNoError(t, jittered.WaitN(ctx, 1), "申请")
+		elapsed := time.Since(start)
+		if elapsed < 20*time.Millisecond || elapsed > 100*time.Millisecond {
+			t.Errorf("等待时长应该在 20ms 到约 40ms 之间，实际 %v", elapsed)
+		}
+		seen[elapsed.Round(time.Millisecond)] = true
+	}
+
+	// Assert
+	if len(seen) < 2 {
+		t.Error("多次申请的等待时长应该被随机打散")
+	}
+	start := time.Now()
+	assertNoError(t, WithJitter(&countingRequestLimiter{}, 0.5).WaitN(ctx, 1), "没有等待的申请")
+	if elapsed := time.Since(start); elapsed > 5*time.Millisecond {
+		t.Errorf("内部限制器没有等待时不应该追加延迟，实际 %v", elapsed)
+	}
+}
@@ -0,0 +1,216 @@
+package ratelimited
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// =============================================================================
+// 按键注册表 - 多租户场景下按需创建并回收限制器链
+// =============================================================================
+
+// Registry 把租户、用户、API Key 等字符串键映射到各自的限制器链，并发安全
+//
+// 链在第一次 Get 时由模板函数创建，之后同一个键总是得到同一条链，
+// 从而共享同一组限制器的令牌。可以限制条目数量（超出时淘汰最久未使用的键）
+// 和空闲时长（超过 TTL 未被访问的键在之后的 Get 中被回收）。
+// 被回收的键再次访问时重新创建，令牌桶从满状态开始。
+type Registry struct {
+	template   func(key string) []Limiter
+	maxEntries int
+	ttl        time.Duration
+	clock      Clock
+	onEvict    func(key string, limiters []Limiter)
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List // 最近使用的在前
+	created uint64     // 用模板创建的链数
+	evicted uint64     // 因容量或 TTL 淘汰的键数
+}
+
+// registryEntry 注册表中的一个条目
+type registryEntry struct {
+	key      string
+	limiters []Limiter
+	lastUsed time.Time
+}
+
+// RegistryOption 注册表的配置选项
+type RegistryOption func(*Registry)
+
+// WithMaxEntries 限制注册表最多保存 n 个键，超出时淘汰最久未使用的键；n <= 0 表示不限制（默认）
+func WithMaxEntries(n int) RegistryOption {
+	return func(r *Registry) {
+		r.maxEntries = n
+	}
+}
+
+// WithTTL 回收超过 d 未被访问的键；d <= 0 表示不回收（默认）
+//
+// 回收在 Get 和 Len 时顺带进行，不启动后台 goroutine。
+func WithTTL(d time.Duration) RegistryOption {
+	return func(r *Registry) {
+		r.ttl = d
+	}
+}
+
+// WithRegistryClock 设置判断空闲时长使用的时钟，默认使用系统时钟
+func WithRegistryClock(c Clock) RegistryOption {
+	return func(r *Registry) {
+		if c != nil {
+			r.clock = c
+		}
+	}
+}
+
+// WithEvictCallback 设置键因容量或 TTL 被淘汰时的回调，Delete 不触发回调
+//
+// 回调在持有注册表锁时同步调用，不能再调用注册表的方法。
+func WithEvictCallback(fn func(key string, limiters []Limiter)) RegistryOption {
+	return func(r *Registry) {
+		r.onEvict = fn
+	}
+}
+
+// NewRegistry 创建用 template 为每个新键创建限制器链的注册表
+//
+// template 在注册表锁之外调用，可能被并发调用，也可以调用注册表的方法。
+// 每次调用都应返回新的限制器，需要所有键共享的层
+// （例如全局带宽上限）可以在每条链中复用同一个限制器实例。
+func NewRegistry(template func(key string) []Limiter, opts ...RegistryOption) *Registry {
+	r := &Registry{
+		template: template,
+		clock:    systemClock{},
+		entries:  make(map[string]*list.Element),
+		lru:      list.New(),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Get 返回 key 对应的限制器链，不存在时用模板创建
+//
+// 模板在注册表锁之外调用，创建较慢的链不会阻塞其他键的 Get。
+// 多个 goroutine 同时首次访问同一个键时模板可能被调用多次，
+// 只有先完成的结果被保存并返回给所有调用方，其余结果被丢弃。
+func (r *Registry) Get(key string) []Limiter {
+	r.mu.Lock()
+	limiters, ok := r.find(key)
+	r.mu.Unlock()
+	if ok {
+		return limiters
+	}
+
+	limiters = r.template(key)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if existing, ok := r.find(key); ok {
+		// 创建期间其他调用方已经保存了该键的链
+		return existing
+	}
+	entry := &registryEntry{key: key, limiters: limiters, lastUsed: r.clock.Now()}
+	r.entries[key] = r.lru.PushFront(entry)
+	r.created++
+	if r.maxEntries > 0 {
+		for r.lru.Len() > r.maxEntries {
+			r.evict(r.lru.Back())
+		}
+	}
+	return entry.limiters
+}
+
+// find 回收过期的键后返回 key 已保存的链并刷新其使用时间，调用方需持有锁
+func (r *Registry) find(key string) ([]Limiter, bool) {
+	now := r.clock.Now()
+	r.expire(now)
+	elem, ok := r.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*registryEntry)
+	entry.lastUsed = now
+	r.lru.MoveToFront(elem)
+	return entry.limiters, true
+}
+
+// Delete 移除 key 对应的链，下次 Get 时重新创建；键不存在时返回 false
+func (r *Registry) Delete(key string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	elem, ok := r.entries[key]
+	if !ok {
+		return false
+	}
+	r.lru.Remove(elem)
+	delete(r.entries, key)
+	return true
+}
+
+// Len 返回注册表中未过期的键数量
+func (r *Registry) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.expire(r.clock.Now())
+	return r.lru.Len()
+}
+
+// RegistryStats 注册表统计的快照
+type RegistryStats struct {
+	Keys    int    // 当前未过期的键数量
+	Created uint64 // 用模板创建的链数（包括被淘汰或删除后重新创建的）
+	Evicted uint64 // 因容量或 TTL 淘汰的键数，不包括 Delete
+}
+
+// Stats 返回注册表统计的快照
+func (r *Registry) Stats() RegistryStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.expire(r.clock.Now())
+	return RegistryStats{Keys: r.lru.Len(), Created: r.created, Evicted: r.evicted}
+}
+
+// chains 返回未过期的键及其限制器链的快照
+func (r *Registry) chains() map[string][]Limiter {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.expire(r.clock.Now())
+	out := make(map[string][]Limiter, len(r.entries))
+	for key, elem := range r.entries {
+		out[key] = elem.Value.(*registryEntry).limiters
+	}
+	return out
+}
+
+// expire 从最久未使用的一端回收空闲超过 TTL 的键，调用方需持有锁
+func (r *Registry) expire(now time.Time) {
+	if r.ttl <= 0 {
+		return
+	}
+	for elem := r.lru.Back(); elem != nil; elem = r.lru.Back() {
+		if now.Sub(elem.Value.(*registryEntry).lastUsed) < r.ttl {
+			return
+		}
+		r.evict(elem)
+	}
+}
+
+// evict 淘汰一个条目并调用淘汰回调，调用方需持有锁
+func (r *Registry) evict(elem *list.Element) {
+	entry := elem.Value.(*registryEntry)
+	r.lru.Remove(elem)
+	delete(r.entries, entry.key)
+	r.evicted++
+	if r.onEvict != nil {
+		r.onEvict(entry.key, entry.limiters)
+	}
+}
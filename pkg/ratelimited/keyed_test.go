@@ -0,0 +1,139 @@
+package ratelimited
+
+import (
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// newTestTemplate 返回记录创建次数的模板函数，每个键得到一个独立的限制器
+func newTestTemplate(created *int) func(string) []Limiter {
+	return func(string) []Limiter {
+		*created++
+		return Chain(rate.NewLimiter(1000, 1000))
+	}
+}
+
+// TestRegistry_Get 测试同一个键复用同一条链、不同键得到独立的链
+func TestRegistry_Get(t *testing.T) {
+	// Arrange
+	created := 0
+	registry := NewRegistry(newTestTemplate(&created))
+
+	// Act
+	first := registry.Get("tenant-a")
+	again := registry.Get("tenant-a")
+	other := registry.Get("tenant-b")
+
+	// Assert
+	assertEqual(t, 2, created, "每个键只应该创建一次")
+	if first[0] != again[0] {
+		t.Error("同一个键应该返回同一条链")
+	}
+	if first[0] == other[0] {
+		t.Error("不同键应该得到独立的链")
+	}
+	assertEqual(t, 2, registry.Len(), "键数量")
+}
+
+// TestRegistry_MaxEntries 测试超出容量时淘汰最久未使用的键
+func TestRegistry_MaxEntries(t *testing.T) {
+	// Arrange
+	created := 0
+	var evicted []string
+	registry := NewRegistry(newTestTemplate(&created),
+		WithMaxEntries(2),
+		WithEvictCallback(func(key string, _ []Limiter) { evicted = append(evicted, key) }))
+
+	// Act: 访问 a 使 b 成为最久未使用的键
+	registry.Get("a")
+	registry.Get("b")
+	registry.Get("a")
+	registry.Get("c")
+
+	// Assert
+	assertEqual(t, 2, registry.Len(), "键数量不应超过上限")
+	if len(evicted) != 1 || evicted[0] != "b" {
+		t.Errorf("应该淘汰最久未使用的 b，实际 %v", evicted)
+	}
+	registry.Get("a")
+	assertEqual(t, 3, created, "未被淘汰的键不应重新创建")
+	registry.Get("b")
+	assertEqual(t, 4, created, "被淘汰的键再次访问时应该重新创建")
+}
+
+// TestRegistry_TTL 测试空闲超过 TTL 的键被回收
+func TestRegistry_TTL(t *testing.T) {
+	// Arrange
+	created := 0
+	clock := newFakeClock()
+	registry := NewRegistry(newTestTemplate(&created), WithTTL(time.Minute), WithRegistryClock(clock))
+	registry.Get("idle")
+	registry.Get("busy")
+
+	// Act
+	clock.Advance(40 * time.Second)
+	registry.Get("busy")
+	clock.Advance(40 * time.Second)
+
+	// Assert
+	assertEqual(t, 1, registry.Len(), "空闲超过 TTL 的键应该被回收")
+	registry.Get("busy")
+	assertEqual(t, 2, created, "仍在使用的键不应重新创建")
+	registry.Get("idle")
+	assertEqual(t, 3, created, "被回收的键再次访问时应该重新创建")
+}
+
+// TestRegistry_Delete 测试手动移除键
+func TestRegistry_Delete(t *testing.T) {
+	// Arrange
+	created := 0
+	registry := NewRegistry(newTestTemplate(&created))
+	registry.Get("a")
+
+	// Act & Assert
+	assertEqual(t, true, registry.Delete("a"), "存在的键应该被移除")
+	assertEqual(t, false, registry.Delete("a"), "不存在的键应该返回 false")
+	assertEqual(t, 0, registry.Len(), "移除后的键数量")
+}
+
+// TestRegistry_TemplateOutsideLock 测试模板在注册表锁之外调用
+//
+// 测试目标：
+//   - 创建较慢的链不阻塞其他键的 Get
+//   - 模板可以调用注册表的方法而不会死锁
+//   - 并发首次访问同一个键时所有调用方得到同一条链
+func TestRegistry_TemplateOutsideLock(t *testing.T) {
+	// Arrange
+	unblock := make(chan struct{})
+	var registry *Registry
+	registry = NewRegistry(func(key string) []Limiter {
+		if key == "slow" {
+			<-unblock
+		}
+		registry.Len()
+		return Chain(rate.NewLimiter(1000, 1000))
+	})
+
+	// Act
+	results := make(chan []Limiter, 2)
+	for range 2 {
+		go func() { results <- registry.Get("slow") }()
+	}
+	fast := make(chan []Limiter, 1)
+	go func() { fast <- registry.Get("fast") }()
+
+	// Assert
+	select {
+	case <-fast:
+	case <-time.After(time.Second):
+		t.Fatal("较慢的模板不应该阻塞其他键的 Get")
+	}
+	close(unblock)
+	first, second := <-results, <-results
+	if first[0] != second[0] {
+		t.Error("并发首次访问同一个键应该得到同一条链")
+	}
+	assertEqual(t, uint64(2), registry.Stats().Created, "只有保存的链计入创建数")
+}
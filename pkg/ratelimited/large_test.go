@@ -0,0 +1,83 @@
+package ratelimited
+
+import "testing"
+
+// TestWithLargeWriteThreshold_Routing 测试大写入与普通写入分别使用不同的链
+//
+// 测试目标：
+//   - 超过阈值的写入只在专用链上按实际大小申请令牌
+//   - 普通写入只使用普通链
+//   - 两类写入分别计数
+func TestWithLargeWriteThreshold_Routing(t *testing.T) {
+	// Arrange
+	normal := &countingLimiter{}
+	large := &countingLimiter{}
+	writer := NewDiscardWriter([]Limiter{normal},
+		WithBatchSize(100),
+		WithLargeWriteThreshold(1000),
+		WithLargeWriteLimiters(large),
+	)
+
+	// Act
+	for i := 0; i < 3; i++ {
+		_, err := writer.Write(createTestData(100))
+		assertNoError(t, err, "普通写入应该成功")
+	}
+	for i := 0; i < 2; i++ {
+		_, err := writer.Write(createTestData(5000))
+		assertNoError(t, err, "大写入应该成功")
+	}
+
+	// Assert
+	small, big := writer.WriteSizeCounts()
+	assertEqual(t, uint64(3), small, "普通写入次数")
+	assertEqual(t, uint64(2), big, "大写入次数")
+	assertEqual(t, int64(3), normal.callCount(), "普通写入只使用普通链")
+	assertEqual(t, int64(300), normal.tokenCount(), "大写入不应该消耗普通链")
+	assertEqual(t, int64(2), large.callCount(), "大写入只使用专用链")
+	assertEqual(t, int64(10000), large.tokenCount(), "专用链按实际大小申请")
+}
+
+// TestWithLargeWriteThreshold_CountOnly 测试未设置专用链时只做分类计数
+func TestWithLargeWriteThreshold_CountOnly(t *testing.T) {
+	// Arrange
+	normal := &countingLimiter{}
+	writer := NewDiscardWriter([]Limiter{normal}, WithLargeWriteThreshold(10))
+
+	// Act
+	_, _ = writer.Write(createTestData(5))
+	_, _ = writer.Write(createTestData(50))
+
+	// Assert
+	small, big := writer.WriteSizeCounts()
+	assertEqual(t, uint64(1), small, "普通写入次数")
+	assertEqual(t, uint64(1), big, "大写入次数")
+	assertEqual(t, int64(1), normal.callCount(), "大写入仍然使用普通链的批次")
+}
+
+// TestWithLargeWriteThreshold_PhaseSplitCountsOnce 测试跨阶段边界拆分的写入只计一次
+//
+// 测试目标：
+//   - 分阶段写入器在边界处拆分的写入按整次写入计数
+//   - 按整次写入的大小分类，而不是拆分后的两部分
+func TestWithLargeWriteThreshold_PhaseSplitCountsOnce(t *testing.T) {
+	// Arrange
+	readPhase := &countingLimiter{}
+	steady := &countingLimiter{}
+	writer := NewDiscardWriter(nil,
+		WithPhaseLimiters(100, []Limiter{readPhase}, []Limiter{steady}),
+		WithLargeWriteThreshold(150),
+	)
+
+	// Act
+	n, err := writer.Write(createTestData(200))
+
+	// Assert
+	assertNoError(t, err, "跨边界写入应该成功")
+	assertEqual(t, 200, n, "写入字节数")
+	small, big := writer.WriteSizeCounts()
+	assertEqual(t, uint64(0), small, "拆分后的部分不应该计为普通写入")
+	assertEqual(t, uint64(1), big, "跨边界写入只计一次大写入")
+	assertEqual(t, int64(1), readPhase.callCount(), "初始阶段链应该被使用")
+	assertEqual(t, int64(1), steady.callCount(), "稳定阶段链应该被使用")
+}
@@ -0,0 +1,66 @@
+package ratelimited
+
+import (
+	"fmt"
+	"log/slog"
+	"runtime"
+	"strings"
+)
+
+// =============================================================================
+// 泄漏诊断 - 记录创建位置并在未关闭时告警
+// =============================================================================
+
+// maxCreationFrames 创建栈最多保留的帧数
+const maxCreationFrames = 32
+
+// WithCreationStack 在创建写入器时记录调用栈，便于定位泄漏的写入器
+//
+// 栈从 NewDiscardWriter 的调用方开始，最多保留 32 帧，可通过 CreationStack 读取，
+// 并会附加在"写入器未关闭"的告警中。捕获调用栈有一定开销，因此默认关闭。
+func WithCreationStack(enabled bool) DiscardWriterOption {
+	return func(w *DiscardWriter) {
+		w.captureStack = enabled
+	}
+}
+
+// CreationStack 返回创建写入器时记录的调用栈，未启用 WithCreationStack 时返回空字符串
+func (w *DiscardWriter) CreationStack() string {
+	return w.creationStack
+}
+
+// captureCreationStack 格式化当前调用栈，跳过包括自身在内的 skip 层
+func captureCreationStack(skip int) string {
+	pcs := make([]uintptr, maxCreationFrames)
+	n := runtime.Callers(skip+1, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	var b strings.Builder
+	for {
+		frame, more := frames.Next()
+		fmt.Fprintf(&b, "%s\n\t%s:%d\n", frame.Function, frame.File, frame.Line)
+		if !more {
+			break
+		}
+	}
+	return b.String()
+}
+
+// needsClose 判断写入器是否启用了需要 Close 才能释放的功能
+func (w *DiscardWriter) needsClose() bool {
+	return w.rateUpdates != nil
+}
+
+// finalizeUnclosed 写入器被回收时仍未调用 Close：以 Warn 级别记录到 WithLogger 设置的日志并停止后台 goroutine
+//
+// 启用 WithCreationStack 时告警带有创建栈（creation_stack 属性）。
+func finalizeUnclosed(w *DiscardWriter) {
+	logger := loggerOrDefault(w.logger)
+	if w.creationStack != "" {
+		logger.Warn("ratelimited: writer garbage collected without Close",
+			slog.String("creation_stack", w.creationStack))
+	} else {
+		logger.Warn("ratelimited: writer garbage collected without Close (enable WithCreationStack to record the creation site)")
+	}
+	w.Close()
+}
@@ -0,0 +1,54 @@
+package ratelimited
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+// TestWithCreationStack 测试创建栈的记录
+//
+// 测试目标：
+//   - 启用后记录非空的调用栈，且从创建写入器的调用方开始
+//   - 未启用时不记录
+func TestWithCreationStack(t *testing.T) {
+	// Act
+	traced := NewDiscardWriter(nil, WithCreationStack(true))
+	plain := NewDiscardWriter(nil)
+
+	// Assert
+	stack := traced.CreationStack()
+	if !strings.HasPrefix(stack, "github.com/lwmacct/250918-go-pkg-ratelimited/pkg/ratelimited.TestWithCreationStack") {
+		t.Errorf("创建栈应该从调用方开始，实际为:\n%s", stack)
+	}
+	assertEqual(t, "", plain.CreationStack(), "未启用时不应该记录创建栈")
+}
+
+// TestFinalizeUnclosed 测试终结器兜底关闭未关闭的写入器
+//
+// 测试目标：
+//   - 告警以 Warn 级别记录到 WithLogger 设置的日志并带有创建栈
+//   - 写入器被关闭，后台 goroutine 退出
+func TestFinalizeUnclosed(t *testing.T) {
+	// Arrange
+	var buf bytes.Buffer
+	updates := make(chan RateUpdate)
+	writer := NewDiscardWriter(nil, WithRateControlChannel(updates), WithCreationStack(true),
+		WithLogger(slog.New(slog.NewTextHandler(&buf, nil))))
+
+	// Act
+	finalizeUnclosed(writer)
+
+	// Assert
+	select {
+	case <-writer.done:
+	default:
+		t.Fatal("终结器应该关闭写入器")
+	}
+	assertNoError(t, writer.Close(), "重复关闭应该是安全的")
+	out := buf.String()
+	if !strings.Contains(out, "level=WARN") || !strings.Contains(out, "creation_stack=") {
+		t.Errorf("告警应该以 Warn 级别记录并带有创建栈，实际:\n%s", out)
+	}
+}
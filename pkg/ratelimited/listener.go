@@ -0,0 +1,40 @@
+package ratelimited
+
+import "net"
+
+// =============================================================================
+// 网络监听 - 每个连接独立限流并共享全局限流
+// =============================================================================
+
+// throttledListener 为每个接受的连接组合全局链和独立链的监听器
+type throttledListener struct {
+	net.Listener
+	global  []Limiter
+	perConn func() []Limiter
+	opts    []Option
+}
+
+// WrapListener 包装 l，使每个接受的连接都经过全局链和该连接独立的链限流
+//
+// 每次 Accept 调用 perConn 为新连接创建独立的限制器链，叠加在所有连接共享的
+// global 链之后：单个连接受自身链约束，所有连接的总和受全局链约束。
+// 连接的读写两个方向使用同一条组合链，即读写共用带宽。perConn 为 nil 时只应用全局链。
+// opts 应用到每个连接，Accept 返回的连接是 *ThrottledConn。
+func WrapListener(l net.Listener, global []Limiter, perConn func() []Limiter, opts ...Option) net.Listener {
+	return &throttledListener{Listener: l, global: global, perConn: perConn, opts: opts}
+}
+
+// Accept 实现 net.Listener 接口
+func (l *throttledListener) Accept() (net.Conn, error) {
+	c, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+
+	chain := make([]Limiter, 0, len(l.global))
+	chain = append(chain, l.global...)
+	if l.perConn != nil {
+		chain = append(chain, l.perConn()...)
+	}
+	return WrapConn(c, chain, chain, l.opts...), nil
+}
@@ -0,0 +1,47 @@
+package ratelimited
+
+import (
+	"io"
+	"net"
+	"testing"
+)
+
+// TestWrapListener_StacksChains 测试每个连接使用独立链并共享全局链
+//
+// 测试目标：
+//   - 每次 Accept 都创建新的独立链
+//   - 所有连接的流量都经过全局链
+func TestWrapListener_StacksChains(t *testing.T) {
+	// Arrange
+	inner, err := net.Listen("tcp", "127.0.0.1:0")
+	assertNoError(t, err, "监听应该成功")
+	global := &countingLimiter{}
+	var perConn []*countingLimiter
+	listener := WrapListener(inner, []Limiter{global}, func() []Limiter {
+		l := &countingLimiter{}
+		perConn = append(perConn, l)
+		return []Limiter{l}
+	}, WithBatchSize(1))
+	defer listener.Close()
+
+	// Act: 两个客户端分别发送 10 和 20 字节
+	for _, size := range []int{10, 20} {
+		client, err := net.Dial("tcp", inner.Addr().String())
+		assertNoError(t, err, "连接应该成功")
+		_, err = client.Write(createTestData(size))
+		assertNoError(t, err, "发送应该成功")
+		client.Close()
+
+		conn, err := listener.Accept()
+		assertNoError(t, err, "接受连接应该成功")
+		_, err = io.ReadAll(conn)
+		assertNoError(t, err, "读取应该成功")
+		conn.Close()
+	}
+
+	// Assert
+	assertEqual(t, 2, len(perConn), "每个连接应该创建独立的链")
+	assertAtomicEqual(t, 10, &perConn[0].tokens, "第一个连接的独立链")
+	assertAtomicEqual(t, 20, &perConn[1].tokens, "第二个连接的独立链")
+	assertAtomicEqual(t, 30, &global.tokens, "全局链应该汇总所有连接")
+}
@@ -0,0 +1,81 @@
+package ratelimited
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// =============================================================================
+// 日志装饰器 - 调试单个限制器的令牌授予
+// =============================================================================
+
+// samplingLogLimiter 按采样间隔记录 WaitN 调用的限制器装饰器
+type samplingLogLimiter struct {
+	inner Limiter
+	name  string
+	every uint64
+	logf  func(format string, args ...any)
+	clock Clock
+
+	calls uint64 // WaitN 调用次数 (需要原子访问)
+}
+
+// LoggingOption 日志装饰器的配置选项
+type LoggingOption func(*samplingLogLimiter)
+
+// WithLoggingClock 设置测量等待时长使用的时钟，默认使用系统时钟
+func WithLoggingClock(c Clock) LoggingOption {
+	return func(l *samplingLogLimiter) {
+		if c != nil {
+			l.clock = c
+		}
+	}
+}
+
+// NewLoggingLimiter 创建按采样记录令牌授予的限制器
+//
+// 每 sampleEvery 次 WaitN 调用记录一次（从第一次开始），包括名称、申请的令牌数、
+// 实际等待时间和错误，用于排查特定限制器的问题而不被日志淹没。
+// sampleEvery <= 1 时记录每一次调用。装饰器不改变准入行为，
+// inner 返回的错误原样透传。logf 的签名与 log.Printf 兼容。
+func NewLoggingLimiter(inner Limiter, name string, sampleEvery int, logf func(format string, args ...any), opts ...LoggingOption) Limiter {
+	every := uint64(1)
+	if sampleEvery > 1 {
+		every = uint64(sampleEvery)
+	}
+	l := &samplingLogLimiter{
+		inner: inner,
+		name:  name,
+		every: every,
+		logf:  logf,
+		clock: systemClock{},
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
+}
+
+// WaitN 实现 Limiter 接口
+func (l *samplingLogLimiter) WaitN(ctx context.Context, n int) error {
+	call := atomic.AddUint64(&l.calls, 1)
+	if (call-1)%l.every != 0 {
+		return l.inner.WaitN(ctx, n)
+	}
+
+	start := l.clock.Now()
+	err := l.inner.WaitN(ctx, n)
+	waited := l.clock.Now().Sub(start)
+
+	if err != nil {
+		l.logf("ratelimited: limiter %s WaitN(n=%d) waited %s, error: %v", l.name, n, waited, err)
+	} else {
+		l.logf("ratelimited: limiter %s WaitN(n=%d) waited %s", l.name, n, waited)
+	}
+	return err
+}
+
+// WriteScope 转发内部限制器的计费方式，装饰器不改变层按写入还是按字节计费
+func (l *samplingLogLimiter) WriteScope() Limiter {
+	return writeScope(l.inner)
+}
@@ -0,0 +1,110 @@
+package ratelimited
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// logCollector 并发安全地收集日志行
+type logCollector struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+func (c *logCollector) logf(format string, args ...any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lines = append(c.lines, format)
+}
+
+// count 返回收集到的日志行数
+func (c *logCollector) count() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.lines)
+}
+
+// TestNewLoggingLimiter_Sampling 测试按采样间隔记录且不改变准入
+//
+// 测试目标：
+//   - 100 次调用、每 10 次采样时恰好记录 10 次
+//   - 内部限制器收到全部调用和令牌
+func TestNewLoggingLimiter_Sampling(t *testing.T) {
+	// Arrange
+	inner := &countingLimiter{}
+	logs := &logCollector{}
+	limiter := NewLoggingLimiter(inner, "tenant", 10, logs.logf)
+
+	// Act
+	for i := 0; i < 100; i++ {
+		assertNoError(t, limiter.WaitN(context.Background(), 5), "WaitN 应该成功")
+	}
+
+	// Assert
+	assertEqual(t, 10, logs.count(), "应该记录约 1/N 的调用")
+	assertEqual(t, int64(100), inner.callCount(), "内部限制器应该收到全部调用")
+	assertEqual(t, int64(500), inner.tokenCount(), "内部限制器应该收到全部令牌")
+}
+
+// TestNewLoggingLimiter_ErrorPassthrough 测试错误原样透传
+func TestNewLoggingLimiter_ErrorPassthrough(t *testing.T) {
+	// Arrange
+	inner := &MockFailingLimiter{shouldFail: true, failError: io.ErrUnexpectedEOF}
+	var logged []string
+	limiter := NewLoggingLimiter(inner, "failing", 1, func(format string, args ...any) {
+		logged = append(logged, format)
+	})
+
+	// Act
+	err := limiter.WaitN(context.Background(), 1)
+
+	// Assert
+	assertEqual(t, io.ErrUnexpectedEOF, err, "错误应该原样透传")
+	assertEqual(t, 1, len(logged), "采样间隔为 1 时应该记录每次调用")
+	if len(logged) == 1 && !strings.Contains(logged[0], "error") {
+		t.Errorf("失败的调用应该记录错误: %q", logged[0])
+	}
+}
+
+// TestNewLoggingLimiter_InChain 测试装饰器作为链中一层工作
+func TestNewLoggingLimiter_InChain(t *testing.T) {
+	// Arrange
+	inner := &countingLimiter{}
+	logs := &logCollector{}
+	writer := NewDiscardWriter([]Limiter{NewLoggingLimiter(inner, "layer", 2, logs.logf)},
+		WithBatchSize(10))
+
+	// Act
+	for i := 0; i < 4; i++ {
+		_, err := writer.Write(createTestData(10))
+		assertNoError(t, err, "写入应该成功")
+	}
+
+	// Assert
+	assertEqual(t, int64(4), inner.callCount(), "每次写入应该申请一次令牌")
+	assertEqual(t, 2, logs.count(), "应该记录一半的调用")
+}
+
+// TestNewLoggingLimiter_Clock 测试等待时长按注入的时钟测量
+func TestNewLoggingLimiter_Clock(t *testing.T) {
+	// Arrange
+	clock := newFakeClock()
+	var logged string
+	limiter := NewLoggingLimiter(&advancingLimiter{clock: clock, delay: 3 * time.Second}, "tenant", 1,
+		func(format string, args ...any) { logged = fmt.Sprintf(format, args...) },
+		WithLoggingClock(clock))
+
+	// Act
+	err := limiter.WaitN(context.Background(), 10)
+
+	// Assert
+	assertNoError(t, err, "申请应该成功")
+	if !strings.Contains(logged, "waited 3s") {
+		t.Errorf("应该记录虚拟时钟上的等待时长，实际 %q", logged)
+	}
+}
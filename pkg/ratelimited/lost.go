@@ -0,0 +1,47 @@
+package ratelimited
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+)
+
+// =============================================================================
+// 丢失字节统计 - 因限流超时而未被接收的源数据
+// =============================================================================
+
+// WithLostBytesCounter 设置丢失字节计数器
+//
+// 写入因上下文取消或超时失败时，p 中未被接收的字节已经从源读出，却不会再被任何人处理。
+// 这些字节同时累加到写入器自身（见 LostBytes）和 c 上。便利复制函数内部创建的写入器
+// 无法从外部访问，通过该选项传入计数器即可得到复制过程中丢失的字节数。
+func WithLostBytesCounter(c *int64) DiscardWriterOption {
+	return func(w *DiscardWriter) {
+		w.lostCounter = c
+	}
+}
+
+// LostBytes 返回因上下文取消或超时而未被接收的字节数
+//
+// 只统计 Write 返回上下文错误时 p 中的剩余字节：配额耗尽、限制器自身的错误不计入。
+// 预计等待会超过上下文截止时间而提前失败的写入返回包装了 context.DeadlineExceeded 的错误，
+// 虽然上下文尚未到期，也按超时计入。
+func (w *DiscardWriter) LostBytes() int64 {
+	return atomic.LoadInt64(&w.lostBytes)
+}
+
+// recordLost 在写入因上下文错误失败时记录未被接收的字节
+func (w *DiscardWriter) recordLost(p []byte, n int, err error) {
+	if err == nil || n >= len(p) {
+		return
+	}
+	if !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded) && w.ctx.Err() == nil {
+		return
+	}
+
+	lost := int64(len(p) - n)
+	atomic.AddInt64(&w.lostBytes, lost)
+	if w.lostCounter != nil {
+		atomic.AddInt64(w.lostCounter, lost)
+	}
+}
@@ -0,0 +1,55 @@
+package ratelimited
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// gateLimiter 放行前 allow 次调用，之后一直阻塞到上下文结束
+type gateLimiter struct {
+	allow int64
+	calls int64
+}
+
+func (l *gateLimiter) WaitN(ctx context.Context, n int) error {
+	if atomic.AddInt64(&l.calls, 1) <= l.allow {
+		return nil
+	}
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+// TestLostBytes_MidCopyTimeout 测试复制中途超时时统计丢失的字节
+//
+// 测试目标：
+//   - 超时前接收的字节不计入丢失
+//   - 超时的那次写入中已从源读出的字节计入丢失
+//   - 写入器自身和外部计数器的统计一致
+func TestLostBytes_MidCopyTimeout(t *testing.T) {
+	// Arrange: 每次读取 1000 字节，限制器只放行前 3 个批次
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	var lost int64
+	writer := NewDiscardWriter([]Limiter{&gateLimiter{allow: 3}},
+		WithContext(ctx),
+		WithBatchSize(1000),
+		WithLostBytesCounter(&lost),
+	)
+	source := &chunkedReader{r: bytes.NewReader(createTestData(10000)), size: 1000}
+
+	// Act
+	copied, err := io.Copy(writer, source)
+
+	// Assert
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("期望 context.DeadlineExceeded，实际为 %v", err)
+	}
+	assertEqual(t, int64(3000), copied, "超时前应该复制 3 个批次")
+	assertEqual(t, int64(1000), writer.LostBytes(), "超时的写入应该计入丢失")
+	assertAtomicEqual(t, 1000, &lost, "外部计数器应该与写入器一致")
+}
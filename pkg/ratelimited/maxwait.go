@@ -0,0 +1,47 @@
+package ratelimited
+
+import (
+	"fmt"
+	"time"
+)
+
+// =============================================================================
+// 最长等待 - 预计等待过久时直接拒绝而不是排队
+// =============================================================================
+
+// MaxWaitError 写入预计需要的等待超过了 WithMaxWait 设置的上限
+//
+// 可通过 errors.As 取出预计的等待时间，用于设置 Retry-After 等重试提示。
+type MaxWaitError struct {
+	Estimated time.Duration // 预计需要的等待时间，无法预估时为 0
+	MaxWait   time.Duration // 允许的最长等待
+}
+
+// Error 实现 error 接口
+func (e *MaxWaitError) Error() string {
+	if e.Estimated <= 0 {
+		return fmt.Sprintf("ratelimited: wait exceeds max wait %s", e.MaxWait)
+	}
+	return fmt.Sprintf("ratelimited: estimated wait %s exceeds max wait %s", e.Estimated, e.MaxWait)
+}
+
+// WithMaxWait 设置单次写入允许的最长等待，超过时立即返回 *MaxWaitError
+//
+// 适合宁可丢弃请求也不愿排队的延迟敏感服务。维护窗口的剩余时间和支持预留的
+// 限制器（如 *rate.Limiter）的预留延迟都会在等待前预估，超过 d 时撤销预留、
+// 回滚配额并返回带预计等待时间的错误，不消耗令牌。不支持预留的限制器无法预估，
+// 其 WaitN 最多等待 d，超时同样返回 *MaxWaitError（Estimated 为 0）。
+// 按批次大小分段申请的令牌按总延迟预估；d <= 0 表示不限制（默认）。
+func WithMaxWait(d time.Duration) DiscardWriterOption {
+	return func(w *DiscardWriter) {
+		w.maxWait = d
+	}
+}
+
+// exceedsMaxWait 判断预计等待 d 是否超过上限，超过时返回对应的错误
+func (w *DiscardWriter) exceedsMaxWait(d time.Duration) error {
+	if w.maxWait > 0 && d > w.maxWait {
+		return &MaxWaitError{Estimated: d, MaxWait: w.maxWait}
+	}
+	return nil
+}
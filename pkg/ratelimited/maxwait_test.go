@@ -0,0 +1,90 @@
+package ratelimited
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// TestWithMaxWait 测试预计等待超过上限时快速失败
+//
+// 测试目标：
+//   - 预计等待不超过上限的写入正常等待
+//   - 超过上限时立即返回 *MaxWaitError，带预计等待时间，不消耗令牌并回滚配额
+//   - 不支持预留的限制器最多等待上限
+func TestWithMaxWait(t *testing.T) {
+	t.Run("预留层快速失败", func(t *testing.T) {
+		// Arrange: 每秒补充 100 个令牌，初始 100 个
+		limiter := rate.NewLimiter(100, 100)
+		quota := int64(1000)
+		writer := NewDiscardWriter(Chain(limiter),
+			WithSharedQuota(&quota),
+			WithBatchSize(1),
+			WithMaxWait(50*time.Millisecond),
+		)
+		_, err := writer.Write(createTestData(100))
+		assertNoError(t, err, "令牌充足时应该立即写入")
+
+		// Act: 再写 50 字节约需等待 500ms
+		start := time.Now()
+		n, err := writer.Write(createTestData(50))
+		elapsed := time.Since(start)
+
+		// Assert
+		var mwErr *MaxWaitError
+		if !errors.As(err, &mwErr) {
+			t.Fatalf("应该返回 *MaxWaitError，实际 %v", err)
+		}
+		assertEqual(t, 0, n, "不应该接收任何字节")
+		assertEqual(t, 50*time.Millisecond, mwErr.MaxWait, "错误应该带有等待上限")
+		if mwErr.Estimated < 400*time.Millisecond || mwErr.Estimated > 500*time.Millisecond {
+			t.Errorf("预计等待应该约为 500ms，实际 %v", mwErr.Estimated)
+		}
+		if elapsed > 40*time.Millisecond {
+			t.Errorf("应该立即返回，实际耗时 %v", elapsed)
+		}
+		assertAtomicEqual(t, 900, &quota, "失败的写入应该回滚配额")
+		if tokens := limiter.Tokens(); tokens > 1 {
+			t.Errorf("失败的写入不应该消耗令牌，实际剩余 %.1f", tokens)
+		}
+	})
+
+	t.Run("上限内正常等待", func(t *testing.T) {
+		// Arrange
+		limiter := rate.NewLimiter(100, 100)
+		writer := NewDiscardWriter(Chain(limiter), WithBatchSize(1), WithMaxWait(time.Second))
+		_, err := writer.Write(createTestData(100))
+		assertNoError(t, err, "令牌充足时应该立即写入")
+
+		// Act: 约需等待 100ms
+		n, err := writer.Write(createTestData(10))
+
+		// Assert
+		assertNoError(t, err, "预计等待在上限内时应该正常写入")
+		assertEqual(t, 10, n, "应该接收全部字节")
+	})
+
+	t.Run("不支持预留的层", func(t *testing.T) {
+		// Arrange: 写入 10 字节需要 1s
+		writer := NewDiscardWriter([]Limiter{&sleepyLimiter{perToken: 100 * time.Millisecond}},
+			WithMaxWait(30*time.Millisecond),
+		)
+
+		// Act
+		start := time.Now()
+		_, err := writer.Write(createTestData(10))
+		elapsed := time.Since(start)
+
+		// Assert
+		var mwErr *MaxWaitError
+		if !errors.As(err, &mwErr) {
+			t.Fatalf("应该返回 *MaxWaitError，实际 %v", err)
+		}
+		assertEqual(t, time.Duration(0), mwErr.Estimated, "无法预估时 Estimated 应该为 0")
+		if elapsed > 500*time.Millisecond {
+			t.Errorf("应该在上限附近返回，实际耗时 %v", elapsed)
+		}
+	})
+}
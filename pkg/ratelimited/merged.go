@@ -0,0 +1,102 @@
+package ratelimited
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// =============================================================================
+// 合并限制器 - 多个 rate.Limiter 只等待一次
+// =============================================================================
+
+// mergedLimiter 同时在所有成员上预留令牌，只按最长的延迟等待一次
+type mergedLimiter struct {
+	limiters []*rate.Limiter
+	clock    Clock
+}
+
+// MergedOption 合并限制器的配置选项
+type MergedOption func(*mergedLimiter)
+
+// WithMergedClock 设置预留和等待使用的时钟，默认使用系统时钟
+func WithMergedClock(c Clock) MergedOption {
+	return func(m *mergedLimiter) {
+		if c != nil {
+			m.clock = c
+		}
+	}
+}
+
+// NewMergedRateLimiter 将多个 *rate.Limiter 合并为一个 Limiter
+//
+// 每次 WaitN 在同一时刻向所有成员 ReserveN，取其中最长的延迟只等待一次，
+// 放行条件与按顺序调用各成员 WaitN 的链相同（所有成员都放行），
+// 但省去了逐层的 WaitN 调用和多次定时器等待，适合层级较深的同构链。
+//
+// 任一成员无法满足 n（超过突发容量）、等待会超过上下文的 deadline，
+// 或等待期间上下文被取消时，所有成员上的预留都会被取消并返回错误。
+// 与 Chain 不同，合并限制器不会跳过失败的成员：它只接受 *rate.Limiter，
+// 不存在非上下文相关的成员错误。nil 成员会被忽略。
+func NewMergedRateLimiter(limiters []*rate.Limiter, opts ...MergedOption) Limiter {
+	members := make([]*rate.Limiter, 0, len(limiters))
+	for _, l := range limiters {
+		if l != nil {
+			members = append(members, l)
+		}
+	}
+	m := &mergedLimiter{limiters: members, clock: systemClock{}}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// WaitN 实现 Limiter 接口
+func (m *mergedLimiter) WaitN(ctx context.Context, n int) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	now := m.clock.Now()
+	reservations := make([]*rate.Reservation, 0, len(m.limiters))
+	cancelAll := func() {
+		for i := len(reservations) - 1; i >= 0; i-- {
+			reservations[i].CancelAt(now)
+		}
+	}
+
+	var delay time.Duration
+	for i, l := range m.limiters {
+		r := l.ReserveN(now, n)
+		if !r.OK() {
+			cancelAll()
+			return fmt.Errorf("ratelimited: merged limiter member %d: WaitN(n=%d) exceeds burst %d", i, n, l.Burst())
+		}
+		reservations = append(reservations, r)
+		if d := r.DelayFrom(now); d > delay {
+			delay = d
+		}
+	}
+
+	if delay == 0 {
+		return nil
+	}
+
+	if deadline, ok := ctx.Deadline(); ok && now.Add(delay).After(deadline) {
+		cancelAll()
+		return fmt.Errorf("ratelimited: merged limiter: WaitN(n=%d) would exceed context deadline: %w", n, context.DeadlineExceeded)
+	}
+
+	select {
+	case <-m.clock.After(delay):
+		return nil
+	case <-ctx.Done():
+		cancelAll()
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,88 @@
+package ratelimited
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// sleepCountingClock 统计 After 调用次数与累计等待时长的自动推进时钟
+type sleepCountingClock struct {
+	*sleepingClock
+	sleeps int
+	slept  time.Duration
+}
+
+func (c *sleepCountingClock) After(d time.Duration) <-chan time.Time {
+	c.sleeps++
+	c.slept += d
+	return c.sleepingClock.After(d)
+}
+
+// newMergedTestLimiters 创建速率不同的三层限制器，最慢的一层为 50 令牌/秒
+func newMergedTestLimiters() []*rate.Limiter {
+	return []*rate.Limiter{
+		rate.NewLimiter(200, 10),
+		rate.NewLimiter(100, 10),
+		rate.NewLimiter(50, 10),
+	}
+}
+
+// TestNewMergedRateLimiter_SingleSleep 测试合并限制器与顺序链速率相同但等待更少
+//
+// 测试目标：
+//   - 长期速率与逐层 WaitN 的链一致（由最慢的成员决定）
+//   - 每次调用最多等待一次，等待次数少于逐层等待
+func TestNewMergedRateLimiter_SingleSleep(t *testing.T) {
+	const calls = 20
+
+	// Arrange: 顺序链在虚拟时间中复现 rate.Limiter.WaitN 的逐层等待
+	chainClock := &sleepCountingClock{sleepingClock: newSleepingClock()}
+	chain := newMergedTestLimiters()
+	chainStart := chainClock.Now()
+
+	mergedClock := &sleepCountingClock{sleepingClock: newSleepingClock()}
+	merged := NewMergedRateLimiter(newMergedTestLimiters(), WithMergedClock(mergedClock))
+	mergedStart := mergedClock.Now()
+
+	// Act
+	for i := 0; i < calls; i++ {
+		for _, l := range chain {
+			now := chainClock.Now()
+			if d := l.ReserveN(now, 10).DelayFrom(now); d > 0 {
+				<-chainClock.After(d)
+			}
+		}
+		assertNoError(t, merged.WaitN(context.Background(), 10), "合并限制器应该放行")
+	}
+
+	// Assert
+	chainElapsed := chainClock.Now().Sub(chainStart)
+	mergedElapsed := mergedClock.Now().Sub(mergedStart)
+	assertEqual(t, chainElapsed, mergedElapsed, "长期速率应该与顺序链一致")
+	assertEqual(t, (calls-1)*200*time.Millisecond, mergedElapsed, "速率应该由最慢的成员决定")
+	if mergedClock.sleeps > calls || mergedClock.sleeps >= chainClock.sleeps {
+		t.Errorf("合并限制器应该等待更少次数: merged=%d chain=%d", mergedClock.sleeps, chainClock.sleeps)
+	}
+}
+
+// TestNewMergedRateLimiter_CancelReleasesAll 测试失败时取消所有成员的预留
+func TestNewMergedRateLimiter_CancelReleasesAll(t *testing.T) {
+	// Arrange: 第二个成员的突发容量不足
+	roomy := rate.NewLimiter(1, 100)
+	tight := rate.NewLimiter(1, 5)
+	limiter := NewMergedRateLimiter([]*rate.Limiter{roomy, tight})
+
+	// Act
+	err := limiter.WaitN(context.Background(), 10)
+
+	// Assert
+	if err == nil {
+		t.Fatal("超过突发容量应该返回错误")
+	}
+	if tokens := roomy.Tokens(); tokens < 99 {
+		t.Errorf("其他成员的预留应该被取消，剩余 %.1f", tokens)
+	}
+}
@@ -0,0 +1,177 @@
+package ratelimited
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// =============================================================================
+// 指标装饰器 - 在不修改限制器的前提下观测每一层
+// =============================================================================
+
+// LayerMetricsSink 单层限制器申请统计的接收端
+//
+// 每次 WaitN 返回后，指标装饰器同步调用 RecordLayerWait。实现必须是并发安全的，且应尽量廉价。
+type LayerMetricsSink interface {
+	// RecordLayerWait 记录一次申请：layer 为层级名称，n 为申请的令牌数，
+	// waited 为本次申请耗费的时间，err 为内部限制器返回的错误（非 nil 表示被拒绝）
+	RecordLayerWait(layer string, n int, waited time.Duration, err error)
+}
+
+// LayerMetricsSinkFunc 将普通函数适配为 LayerMetricsSink
+type LayerMetricsSinkFunc func(layer string, n int, waited time.Duration, err error)
+
+// RecordLayerWait 实现 LayerMetricsSink 接口
+func (f LayerMetricsSinkFunc) RecordLayerWait(layer string, n int, waited time.Duration, err error) {
+	f(layer, n, waited, err)
+}
+
+// MetricsLimiter 把每次申请记录到 LayerMetricsSink 的限制器装饰器，实现 Limiter 接口
+//
+// 装饰器不改变准入行为，内部限制器的错误原样透传。
+type MetricsLimiter struct {
+	inner Limiter
+	name  string
+	sink  LayerMetricsSink
+	clock Clock
+}
+
+// MetricsOption 指标装饰器的配置选项
+type MetricsOption func(*MetricsLimiter)
+
+// WithMetricsClock 设置测量等待时长使用的时钟，默认使用系统时钟
+func WithMetricsClock(c Clock) MetricsOption {
+	return func(m *MetricsLimiter) {
+		if c != nil {
+			m.clock = c
+		}
+	}
+}
+
+// NewMetricsLimiter 创建把名为 name 的层级 inner 的申请记录到 sink 的装饰器
+func NewMetricsLimiter(name string, inner Limiter, sink LayerMetricsSink, opts ...MetricsOption) *MetricsLimiter {
+	m := &MetricsLimiter{inner: inner, name: name, sink: sink, clock: systemClock{}}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// InstrumentChain 用指标装饰器包装链中的每一层，返回新的限制器链
+//
+// names 为各层名称，缺失或为空时使用 "limiter[i]"，与写入器的层级命名一致。
+//
+// 使用示例：
+//
+//	metrics := ratelimited.NewLayerMetrics()
+//	chain := ratelimited.InstrumentChain(ratelimited.Chain(perConn, global),
+//	    []string{"conn", "global"}, metrics)
+func InstrumentChain(limiters []Limiter, names []string, sink LayerMetricsSink, opts ...MetricsOption) []Limiter {
+	out := make([]Limiter, len(limiters))
+	for i, limiter := range limiters {
+		out[i] = NewMetricsLimiter(limiterName(names, i, false), limiter, sink, opts...)
+	}
+	return out
+}
+
+// WaitN 实现 Limiter 接口
+func (m *MetricsLimiter) WaitN(ctx context.Context, n int) error {
+	start := m.clock.Now()
+	err := m.inner.WaitN(ctx, n)
+	m.sink.RecordLayerWait(m.name, n, m.clock.Now().Sub(start), err)
+	return err
+}
+
+// Unwrap 返回内部的限制器
+func (m *MetricsLimiter) Unwrap() Limiter {
+	return m.inner
+}
+
+// WriteScope 转发内部限制器的计费方式，InstrumentChain 包装的 PerRequest 层和并发限制器仍按写入计费
+func (m *MetricsLimiter) WriteScope() Limiter {
+	return writeScope(m.inner)
+}
+
+// =============================================================================
+// 内存指标 - 开箱即用的 LayerMetricsSink
+// =============================================================================
+
+// LayerStats 单层限制器的累计统计
+type LayerStats struct {
+	Calls  int64         // WaitN 调用次数
+	Tokens int64         // 成功授予的令牌数
+	Waited time.Duration // 累计耗费时间（包括被拒绝的申请）
+	Denied int64         // 返回错误的调用次数
+}
+
+// String 返回便于日志输出的统计摘要
+func (s LayerStats) String() string {
+	return fmt.Sprintf("calls=%d tokens=%d waited=%s denied=%d", s.Calls, s.Tokens, s.Waited, s.Denied)
+}
+
+// LayerMetrics 按层级名称在内存中累计统计的 LayerMetricsSink
+type LayerMetrics struct {
+	mu     sync.Mutex
+	layers map[string]*LayerStats
+}
+
+// NewLayerMetrics 创建空的内存指标
+func NewLayerMetrics() *LayerMetrics {
+	return &LayerMetrics{layers: make(map[string]*LayerStats)}
+}
+
+// RecordLayerWait 实现 LayerMetricsSink 接口
+func (m *LayerMetrics) RecordLayerWait(layer string, n int, waited time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stats, ok := m.layers[layer]
+	if !ok {
+		stats = &LayerStats{}
+		m.layers[layer] = stats
+	}
+	stats.Calls++
+	stats.Waited += waited
+	if err != nil {
+		stats.Denied++
+		return
+	}
+	stats.Tokens += int64(n)
+}
+
+// Layer 返回指定层级的统计快照，未记录过的层级返回零值
+func (m *LayerMetrics) Layer(layer string) LayerStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if stats, ok := m.layers[layer]; ok {
+		return *stats
+	}
+	return LayerStats{}
+}
+
+// Snapshot 返回所有层级统计的快照
+func (m *LayerMetrics) Snapshot() map[string]LayerStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	out := make(map[string]LayerStats, len(m.layers))
+	for name, stats := range m.layers {
+		out[name] = *stats
+	}
+	return out
+}
+
+// Reset 清空所有统计
+func (m *LayerMetrics) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.layers = make(map[string]*LayerStats)
+}
+
+var (
+	_ Limiter          = (*MetricsLimiter)(nil)
+	_ LayerMetricsSink = (*LayerMetrics)(nil)
+)
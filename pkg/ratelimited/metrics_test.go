@@ -0,0 +1,67 @@
+package ratelimited
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"golang.org/x/time/rate"
+)
+
+// TestInstrumentChain 测试指标装饰器按层记录申请、令牌和拒绝
+//
+// 测试目标：
+//   - 每一层的调用次数和授予的令牌数被分别记录
+//   - 内部限制器返回错误时计入拒绝次数且错误原样透传
+//   - 未命名的层使用 "limiter[i]" 名称
+func TestInstrumentChain(t *testing.T) {
+	// Arrange
+	metrics := NewLayerMetrics()
+	boom := errors.New("boom")
+	chain := InstrumentChain(
+		[]Limiter{rate.NewLimiter(rate.Inf, 0), failingWaitLimiter{err: boom}},
+		[]string{"global"},
+		metrics,
+	)
+	ctx := context.Background()
+
+	// Act
+	assertNoError(t, chain[0].WaitN(ctx, 100), "第一层申请")
+	assertNoError(t, chain[0].WaitN(ctx, 50), "第一层申请")
+	err := chain[1].WaitN(ctx, 10)
+
+	// Assert
+	if !errors.Is(err, boom) {
+		t.Errorf("错误应该原样透传，实际 %v", err)
+	}
+	global := metrics.Layer("global")
+	assertEqual(t, int64(2), global.Calls, "第一层调用次数")
+	assertEqual(t, int64(150), global.Tokens, "第一层授予的令牌数")
+	assertEqual(t, int64(0), global.Denied, "第一层拒绝次数")
+	second := metrics.Layer("limiter[1]")
+	assertEqual(t, int64(1), second.Calls, "第二层调用次数")
+	assertEqual(t, int64(0), second.Tokens, "被拒绝的申请不计入令牌数")
+	assertEqual(t, int64(1), second.Denied, "第二层拒绝次数")
+	assertEqual(t, 2, len(metrics.Snapshot()), "快照应该包含两层")
+
+	metrics.Reset()
+	assertEqual(t, 0, len(metrics.Snapshot()), "重置后快照应该为空")
+}
+
+// TestMetricsLimiter_WithWriter 测试装饰后的链仍可用于写入器
+func TestMetricsLimiter_WithWriter(t *testing.T) {
+	// Arrange
+	metrics := NewLayerMetrics()
+	chain := InstrumentChain(Chain(rate.NewLimiter(rate.Inf, 0)), []string{"conn"}, metrics)
+	w := NewDiscardWriter(chain)
+
+	// Act
+	_, err := w.Write(createTestData(4096))
+
+	// Assert
+	assertNoError(t, err, "写入")
+	stats := metrics.Layer("conn")
+	if stats.Calls == 0 || stats.Tokens < 4096 {
+		t.Errorf("写入应该经过指标装饰器，实际 %s", stats)
+	}
+}
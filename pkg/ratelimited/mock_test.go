@@ -0,0 +1,120 @@
+package ratelimited_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/lwmacct/250918-go-pkg-ratelimited/pkg/ratelimited"
+	"github.com/lwmacct/250918-go-pkg-ratelimited/pkg/ratelimited/ratelimitedtest"
+)
+
+// TestMockLimiter_ScriptedWithFakeClock 测试模拟限制器按脚本在虚拟时间中延迟和失败
+//
+// 测试目标：
+//   - 脚本中的延迟在虚拟时间推进前不会结束
+//   - 脚本中的错误原样返回给写入器
+//   - 调用记录包含令牌数、虚拟时间和错误
+func TestMockLimiter_ScriptedWithFakeClock(t *testing.T) {
+	// Arrange
+	clock := ratelimitedtest.NewFakeClock()
+	boom := errors.New("boom")
+	mock := ratelimitedtest.NewMockLimiter(
+		ratelimitedtest.WithMockClock(clock),
+		ratelimitedtest.WithMockScript(
+			ratelimitedtest.MockStep{Delay: time.Second},
+			ratelimitedtest.MockStep{Err: boom},
+		),
+	)
+	var written int64
+	w := ratelimited.NewDiscardWriter([]ratelimited.Limiter{mock},
+		ratelimited.WithBatchSize(100),
+		ratelimited.WithBytesCounter(&written),
+	)
+	start := clock.Now()
+	done := make(chan error, 1)
+
+	// Act: 第一次写入在虚拟时间推进前阻塞
+	go func() {
+		_, err := w.Write(make([]byte, 100))
+		done <- err
+	}()
+	clock.BlockUntilWaiters(t, 1)
+	ratelimitedtest.AssertCounter(t, &written, 0, "延迟结束前不应该准入")
+	clock.Advance(time.Second)
+	firstErr := <-done
+	_, secondErr := w.Write(make([]byte, 100))
+
+	// Assert
+	if firstErr != nil {
+		t.Fatalf("第一次写入应该成功，实际 %v", firstErr)
+	}
+	if !errors.Is(secondErr, boom) {
+		t.Errorf("第二次写入应该返回脚本中的错误，实际 %v", secondErr)
+	}
+	ratelimitedtest.AssertCounter(t, &written, 100, "只有第一次写入被准入")
+	calls := mock.Calls()
+	if len(calls) != 2 {
+		t.Fatalf("应该记录 2 次调用，实际 %d 次", len(calls))
+	}
+	if calls[0].N != 100 || !calls[0].At.Equal(start) || calls[0].Err != nil {
+		t.Errorf("第一次调用记录不正确: %+v", calls[0])
+	}
+	if calls[1].N != 100 || !errors.Is(calls[1].Err, boom) {
+		t.Errorf("第二次调用记录不正确: %+v", calls[1])
+	}
+	if got := mock.Granted(); got != 100 {
+		t.Errorf("授予的令牌数应该为 100，实际 %d", got)
+	}
+}
+
+// TestMockLimiter_DefaultsAndContext 测试脚本之外的默认行为和上下文取消
+func TestMockLimiter_DefaultsAndContext(t *testing.T) {
+	// Arrange
+	clock := ratelimitedtest.NewFakeClock()
+	mock := ratelimitedtest.NewMockLimiter(
+		ratelimitedtest.WithMockClock(clock),
+		ratelimitedtest.WithMockDelay(time.Hour),
+	)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// Act
+	err := mock.WaitN(ctx, 10)
+	mock.ReturnN(3)
+
+	// Assert
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("延迟期间上下文结束时应该返回其错误，实际 %v", err)
+	}
+	if mock.CallCount() != 1 || mock.Returned() != 3 {
+		t.Errorf("调用次数和归还数不正确: calls=%d returned=%d", mock.CallCount(), mock.Returned())
+	}
+	mock.Reset()
+	if mock.CallCount() != 0 || mock.Returned() != 0 {
+		t.Error("重置后记录应该为空")
+	}
+}
+
+// TestAssertHelpers 测试计数器与配额断言在写入后通过
+func TestAssertHelpers(t *testing.T) {
+	// Arrange
+	quota := ratelimited.NewQuotaManager(1000)
+	var requests uint64
+	w := ratelimited.NewDiscardWriter([]ratelimited.Limiter{ratelimitedtest.NewMockLimiter()},
+		ratelimited.WithQuota(quota),
+		ratelimited.WithRequestCounter(&requests),
+	)
+
+	// Act
+	_, err := w.Write(make([]byte, 300))
+
+	// Assert
+	if err != nil {
+		t.Fatalf("写入应该成功，实际 %v", err)
+	}
+	ratelimitedtest.AssertQuotaRemaining(t, quota, 700, "写入后的剩余配额")
+	ratelimitedtest.AssertCounter(t, &requests, 1, "请求计数")
+	ratelimitedtest.AssertCounterBetween(t, &requests, 1, 2, "请求计数范围")
+}
@@ -0,0 +1,204 @@
+// Package otel 将 ratelimited 写入器和注册表的统计导出为 OpenTelemetry 指标，
+// 并把限流等待记录到分布式追踪中
+//
+// 使用示例：
+//
+//	limiters, names := ratelimited.NewBuilder().
+//	    Add("global", globalLimiter).
+//	    Add("tenant", tenantLimiter).
+//	    BuildWithNames()
+//	w := ratelimited.NewDiscardWriter(limiters,
+//	    otel.WithMeterProvider(mp),
+//	    otel.WithTracing(100*time.Millisecond),
+//	    ratelimited.WithLimiterNames(names),
+//	)
+//
+//	registry := ratelimited.NewRegistry(template,
+//	    otel.WithRegistryMeterProvider(mp, "per-ip"))
+package otel
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/lwmacct/250918-go-pkg-ratelimited/pkg/ratelimited"
+	global "go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// ScopeName 创建 Meter 使用的仪表库名称
+const ScopeName = "github.com/lwmacct/250918-go-pkg-ratelimited/pkg/ratelimited/otel"
+
+// 指标属性的键
+const (
+	LayerKey    = attribute.Key("ratelimited.layer")    // 限制器层级名称
+	QuotaKey    = attribute.Key("ratelimited.quota")    // 登记的配额名称
+	RegistryKey = attribute.Key("ratelimited.registry") // 登记的注册表名称
+)
+
+// Instruments 一组汇总写入器统计的 OpenTelemetry 仪表
+//
+// 通过 Options 返回的选项接入写入器，可以被多个写入器共享，指标是所有写入器的总和。
+// 注册的仪表：
+//   - ratelimited.bytes：写入的字节数
+//   - ratelimited.requests：写入次数
+//   - ratelimited.wait.duration：每次写入等待令牌的时长分布
+//   - ratelimited.layer.wait.duration{ratelimited.layer}：各层限制器单次等待的时长分布，只记录发生了等待的申请
+//   - ratelimited.quota.remaining{ratelimited.quota}：通过 TrackQuota 登记的配额的剩余字节数
+//   - ratelimited.registry.keys{ratelimited.registry}：通过 TrackRegistry 登记的注册表的键数量
+type Instruments struct {
+	bytes     metric.Int64Counter
+	requests  metric.Int64Counter
+	wait      metric.Float64Histogram
+	layerWait metric.Float64Histogram
+
+	mu         sync.Mutex
+	quotas     map[string]ratelimited.Quota
+	registries map[string]*ratelimited.Registry
+}
+
+// NewInstruments 在 mp 提供的 Meter 上注册仪表
+//
+// 仪表注册失败时返回错误，此时返回的 Instruments 仍然可用，失败的仪表不记录数据。
+func NewInstruments(mp metric.MeterProvider) (*Instruments, error) {
+	meter := mp.Meter(ScopeName)
+	i := &Instruments{
+		quotas:     make(map[string]ratelimited.Quota),
+		registries: make(map[string]*ratelimited.Registry),
+	}
+
+	var err, e error
+	keep := func(e error) {
+		if err == nil {
+			err = e
+		}
+	}
+	i.bytes, e = meter.Int64Counter("ratelimited.bytes",
+		metric.WithDescription("Bytes admitted by rate-limited writers."), metric.WithUnit("By"))
+	keep(e)
+	i.requests, e = meter.Int64Counter("ratelimited.requests",
+		metric.WithDescription("Writes admitted by rate-limited writers."), metric.WithUnit("{request}"))
+	keep(e)
+	i.wait, e = meter.Float64Histogram("ratelimited.wait.duration",
+		metric.WithDescription("Time each write spent waiting for tokens."), metric.WithUnit("s"))
+	keep(e)
+	i.layerWait, e = meter.Float64Histogram("ratelimited.layer.wait.duration",
+		metric.WithDescription("Time spent waiting on each limiter layer."), metric.WithUnit("s"))
+	keep(e)
+	quotaGauge, e := meter.Int64ObservableGauge("ratelimited.quota.remaining",
+		metric.WithDescription("Remaining bytes of a tracked quota."), metric.WithUnit("By"))
+	keep(e)
+	registryGauge, e := meter.Int64ObservableGauge("ratelimited.registry.keys",
+		metric.WithDescription("Keys held by a tracked limiter registry."), metric.WithUnit("{key}"))
+	keep(e)
+	_, e = meter.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+		i.mu.Lock()
+		defer i.mu.Unlock()
+		for name, q := range i.quotas {
+			o.ObserveInt64(quotaGauge, q.Remaining(), metric.WithAttributes(QuotaKey.String(name)))
+		}
+		for name, r := range i.registries {
+			o.ObserveInt64(registryGauge, int64(r.Len()), metric.WithAttributes(RegistryKey.String(name)))
+		}
+		return nil
+	}, quotaGauge, registryGauge)
+	keep(e)
+	return i, err
+}
+
+// Options 返回把写入器接入仪表的选项
+//
+// 选项注册一个统计接收端和一个单层等待观察者，两者都是追加的，
+// 不影响写入器上已有的接收端、观察者和单层等待告警，可以与 prom 包同时使用。
+func (i *Instruments) Options() []ratelimited.Option {
+	return []ratelimited.Option{
+		ratelimited.WithStatsSinks(i),
+		ratelimited.WithLayerWaitObservers(i),
+	}
+}
+
+// TrackQuota 登记一个配额，以 name 为属性导出其剩余字节数
+func (i *Instruments) TrackQuota(name string, q ratelimited.Quota) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.quotas[name] = q
+}
+
+// TrackRegistry 登记一个注册表，以 name 为属性导出其键数量
+func (i *Instruments) TrackRegistry(name string, r *ratelimited.Registry) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.registries[name] = r
+}
+
+// RecordWrite 实现 ratelimited.StatsSink 接口
+func (i *Instruments) RecordWrite(n int, waited time.Duration) {
+	ctx := context.Background()
+	i.bytes.Add(ctx, int64(n))
+	i.requests.Add(ctx, 1)
+	i.wait.Record(ctx, waited.Seconds())
+}
+
+// ObserveLayerWait 实现 ratelimited.LayerWaitObserver 接口，在写入的上下文中记录单层限制器的一次等待
+func (i *Instruments) ObserveLayerWait(ctx context.Context, name string, waited time.Duration) {
+	i.layerWait.Record(ctx, waited.Seconds(), metric.WithAttributes(LayerKey.String(name)))
+}
+
+// =============================================================================
+// 便利选项 - 按 MeterProvider 共享仪表
+// =============================================================================
+
+var (
+	instrumentsMu sync.Mutex
+	instruments   = make(map[metric.MeterProvider]*Instruments)
+)
+
+// instrumentsFor 返回 mp 对应的共享仪表，首次调用时注册
+//
+// 注册失败的错误交给 OpenTelemetry 的全局错误处理器。
+func instrumentsFor(mp metric.MeterProvider) *Instruments {
+	instrumentsMu.Lock()
+	defer instrumentsMu.Unlock()
+
+	if i, ok := instruments[mp]; ok {
+		return i
+	}
+	i, err := NewInstruments(mp)
+	if err != nil {
+		global.Handle(err)
+	}
+	instruments[mp] = i
+	return i
+}
+
+// WithMeterProvider 把写入器接入 mp 上的共享仪表
+//
+// 同一个 mp 的所有写入器共享同一组仪表，与直接使用 NewInstruments(mp).Options() 等价。
+// mp 为 nil 时使用全局 MeterProvider。
+func WithMeterProvider(mp metric.MeterProvider) ratelimited.Option {
+	if mp == nil {
+		mp = global.GetMeterProvider()
+	}
+	opts := instrumentsFor(mp).Options()
+	return func(w *ratelimited.DiscardWriter) {
+		for _, opt := range opts {
+			opt(w)
+		}
+	}
+}
+
+// WithRegistryMeterProvider 以 name 为属性在 mp 上导出注册表的键数量
+//
+// mp 为 nil 时使用全局 MeterProvider。注册表在整个进程生命周期内保持登记，
+// 适用于长期存在的注册表。
+func WithRegistryMeterProvider(mp metric.MeterProvider, name string) ratelimited.RegistryOption {
+	if mp == nil {
+		mp = global.GetMeterProvider()
+	}
+	i := instrumentsFor(mp)
+	return func(r *ratelimited.Registry) {
+		i.TrackRegistry(name, r)
+	}
+}
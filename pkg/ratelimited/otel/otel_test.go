@@ -0,0 +1,123 @@
+package otel
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lwmacct/250918-go-pkg-ratelimited/pkg/ratelimited"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"golang.org/x/time/rate"
+)
+
+// collect 从 reader 收集一次指标，按仪表名称索引
+func collect(t *testing.T, reader sdkmetric.Reader) map[string]metricdata.Aggregation {
+	t.Helper()
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatalf("收集指标失败: %v", err)
+	}
+	out := make(map[string]metricdata.Aggregation)
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			out[m.Name] = m.Data
+		}
+	}
+	return out
+}
+
+// TestWithMeterProvider_ReportsWriterStats 测试写入统计、分层等待、配额和注册表被导出
+//
+// 测试目标：
+//   - 字节数与写入次数汇总所有接入的写入器
+//   - 分层等待带有层级名称属性
+//   - 登记的配额和注册表导出当前值
+func TestWithMeterProvider_ReportsWriterStats(t *testing.T) {
+	// Arrange
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	quota := ratelimited.NewQuotaManager(1000)
+	instrumentsFor(mp).TrackQuota("daily", quota)
+	registry := ratelimited.NewRegistry(func(string) []ratelimited.Limiter {
+		return []ratelimited.Limiter{rate.NewLimiter(rate.Inf, 0)}
+	}, WithRegistryMeterProvider(mp, "per-ip"))
+	registry.Get("10.0.0.1")
+	registry.Get("10.0.0.2")
+
+	// 突发容量很小，每个批次都需要等待
+	limiters, names := ratelimited.NewBuilder().
+		Add("global", rate.NewLimiter(10000, 10)).
+		BuildWithNames()
+	opts := []ratelimited.Option{
+		WithMeterProvider(mp),
+		ratelimited.WithLimiterNames(names),
+		ratelimited.WithBatchSize(10),
+		ratelimited.WithQuota(quota),
+	}
+	first := ratelimited.NewDiscardWriter(limiters, opts...)
+	second := ratelimited.NewDiscardWriter(limiters, opts...)
+
+	// Act
+	_, _ = first.Write(make([]byte, 100))
+	_, _ = second.Write(make([]byte, 50))
+
+	// Assert
+	data := collect(t, reader)
+	if sum := data["ratelimited.bytes"].(metricdata.Sum[int64]); sum.DataPoints[0].Value != 150 {
+		t.Errorf("字节数应该为 150，实际 %d", sum.DataPoints[0].Value)
+	}
+	if sum := data["ratelimited.requests"].(metricdata.Sum[int64]); sum.DataPoints[0].Value != 2 {
+		t.Errorf("写入次数应该为 2，实际 %d", sum.DataPoints[0].Value)
+	}
+	if hist := data["ratelimited.wait.duration"].(metricdata.Histogram[float64]); hist.DataPoints[0].Count != 2 {
+		t.Errorf("等待分布应该包含 2 次写入，实际 %d", hist.DataPoints[0].Count)
+	}
+	quotaPoint := data["ratelimited.quota.remaining"].(metricdata.Gauge[int64]).DataPoints[0]
+	if v, _ := quotaPoint.Attributes.Value(QuotaKey); v.AsString() != "daily" || quotaPoint.Value != 850 {
+		t.Errorf("配额指标不正确: %v = %d", v.AsString(), quotaPoint.Value)
+	}
+	registryPoint := data["ratelimited.registry.keys"].(metricdata.Gauge[int64]).DataPoints[0]
+	if v, _ := registryPoint.Attributes.Value(RegistryKey); v.AsString() != "per-ip" || registryPoint.Value != 2 {
+		t.Errorf("注册表指标不正确: %v = %d", v.AsString(), registryPoint.Value)
+	}
+}
+
+// TestInstruments_LayerWait 测试分层等待在写入返回前以层级名称为属性记录
+//
+// 测试目标：
+//   - 观察是同步的，写入返回时分层等待已经记录
+//   - 与写入器上已有的等待观察者共存，两者都收到等待
+func TestInstruments_LayerWait(t *testing.T) {
+	// Arrange: 突发容量很小，每个批次都需要等待
+	reader := sdkmetric.NewManualReader()
+	instruments, err := NewInstruments(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+	if err != nil {
+		t.Fatalf("注册仪表失败: %v", err)
+	}
+	var observed atomic.Int64
+	limiters, names := ratelimited.NewBuilder().
+		Add("global", rate.NewLimiter(10000, 10)).
+		BuildWithNames()
+	opts := append([]ratelimited.Option{
+		ratelimited.WithLayerWaitObserver(0, func(context.Context, string, time.Duration) { observed.Add(1) }),
+		ratelimited.WithLimiterNames(names),
+		ratelimited.WithBatchSize(10),
+	}, instruments.Options()...)
+	writer := ratelimited.NewDiscardWriter(limiters, opts...)
+
+	// Act
+	_, _ = writer.Write(make([]byte, 100))
+
+	// Assert
+	hist := collect(t, reader)["ratelimited.layer.wait.duration"].(metricdata.Histogram[float64])
+	counts := make(map[string]uint64)
+	for _, dp := range hist.DataPoints {
+		v, _ := dp.Attributes.Value(LayerKey)
+		counts[v.AsString()] = dp.Count
+	}
+	if counts["global"] == 0 || uint64(observed.Load()) != counts["global"] {
+		t.Errorf("仪表与已有观察者应该收到相同次数的等待: 仪表 %v，观察者 %d", counts, observed.Load())
+	}
+}
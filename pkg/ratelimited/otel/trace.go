@@ -0,0 +1,84 @@
+package otel
+
+import (
+	"context"
+	"time"
+
+	"github.com/lwmacct/250918-go-pkg-ratelimited/pkg/ratelimited"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// =============================================================================
+// 追踪 - 让限流等待出现在分布式追踪中
+// =============================================================================
+
+// WaitKey 等待时长（秒）的属性键
+const WaitKey = attribute.Key("ratelimited.wait.duration")
+
+// waitName 限流等待的 span 名称和事件名称
+const waitName = "ratelimited.wait"
+
+// tracing WithTracing 的配置
+type tracing struct {
+	tracer trace.Tracer      // nil 表示只在活动 span 上添加事件
+	clock  ratelimited.Clock // 事件和 span 的时间戳来源
+}
+
+// TraceOption 追踪的配置选项
+type TraceOption func(*tracing)
+
+// WithTracerProvider 为每次超过阈值的等待在 ctx 的活动 span 下创建子 span
+//
+// 未设置时只在 ctx 的活动 span 上添加事件，不创建新的 span。
+func WithTracerProvider(tp trace.TracerProvider) TraceOption {
+	return func(t *tracing) {
+		if tp != nil {
+			t.tracer = tp.Tracer(ScopeName)
+		}
+	}
+}
+
+// WithTraceClock 设置事件和 span 时间戳使用的时钟，默认使用系统时钟
+//
+// 写入器通过 ratelimited.WithClock 使用了其他时钟时应传入同一个时钟，
+// 使等待的起止时间与写入器测量的等待时长一致。
+func WithTraceClock(c ratelimited.Clock) TraceOption {
+	return func(t *tracing) {
+		if c != nil {
+			t.clock = c
+		}
+	}
+}
+
+// WithTracing 把超过 threshold 的单层等待记录到写入器上下文的追踪中
+//
+// 默认在 ctx（WithContext 设置的上下文）的活动 span 上添加名为 "ratelimited.wait" 的事件；
+// 设置 WithTracerProvider 后改为创建同名的子 span，其起止时间就是等待的起止时间。
+// 事件和 span 都带有层级名称（ratelimited.layer）和等待秒数（ratelimited.wait.duration）属性。
+// ctx 中没有正在记录的 span 时不添加事件。
+//
+// 与 WithMeterProvider 以及 prom 包同样使用追加的单层等待观察者，可以同时使用。
+func WithTracing(threshold time.Duration, opts ...TraceOption) ratelimited.Option {
+	t := &tracing{clock: ratelimited.SystemClock()}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return ratelimited.WithLayerWaitObserver(threshold, t.observe)
+}
+
+// observe 把一次等待记录为事件或子 span，等待刚刚结束
+func (t *tracing) observe(ctx context.Context, name string, waited time.Duration) {
+	end := t.clock.Now()
+	attrs := trace.WithAttributes(LayerKey.String(name), WaitKey.Float64(waited.Seconds()))
+
+	if t.tracer == nil {
+		span := trace.SpanFromContext(ctx)
+		if span.IsRecording() {
+			span.AddEvent(waitName, attrs, trace.WithTimestamp(end))
+		}
+		return
+	}
+	_, span := t.tracer.Start(ctx, waitName, attrs, trace.WithTimestamp(end.Add(-waited)))
+	span.End(trace.WithTimestamp(end))
+}
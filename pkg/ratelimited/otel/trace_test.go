@@ -0,0 +1,150 @@
+package otel
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lwmacct/250918-go-pkg-ratelimited/pkg/ratelimited"
+	"github.com/lwmacct/250918-go-pkg-ratelimited/pkg/ratelimited/ratelimitedtest"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// sleepyLimiter 每次 WaitN 都真实等待 d 的限制器
+type sleepyLimiter struct {
+	d time.Duration
+}
+
+func (l sleepyLimiter) WaitN(ctx context.Context, n int) error {
+	time.Sleep(l.d)
+	return nil
+}
+
+// advancingLimiter 每次 WaitN 把虚拟时钟推进 d 的限制器
+type advancingLimiter struct {
+	clock *ratelimitedtest.FakeClock
+	d     time.Duration
+}
+
+func (l advancingLimiter) WaitN(ctx context.Context, n int) error {
+	l.clock.Advance(l.d)
+	return nil
+}
+
+// TestWithTracing 测试超过阈值的等待被记录为活动 span 的事件或子 span
+//
+// 测试目标：
+//   - 默认只在活动 span 上添加带层级名称的事件
+//   - 设置 TracerProvider 后创建子 span
+//   - 未超过阈值的层不被记录
+func TestWithTracing(t *testing.T) {
+	for _, withSpans := range []bool{false, true} {
+		t.Run(map[bool]string{false: "事件", true: "子 span"}[withSpans], func(t *testing.T) {
+			// Arrange
+			recorder := tracetest.NewSpanRecorder()
+			tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+			ctx, parent := tp.Tracer("test").Start(context.Background(), "download")
+
+			var opts []TraceOption
+			if withSpans {
+				opts = append(opts, WithTracerProvider(tp))
+			}
+			w := ratelimited.NewDiscardWriter(
+				[]ratelimited.Limiter{sleepyLimiter{}, sleepyLimiter{d: 20 * time.Millisecond}},
+				ratelimited.WithContext(ctx),
+				ratelimited.WithLimiterNames([]string{"global", "tenant"}),
+				WithTracing(10*time.Millisecond, opts...),
+			)
+
+			// Act
+			_, err := w.Write(make([]byte, 10))
+			parent.End()
+
+			// Assert
+			if err != nil {
+				t.Fatalf("写入应该成功，实际 %v", err)
+			}
+			spans := recorder.Ended()
+			var (
+				events []sdktrace.Event
+				waits  []sdktrace.ReadOnlySpan
+			)
+			for _, span := range spans {
+				switch span.Name() {
+				case "download":
+					events = span.Events()
+				case waitName:
+					waits = append(waits, span)
+				}
+			}
+			if withSpans {
+				if len(waits) != 1 || len(events) != 0 {
+					t.Fatalf("应该创建 1 个子 span 且不添加事件，实际 %d 个 span、%d 个事件", len(waits), len(events))
+				}
+				if waits[0].Parent().SpanID() != parent.SpanContext().SpanID() {
+					t.Error("等待 span 应该是写入上下文中 span 的子 span")
+				}
+				if d := waits[0].EndTime().Sub(waits[0].StartTime()); d < 20*time.Millisecond {
+					t.Errorf("子 span 应该覆盖等待时长，实际 %s", d)
+				}
+				assertLayer(t, waits[0].Attributes(), "tenant")
+				return
+			}
+			if len(events) != 1 || len(waits) != 0 {
+				t.Fatalf("应该添加 1 个事件且不创建子 span，实际 %d 个事件、%d 个 span", len(events), len(waits))
+			}
+			assertLayer(t, events[0].Attributes, "tenant")
+		})
+	}
+}
+
+// TestWithTraceClock 测试子 span 的起止时间取自注入的时钟
+func TestWithTraceClock(t *testing.T) {
+	// Arrange
+	clock := ratelimitedtest.NewFakeClock()
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	ctx, parent := tp.Tracer("test").Start(context.Background(), "download")
+	w := ratelimited.NewDiscardWriter(
+		[]ratelimited.Limiter{advancingLimiter{clock: clock, d: time.Minute}},
+		ratelimited.WithClock(clock),
+		ratelimited.WithContext(ctx),
+		WithTracing(0, WithTracerProvider(tp), WithTraceClock(clock)),
+	)
+	start := clock.Now()
+
+	// Act
+	_, err := w.Write(make([]byte, 10))
+	parent.End()
+
+	// Assert
+	if err != nil {
+		t.Fatalf("写入应该成功，实际 %v", err)
+	}
+	for _, span := range recorder.Ended() {
+		if span.Name() != waitName {
+			continue
+		}
+		if !span.StartTime().Equal(start) || !span.EndTime().Equal(start.Add(time.Minute)) {
+			t.Errorf("子 span 应该覆盖虚拟时钟上的等待，实际 %s ~ %s", span.StartTime(), span.EndTime())
+		}
+		return
+	}
+	t.Error("应该创建等待子 span")
+}
+
+// assertLayer 断言属性中的层级名称
+func assertLayer(t *testing.T, attrs []attribute.KeyValue, want string) {
+	t.Helper()
+	for _, kv := range attrs {
+		if kv.Key == LayerKey {
+			if got := kv.Value.AsString(); got != want {
+				t.Errorf("层级名称应该为 %s，实际 %s", want, got)
+			}
+			return
+		}
+	}
+	t.Errorf("缺少层级名称属性: %v", attrs)
+}
@@ -0,0 +1,126 @@
+package ratelimited
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"golang.org/x/time/rate"
+)
+
+// =============================================================================
+// 严格匀速限制器 - 漏桶，不积累突发
+// =============================================================================
+
+// Pacer 严格匀速发放令牌的漏桶限制器，实现 Limiter 接口
+//
+// 与令牌桶不同，空闲期间不积累令牌：每次发放 n 个令牌后，下一次发放至少推迟
+// n/limit 秒，因此任意时间窗口内发放的令牌都不超过 limit×窗口长度 + quantum。
+// 单次申请不能超过 quantum，例如 1 MB/s、quantum 为 1KB 时每毫秒最多发放约 1KB，
+// 适合对微突发敏感的下游设备。
+//
+// 写入器按批次向限制器申请令牌，并在申请完成后一次写出整个 Write 的数据，
+// 因此需要配合 WithBatchSize(quantum) 使用，并以不超过 quantum 的块写入
+// （例如 io.CopyBuffer 使用 quantum 大小的缓冲区），才能保证下游看到的数据同样匀速。
+// 发放间隔按浮点秒计算并以小数纳秒精度累加，任意速率下都没有截断误差。
+type Pacer struct {
+	clock Clock
+
+	mu      sync.Mutex
+	limit   float64     // 速率（令牌/秒），0 表示不限制
+	quantum int         // 单次申请的上限
+	next    virtualTime // 下一次允许发放的时间
+}
+
+// PacerOption 严格匀速限制器的配置选项
+type PacerOption func(*Pacer)
+
+// WithPacerClock 设置限制器使用的时钟，默认使用系统时钟
+func WithPacerClock(c Clock) PacerOption {
+	return func(p *Pacer) {
+		if c != nil {
+			p.clock = c
+		}
+	}
+}
+
+// NewPacer 创建速率为 limit、单次申请不超过 quantum 个令牌的严格匀速限制器
+//
+// limit 为 rate.Inf 时不限制。
+func NewPacer(limit rate.Limit, quantum int, opts ...PacerOption) *Pacer {
+	p := &Pacer{clock: systemClock{}, quantum: quantum}
+	if limit != rate.Inf && limit > 0 {
+		p.limit = float64(limit)
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// WaitN 实现 Limiter 接口，阻塞直到上一次发放的令牌按速率耗完
+//
+// n 超过 quantum 或等待会超过 ctx 的 deadline 时不占用发放时间并立即返回错误，
+// 与 *rate.Limiter 的行为一致；ctx 在等待期间结束时归还占用的发放时间。
+func (p *Pacer) WaitN(ctx context.Context, n int) error {
+	p.mu.Lock()
+	if p.limit <= 0 {
+		p.mu.Unlock()
+		return nil
+	}
+	if n > p.quantum {
+		p.mu.Unlock()
+		return fmt.Errorf("ratelimited: WaitN(n=%d) exceeds limiter's burst %d", n, p.quantum)
+	}
+
+	now := p.clock.Now()
+	next := p.next
+	next.clampTo(now)
+	wait := next.t.Sub(now)
+	if deadline, ok := ctx.Deadline(); ok && wait > deadline.Sub(now) {
+		p.mu.Unlock()
+		return fmt.Errorf("ratelimited: WaitN(n=%d) would exceed context deadline: %w", n, context.DeadlineExceeded)
+	}
+	next.add(tokenNanos(n, p.limit))
+	p.next = next
+	p.mu.Unlock()
+
+	if wait <= 0 {
+		return nil
+	}
+	select {
+	case <-ctx.Done():
+		p.ReturnN(n)
+		return ctx.Err()
+	case <-p.clock.After(wait):
+		return nil
+	}
+}
+
+// ReturnN 实现 TokenReturner 接口，把下一次发放时间提前 n 个令牌的间隔，但不早于当前时间
+func (p *Pacer) ReturnN(n int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.limit <= 0 || n <= 0 {
+		return
+	}
+	p.next.add(-tokenNanos(n, p.limit))
+	p.next.clampTo(p.clock.Now())
+}
+
+// Limit 返回限制器的速率（令牌/秒）
+func (p *Pacer) Limit() rate.Limit {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.limit <= 0 {
+		return rate.Inf
+	}
+	return rate.Limit(p.limit)
+}
+
+// Burst 返回单次申请的上限 quantum
+func (p *Pacer) Burst() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.quantum
+}
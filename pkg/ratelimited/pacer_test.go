@@ -0,0 +1,101 @@
+package ratelimited
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// TestPacer_NoBurstAccumulation 测试空闲后不积累突发，每次发放都按上一次的大小推迟
+//
+// 测试目标：
+//   - 第一次申请立即放行
+//   - 空闲很久之后连续两次申请，第二次仍需等待第一次的令牌按速率耗完
+//   - 超过 quantum 的申请立即失败
+func TestPacer_NoBurstAccumulation(t *testing.T) {
+	// Arrange: 每秒 1000 个令牌，单次最多 10 个
+	clock := newFakeClock()
+	p := NewPacer(1000, 10, WithPacerClock(clock))
+	ctx := context.Background()
+
+	// Act & Assert
+	assertNoError(t, p.WaitN(ctx, 10), "第一次申请应该立即放行")
+	clock.Advance(time.Hour)
+	assertNoError(t, p.WaitN(ctx, 10), "空闲后的第一次申请应该立即放行")
+
+	done := make(chan error, 1)
+	go func() { done <- p.WaitN(ctx, 5) }()
+	clock.waitForWaiters(t, 1)
+	clock.Advance(9 * time.Millisecond)
+	select {
+	case <-done:
+		t.Fatal("空闲期间不应积累令牌，第二次申请应该等待 10ms")
+	default:
+	}
+	clock.Advance(time.Millisecond)
+	assertNoError(t, <-done, "等待上一次的令牌耗完后应该放行")
+
+	if err := p.WaitN(ctx, 11); err == nil {
+		t.Error("超过 quantum 的申请应该返回错误")
+	}
+}
+
+// TestPacer_DeadlineAndCancel 测试等待超过 deadline 时立即失败、取消时归还发放时间
+func TestPacer_DeadlineAndCancel(t *testing.T) {
+	// Arrange
+	clock := newFakeClock()
+	p := NewPacer(1000, 10, WithPacerClock(clock))
+	assertNoError(t, p.WaitN(context.Background(), 10), "第一次申请")
+
+	// Act & Assert: deadline 不足以等到下一次发放
+	short, cancel := context.WithDeadline(context.Background(), clock.Now().Add(5*time.Millisecond))
+	defer cancel()
+	if err := p.WaitN(short, 1); err == nil {
+		t.Error("等待会超过 deadline 时应该立即返回错误")
+	}
+
+	// Act & Assert: 等待期间取消
+	ctx, cancelWait := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- p.WaitN(ctx, 10) }()
+	clock.waitForWaiters(t, 1)
+	cancelWait()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("取消后应该返回 context.Canceled，实际 %v", err)
+	}
+	clock.Advance(10 * time.Millisecond)
+	now, cancelNow := context.WithDeadline(context.Background(), clock.Now())
+	defer cancelNow()
+	assertNoError(t, p.WaitN(now, 1), "取消的申请应该归还发放时间")
+}
+
+// TestPacer_Introspection 测试速率和突发容量的内省
+func TestPacer_Introspection(t *testing.T) {
+	// Arrange & Act
+	p := NewPacer(1<<20, 1024)
+
+	// Assert
+	assertEqual(t, 1024, p.Burst(), "突发容量等于 quantum")
+	assertEqual(t, rate.Inf, NewPacer(rate.Inf, 0).Limit(), "无限速率")
+	assertNoError(t, NewPacer(rate.Inf, 0).WaitN(context.Background(), 1<<20), "无限速率不应该等待")
+}
+
+// TestPacer_FractionalInterval 测试发放间隔不是整数纳秒的速率没有截断误差
+func TestPacer_FractionalInterval(t *testing.T) {
+	// Arrange
+	clock := newFakeClock()
+	p := NewPacer(187.5e6, 1000, WithPacerClock(clock))
+	fast := NewPacer(2e9, 1000, WithPacerClock(clock))
+	start := clock.Now()
+
+	// Act
+	assertNoError(t, p.WaitN(context.Background(), 1000), "第一次申请应该立即放行")
+	assertNoError(t, fast.WaitN(context.Background(), 1000), "第一次申请应该立即放行")
+
+	// Assert
+	assertEqual(t, 5333*time.Nanosecond, p.next.t.Sub(start), "下一次发放时间应该按小数间隔推迟")
+	assertEqual(t, 500*time.Nanosecond, fast.next.t.Sub(start), "超过 1e9 的速率也应该推迟")
+	assertEqual(t, rate.Limit(2e9), fast.Limit(), "超过 1e9 的速率应该保持原值")
+}
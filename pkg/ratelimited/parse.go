@@ -0,0 +1,117 @@
+package ratelimited
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"golang.org/x/time/rate"
+)
+
+// =============================================================================
+// 速率解析 - 从可读字符串构造限制器
+// =============================================================================
+
+// ratePrefixes 单位前缀对应的倍数，按前缀小写索引
+//
+// 十进制前缀（K、M）按 1000 进位，二进制前缀（Ki、Mi）按 1024 进位。
+var ratePrefixes = map[string]float64{
+	"":   1,
+	"k":  1e3,
+	"m":  1e6,
+	"g":  1e9,
+	"t":  1e12,
+	"ki": 1 << 10,
+	"mi": 1 << 20,
+	"gi": 1 << 30,
+	"ti": 1 << 40,
+}
+
+// ParseRate 解析 "10MiB/s"、"1.5Gbps" 形式的速率，返回每秒字节数和建议的突发容量
+//
+// 格式为数值加单位。单位以 B 结尾表示字节、以 b 结尾表示比特，两者区分大小写，
+// 其余部分不区分大小写：前缀 K/M/G/T 按 1000 进位，Ki/Mi/Gi/Ti 按 1024 进位，
+// 单位后可带 "/s"，也可以写作 "ps"（如 Mbps、MBps），但两者不能同时出现。
+// 例如 "10MB/s" 为每秒 10^7 字节，"10Mb/s" 与 "10Mbps" 为每秒 10^7 比特。
+// "inf" 或 "unlimited" 表示不限速，返回 rate.Inf 和 0。
+//
+// 突发容量取一秒的令牌量，且不小于默认批次大小（64KB），
+// 保证默认配置的写入器按批次申请令牌时不会超过突发容量。
+func ParseRate(s string) (rate.Limit, int, error) {
+	spec := strings.TrimSpace(s)
+	if strings.EqualFold(spec, "inf") || strings.EqualFold(spec, "unlimited") {
+		return rate.Inf, 0, nil
+	}
+
+	end := strings.IndexFunc(spec, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	if end <= 0 {
+		return 0, 0, fmt.Errorf("ratelimited: invalid rate %q", s)
+	}
+
+	value, err := strconv.ParseFloat(spec[:end], 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("ratelimited: invalid rate %q: %w", s, err)
+	}
+	unit, ok := parseRateUnit(strings.TrimSpace(spec[end:]))
+	if !ok {
+		return 0, 0, fmt.Errorf("ratelimited: invalid rate %q: unknown unit %q", s, spec[end:])
+	}
+	if value <= 0 {
+		return 0, 0, fmt.Errorf("ratelimited: invalid rate %q: must be positive", s)
+	}
+
+	perSecond := value * unit
+	if perSecond >= math.MaxInt {
+		return 0, 0, fmt.Errorf("ratelimited: invalid rate %q: too large", s)
+	}
+	burst := max(int(math.Ceil(perSecond)), defaultBatchSize)
+	return rate.Limit(perSecond), burst, nil
+}
+
+// parseRateUnit 返回速率单位对应的每秒字节数
+func parseRateUnit(unit string) (float64, bool) {
+	lower := strings.ToLower(unit)
+	switch {
+	case strings.HasSuffix(lower, "/s"):
+		unit = unit[:len(unit)-2]
+	case strings.HasSuffix(lower, "ps"):
+		unit = unit[:len(unit)-2]
+	}
+	if unit == "" {
+		return 0, false
+	}
+
+	var scale float64
+	switch unit[len(unit)-1] {
+	case 'B':
+		scale = 1
+	case 'b':
+		scale = 1.0 / 8
+	default:
+		return 0, false
+	}
+	prefix, ok := ratePrefixes[strings.ToLower(unit[:len(unit)-1])]
+	if !ok {
+		return 0, false
+	}
+	return prefix * scale, true
+}
+
+// ChainFromStrings 按 ParseRate 解析每个速率并创建对应的多层限制器链
+//
+// 适用于从命令行参数或配置文件读取限速的场景，层级顺序与参数顺序一致。
+// 任何一个速率无法解析时返回带下标的错误。
+func ChainFromStrings(specs ...string) ([]Limiter, error) {
+	limiters := make([]Limiter, 0, len(specs))
+	for i, spec := range specs {
+		limit, burst, err := ParseRate(spec)
+		if err != nil {
+			return nil, fmt.Errorf("layer %d: %w", i, err)
+		}
+		limiters = append(limiters, rate.NewLimiter(limit, burst))
+	}
+	return limiters, nil
+}
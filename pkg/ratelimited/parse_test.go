@@ -0,0 +1,66 @@
+package ratelimited
+
+import (
+	"testing"
+
+	"golang.org/x/time/rate"
+)
+
+// TestParseRate 测试可读速率字符串的解析
+func TestParseRate(t *testing.T) {
+	testCases := []struct {
+		name          string
+		input         string
+		expectedLimit rate.Limit
+		expectedBurst int
+	}{
+		{name: "二进制前缀", input: "10MiB/s", expectedLimit: 10 << 20, expectedBurst: 10 << 20},
+		{name: "比特速率", input: "1.5Gbps", expectedLimit: 1.5e9 / 8, expectedBurst: 1.5e9 / 8},
+		{name: "十进制前缀不带后缀", input: "2 MB", expectedLimit: 2e6, expectedBurst: 2e6},
+		{name: "前缀和后缀不区分大小写", input: "512kiB/S", expectedLimit: 512 << 10, expectedBurst: 512 << 10},
+		{name: "小写 b 表示比特", input: "10Mb/s", expectedLimit: 10e6 / 8, expectedBurst: 10e6 / 8},
+		{name: "大写 B 加 ps 表示字节", input: "10MBps", expectedLimit: 10e6, expectedBurst: 10e6},
+		{name: "低速率突发不小于批次", input: "1KB/s", expectedLimit: 1e3, expectedBurst: defaultBatchSize},
+		{name: "不限速", input: "unlimited", expectedLimit: rate.Inf, expectedBurst: 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Act
+			limit, burst, err := ParseRate(tc.input)
+
+			// Assert
+			assertNoError(t, err, "合法的速率应该解析成功")
+			assertEqual(t, tc.expectedLimit, limit, "速率应该正确")
+			assertEqual(t, tc.expectedBurst, burst, "突发容量应该正确")
+		})
+	}
+
+	for _, input := range []string{"", "MiB/s", "10", "10XB/s", "-5MB/s", "0B/s", "1.2.3MB", "10Mbps/s", "10M/s", "10/s", "10Xib"} {
+		t.Run("非法输入 "+input, func(t *testing.T) {
+			// Act
+			_, _, err := ParseRate(input)
+
+			// Assert
+			if err == nil {
+				t.Errorf("非法的速率 %q 应该返回错误", input)
+			}
+		})
+	}
+}
+
+// TestChainFromStrings 测试从速率字符串创建限制器链
+func TestChainFromStrings(t *testing.T) {
+	// Act
+	limiters, err := ChainFromStrings("100MiB/s", "10Mbps")
+
+	// Assert
+	assertNoError(t, err, "合法的速率应该创建成功")
+	assertEqual(t, 2, len(limiters), "应该创建两层")
+	assertEqual(t, rate.Limit(10e6/8), limiters[1].(*rate.Limiter).Limit(), "层级顺序应该与参数一致")
+
+	_, err = ChainFromStrings("1MB/s", "fast")
+	if err == nil {
+		t.Error("任何一个速率非法时应该返回错误")
+	}
+}
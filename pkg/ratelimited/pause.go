@@ -0,0 +1,58 @@
+package ratelimited
+
+import "context"
+
+// =============================================================================
+// 暂停与恢复 - 临时冻结传输而不取消上下文
+// =============================================================================
+
+// Pause 暂停写入器，之后的写入阻塞直到 Resume 被调用或上下文结束
+//
+// 适用于高峰时段临时冻结限流传输的场景：无需取消上下文、重新开始复制。
+// 已经在等待令牌的写入不受影响，暂停在下一次写入开始时生效；
+// 暂停期间批次剩余令牌和统计保持不变。非阻塞写入（TryWrite）在暂停期间返回 ErrWouldBlock。
+// 重复调用 Pause 是空操作。
+func (w *DiscardWriter) Pause() {
+	w.pauseMu.Lock()
+	defer w.pauseMu.Unlock()
+	if w.resumed == nil {
+		w.resumed = make(chan struct{})
+	}
+}
+
+// Resume 恢复被暂停的写入器，唤醒所有阻塞在暂停上的写入；未暂停时是空操作
+func (w *DiscardWriter) Resume() {
+	w.pauseMu.Lock()
+	defer w.pauseMu.Unlock()
+	if w.resumed != nil {
+		close(w.resumed)
+		w.resumed = nil
+	}
+}
+
+// Paused 判断写入器当前是否处于暂停状态
+func (w *DiscardWriter) Paused() bool {
+	w.pauseMu.Lock()
+	defer w.pauseMu.Unlock()
+	return w.resumed != nil
+}
+
+// waitResumed 在写入器暂停时阻塞，直到恢复或 ctx 结束
+func (w *DiscardWriter) waitResumed(ctx context.Context) error {
+	w.pauseMu.Lock()
+	resumed := w.resumed
+	w.pauseMu.Unlock()
+	if resumed == nil {
+		return nil
+	}
+	if isNonBlocking(ctx) {
+		return ErrWouldBlock
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-resumed:
+		return nil
+	}
+}
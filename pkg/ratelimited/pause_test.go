@@ -0,0 +1,78 @@
+package ratelimited
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// TestDiscardWriter_PauseResume 测试暂停与恢复
+//
+// 测试目标：
+//   - 暂停后写入阻塞，恢复后继续完成
+//   - 暂停期间上下文结束时写入返回上下文错误
+//   - 暂停期间 TryWrite 返回 ErrWouldBlock
+func TestDiscardWriter_PauseResume(t *testing.T) {
+	t.Run("恢复后继续写入", func(t *testing.T) {
+		// Arrange
+		writer := NewDiscardWriter(Chain(rate.NewLimiter(rate.Inf, 0)))
+		writer.Pause()
+		assertEqual(t, true, writer.Paused(), "应该处于暂停状态")
+
+		// Act
+		done := make(chan error, 1)
+		go func() {
+			_, err := writer.Write(createTestData(10))
+			done <- err
+		}()
+
+		// Assert
+		select {
+		case err := <-done:
+			t.Fatalf("暂停期间写入不应该返回，实际 %v", err)
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		writer.Resume()
+		select {
+		case err := <-done:
+			assertNoError(t, err, "恢复后写入应该成功")
+		case <-time.After(time.Second):
+			t.Fatal("恢复后写入应该完成")
+		}
+		assertEqual(t, false, writer.Paused(), "恢复后不应该处于暂停状态")
+		assertEqual(t, int64(10), writer.Stats().Bytes, "应该统计恢复后写入的字节")
+	})
+
+	t.Run("暂停期间上下文结束", func(t *testing.T) {
+		// Arrange
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+		defer cancel()
+		writer := NewDiscardWriter(Chain(rate.NewLimiter(rate.Inf, 0)), WithContext(ctx))
+		writer.Pause()
+
+		// Act
+		n, err := writer.Write(createTestData(10))
+
+		// Assert
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("应该返回上下文超时错误，实际 %v", err)
+		}
+		assertEqual(t, 0, n, "不应该接收任何字节")
+	})
+
+	t.Run("暂停期间非阻塞写入", func(t *testing.T) {
+		// Arrange
+		writer := NewDiscardWriter(Chain(rate.NewLimiter(rate.Inf, 0)))
+		writer.Pause()
+
+		// Act
+		_, err := writer.TryWrite(createTestData(10))
+
+		// Assert
+		assertEqual(t, ErrWouldBlock, err, "暂停期间 TryWrite 应该返回 ErrWouldBlock")
+	})
+}
@@ -0,0 +1,90 @@
+package ratelimited
+
+// =============================================================================
+// 分阶段限制 - 模拟慢启动
+// =============================================================================
+
+// WithPhaseLimiters 设置分阶段的限制器链：前 k 字节使用 readPhase，其余使用 steadyState
+//
+// 用于精确模拟慢启动等行为，例如源仍在产生数据时限制得更严格、进入稳定排空后放开。
+// 该选项替换传入 NewDiscardWriter 的限制器链，因此通常与空链一起使用：
+//
+//	CopyWithRateLimit(ctx, r, nil, WithPhaseLimiters(64*1024, slowStart, steady))
+//
+// 跨越边界的写入会在边界处拆分，两部分分别在各自的链上申请令牌，不会产生短写。
+// 切换时作废初始链剩余的批次令牌（计入 DroppedTokens），避免用旧链的令牌支付新阶段。
+// 分阶段写入器在初始阶段的写入和 Charge 是串行执行的，适用于单个复制流程；
+// Release、WouldBlock 等入口读取当前阶段链的快照，可以与写入并发调用。
+// k <= 0 时直接使用 steadyState。
+func WithPhaseLimiters(k int64, readPhase, steadyState []Limiter) DiscardWriterOption {
+	return func(w *DiscardWriter) {
+		if k <= 0 {
+			w.limiters = steadyState
+			w.steadyLimiters = nil
+			w.phaseChain.Store(nil)
+			return
+		}
+		w.limiters = readPhase
+		w.phaseBoundary = k
+		w.phaseWritten = 0
+		w.steadyLimiters = steadyState
+		if w.steadyLimiters == nil {
+			w.steadyLimiters = []Limiter{}
+		}
+		w.phaseChain.Store(&readPhase)
+	}
+}
+
+// inReadPhase 判断分阶段写入器是否仍处于初始阶段
+func (w *DiscardWriter) inReadPhase() bool {
+	return w.steadyLimiters != nil && !w.phaseSteady.Load()
+}
+
+// admitPhased 按阶段边界拆分 size 字节的准入，p 为 nil 时表示纯计费
+func (w *DiscardWriter) admitPhased(p []byte, size int, chargeQuota bool) (int, error) {
+	w.phaseMu.Lock()
+	defer w.phaseMu.Unlock()
+
+	if w.phaseSteady.Load() {
+		// 等待锁期间已经切换到稳定阶段
+		return w.admit(p, size, chargeQuota)
+	}
+
+	head := size
+	if left := w.phaseBoundary - w.phaseWritten; int64(size) > left {
+		head = int(left)
+	}
+
+	n, err := w.admit(phaseSlice(p, 0, head), head, chargeQuota)
+	w.phaseWritten += int64(n)
+	if err != nil || n < head {
+		return n, err
+	}
+	if w.phaseWritten < w.phaseBoundary {
+		return n, nil
+	}
+
+	w.enterSteadyPhase()
+	if n == size {
+		return n, nil
+	}
+	m, err := w.admit(phaseSlice(p, n, size), size-n, chargeQuota)
+	return n + m, err
+}
+
+// phaseSlice 返回 p[from:to]，p 为 nil（纯计费）时返回 nil
+func phaseSlice(p []byte, from, to int) []byte {
+	if p == nil {
+		return nil
+	}
+	return p[from:to]
+}
+
+// enterSteadyPhase 切换到稳定阶段的链，调用方必须持有 phaseMu
+//
+// 先发布新链再标记阶段结束，之后的 chain() 快照都不会再看到初始链。
+func (w *DiscardWriter) enterSteadyPhase() {
+	w.phaseChain.Store(&w.steadyLimiters)
+	w.phaseSteady.Store(true)
+	w.dropTokens()
+}
@@ -0,0 +1,101 @@
+package ratelimited
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// pacedLimiter 按固定速率推进虚拟时钟的限制器，n 个令牌耗时 n/rate 秒
+type pacedLimiter struct {
+	clock *fakeClock
+	rate  float64
+	total int64
+}
+
+func (l *pacedLimiter) WaitN(ctx context.Context, n int) error {
+	l.total += int64(n)
+	l.clock.Advance(time.Duration(float64(n) / l.rate * float64(time.Second)))
+	return nil
+}
+
+// TestWithPhaseLimiters_ThroughputProfile 测试吞吐量在边界处切换
+//
+// 测试目标：
+//   - 前 K 字节按初始阶段的速率、其余按稳定阶段的速率放行
+//   - 跨越边界的写入被拆分，两个阶段的令牌量恰好等于各自的字节数
+func TestWithPhaseLimiters_ThroughputProfile(t *testing.T) {
+	// Arrange: 初始阶段 1000 B/s，稳定阶段 4000 B/s，边界不与写入对齐
+	clock := newFakeClock()
+	slow := &pacedLimiter{clock: clock, rate: 1000}
+	fast := &pacedLimiter{clock: clock, rate: 4000}
+	quota := int64(1 << 20) // 共享配额使每次申请量等于写入大小
+	writer := NewDiscardWriter(nil,
+		WithPhaseLimiters(1025, []Limiter{slow}, []Limiter{fast}),
+		WithSharedQuota(&quota),
+	)
+	start := clock.Now()
+
+	// Act
+	var boundaryElapsed time.Duration // 边界前最后一次对齐写入完成时的耗时
+	for written := 0; written < 3000; written += 50 {
+		n, err := writer.Write(createTestData(50))
+		assertNoError(t, err, "写入应该成功")
+		assertEqual(t, 50, n, "跨越边界的写入不应该短写")
+		if written+50 == 1000 {
+			boundaryElapsed = clock.Now().Sub(start)
+		}
+	}
+	total := clock.Now().Sub(start)
+
+	// Assert
+	assertEqual(t, int64(1025), slow.total, "初始阶段的令牌量应该等于边界")
+	assertEqual(t, int64(1975), fast.total, "稳定阶段的令牌量应该等于剩余字节")
+	assertEqual(t, time.Second, boundaryElapsed, "初始阶段按 1000 B/s 放行")
+	assertEqual(t, 1025*time.Millisecond+1975*time.Second/4000, total, "稳定阶段按 4000 B/s 放行")
+}
+
+// TestWithPhaseLimiters_ConcurrentEntryPoints 测试阶段切换与其他入口并发时没有数据竞争
+//
+// 测试目标：
+//   - Write、Charge 与 Release、WouldBlock 并发调用时读取的是一致的链快照（配合 -race）
+//   - Charge 与 Write 一样计入阶段边界
+func TestWithPhaseLimiters_ConcurrentEntryPoints(t *testing.T) {
+	// Arrange: 两个阶段都使用不限速的令牌桶，边界为 1000 字节
+	readPhase := []Limiter{rate.NewLimiter(rate.Inf, 0)}
+	steady := []Limiter{rate.NewLimiter(rate.Inf, 0)}
+	writer := NewDiscardWriter(nil, WithPhaseLimiters(1000, readPhase, steady))
+
+	// Act
+	var wg sync.WaitGroup
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			_, _ = writer.Write(createTestData(10))
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			_ = writer.Charge(10)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			writer.Release()
+			writer.WouldBlock(10)
+		}
+	}()
+	wg.Wait()
+
+	// Assert: 合计 2000 字节，初始阶段恰好准入边界内的 1000 字节
+	assertEqual(t, true, writer.phaseSteady.Load(), "越过边界后应该切换到稳定阶段")
+	assertEqual(t, int64(1000), writer.phaseWritten, "Write 与 Charge 应该共同计入阶段边界")
+	_, err := writer.TryWrite(createTestData(10))
+	assertNoError(t, err, "稳定阶段应该允许非阻塞写入")
+}
@@ -0,0 +1,98 @@
+package ratelimited
+
+import (
+	"io"
+	"time"
+)
+
+// =============================================================================
+// 播放速率读取器 - 按恒定码率投递数据
+// =============================================================================
+
+// defaultPlaybackInterval 默认每个数据块对应的播放时长，决定了最大突发量
+const defaultPlaybackInterval = 20 * time.Millisecond
+
+// PlaybackReader 按恒定码率（CBR）投递数据的读取器
+//
+// 与通用的限速读取器不同，PlaybackReader 面向实时媒体投递：每次 Read 最多返回
+// 一个小数据块，并在该数据块按码率"播放完毕"的时刻才返回，使下游播放器以实时速度读取。
+// 投递时刻按从首次读取开始的累计字节数计算，单次延迟不会累积为长期漂移。
+// PlaybackReader 不是并发安全的，与普通 io.Reader 一样应由单个 goroutine 读取。
+type PlaybackReader struct {
+	src         io.Reader
+	bytesPerSec float64
+	chunk       int
+	clock       Clock
+
+	started   bool
+	start     time.Time
+	delivered int64
+}
+
+// PlaybackOption PlaybackReader 的配置选项
+type PlaybackOption func(*PlaybackReader)
+
+// WithPlaybackClock 设置 PlaybackReader 使用的时钟
+func WithPlaybackClock(c Clock) PlaybackOption {
+	return func(r *PlaybackReader) {
+		if c != nil {
+			r.clock = c
+		}
+	}
+}
+
+// WithPlaybackChunk 设置单次 Read 返回的最大字节数，即允许的最大突发量
+//
+// 默认为 20ms 播放时长对应的字节数。
+func WithPlaybackChunk(size int) PlaybackOption {
+	return func(r *PlaybackReader) {
+		if size > 0 {
+			r.chunk = size
+		}
+	}
+}
+
+// NewPlaybackReader 创建按 bitrate（比特每秒）投递 src 数据的读取器
+func NewPlaybackReader(src io.Reader, bitrate float64, opts ...PlaybackOption) *PlaybackReader {
+	r := &PlaybackReader{
+		src:         src,
+		bytesPerSec: bitrate / 8,
+		clock:       systemClock{},
+	}
+	r.chunk = int(r.bytesPerSec * defaultPlaybackInterval.Seconds())
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	if r.chunk < 1 {
+		r.chunk = 1
+	}
+	return r
+}
+
+// Read 实现 io.Reader 接口，读取至多一个数据块并等待到其投递时刻
+func (r *PlaybackReader) Read(p []byte) (int, error) {
+	if len(p) > r.chunk {
+		p = p[:r.chunk]
+	}
+
+	n, err := r.src.Read(p)
+	if n <= 0 || r.bytesPerSec <= 0 {
+		return n, err
+	}
+
+	if !r.started {
+		r.started = true
+		r.start = r.clock.Now()
+	}
+
+	// 第 delivered+n 个字节按码率应该在此刻投递完毕
+	r.delivered += int64(n)
+	due := r.start.Add(time.Duration(float64(r.delivered) / r.bytesPerSec * float64(time.Second)))
+	if wait := due.Sub(r.clock.Now()); wait > 0 {
+		<-r.clock.After(wait)
+	}
+
+	return n, err
+}
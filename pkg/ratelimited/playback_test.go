@@ -0,0 +1,70 @@
+package ratelimited
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+)
+
+// TestPlaybackReader_MatchesBitrate 测试投递速率与码率一致
+//
+// 测试目标：
+//   - 3 秒内容在虚拟时间上恰好用 3 秒投递完毕
+//   - 每次 Read 返回的数据不超过 20ms 对应的字节数
+//   - 中途任意时刻的累计投递量与码率相符
+func TestPlaybackReader_MatchesBitrate(t *testing.T) {
+	// Arrange: 64kbps = 8000 B/s，20ms 数据块为 160 字节
+	clock := newSleepingClock()
+	const bitrate = 64000
+	content := make([]byte, 3*8000)
+	reader := NewPlaybackReader(bytes.NewReader(content), bitrate, WithPlaybackClock(clock))
+
+	start := clock.Now()
+	buf := make([]byte, 4096)
+	var total int
+	maxChunk := 0
+
+	// Act
+	for {
+		n, err := reader.Read(buf)
+		total += n
+		if n > maxChunk {
+			maxChunk = n
+		}
+
+		// 每个数据块返回时，累计投递量应该与已播放时长对应
+		expected := int(clock.Now().Sub(start).Seconds() * 8000)
+		if n > 0 && (total < expected-1 || total > expected+1) {
+			t.Fatalf("投递进度偏离码率: 已投递 %d 字节，期望约 %d 字节", total, expected)
+		}
+
+		if err == io.EOF {
+			break
+		}
+		assertNoError(t, err, "读取应该成功")
+	}
+
+	// Assert
+	assertEqual(t, len(content), total, "应该投递全部内容")
+	assertEqual(t, 3*time.Second, clock.Now().Sub(start), "投递时长应该等于播放时长")
+	assertEqual(t, 160, maxChunk, "单次投递不应该超过一个数据块")
+}
+
+// TestPlaybackReader_CustomChunk 测试自定义数据块大小
+func TestPlaybackReader_CustomChunk(t *testing.T) {
+	// Arrange
+	clock := newSleepingClock()
+	reader := NewPlaybackReader(bytes.NewReader(make([]byte, 100)), 8000,
+		WithPlaybackClock(clock),
+		WithPlaybackChunk(10),
+	)
+
+	// Act
+	n, err := reader.Read(make([]byte, 100))
+
+	// Assert
+	assertNoError(t, err, "读取应该成功")
+	assertEqual(t, 10, n, "单次读取不应该超过自定义数据块")
+	assertEqual(t, 10*time.Millisecond, clock.Now().Sub(newFakeClock().Now()), "10 字节在 1000 B/s 下应该播放 10ms")
+}
@@ -0,0 +1,115 @@
+package ratelimited
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// =============================================================================
+// 进度回调 - 复制进度、速率与预计完成时间
+// =============================================================================
+
+// Progress 一次进度报告
+type Progress struct {
+	Bytes   int64         // 已写入的字节数
+	Total   int64         // 总字节数，未知时为 -1
+	Rate    float64       // 当前速率（字节/秒），即 Throughput 的移动平均
+	Elapsed time.Duration // 自写入器创建以来经过的时间
+	ETA     time.Duration // 预计剩余时间，总字节数未知或当前速率为 0 时为 -1
+	Done    bool          // 写入器关闭时的最后一次报告
+}
+
+// Percent 返回完成的百分比（0~100），总字节数未知时返回 -1
+func (p Progress) Percent() float64 {
+	if p.Total < 0 {
+		return -1
+	}
+	if p.Total == 0 {
+		return 100
+	}
+	return min(float64(p.Bytes)/float64(p.Total)*100, 100)
+}
+
+// progressReporter 按间隔触发进度回调
+type progressReporter struct {
+	interval time.Duration
+	fn       func(Progress)
+	total    int64 // 需要原子访问，-1 表示未知
+	start    time.Time
+	last     int64 // 上一次报告的时间 (UnixNano)，需要原子访问
+}
+
+// WithProgress 在写入期间每隔 interval 以当前进度调用 fn，并在 Close 时做最后一次报告
+//
+// 报告由写入触发，不启动后台 goroutine：长时间阻塞等待令牌期间不会报告，
+// 恢复写入后的第一次写入立即报告。interval <= 0 时每次写入都报告。
+// fn 在写入的 goroutine 中同步调用，应尽快返回；同一间隔内最多调用一次。
+// 总字节数通过 WithProgressTotal 设置，CopyNWithRateLimit 会自动设置为 n。
+//
+// 使用示例：
+//
+//	_, err := ratelimited.CopyNWithRateLimit(ctx, src, size, limiters,
+//	    ratelimited.WithProgress(time.Second, func(p ratelimited.Progress) {
+//	        log.Printf("%.1f%% %.0f B/s eta %s", p.Percent(), p.Rate, p.ETA)
+//	    }),
+//	)
+func WithProgress(interval time.Duration, fn func(Progress)) DiscardWriterOption {
+	return func(w *DiscardWriter) {
+		if fn == nil {
+			return
+		}
+		total := int64(-1)
+		if w.progress != nil {
+			total = atomic.LoadInt64(&w.progress.total)
+		}
+		w.progress = &progressReporter{interval: interval, fn: fn, total: total}
+		w.sinks = append(w.sinks, StatsSinkFunc(func(int, time.Duration) {
+			w.reportProgress(false)
+		}))
+	}
+}
+
+// WithProgressTotal 设置进度报告的总字节数，用于计算完成比例和预计剩余时间
+//
+// 与 WithProgress 的先后顺序无关；未设置 WithProgress 时不生效。
+func WithProgressTotal(total int64) DiscardWriterOption {
+	return func(w *DiscardWriter) {
+		if w.progress == nil {
+			w.progress = &progressReporter{}
+		}
+		atomic.StoreInt64(&w.progress.total, total)
+	}
+}
+
+// reportProgress 距上一次报告超过间隔时调用进度回调，final 为 true 时总是报告
+func (w *DiscardWriter) reportProgress(final bool) {
+	p := w.progress
+	if p == nil || p.fn == nil {
+		return
+	}
+	now := w.clock.Now()
+	if !final && p.interval > 0 {
+		last := atomic.LoadInt64(&p.last)
+		if now.UnixNano()-last < int64(p.interval) || !atomic.CompareAndSwapInt64(&p.last, last, now.UnixNano()) {
+			return
+		}
+	}
+
+	progress := Progress{
+		Bytes:   atomic.LoadInt64(&w.bytesTotal),
+		Total:   atomic.LoadInt64(&p.total),
+		Rate:    w.Throughput(),
+		Elapsed: now.Sub(p.start),
+		ETA:     -1,
+		Done:    final,
+	}
+	if progress.Total >= 0 {
+		switch remaining := progress.Total - progress.Bytes; {
+		case remaining <= 0:
+			progress.ETA = 0
+		case progress.Rate > 0:
+			progress.ETA = time.Duration(float64(remaining) / progress.Rate * float64(time.Second))
+		}
+	}
+	p.fn(progress)
+}
@@ -0,0 +1,83 @@
+package ratelimited
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+)
+
+// TestWithProgress 测试按间隔报告进度并在关闭时做最后一次报告
+//
+// 测试目标：
+//   - 同一间隔内最多报告一次
+//   - 报告包含已写入字节数、速率和按总量计算的预计剩余时间
+//   - Close 时总是报告，且 Done 为 true
+func TestWithProgress(t *testing.T) {
+	// Arrange: 每次写入推进虚拟时钟 100ms，每秒报告一次
+	clock := newFakeClock()
+	var reports []Progress
+	w := NewDiscardWriter([]Limiter{&advancingLimiter{clock: clock, delay: 100 * time.Millisecond}},
+		WithClock(clock),
+		WithBatchSize(100),
+		WithProgressTotal(10000),
+		WithProgress(time.Second, func(p Progress) { reports = append(reports, p) }),
+	)
+
+	// Act: 写入 3 秒
+	for range 30 {
+		_, err := w.Write(createTestData(100))
+		assertNoError(t, err, "写入")
+	}
+	assertNoError(t, w.Close(), "关闭")
+
+	// Assert
+	assertEqual(t, 4, len(reports), "3 秒内每秒报告一次，关闭时再报告一次")
+	first := reports[0]
+	assertEqual(t, int64(1000), first.Bytes, "第一次报告的字节数")
+	assertEqual(t, int64(10000), first.Total, "总字节数")
+	assertEqual(t, time.Second, first.Elapsed, "第一次报告的耗时")
+	if first.Rate <= 0 || first.ETA <= 0 {
+		t.Errorf("应该报告速率和预计剩余时间: %+v", first)
+	}
+	last := reports[len(reports)-1]
+	assertEqual(t, true, last.Done, "最后一次报告应该标记完成")
+	assertEqual(t, int64(3000), last.Bytes, "最后一次报告的字节数")
+	assertEqual(t, 30.0, last.Percent(), "完成比例")
+}
+
+// TestCopyNWithRateLimit_Progress 测试 CopyNWithRateLimit 自动设置进度总量
+func TestCopyNWithRateLimit_Progress(t *testing.T) {
+	// Arrange
+	var last Progress
+	src := bytes.NewReader(createTestData(5000))
+
+	// Act
+	n, err := CopyNWithRateLimit(context.Background(), src, 4000, nil,
+		WithProgress(time.Hour, func(p Progress) { last = p }),
+	)
+
+	// Assert
+	assertNoError(t, err, "复制")
+	assertEqual(t, int64(4000), n, "复制的字节数")
+	assertEqual(t, true, last.Done, "关闭时应该报告")
+	assertEqual(t, int64(4000), last.Total, "总量应该为 n")
+	assertEqual(t, time.Duration(0), last.ETA, "完成后预计剩余时间为 0")
+	assertEqual(t, 100.0, last.Percent(), "完成比例")
+}
+
+// TestProgress_UnknownTotal 测试总量未知时不计算比例和预计剩余时间
+func TestProgress_UnknownTotal(t *testing.T) {
+	// Arrange
+	var last Progress
+	w := NewDiscardWriter(nil, WithProgress(0, func(p Progress) { last = p }))
+
+	// Act
+	_, err := w.Write(createTestData(10))
+
+	// Assert
+	assertNoError(t, err, "写入")
+	assertEqual(t, int64(-1), last.Total, "总量未知")
+	assertEqual(t, time.Duration(-1), last.ETA, "预计剩余时间未知")
+	assertEqual(t, -1.0, last.Percent(), "完成比例未知")
+}
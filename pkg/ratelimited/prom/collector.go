@@ -0,0 +1,128 @@
+// Package prom 将 ratelimited 写入器的统计导出为 Prometheus 指标
+//
+// 使用示例：
+//
+//	collector := prom.NewCollector("downloads")
+//	collector.TrackQuota("daily", ratelimited.SharedQuota(&quota))
+//	prometheus.MustRegister(collector)
+//
+//	limiters, names := ratelimited.NewBuilder().
+//	    Add("global", globalLimiter).
+//	    Add("tenant", tenantLimiter).
+//	    BuildWithNames()
+//	w := ratelimited.NewDiscardWriter(limiters,
+//	    append(collector.Options(), ratelimited.WithLimiterNames(names))...)
+package prom
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/lwmacct/250918-go-pkg-ratelimited/pkg/ratelimited"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Collector 汇总一个或多个写入器统计的 Prometheus 收集器
+//
+// 通过 Options 返回的选项接入写入器，可以被多个写入器共享，指标是所有写入器的总和。
+// 导出的指标（namespace 为创建时传入的前缀）：
+//   - <namespace>_bytes_total：写入的字节数
+//   - <namespace>_requests_total：写入次数
+//   - <namespace>_wait_seconds_total：写入等待令牌的总时长
+//   - <namespace>_layer_wait_seconds_total{layer}：各层限制器的等待总时长，layer 为 Builder 或 WithLimiterNames 的名称
+//   - <namespace>_quota_remaining{quota}：通过 TrackQuota 登记的配额的剩余字节数
+type Collector struct {
+	bytes    int64 // 需要原子访问
+	requests int64 // 需要原子访问
+	waitNs   int64 // 需要原子访问
+
+	mu        sync.Mutex
+	layerWait map[string]time.Duration
+	quotas    map[string]ratelimited.Quota
+
+	bytesDesc     *prometheus.Desc
+	requestsDesc  *prometheus.Desc
+	waitDesc      *prometheus.Desc
+	layerWaitDesc *prometheus.Desc
+	quotaDesc     *prometheus.Desc
+}
+
+// NewCollector 创建指标名前缀为 namespace 的收集器
+func NewCollector(namespace string) *Collector {
+	return &Collector{
+		layerWait: make(map[string]time.Duration),
+		quotas:    make(map[string]ratelimited.Quota),
+
+		bytesDesc: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "bytes_total"),
+			"Bytes admitted by rate-limited writers.", nil, nil),
+		requestsDesc: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "requests_total"),
+			"Writes admitted by rate-limited writers.", nil, nil),
+		waitDesc: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "wait_seconds_total"),
+			"Total time writes spent waiting for tokens.", nil, nil),
+		layerWaitDesc: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "layer_wait_seconds_total"),
+			"Total time spent waiting on each limiter layer.", []string{"layer"}, nil),
+		quotaDesc: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "quota_remaining"),
+			"Remaining bytes of a tracked shared quota.", []string{"quota"}, nil),
+	}
+}
+
+// Options 返回把写入器接入收集器的选项
+//
+// 选项注册一个统计接收端和一个单层等待观察者，两者都是追加的，
+// 不影响写入器上已有的接收端、观察者和单层等待告警，可以与 otel 包同时使用。
+func (c *Collector) Options() []ratelimited.Option {
+	return []ratelimited.Option{
+		ratelimited.WithStatsSinks(c),
+		ratelimited.WithLayerWaitObservers(c),
+	}
+}
+
+// TrackQuota 登记一个配额，以 name 为标签导出其剩余字节数
+//
+// 共享配额指针通过 ratelimited.SharedQuota 适配。
+func (c *Collector) TrackQuota(name string, quota ratelimited.Quota) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.quotas[name] = quota
+}
+
+// RecordWrite 实现 ratelimited.StatsSink 接口
+func (c *Collector) RecordWrite(n int, waited time.Duration) {
+	atomic.AddInt64(&c.bytes, int64(n))
+	atomic.AddInt64(&c.requests, 1)
+	atomic.AddInt64(&c.waitNs, int64(waited))
+}
+
+// ObserveLayerWait 实现 ratelimited.LayerWaitObserver 接口，累计单层限制器的等待时长
+func (c *Collector) ObserveLayerWait(_ context.Context, name string, waited time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.layerWait[name] += waited
+}
+
+// Describe 实现 prometheus.Collector 接口
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.bytesDesc
+	ch <- c.requestsDesc
+	ch <- c.waitDesc
+	ch <- c.layerWaitDesc
+	ch <- c.quotaDesc
+}
+
+// Collect 实现 prometheus.Collector 接口
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	ch <- prometheus.MustNewConstMetric(c.bytesDesc, prometheus.CounterValue, float64(atomic.LoadInt64(&c.bytes)))
+	ch <- prometheus.MustNewConstMetric(c.requestsDesc, prometheus.CounterValue, float64(atomic.LoadInt64(&c.requests)))
+	ch <- prometheus.MustNewConstMetric(c.waitDesc, prometheus.CounterValue, time.Duration(atomic.LoadInt64(&c.waitNs)).Seconds())
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for name, waited := range c.layerWait {
+		ch <- prometheus.MustNewConstMetric(c.layerWaitDesc, prometheus.CounterValue, waited.Seconds(), name)
+	}
+	for name, quota := range c.quotas {
+		ch <- prometheus.MustNewConstMetric(c.quotaDesc, prometheus.GaugeValue, float64(quota.Remaining()), name)
+	}
+}
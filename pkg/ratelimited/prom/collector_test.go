@@ -0,0 +1,95 @@
+package prom
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/lwmacct/250918-go-pkg-ratelimited/pkg/ratelimited"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"golang.org/x/time/rate"
+)
+
+// TestCollector_ReportsWriterStats 测试收集器导出写入统计、分层等待和剩余配额
+//
+// 测试目标：
+//   - 字节数与写入次数汇总所有接入的写入器
+//   - 分层等待以 Builder 名称为标签
+//   - 登记的配额导出剩余字节数
+func TestCollector_ReportsWriterStats(t *testing.T) {
+	// Arrange
+	collector := NewCollector("test")
+	quota := int64(1000)
+	collector.TrackQuota("daily", ratelimited.SharedQuota(&quota))
+
+	// 突发容量很小，每个批次都需要等待
+	limiters, names := ratelimited.NewBuilder().
+		Add("global", rate.NewLimiter(10000, 10)).
+		BuildWithNames()
+	opts := append(collector.Options(),
+		ratelimited.WithLimiterNames(names),
+		ratelimited.WithBatchSize(10),
+		ratelimited.WithSharedQuota(&quota),
+	)
+	first := ratelimited.NewDiscardWriter(limiters, opts...)
+	second := ratelimited.NewDiscardWriter(limiters, opts...)
+
+	// Act
+	_, _ = first.Write(make([]byte, 100))
+	_, _ = second.Write(make([]byte, 50))
+
+	// Assert
+	expected := `
+# HELP test_bytes_total Bytes admitted by rate-limited writers.
+# TYPE test_bytes_total counter
+test_bytes_total 150
+# HELP test_quota_remaining Remaining bytes of a tracked shared quota.
+# TYPE test_quota_remaining gauge
+test_quota_remaining{quota="daily"} 850
+# HELP test_requests_total Writes admitted by rate-limited writers.
+# TYPE test_requests_total counter
+test_requests_total 2
+`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"test_bytes_total", "test_requests_total", "test_quota_remaining"); err != nil {
+		t.Error(err)
+	}
+
+	if problems, err := testutil.CollectAndLint(collector); err != nil || len(problems) > 0 {
+		t.Errorf("指标不符合规范: %v %v", problems, err)
+	}
+	collector.mu.Lock()
+	waited := collector.layerWait["global"]
+	collector.mu.Unlock()
+	if waited <= 0 {
+		t.Error("应该以 Builder 名称为标签记录分层等待")
+	}
+}
+
+// TestCollector_SharesLayerWaitObservation 测试多个收集器同时观察同一个写入器的分层等待
+//
+// 测试目标：
+//   - 接入收集器不会替换写入器上已有的观察者，每个收集器都同步收到分层等待
+func TestCollector_SharesLayerWaitObservation(t *testing.T) {
+	// Arrange
+	first := NewCollector("first")
+	second := NewCollector("second")
+	limiters, names := ratelimited.NewBuilder().
+		Add("global", rate.NewLimiter(10000, 10)).
+		BuildWithNames()
+	opts := append(first.Options(), second.Options()...)
+	opts = append(opts, ratelimited.WithLimiterNames(names), ratelimited.WithBatchSize(10))
+	writer := ratelimited.NewDiscardWriter(limiters, opts...)
+
+	// Act
+	_, _ = writer.Write(make([]byte, 100))
+
+	// Assert: 观察是同步的，写入返回时两个收集器都已记录
+	for _, c := range []*Collector{first, second} {
+		c.mu.Lock()
+		waited := c.layerWait["global"]
+		c.mu.Unlock()
+		if waited <= 0 {
+			t.Error("每个收集器都应该记录分层等待")
+		}
+	}
+}
@@ -0,0 +1,258 @@
+package ratelimited
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// =============================================================================
+// 配额管理器 - 可补充的共享配额
+// =============================================================================
+
+// QuotaManager 可在多个写入器之间共享的字节配额，支持后台定期补充
+//
+// 通过 WithQuotaManager 交给写入器后，写入器与直接使用 WithSharedQuota 时一样
+// 原子地扣除配额；管理器额外提供手动的 Acquire / Release、用量统计和定期补充。
+// 配置了补充时后台 goroutine 定期增加配额，不再使用时必须调用 Close。
+type QuotaManager struct {
+	remaining int64 // 本节点的剩余配额 (需要原子访问)
+	supplied  int64 // 累计供给量：初始配额加上所有补充 (需要原子访问)
+
+	// 配额树 (可选，见 Child)
+	name     string
+	parent   *QuotaManager
+	childMu  sync.Mutex
+	children map[string]*QuotaManager
+
+	clock       Clock
+	refill      int64         // 每次补充的字节数
+	refillEvery time.Duration // 补充间隔，0 表示不补充
+	refillCap   int64         // 补充后剩余配额的上限，0 表示不限制
+
+	budget      int64         // 每个窗口的预算，即初始配额
+	resetPeriod time.Duration // 按时间窗口重置的周期，0 表示不重置
+	nextReset   int64         // 下一次重置的时间 (UnixNano，需要原子访问)
+
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// QuotaOption 配额管理器的配置选项
+type QuotaOption func(*QuotaManager)
+
+// WithRefill 每隔 every 把剩余配额增加 amount 字节，例如每分钟补充 100MB
+func WithRefill(amount int64, every time.Duration) QuotaOption {
+	return func(q *QuotaManager) {
+		q.refill = amount
+		q.refillEvery = every
+	}
+}
+
+// WithRefillCap 限制补充后的剩余配额不超过 limit，避免长期空闲后积累过多配额
+func WithRefillCap(limit int64) QuotaOption {
+	return func(q *QuotaManager) {
+		q.refillCap = limit
+	}
+}
+
+// WithQuotaClock 设置补充使用的时钟，默认使用系统时钟
+func WithQuotaClock(c Clock) QuotaOption {
+	return func(q *QuotaManager) {
+		if c != nil {
+			q.clock = c
+		}
+	}
+}
+
+// WithQuotaReset 按固定周期重置配额，例如每天 10GB、UTC 零点重置
+//
+// 窗口边界按 period 对齐到绝对时间：24h 对齐到 UTC 零点，1h 对齐到整点。
+// 每到边界，剩余配额被重置为创建时的初始配额，已用配额归零，配额树中的所有子节点
+// 同时重置为各自的预算；窗口内配额耗尽后写入返回 ErrQuotaExhausted，直到下一个窗口开始。
+func WithQuotaReset(period time.Duration) QuotaOption {
+	return func(q *QuotaManager) {
+		q.resetPeriod = period
+	}
+}
+
+// NewQuotaManager 创建初始剩余 initial 字节的配额管理器
+//
+// 配置了 WithRefill（amount 和间隔都大于 0）时启动后台补充 goroutine，
+// 配置了 WithQuotaReset 时启动后台重置 goroutine。
+func NewQuotaManager(initial int64, opts ...QuotaOption) *QuotaManager {
+	q := &QuotaManager{
+		remaining: initial,
+		supplied:  initial,
+		budget:    initial,
+		clock:     systemClock{},
+		done:      make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(q)
+	}
+	if q.refill > 0 && q.refillEvery > 0 {
+		go q.refillLoop()
+	}
+	if q.resetPeriod > 0 {
+		q.scheduleReset()
+		go q.resetLoop()
+	}
+	return q
+}
+
+// WithQuotaManager 使用配额管理器（或配额树中的节点）作为写入器的共享配额，替换 WithSharedQuota 设置的配额
+func WithQuotaManager(q *QuotaManager) DiscardWriterOption {
+	return func(w *DiscardWriter) {
+		w.sharedRemaining = nil
+		w.quota = nil
+		if q != nil {
+			w.quota = q
+		}
+	}
+}
+
+// Used 返回本节点已经消耗的配额，即累计供给量减去剩余配额
+func (q *QuotaManager) Used() int64 {
+	return atomic.LoadInt64(&q.supplied) - atomic.LoadInt64(&q.remaining)
+}
+
+// NextReset 返回下一次按时间窗口重置配额的时间，未配置 WithQuotaReset 时返回零值
+//
+// 配额树中的节点返回最近的配置了重置的祖先节点的重置时间。
+// 可用于在配额耗尽时告诉调用方何时重试（如 HTTP 的 Retry-After）。
+func (q *QuotaManager) NextReset() time.Time {
+	owner := q.resetOwner()
+	if owner == nil {
+		return time.Time{}
+	}
+	return time.Unix(0, atomic.LoadInt64(&owner.nextReset))
+}
+
+// Close 停止后台补充和重置，之后配额只随写入和 Release 变化
+//
+// Close 是幂等的，总是返回 nil。
+func (q *QuotaManager) Close() error {
+	q.closeOnce.Do(func() {
+		close(q.done)
+	})
+	return nil
+}
+
+// refillLoop 每隔 refillEvery 补充一次配额，直到 Close 被调用
+func (q *QuotaManager) refillLoop() {
+	for {
+		select {
+		case <-q.done:
+			return
+		case <-q.clock.After(q.refillEvery):
+		}
+		q.add(q.refill)
+	}
+}
+
+// =============================================================================
+// 配额接口 - 写入器扣除配额的统一入口
+// =============================================================================
+
+// Quota 写入器扣除字节配额的来源
+//
+// 共享配额指针（WithSharedQuota）和 QuotaManager 都以该接口接入写入器；
+// 分布式配额等其他实现通过 WithQuota 接入。所有方法都必须是并发安全的。
+type Quota interface {
+	// AcquireUpTo 扣除至多 n 字节，返回实际扣除量和扣除前的可用配额；没有可用配额时扣除 0
+	AcquireUpTo(n int64) (acquired, available int64)
+	// Acquire 扣除 n 字节，可用配额不足时不扣除并返回 false
+	Acquire(n int64) bool
+	// Release 无条件归还 n 字节；n 为负数时表示补扣（例如估算计费的结算）
+	Release(n int64)
+	// Remaining 返回当前可用的配额，可能为负数
+	Remaining() int64
+}
+
+// WithQuota 使用任意 Quota 实现作为写入器的共享配额，替换之前设置的配额
+func WithQuota(q Quota) DiscardWriterOption {
+	return func(w *DiscardWriter) {
+		w.sharedRemaining = nil
+		w.quota = q
+	}
+}
+
+// SharedQuota 把 WithSharedQuota 使用的共享配额指针适配为 Quota
+//
+// 用于需要 Quota 的场景，例如 prom 与 otel 包的 TrackQuota；对 p 的操作都是原子的。
+func SharedQuota(p *int64) Quota {
+	return sharedQuota{p}
+}
+
+// sharedQuota 基于 WithSharedQuota 共享指针的配额
+type sharedQuota struct {
+	p *int64
+}
+
+func (q sharedQuota) AcquireUpTo(n int64) (int64, int64) {
+	for {
+		current := atomic.LoadInt64(q.p)
+		if current <= 0 {
+			return 0, current
+		}
+		taken := min(n, current)
+		if atomic.CompareAndSwapInt64(q.p, current, current-taken) {
+			return taken, current
+		}
+		// 如果CAS失败，说明其他goroutine修改了配额，重试
+	}
+}
+
+func (q sharedQuota) Acquire(n int64) bool {
+	for {
+		current := atomic.LoadInt64(q.p)
+		if current < n {
+			return false
+		}
+		if atomic.CompareAndSwapInt64(q.p, current, current-n) {
+			return true
+		}
+	}
+}
+
+func (q sharedQuota) Release(n int64)  { atomic.AddInt64(q.p, n) }
+func (q sharedQuota) Remaining() int64 { return atomic.LoadInt64(q.p) }
+
+// resetLoop 在每个窗口边界重置配额，直到 Close 被调用
+func (q *QuotaManager) resetLoop() {
+	for {
+		next := time.Unix(0, atomic.LoadInt64(&q.nextReset))
+		select {
+		case <-q.done:
+			return
+		case <-q.clock.After(next.Sub(q.clock.Now())):
+		}
+		q.resetSubtree()
+		q.scheduleReset()
+	}
+}
+
+// scheduleReset 把下一次重置时间设为当前时间之后的第一个窗口边界
+func (q *QuotaManager) scheduleReset() {
+	next := q.clock.Now().Truncate(q.resetPeriod).Add(q.resetPeriod)
+	atomic.StoreInt64(&q.nextReset, next.UnixNano())
+}
+
+// add 增加 n 字节配额，受补充上限约束，返回实际增加的字节数
+func (q *QuotaManager) add(n int64) int64 {
+	for {
+		current := atomic.LoadInt64(&q.remaining)
+		delta := n
+		if q.refillCap > 0 {
+			delta = min(delta, q.refillCap-current)
+		}
+		if delta <= 0 {
+			return 0
+		}
+		if atomic.CompareAndSwapInt64(&q.remaining, current, current+delta) {
+			atomic.AddInt64(&q.supplied, delta)
+			return delta
+		}
+	}
+}
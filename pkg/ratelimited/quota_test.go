@@ -0,0 +1,120 @@
+package ratelimited
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// TestQuotaManager_AcquireRelease 测试手动扣除与归还配额
+func TestQuotaManager_AcquireRelease(t *testing.T) {
+	// Arrange
+	qm := NewQuotaManager(100)
+
+	// Act & Assert
+	assertEqual(t, true, qm.Acquire(60), "剩余充足时应该扣除成功")
+	assertEqual(t, false, qm.Acquire(50), "剩余不足时应该扣除失败")
+	assertEqual(t, int64(40), qm.Remaining(), "失败的扣除不应该改变剩余配额")
+	qm.Release(20)
+	assertEqual(t, int64(60), qm.Remaining(), "归还应该增加剩余配额")
+	assertEqual(t, int64(40), qm.Used(), "归还应该减少已用配额")
+}
+
+// TestQuotaManager_Writers 测试多个写入器共享配额管理器
+func TestQuotaManager_Writers(t *testing.T) {
+	// Arrange
+	qm := NewQuotaManager(100)
+	limiters := Chain(rate.NewLimiter(rate.Inf, 0))
+	first := NewDiscardWriter(limiters, WithQuotaManager(qm))
+	second := NewDiscardWriter(limiters, WithQuotaManager(qm))
+
+	// Act
+	_, err := first.Write(createTestData(70))
+	assertNoError(t, err, "配额充足时应该写入成功")
+	n, err := second.Write(createTestData(50))
+	assertNoError(t, err, "配额部分充足时应该短写")
+	_, err = second.Write(createTestData(1))
+
+	// Assert
+	assertEqual(t, 30, n, "第二个写入器应该只接收剩余的配额")
+	if !errors.Is(err, ErrQuotaExhausted) {
+		t.Errorf("配额耗尽时应该返回 ErrQuotaExhausted，实际 %v", err)
+	}
+	assertEqual(t, int64(100), qm.Used(), "写入器的扣除应该计入已用配额")
+	assertEqual(t, int64(0), second.Stats().QuotaRemaining, "写入器统计应该反映管理器的剩余配额")
+}
+
+// TestQuotaManager_Refill 测试后台定期补充及补充上限
+func TestQuotaManager_Refill(t *testing.T) {
+	// Arrange
+	clock := newFakeClock()
+	qm := NewQuotaManager(10,
+		WithRefill(100, time.Minute),
+		WithRefillCap(150),
+		WithQuotaClock(clock),
+	)
+	defer qm.Close()
+	writer := NewDiscardWriter(Chain(rate.NewLimiter(rate.Inf, 0)), WithQuotaManager(qm))
+	_, err := writer.Write(createTestData(10))
+	assertNoError(t, err, "初始配额内应该写入成功")
+
+	// Act: 第一次补充
+	clock.waitForWaiters(t, 1)
+	clock.Advance(time.Minute)
+	clock.waitForWaiters(t, 1)
+
+	// Assert
+	assertEqual(t, int64(100), qm.Remaining(), "应该补充 100 字节")
+	_, err = writer.Write(createTestData(100))
+	assertNoError(t, err, "补充后应该可以继续写入")
+
+	// Act: 连续补充受上限约束
+	clock.Advance(time.Minute)
+	clock.waitForWaiters(t, 1)
+	clock.Advance(time.Minute)
+	clock.waitForWaiters(t, 1)
+
+	// Assert
+	assertEqual(t, int64(150), qm.Remaining(), "补充后的剩余配额不应该超过上限")
+	assertEqual(t, int64(110), qm.Used(), "被上限截断的部分不应该计入供给")
+}
+
+// TestQuotaManager_Reset 测试按时间窗口重置配额
+//
+// 测试目标：
+//   - 窗口内配额耗尽后写入返回 ErrQuotaExhausted
+//   - 窗口边界对齐到绝对时间，到达边界时配额重置、已用归零
+func TestQuotaManager_Reset(t *testing.T) {
+	// Arrange: 虚拟时钟起始于 UTC 零点，推进到 10:00
+	clock := newFakeClock()
+	clock.Advance(10 * time.Hour)
+	qm := NewQuotaManager(100, WithQuotaReset(24*time.Hour), WithQuotaClock(clock))
+	defer qm.Close()
+	writer := NewDiscardWriter(Chain(rate.NewLimiter(rate.Inf, 0)), WithQuotaManager(qm))
+
+	// Act
+	_, err := writer.Write(createTestData(100))
+	assertNoError(t, err, "窗口预算内应该写入成功")
+	_, err = writer.Write(createTestData(1))
+
+	// Assert
+	if !errors.Is(err, ErrQuotaExhausted) {
+		t.Errorf("窗口内配额耗尽时应该返回 ErrQuotaExhausted，实际 %v", err)
+	}
+	midnight := time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC)
+	assertEqual(t, true, qm.NextReset().Equal(midnight), "下一次重置应该在 UTC 零点")
+
+	// Act: 推进到次日零点
+	clock.waitForWaiters(t, 1)
+	clock.Advance(14 * time.Hour)
+	clock.waitForWaiters(t, 1)
+
+	// Assert
+	assertEqual(t, int64(100), qm.Remaining(), "新窗口应该重置为完整预算")
+	assertEqual(t, int64(0), qm.Used(), "新窗口的已用配额应该归零")
+	assertEqual(t, true, qm.NextReset().Equal(midnight.Add(24*time.Hour)), "应该安排下一个窗口的重置")
+	_, err = writer.Write(createTestData(50))
+	assertNoError(t, err, "新窗口应该可以继续写入")
+}
@@ -0,0 +1,116 @@
+package ratelimited
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+)
+
+// =============================================================================
+// 配额持久化 - 跨进程重启保存预算
+// =============================================================================
+
+// quotaSnapshot 配额管理器（含子节点）的持久化状态
+type quotaSnapshot struct {
+	Remaining int64                    `json:"remaining"`
+	Supplied  int64                    `json:"supplied"`
+	SavedAt   time.Time                `json:"saved_at"`
+	Children  map[string]quotaSnapshot `json:"children,omitempty"`
+}
+
+// Save 以 JSON 格式把配额状态写入 dst，包括所有子节点
+//
+// 状态带有保存时间，Load 据此判断按时间窗口重置的配额是否已经进入新的窗口。
+// 并发写入期间保存的状态只反映调用时刻的近似值。
+func (q *QuotaManager) Save(dst io.Writer) error {
+	return json.NewEncoder(dst).Encode(q.snapshot(q.clock.Now()))
+}
+
+// Load 从 src 读取 Save 写出的状态并恢复剩余配额和用量
+//
+// 配置了 WithQuotaReset 且保存时间与当前时间不在同一个窗口时，保存的用量已经过期，
+// 本节点和所有子节点保持新窗口的完整预算；子节点按根节点的窗口判断。
+// 停机期间的定期补充不会补发。
+// 状态中存在而本地没有的子节点以保存时的累计供给量为预算创建。
+// 应在写入开始之前调用，与并发写入同时进行时结果不确定。
+func (q *QuotaManager) Load(src io.Reader) error {
+	var snap quotaSnapshot
+	if err := json.NewDecoder(src).Decode(&snap); err != nil {
+		return fmt.Errorf("ratelimited: decode quota state: %w", err)
+	}
+	q.restore(snap, q.clock.Now())
+	return nil
+}
+
+// SaveFile 把配额状态保存到 path，先写临时文件再重命名，避免崩溃时留下不完整的文件
+func (q *QuotaManager) SaveFile(path string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // 重命名成功后是空操作
+
+	if err := q.Save(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// LoadFile 从 path 恢复配额状态，见 Load
+func (q *QuotaManager) LoadFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return q.Load(f)
+}
+
+// snapshot 递归导出本节点及子节点的状态
+func (q *QuotaManager) snapshot(now time.Time) quotaSnapshot {
+	snap := quotaSnapshot{
+		Remaining: atomic.LoadInt64(&q.remaining),
+		Supplied:  atomic.LoadInt64(&q.supplied),
+		SavedAt:   now,
+	}
+
+	q.childMu.Lock()
+	defer q.childMu.Unlock()
+	if len(q.children) > 0 {
+		snap.Children = make(map[string]quotaSnapshot, len(q.children))
+		for name, child := range q.children {
+			snap.Children[name] = child.snapshot(now)
+		}
+	}
+	return snap
+}
+
+// restore 递归恢复本节点及子节点的状态
+func (q *QuotaManager) restore(snap quotaSnapshot, now time.Time) {
+	if !q.windowRolled(snap.SavedAt, now) {
+		atomic.StoreInt64(&q.supplied, snap.Supplied)
+		atomic.StoreInt64(&q.remaining, snap.Remaining)
+	}
+	for name, childSnap := range snap.Children {
+		q.Child(name, childSnap.Supplied).restore(childSnap, now)
+	}
+}
+
+// windowRolled 判断 savedAt 与 now 是否处于不同的重置窗口
+//
+// 子节点随祖先节点一起重置，使用最近的配置了重置的祖先节点的窗口。
+func (q *QuotaManager) windowRolled(savedAt, now time.Time) bool {
+	owner := q.resetOwner()
+	if owner == nil {
+		return false
+	}
+	return !savedAt.Truncate(owner.resetPeriod).Equal(now.Truncate(owner.resetPeriod))
+}
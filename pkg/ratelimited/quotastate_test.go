@@ -0,0 +1,102 @@
+package ratelimited
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestQuotaManager_SaveLoad 测试配额状态的保存与恢复
+//
+// 测试目标：
+//   - 剩余配额、用量和子节点状态在恢复后保持一致
+//   - 文件保存与恢复
+//   - 按时间窗口重置的配额在窗口已滚动时不恢复过期的用量
+func TestQuotaManager_SaveLoad(t *testing.T) {
+	t.Run("往返恢复", func(t *testing.T) {
+		// Arrange
+		saved := NewQuotaManager(1000)
+		saved.Acquire(300)
+		saved.Child("alice", 500).Acquire(200)
+		var buf bytes.Buffer
+
+		// Act
+		err := saved.Save(&buf)
+		assertNoError(t, err, "保存应该成功")
+		restored := NewQuotaManager(1000)
+		err = restored.Load(&buf)
+		assertNoError(t, err, "恢复应该成功")
+
+		// Assert
+		assertEqual(t, int64(500), restored.Remaining(), "应该恢复剩余配额")
+		assertEqual(t, int64(500), restored.Used(), "应该恢复用量")
+		alice := restored.Child("alice", 0)
+		assertEqual(t, int64(300), alice.Remaining(), "应该恢复子节点的剩余配额")
+		assertEqual(t, int64(200), alice.Used(), "应该恢复子节点的用量")
+	})
+
+	t.Run("文件", func(t *testing.T) {
+		// Arrange
+		path := filepath.Join(t.TempDir(), "quota.json")
+		saved := NewQuotaManager(100)
+		saved.Acquire(40)
+
+		// Act
+		err := saved.SaveFile(path)
+		assertNoError(t, err, "保存到文件应该成功")
+		restored := NewQuotaManager(100)
+		err = restored.LoadFile(path)
+
+		// Assert
+		assertNoError(t, err, "从文件恢复应该成功")
+		assertEqual(t, int64(60), restored.Remaining(), "应该恢复剩余配额")
+	})
+
+	t.Run("窗口已滚动", func(t *testing.T) {
+		// Arrange: 在第一天保存，在第二天恢复
+		clock := newFakeClock()
+		saved := NewQuotaManager(100, WithQuotaReset(24*time.Hour), WithQuotaClock(clock))
+		defer saved.Close()
+		saved.Acquire(80)
+		var sameDay, nextDay bytes.Buffer
+		assertNoError(t, saved.Save(&sameDay), "保存应该成功")
+		assertNoError(t, saved.Save(&nextDay), "保存应该成功")
+
+		// Act
+		clock.Advance(time.Hour)
+		sameWindow := NewQuotaManager(100, WithQuotaReset(24*time.Hour), WithQuotaClock(clock))
+		defer sameWindow.Close()
+		assertNoError(t, sameWindow.Load(&sameDay), "恢复应该成功")
+		sameRemaining := sameWindow.Remaining()
+		clock.Advance(24 * time.Hour)
+		newWindow := NewQuotaManager(100, WithQuotaReset(24*time.Hour), WithQuotaClock(clock))
+		defer newWindow.Close()
+		assertNoError(t, newWindow.Load(&nextDay), "恢复应该成功")
+
+		// Assert
+		assertEqual(t, int64(20), sameRemaining, "同一窗口内应该恢复用量")
+		assertEqual(t, int64(100), newWindow.Remaining(), "窗口滚动后应该保持完整预算")
+	})
+
+	t.Run("窗口已滚动的子节点", func(t *testing.T) {
+		// Arrange: 子节点自身没有重置设置，按根节点的窗口判断
+		clock := newFakeClock()
+		saved := NewQuotaManager(1000, WithQuotaReset(24*time.Hour), WithQuotaClock(clock))
+		defer saved.Close()
+		saved.Child("alice", 100).Acquire(80)
+		var buf bytes.Buffer
+		assertNoError(t, saved.Save(&buf), "保存应该成功")
+
+		// Act
+		clock.Advance(24 * time.Hour)
+		restored := NewQuotaManager(1000, WithQuotaReset(24*time.Hour), WithQuotaClock(clock))
+		defer restored.Close()
+		assertNoError(t, restored.Load(&buf), "恢复应该成功")
+
+		// Assert
+		alice := restored.Child("alice", 0)
+		assertEqual(t, int64(100), alice.Remaining(), "窗口滚动后子节点不应该恢复过期的用量")
+		assertEqual(t, int64(0), alice.Used(), "子节点的用量应该归零")
+	})
+}
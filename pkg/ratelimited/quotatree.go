@@ -0,0 +1,144 @@
+package ratelimited
+
+import "sync/atomic"
+
+// =============================================================================
+// 配额树 - 父子层级的预算
+// =============================================================================
+
+// QuotaNode 配额树中的节点，例如账户配额下按用户划分的子配额
+//
+// 节点就是带有父节点的 QuotaManager，可以直接通过 WithQuotaManager 交给写入器。
+// 从节点扣除配额时，本节点和所有祖先节点都必须有足够的剩余，扣除全部成功或全部不发生；
+// 退还和补扣同样作用于整条路径。
+type QuotaNode = QuotaManager
+
+// Child 返回名为 name、预算为 limit 字节的子节点，子节点从本节点的配额中扣除
+//
+// 已存在同名子节点时直接返回该节点，limit 被忽略。根节点配置了 WithQuotaReset 时，
+// 每个窗口边界整棵树一起重置，子节点恢复为 limit；子节点不继承 WithRefill 的补充，
+// 其预算只随重置恢复。
+func (q *QuotaManager) Child(name string, limit int64) *QuotaNode {
+	q.childMu.Lock()
+	defer q.childMu.Unlock()
+
+	if child, ok := q.children[name]; ok {
+		return child
+	}
+	child := &QuotaManager{
+		remaining: limit,
+		supplied:  limit,
+		budget:    limit,
+		name:      name,
+		parent:    q,
+		clock:     q.clock,
+		done:      make(chan struct{}),
+	}
+	if q.children == nil {
+		q.children = make(map[string]*QuotaManager)
+	}
+	q.children[name] = child
+	return child
+}
+
+// resetSubtree 把本节点及所有子节点恢复为各自的完整预算
+func (q *QuotaManager) resetSubtree() {
+	atomic.StoreInt64(&q.supplied, q.budget)
+	atomic.StoreInt64(&q.remaining, q.budget)
+
+	q.childMu.Lock()
+	children := make([]*QuotaManager, 0, len(q.children))
+	for _, child := range q.children {
+		children = append(children, child)
+	}
+	q.childMu.Unlock()
+	for _, child := range children {
+		child.resetSubtree()
+	}
+}
+
+// resetOwner 返回决定本节点重置窗口的节点：本节点或最近的配置了重置的祖先，没有时返回 nil
+func (q *QuotaManager) resetOwner() *QuotaManager {
+	for node := q; node != nil; node = node.parent {
+		if node.resetPeriod > 0 {
+			return node
+		}
+	}
+	return nil
+}
+
+// Name 返回节点的名称，NewQuotaManager 创建的根节点名称为空
+func (q *QuotaManager) Name() string {
+	return q.name
+}
+
+// Parent 返回父节点，根节点返回 nil
+func (q *QuotaManager) Parent() *QuotaNode {
+	return q.parent
+}
+
+// AcquireUpTo 扣除至多 n 字节，返回实际扣除量和扣除前的可用配额
+//
+// 对配额树中的节点，按路径上最小的剩余配额扣除。
+func (q *QuotaManager) AcquireUpTo(n int64) (int64, int64) {
+	for {
+		available := q.Remaining()
+		if available <= 0 {
+			return 0, available
+		}
+		taken := min(n, available)
+		if q.Acquire(taken) {
+			return taken, available
+		}
+		// 其他写入器在读取与扣除之间修改了路径上的配额，重试
+	}
+}
+
+// Acquire 从配额中扣除 n 字节，剩余不足时不扣除并返回 false
+//
+// 对配额树中的节点，从本节点到根节点依次扣除，任何一级不足时回滚已扣除的部分，
+// 扣除全部成功或全部不发生。
+func (q *QuotaManager) Acquire(n int64) bool {
+	for node := q; node != nil; node = node.parent {
+		if !node.takeLocal(n) {
+			for undo := q; undo != node; undo = undo.parent {
+				atomic.AddInt64(&undo.remaining, n)
+			}
+			return false
+		}
+	}
+	return true
+}
+
+// takeLocal 只在本节点上扣除 n 字节
+func (q *QuotaManager) takeLocal(n int64) bool {
+	for {
+		current := atomic.LoadInt64(&q.remaining)
+		if current < n {
+			return false
+		}
+		if atomic.CompareAndSwapInt64(&q.remaining, current, current-n) {
+			return true
+		}
+	}
+}
+
+// Release 把 n 字节归还给配额（包括所有祖先节点），用于撤销未实际使用的 Acquire
+//
+// n 为负数时无条件补扣，可能使配额变为负数。
+func (q *QuotaManager) Release(n int64) {
+	for node := q; node != nil; node = node.parent {
+		atomic.AddInt64(&node.remaining, n)
+	}
+}
+
+// Remaining 返回当前可用的配额，估算计费的结算可能使其为负数
+//
+// 对配额树中的节点，返回本节点与所有祖先节点剩余配额中的最小值。
+func (q *QuotaManager) Remaining() int64 {
+	least := atomic.LoadInt64(&q.remaining)
+	for node := q.parent; node != nil; node = node.parent {
+		least = min(least, atomic.LoadInt64(&node.remaining))
+	}
+	return least
+}
@@ -0,0 +1,85 @@
+package ratelimited
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// TestQuotaNode_Hierarchy 测试子配额从父配额中扣除
+//
+// 测试目标：
+//   - 写入同时受子节点和父节点的剩余配额约束
+//   - 任何一级不足时 Acquire 整体失败，不留下部分扣除
+//   - Release 作用于整条路径
+func TestQuotaNode_Hierarchy(t *testing.T) {
+	// Arrange: 账户 100 字节，两个用户各 80 字节
+	account := NewQuotaManager(100)
+	alice := account.Child("alice", 80)
+	bob := account.Child("bob", 80)
+	limiters := Chain(rate.NewLimiter(rate.Inf, 0))
+	aliceWriter := NewDiscardWriter(limiters, WithQuotaManager(alice))
+	bobWriter := NewDiscardWriter(limiters, WithQuotaManager(bob))
+
+	// Act
+	_, err := aliceWriter.Write(createTestData(70))
+	assertNoError(t, err, "用户与账户配额都充足时应该写入成功")
+	n, err := bobWriter.Write(createTestData(50))
+
+	// Assert
+	assertNoError(t, err, "账户配额部分充足时应该短写")
+	assertEqual(t, 30, n, "应该只接收账户剩余的配额")
+	assertEqual(t, int64(0), bob.Remaining(), "子节点的可用配额应该受父节点约束")
+	assertEqual(t, int64(30), bob.Used(), "子节点应该记录自身的用量")
+	assertEqual(t, int64(100), account.Used(), "父节点应该记录所有子节点的用量")
+	_, err = bobWriter.Write(createTestData(1))
+	if !errors.Is(err, ErrQuotaExhausted) {
+		t.Errorf("父节点耗尽时应该返回 ErrQuotaExhausted，实际 %v", err)
+	}
+
+	t.Run("原子扣除与归还", func(t *testing.T) {
+		// Act
+		alice.Release(20)
+		acquired := bob.Acquire(30)
+
+		// Assert
+		assertEqual(t, false, acquired, "父节点不足时应该整体失败")
+		assertEqual(t, int64(30), bob.Used(), "失败的扣除不应该改变子节点")
+		assertEqual(t, int64(20), account.Remaining(), "归还应该作用于父节点")
+		assertEqual(t, true, bob.Acquire(20), "各级都充足时应该扣除成功")
+		assertEqual(t, int64(0), account.Remaining(), "扣除应该作用于父节点")
+	})
+
+	t.Run("同名子节点", func(t *testing.T) {
+		// Assert
+		assertEqual(t, alice, account.Child("alice", 999), "同名子节点应该返回已有节点")
+		assertEqual(t, "alice", alice.Name(), "应该记录节点名称")
+		assertEqual(t, account, alice.Parent(), "应该记录父节点")
+	})
+}
+
+// TestQuotaNode_ResetWithRoot 测试子节点在根节点的窗口边界一起重置
+//
+// 测试目标：
+//   - 根节点按窗口重置时，已耗尽的子节点恢复为各自的预算
+//   - 子节点的下一次重置时间取自根节点
+func TestQuotaNode_ResetWithRoot(t *testing.T) {
+	// Arrange
+	clock := newFakeClock()
+	root := NewQuotaManager(1000, WithQuotaReset(time.Hour), WithQuotaClock(clock))
+	defer root.Close()
+	alice := root.Child("alice", 100)
+	assertEqual(t, true, alice.Acquire(100), "子节点预算内的扣除应该成功")
+
+	// Act
+	clock.waitForWaiters(t, 1)
+	clock.Advance(time.Hour)
+	clock.waitForWaiters(t, 1)
+
+	// Assert
+	assertEqual(t, int64(100), alice.Remaining(), "子节点应该随根节点重置")
+	assertEqual(t, int64(0), alice.Used(), "子节点的用量应该归零")
+	assertEqual(t, root.NextReset(), alice.NextReset(), "子节点的重置时间应该取自根节点")
+}
@@ -0,0 +1,66 @@
+package ratelimited
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// =============================================================================
+// 配额感知等待 - 配额将尽时快速失败
+// =============================================================================
+
+// quotaWaitBudget 配额充足时单次写入等待令牌的参考期限，实际期限按剩余配额比例缩短
+const quotaWaitBudget = time.Second
+
+// QuotaWaitError 配额将尽时，写入预计需要的等待超过了按剩余配额缩短的期限
+//
+// 可通过 errors.As 取出预计等待和期限；errors.Is(err, context.DeadlineExceeded) 同样成立。
+type QuotaWaitError struct {
+	Estimated time.Duration // 某一层预计需要的等待时间
+	Budget    time.Duration // 按剩余配额计算的等待期限
+}
+
+// Error 实现 error 接口
+func (e *QuotaWaitError) Error() string {
+	return fmt.Sprintf("ratelimited: estimated wait %s exceeds quota-aware budget %s", e.Estimated, e.Budget)
+}
+
+// Unwrap 返回 context.DeadlineExceeded
+func (e *QuotaWaitError) Unwrap() error {
+	return context.DeadlineExceeded
+}
+
+// WithQuotaAwareWait 启用配额感知的等待期限
+//
+// 共享配额即将耗尽时，长时间等待令牌往往是浪费：写入会被截断为很小的一段，
+// 或者紧接着就因配额耗尽而失败。启用后，当预留前的剩余配额小于请求写入的字节数时，
+// 本次写入的令牌等待期限为 1s × 剩余配额 / 请求字节数，剩余越少期限越短，按写入器的时钟计时。
+// 支持预留的层（如 *rate.Limiter）在等待前按预留延迟判断：任何一层超出期限时
+// 撤销所有层的预留、回滚配额并返回 *QuotaWaitError，不会跳过该层用其余层的令牌放行；
+// 不支持预留的层最多等待到期限，超时返回 context.DeadlineExceeded。
+//
+// 这是启发式规则：配额充足或未设置共享配额时不受影响，等待仍只受写入器上下文约束；
+// 期限与限制器的实际速率无关，速率很低的链在配额将尽时可能每次都快速失败。
+func WithQuotaAwareWait(enabled bool) DiscardWriterOption {
+	return func(w *DiscardWriter) {
+		w.quotaAwareWait = enabled
+	}
+}
+
+// quotaWaitKey 记录配额感知期限的上下文键
+type quotaWaitKey struct{}
+
+// quotaWaitContext 返回期限按 remaining/size 比例缩短的上下文，期限按时钟 c 计算
+//
+// 期限同时记录在上下文中，供预留阶段直接与预留延迟比较。
+func quotaWaitContext(ctx context.Context, c Clock, remaining int64, size int) (context.Context, context.CancelFunc) {
+	budget := time.Duration(float64(quotaWaitBudget) * float64(remaining) / float64(size))
+	return withClockTimeout(context.WithValue(ctx, quotaWaitKey{}, budget), c, budget)
+}
+
+// quotaWaitLimit 返回 ctx 中的配额感知期限
+func quotaWaitLimit(ctx context.Context) (time.Duration, bool) {
+	budget, ok := ctx.Value(quotaWaitKey{}).(time.Duration)
+	return budget, ok
+}
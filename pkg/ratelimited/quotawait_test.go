@@ -0,0 +1,120 @@
+package ratelimited
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// sleepyLimiter 每个令牌等待固定时长的限制器，等待期间响应上下文取消
+type sleepyLimiter struct {
+	perToken time.Duration
+}
+
+func (l *sleepyLimiter) WaitN(ctx context.Context, n int) error {
+	select {
+	case <-time.After(time.Duration(n) * l.perToken):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// TestWithQuotaAwareWait_FailsFastNearExhaustion 测试配额将尽时写入快速失败
+//
+// 测试目标：
+//   - 剩余配额远小于写入大小时，等待期限按比例缩短，写入在完整等待之前返回
+//   - 失败的写入回滚预留的配额
+//   - 配额充足的写入不受影响
+func TestWithQuotaAwareWait_FailsFastNearExhaustion(t *testing.T) {
+	// Arrange: 剩余 100 字节需要等待 2s，而期限只有 1s × 100/10000 = 10ms
+	limiter := &sleepyLimiter{perToken: 20 * time.Millisecond}
+	quota := int64(100)
+	writer := NewDiscardWriter([]Limiter{limiter},
+		WithSharedQuota(&quota),
+		WithQuotaAwareWait(true),
+	)
+
+	// Act
+	start := time.Now()
+	n, err := writer.Write(createTestData(10000))
+	elapsed := time.Since(start)
+
+	// Assert
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("期望 context.DeadlineExceeded，实际为 %v", err)
+	}
+	assertEqual(t, 0, n, "失败的写入不应该接收字节")
+	if elapsed > 500*time.Millisecond {
+		t.Errorf("写入耗时 %v，应该远快于完整等待的 2s", elapsed)
+	}
+	assertAtomicEqual(t, 100, &quota, "配额应该被回滚")
+
+	// 配额充足时按正常等待完成
+	n, err = writer.Write(createTestData(1))
+	assertNoError(t, err, "配额充足的写入应该成功")
+	assertEqual(t, 1, n, "应该接收全部字节")
+}
+
+// TestWithQuotaAwareWait_Clock 测试配额感知的期限按写入器的时钟计算
+//
+// 虚拟时钟停在过去的某个时刻，期限若按实际时间计算则永远不会被判定为超出，写入会一直等待。
+func TestWithQuotaAwareWait_Clock(t *testing.T) {
+	// Arrange: 100 B/s 且突发已耗尽，100 字节需要等待 1s，期限只有 1s × 100/10000 = 10ms
+	clock := newFakeClock()
+	limiter := rate.NewLimiter(100, 100)
+	limiter.ReserveN(clock.Now(), 100)
+	quota := int64(100)
+	writer := NewDiscardWriter([]Limiter{limiter},
+		WithClock(clock),
+		WithSharedQuota(&quota),
+		WithQuotaAwareWait(true),
+	)
+
+	// Act
+	_, err := writer.Write(createTestData(10000))
+
+	// Assert
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("期望 context.DeadlineExceeded，实际为 %v", err)
+	}
+	assertAtomicEqual(t, 100, &quota, "配额应该被回滚")
+}
+
+// TestWithQuotaAwareWait_MultiLayer 测试任意一层超出配额感知期限时整次写入快速失败
+//
+// 测试目标：
+//   - 超出期限的层不会被跳过，写入不会只凭其余层的令牌放行
+//   - 返回带预计等待和期限的 *QuotaWaitError，且仍满足 errors.Is(err, context.DeadlineExceeded)
+//   - 所有层的预留被撤销，配额被回滚
+func TestWithQuotaAwareWait_MultiLayer(t *testing.T) {
+	// Arrange: 宽松层立即放行，紧张层需要等待 1s，期限只有 10ms
+	clock := newFakeClock()
+	roomy := rate.NewLimiter(1000, 1000)
+	tight := rate.NewLimiter(100, 100)
+	tight.ReserveN(clock.Now(), 100)
+	quota := int64(100)
+	writer := NewDiscardWriter([]Limiter{roomy, tight},
+		WithClock(clock),
+		WithSharedQuota(&quota),
+		WithQuotaAwareWait(true),
+	)
+
+	// Act
+	n, err := writer.Write(createTestData(10000))
+
+	// Assert
+	var qwErr *QuotaWaitError
+	if !errors.As(err, &qwErr) {
+		t.Fatalf("期望 *QuotaWaitError，实际为 %v", err)
+	}
+	assertEqual(t, time.Second, qwErr.Estimated, "预计等待应该来自紧张层")
+	assertEqual(t, 10*time.Millisecond, qwErr.Budget, "期限应该按剩余配额计算")
+	assertEqual(t, true, errors.Is(err, context.DeadlineExceeded), "应该仍然表现为超时")
+	assertEqual(t, 0, n, "失败的写入不应该接收字节")
+	assertAtomicEqual(t, 100, &quota, "配额应该被回滚")
+	assertEqual(t, float64(1000), roomy.TokensAt(clock.Now()), "宽松层的预留应该被撤销")
+}
@@ -0,0 +1,141 @@
+package ratelimited
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// =============================================================================
+// 预热限制器 - 从低速率逐步提升到目标速率
+// =============================================================================
+
+// RampCurve 预热期间速率的增长曲线
+type RampCurve int
+
+const (
+	// RampLinear 速率随时间线性增长（默认）
+	RampLinear RampCurve = iota
+	// RampExponential 速率随时间指数增长，前期增长慢、后期增长快
+	RampExponential
+)
+
+// RampLimiter 在预热期内额外限制速率的装饰器，实现 Limiter 接口
+//
+// 预热从第一次 WaitN 开始，速率在 over 时长内从 from 增长到 to，期间每次申请
+// 同时受预热速率和内部限制器约束；预热结束后只由内部限制器决定，预热层不再有任何开销。
+// 适合新启动的传输避免一开始就冲击冷缓存或链路。
+type RampLimiter struct {
+	inner Limiter
+	clock Clock
+	from  rate.Limit
+	to    rate.Limit
+	over  time.Duration
+	curve RampCurve
+
+	mu    sync.Mutex
+	ramp  *rate.Limiter // 预热速率的令牌桶
+	start time.Time     // 预热开始时间，零值表示尚未开始
+	done  bool
+}
+
+// RampOption 预热限制器的配置选项
+type RampOption func(*RampLimiter)
+
+// WithRampCurve 设置速率的增长曲线，默认为 RampLinear
+func WithRampCurve(curve RampCurve) RampOption {
+	return func(r *RampLimiter) {
+		r.curve = curve
+	}
+}
+
+// WithRampClock 设置计算预热进度使用的时钟，默认使用系统时钟
+func WithRampClock(c Clock) RampOption {
+	return func(r *RampLimiter) {
+		if c != nil {
+			r.clock = c
+		}
+	}
+}
+
+// NewRampLimiter 包装 inner，使速率在 over 时长内从 from 提升到 to
+//
+// 预热令牌桶的突发容量取内部限制器的突发容量，无法内省时使用默认批次大小。
+// from 应大于 0；指数曲线下 from 为 0 时按线性曲线处理。
+func NewRampLimiter(inner Limiter, from, to rate.Limit, over time.Duration, opts ...RampOption) *RampLimiter {
+	burst := defaultBatchSize
+	if _, b, ok := introspect(inner); ok && b > 0 {
+		burst = b
+	}
+	r := &RampLimiter{
+		inner: inner,
+		clock: systemClock{},
+		from:  from,
+		to:    to,
+		over:  over,
+		ramp:  rate.NewLimiter(from, burst),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// WaitN 实现 Limiter 接口，预热期间先按当前预热速率等待，再向内部限制器申请
+func (r *RampLimiter) WaitN(ctx context.Context, n int) error {
+	if ramp := r.current(); ramp != nil {
+		if err := waitRate(ctx, r.clock, ramp, n); err != nil {
+			return err
+		}
+	}
+	return r.inner.WaitN(ctx, n)
+}
+
+// Limit 返回当前的预热速率，预热结束后返回 to
+func (r *RampLimiter) Limit() rate.Limit {
+	if ramp := r.current(); ramp != nil {
+		return ramp.Limit()
+	}
+	return r.to
+}
+
+// Reset 重新开始预热，下一次 WaitN 时从 from 开始
+func (r *RampLimiter) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.start = time.Time{}
+	r.done = false
+	r.ramp.SetLimitAt(r.clock.Now(), r.from)
+}
+
+// current 更新并返回预热令牌桶，预热结束后返回 nil
+func (r *RampLimiter) current() *rate.Limiter {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.done {
+		return nil
+	}
+
+	now := r.clock.Now()
+	if r.start.IsZero() {
+		r.start = now
+	}
+	elapsed := now.Sub(r.start)
+	if r.over <= 0 || elapsed >= r.over {
+		r.done = true
+		return nil
+	}
+	r.ramp.SetLimitAt(now, r.rateAt(float64(elapsed)/float64(r.over)))
+	return r.ramp
+}
+
+// rateAt 返回预热进度为 progress (0~1) 时的速率
+func (r *RampLimiter) rateAt(progress float64) rate.Limit {
+	if r.curve == RampExponential && r.from > 0 {
+		return r.from * rate.Limit(math.Pow(float64(r.to/r.from), progress))
+	}
+	return r.from + (r.to-r.from)*rate.Limit(progress)
+}
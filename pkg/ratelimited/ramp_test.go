@@ -0,0 +1,59 @@
+package ratelimited
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// TestRampLimiter_Linear 测试线性预热的速率变化
+//
+// 测试目标：
+//   - 预热从第一次申请开始，初始速率为 from
+//   - 速率随时间线性增长
+//   - 预热结束后只由内部限制器决定
+func TestRampLimiter_Linear(t *testing.T) {
+	// Arrange
+	clock := newFakeClock()
+	inner := &countingRequestLimiter{}
+	ramp := NewRampLimiter(inner, 100, 1100, 10*time.Second, WithRampClock(clock))
+	ctx := context.Background()
+
+	// Act & Assert
+	clock.Advance(time.Hour) // 创建之后的空闲不计入预热
+	assertNoError(t, ramp.WaitN(ctx, 1), "第一次申请")
+	assertEqual(t, rate.Limit(100), ramp.Limit(), "预热开始时的速率")
+
+	clock.Advance(5 * time.Second)
+	assertEqual(t, rate.Limit(600), ramp.Limit(), "预热过半时的速率")
+
+	clock.Advance(5 * time.Second)
+	assertEqual(t, rate.Limit(1100), ramp.Limit(), "预热结束后的速率")
+	assertNoError(t, ramp.WaitN(ctx, 1<<20), "预热结束后不受预热令牌桶的突发容量限制")
+	assertAtomicEqual(t, 2, &inner.calls, "每次申请都应该经过内部限制器")
+
+	ramp.Reset()
+	assertEqual(t, rate.Limit(100), ramp.Limit(), "Reset 后重新从 from 开始")
+}
+
+// TestRampLimiter_Exponential 测试指数预热的速率变化
+func TestRampLimiter_Exponential(t *testing.T) {
+	// Arrange
+	clock := newFakeClock()
+	ramp := NewRampLimiter(rate.NewLimiter(rate.Inf, 0), 10, 1000, 10*time.Second,
+		WithRampClock(clock), WithRampCurve(RampExponential))
+
+	// Act
+	start := ramp.Limit()
+	clock.Advance(5 * time.Second)
+	half := ramp.Limit()
+
+	// Assert
+	assertEqual(t, rate.Limit(10), start, "预热开始时的速率")
+	if math.Abs(float64(half)-100) > 1e-6 {
+		t.Errorf("指数预热过半时速率应为 100，实际 %v", half)
+	}
+}
@@ -0,0 +1,56 @@
+package ratelimitedtest
+
+import (
+	"sync/atomic"
+	"testing"
+
+	"github.com/lwmacct/250918-go-pkg-ratelimited/pkg/ratelimited"
+)
+
+// =============================================================================
+// 断言辅助函数 - 计数器与配额
+// =============================================================================
+
+// Counter 写入器使用的计数器类型：字节计数器为 int64，请求计数器为 uint64
+type Counter interface {
+	int64 | uint64
+}
+
+// AssertCounter 原子地读取 counter 并断言其值等于 want
+//
+// 适用于 WithBytesCounter、WithRequestCounter、WithSharedQuota 等选项使用的计数器。
+func AssertCounter[T Counter](t testing.TB, counter *T, want T, msg string) {
+	t.Helper()
+	if got := loadCounter(counter); got != want {
+		t.Errorf("ratelimitedtest: %s: counter = %d, want %d", msg, got, want)
+	}
+}
+
+// AssertCounterBetween 原子地读取 counter 并断言其值在 [lo, hi] 范围内
+//
+// 真实时间下的测试可以用它容忍调度带来的误差。
+func AssertCounterBetween[T Counter](t testing.TB, counter *T, lo, hi T, msg string) {
+	t.Helper()
+	if got := loadCounter(counter); got < lo || got > hi {
+		t.Errorf("ratelimitedtest: %s: counter = %d, want in [%d, %d]", msg, got, lo, hi)
+	}
+}
+
+// AssertQuotaRemaining 断言配额 q 的剩余量等于 want
+func AssertQuotaRemaining(t testing.TB, q ratelimited.Quota, want int64, msg string) {
+	t.Helper()
+	if got := q.Remaining(); got != want {
+		t.Errorf("ratelimitedtest: %s: quota remaining = %d, want %d", msg, got, want)
+	}
+}
+
+// loadCounter 按计数器的类型原子读取
+func loadCounter[T Counter](counter *T) T {
+	switch c := any(counter).(type) {
+	case *int64:
+		return T(atomic.LoadInt64(c))
+	case *uint64:
+		return T(atomic.LoadUint64(c))
+	}
+	panic("unreachable")
+}
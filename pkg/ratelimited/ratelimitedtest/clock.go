@@ -0,0 +1,95 @@
+package ratelimitedtest
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lwmacct/250918-go-pkg-ratelimited/pkg/ratelimited"
+)
+
+// =============================================================================
+// 虚拟时钟 - 让依赖时间的测试在虚拟时间中运行
+// =============================================================================
+
+// FakeClock 只在 Advance 时推进的虚拟时钟，实现 ratelimited.Clock 接口，并发安全
+//
+// 通过 ratelimited.WithClock、WithGCRAClock 等选项注入后，限制器和写入器的等待
+// 只有在测试调用 Advance 时才会结束，不再依赖真实的 sleep。
+type FakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []fakeWaiter
+}
+
+// fakeWaiter 等待虚拟时间到达 at 的 After 调用
+type fakeWaiter struct {
+	at time.Time
+	ch chan time.Time
+}
+
+// NewFakeClock 创建起始于固定时间点（2025-01-01 00:00:00 UTC）的虚拟时钟
+func NewFakeClock() *FakeClock {
+	return &FakeClock{now: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+// Now 实现 ratelimited.Clock 接口
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// After 实现 ratelimited.Clock 接口，d <= 0 时返回的通道立即可读
+func (c *FakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- c.now
+		return ch
+	}
+	c.waiters = append(c.waiters, fakeWaiter{at: c.now.Add(d), ch: ch})
+	return ch
+}
+
+// Advance 推进虚拟时间并触发所有到期的等待者
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+	pending := c.waiters[:0]
+	for _, waiter := range c.waiters {
+		if !waiter.at.After(c.now) {
+			waiter.ch <- c.now
+			continue
+		}
+		pending = append(pending, waiter)
+	}
+	c.waiters = pending
+}
+
+// Waiters 返回尚未到期的等待者数量
+func (c *FakeClock) Waiters() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.waiters)
+}
+
+// BlockUntilWaiters 阻塞直到至少有 n 个等待者注册，用于与后台 goroutine 同步
+//
+// 2 秒（真实时间）内没有达到 n 个等待者时判定测试失败。
+func (c *FakeClock) BlockUntilWaiters(t testing.TB, n int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for c.Waiters() < n {
+		if time.Now().After(deadline) {
+			t.Fatalf("ratelimitedtest: timed out waiting for %d clock waiters, have %d", n, c.Waiters())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+var _ ratelimited.Clock = (*FakeClock)(nil)
@@ -0,0 +1,130 @@
+// Package ratelimitedtest 提供验证 ratelimited 限制器链行为的测试辅助函数
+//
+// 包括速率合规性检查（VerifyRateCompliance）、虚拟时钟（FakeClock）、
+// 可编排的模拟限制器（MockLimiter）以及计数器和配额的断言函数。
+// 与 net/http/httptest 类似，该包只应在测试代码中导入。
+package ratelimitedtest
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lwmacct/250918-go-pkg-ratelimited/pkg/ratelimited"
+)
+
+// Writers 为 VerifyRateCompliance 并发写入的 goroutine 数量
+const Writers = 8
+
+// burstIntrospector 可以报告突发容量的限制器，*rate.Limiter 实现了该接口
+type burstIntrospector interface {
+	Burst() int
+}
+
+// VerifyRateCompliance 在真实时间下对限制器链施压，验证准入速率不超过 expectedMax
+//
+// 在 dur 时间内，Writers 个 goroutine 通过同一个 DiscardWriter 持续写入，
+// 统计被准入的字节数。准入量超过 expectedMax×耗时 加上突发容量时判定失败。
+// 突发容量取链中可内省限制器（如 *rate.Limiter）的最大 Burst()，
+// 都不可内省时按 expectedMax 的一秒用量计算。
+//
+// 写入混合使用小于和大于批次大小的两种块，同时覆盖批次内消费与跨批次申请；
+// 批次大小按最小的突发容量选取，保证单次申请不超过任何一层的突发容量。
+// 该函数使用真实时钟和真实的限流路径，目的是发现批量令牌等实现中的并发缺陷，
+// 因此会实际运行 dur 时长；dur 过短时突发容量占比过大，检测会变得不灵敏。
+func VerifyRateCompliance(t testing.TB, limiters []ratelimited.Limiter, expectedMax float64, dur time.Duration) {
+	t.Helper()
+
+	minBurst, maxBurst := burstRange(limiters, expectedMax)
+	batch := clamp(minBurst/4, 1, 64*1024)
+	small := clamp(batch/2, 1, batch)
+	large := 3 * small // 大于批次，需要跨批次申请
+
+	// 按时到期取消而不是设置 deadline，避免 rate.Limiter 在 deadline 前提前拒绝等待
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	timer := time.AfterFunc(dur, cancel)
+	defer timer.Stop()
+
+	var admitted int64
+	writer := ratelimited.NewDiscardWriter(limiters,
+		ratelimited.WithContext(ctx),
+		ratelimited.WithBatchSize(batch),
+		ratelimited.WithBytesCounter(&admitted),
+	)
+
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+	)
+	buf := make([]byte, large)
+	start := time.Now()
+	for i := 0; i < Writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := i; ctx.Err() == nil; j++ {
+				p := buf[:large]
+				if j%4 == 0 {
+					p = buf[:small]
+				}
+				if _, err := writer.Write(p); err != nil {
+					if ctx.Err() == nil {
+						errOnce.Do(func() { firstErr = err })
+					}
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	if firstErr != nil {
+		t.Errorf("ratelimitedtest: write failed before %v elapsed: %v", dur, firstErr)
+	}
+
+	got := atomic.LoadInt64(&admitted)
+	limit := expectedMax*elapsed.Seconds() + float64(maxBurst)
+	if float64(got) > limit {
+		t.Errorf("ratelimitedtest: admitted %d bytes in %v (%.0f B/s), exceeds %.0f B/s plus burst %d (limit %.0f bytes)",
+			got, elapsed, float64(got)/elapsed.Seconds(), expectedMax, maxBurst, limit)
+	}
+}
+
+// burstRange 返回链中可内省限制器的最小与最大突发容量
+//
+// 都不可内省时两者均为 expectedMax 一秒的用量。
+func burstRange(limiters []ratelimited.Limiter, expectedMax float64) (minBurst, maxBurst int64) {
+	for _, l := range limiters {
+		bi, ok := l.(burstIntrospector)
+		if !ok {
+			continue
+		}
+		b := int64(bi.Burst())
+		if minBurst == 0 || b < minBurst {
+			minBurst = b
+		}
+		if b > maxBurst {
+			maxBurst = b
+		}
+	}
+	if maxBurst == 0 {
+		minBurst, maxBurst = int64(expectedMax), int64(expectedMax)
+	}
+	return minBurst, maxBurst
+}
+
+// clamp 将 v 限制在 [lo, hi] 范围内
+func clamp(v, lo, hi int64) int64 {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
@@ -0,0 +1,159 @@
+package ratelimitedtest
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/lwmacct/250918-go-pkg-ratelimited/pkg/ratelimited"
+)
+
+// =============================================================================
+// 可编排的模拟限制器 - 注入延迟和错误并记录调用
+// =============================================================================
+
+// MockStep 模拟限制器一次 WaitN 调用的行为
+type MockStep struct {
+	Delay time.Duration // 返回前等待的时长
+	Err   error         // 等待结束后返回的错误
+}
+
+// MockCall 模拟限制器记录的一次 WaitN 调用
+type MockCall struct {
+	N   int       // 申请的令牌数
+	At  time.Time // 调用开始的时间（按模拟限制器的时钟）
+	Err error     // 返回的错误
+}
+
+// MockLimiter 按脚本返回延迟和错误、并记录所有调用的限制器，实现 ratelimited.Limiter
+// 和 ratelimited.TokenReturner 接口，并发安全
+//
+// 第 i 次 WaitN 按脚本的第 i 步执行，脚本用完后按默认行为（WithMockDelay、WithMockError）执行。
+// 延迟通过注入的时钟等待，ctx 在延迟期间结束时返回其错误。
+type MockLimiter struct {
+	mu       sync.Mutex
+	clock    ratelimited.Clock
+	script   []MockStep
+	fallback MockStep
+	calls    []MockCall
+	returned int64
+}
+
+// MockOption 模拟限制器的配置选项
+type MockOption func(*MockLimiter)
+
+// WithMockScript 设置前若干次调用的行为，可多次调用追加
+func WithMockScript(steps ...MockStep) MockOption {
+	return func(m *MockLimiter) {
+		m.script = append(m.script, steps...)
+	}
+}
+
+// WithMockDelay 设置脚本之外的调用返回前等待的时长
+func WithMockDelay(d time.Duration) MockOption {
+	return func(m *MockLimiter) {
+		m.fallback.Delay = d
+	}
+}
+
+// WithMockError 设置脚本之外的调用返回的错误
+func WithMockError(err error) MockOption {
+	return func(m *MockLimiter) {
+		m.fallback.Err = err
+	}
+}
+
+// WithMockClock 设置等待延迟和记录调用时间使用的时钟，默认使用系统时钟
+func WithMockClock(c ratelimited.Clock) MockOption {
+	return func(m *MockLimiter) {
+		if c != nil {
+			m.clock = c
+		}
+	}
+}
+
+// NewMockLimiter 创建模拟限制器，默认立即放行所有申请
+func NewMockLimiter(opts ...MockOption) *MockLimiter {
+	m := &MockLimiter{clock: ratelimited.SystemClock()}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// WaitN 实现 ratelimited.Limiter 接口
+func (m *MockLimiter) WaitN(ctx context.Context, n int) error {
+	m.mu.Lock()
+	step := m.fallback
+	if len(m.calls) < len(m.script) {
+		step = m.script[len(m.calls)]
+	}
+	index := len(m.calls)
+	m.calls = append(m.calls, MockCall{N: n, At: m.clock.Now()})
+	m.mu.Unlock()
+
+	err := ratelimited.Sleep(ctx, m.clock, step.Delay)
+	if err == nil {
+		err = step.Err
+	}
+
+	m.mu.Lock()
+	m.calls[index].Err = err
+	m.mu.Unlock()
+	return err
+}
+
+// ReturnN 实现 ratelimited.TokenReturner 接口，只记录归还的令牌数
+func (m *MockLimiter) ReturnN(n int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.returned += int64(n)
+}
+
+// Calls 返回已记录调用的副本
+func (m *MockLimiter) Calls() []MockCall {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]MockCall(nil), m.calls...)
+}
+
+// CallCount 返回 WaitN 的调用次数
+func (m *MockLimiter) CallCount() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.calls)
+}
+
+// Granted 返回成功授予的令牌总数
+func (m *MockLimiter) Granted() int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var total int64
+	for _, call := range m.calls {
+		if call.Err == nil {
+			total += int64(call.N)
+		}
+	}
+	return total
+}
+
+// Returned 返回通过 ReturnN 归还的令牌总数
+func (m *MockLimiter) Returned() int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.returned
+}
+
+// Reset 清空调用记录和归还计数，脚本重新从第一步开始
+func (m *MockLimiter) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls = nil
+	m.returned = 0
+}
+
+var (
+	_ ratelimited.Limiter       = (*MockLimiter)(nil)
+	_ ratelimited.TokenReturner = (*MockLimiter)(nil)
+)
@@ -0,0 +1,77 @@
+package ratelimited
+
+import (
+	"errors"
+	"io"
+)
+
+// =============================================================================
+// 限流读取器 - 对任意 io.Reader 的消费速度应用限制器链
+// =============================================================================
+
+// ThrottledReader 按限制器链限制从源读取速度的读取器
+//
+// 与 DiscardWriter 共用批量令牌、共享配额、上下文和统计的全部机制：
+// 每次从源读到数据后，按读到的字节数申请令牌并更新计数器，再把数据交给调用方。
+// 准入与 Write、Charge 走同一路径，分阶段限制（WithPhaseLimiters）同样按读到的累计字节切换链。
+type ThrottledReader struct {
+	src io.Reader
+	w   *DiscardWriter
+}
+
+// NewReader 创建按限制器链限速读取 src 的读取器
+//
+// 使用示例：
+//
+//	body := ratelimited.NewReader(resp.Body, ratelimited.Chain(limiter),
+//	    ratelimited.WithContext(ctx),
+//	    ratelimited.WithBytesCounter(&received),
+//	)
+//	data, err := io.ReadAll(body)
+func NewReader(src io.Reader, limiters []Limiter, opts ...Option) *ThrottledReader {
+	return &ThrottledReader{src: src, w: NewDiscardWriter(limiters, opts...)}
+}
+
+// Read 实现 io.Reader 接口
+//
+// 设置共享配额时每次最多读取剩余配额大小的数据，配额耗尽后返回 io.EOF 而不是 ErrQuotaExhausted，以符合 io.Reader 的约定。
+// 多个读取器并发共享同一配额时，读到的数据可能在准入时被其他读取器抢先用掉配额，
+// 此时只返回配额覆盖的部分，其余数据被丢弃。令牌等待失败（如上下文取消）时
+// 本次读到的数据不返回给调用方。
+func (r *ThrottledReader) Read(p []byte) (int, error) {
+	if quota := r.w.quota; quota != nil {
+		remaining := quota.Remaining()
+		if remaining <= 0 {
+			return 0, io.EOF
+		}
+		if int64(len(p)) > remaining {
+			p = p[:remaining]
+		}
+	}
+
+	n, err := r.src.Read(p)
+	if n <= 0 {
+		return 0, err
+	}
+
+	admitted, werr := r.w.admitCharged(p[:n], n)
+	r.w.countSize(admitted)
+	if errors.Is(werr, ErrQuotaExhausted) {
+		return admitted, io.EOF
+	}
+	if werr != nil {
+		return admitted, werr
+	}
+	if admitted < n && err == nil {
+		err = io.EOF // 配额在读取与准入之间被耗尽
+	}
+	return admitted, err
+}
+
+// Close 归还未使用的批次令牌并停止内部写入器的后台 goroutine（见 DiscardWriter.Close）
+//
+// 不关闭源，源的生命周期由调用方管理。Close 是幂等的，总是返回 nil。
+// 共享限制器链时，读取结束后应调用 Close，否则最多批次大小减一个预取的令牌无法归还。
+func (r *ThrottledReader) Close() error {
+	return r.w.Close()
+}
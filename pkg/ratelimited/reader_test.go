@@ -0,0 +1,94 @@
+package ratelimited
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+)
+
+// TestNewReader_SharesWriterMachinery 测试限流读取器复用配额、计数器和批量令牌
+//
+// 测试目标：
+//   - 配额耗尽后读取以 io.EOF 结束，读到的数据不超过配额
+//   - 字节计数器按读到的字节数更新
+//   - 令牌按批次申请
+func TestNewReader_SharesWriterMachinery(t *testing.T) {
+	t.Run("配额与计数器", func(t *testing.T) {
+		// Arrange
+		source := createTestData(1000)
+		quota := int64(600)
+		var bytesRead int64
+		reader := NewReader(bytes.NewReader(source), []Limiter{&countingLimiter{}},
+			WithSharedQuota(&quota),
+			WithBytesCounter(&bytesRead),
+		)
+
+		// Act
+		data, err := io.ReadAll(reader)
+
+		// Assert
+		assertNoError(t, err, "配额耗尽应该表现为正常结束")
+		if !bytes.Equal(source[:600], data) {
+			t.Error("应该读到配额内的原样数据")
+		}
+		assertAtomicEqual(t, 600, &bytesRead, "字节计数器应该按读到的字节数更新")
+		assertAtomicEqual(t, 0, &quota, "配额应该被耗尽")
+	})
+
+	t.Run("批量令牌", func(t *testing.T) {
+		// Arrange
+		limiter := &countingLimiter{}
+		reader := NewReader(&chunkedReader{r: bytes.NewReader(createTestData(1000)), size: 100},
+			[]Limiter{limiter}, WithBatchSize(500))
+
+		// Act
+		data, err := io.ReadAll(reader)
+
+		// Assert
+		assertNoError(t, err, "读取应该成功")
+		assertEqual(t, 1000, len(data), "应该读到全部数据")
+		assertEqual(t, int64(2), limiter.callCount(), "10 次读取应该只申请 2 个批次")
+	})
+
+	t.Run("上下文取消", func(t *testing.T) {
+		// Arrange
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		reader := NewReader(bytes.NewReader(createTestData(10)), []Limiter{&countingLimiter{}}, WithContext(ctx))
+
+		// Act
+		n, err := reader.Read(make([]byte, 10))
+
+		// Assert
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("期望 context.Canceled，实际为 %v", err)
+		}
+		assertEqual(t, 0, n, "取消后不应该返回数据")
+	})
+}
+
+// TestNewReader_PhaseLimiters 测试限流读取器应用分阶段限制
+//
+// 测试目标：
+//   - 前 k 字节在初始阶段链上申请令牌，其余在稳定阶段链上申请
+//   - 跨越边界的读取在边界处拆分
+func TestNewReader_PhaseLimiters(t *testing.T) {
+	// Arrange
+	readPhase := &countingLimiter{}
+	steady := &countingLimiter{}
+	reader := NewReader(&chunkedReader{r: bytes.NewReader(createTestData(300)), size: 150}, nil,
+		WithPhaseLimiters(200, []Limiter{readPhase}, []Limiter{steady}),
+		WithBatchSize(1),
+	)
+
+	// Act
+	data, err := io.ReadAll(reader)
+
+	// Assert
+	assertNoError(t, err, "读取应该成功")
+	assertEqual(t, 300, len(data), "应该读到全部数据")
+	assertEqual(t, int64(200), readPhase.tokenCount(), "前 200 字节应该使用初始阶段链")
+	assertEqual(t, int64(100), steady.tokenCount(), "其余字节应该使用稳定阶段链")
+}
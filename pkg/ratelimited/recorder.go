@@ -0,0 +1,66 @@
+package ratelimited
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// =============================================================================
+// 统计记录器 - 字节、请求与等待的可插拔后端
+// =============================================================================
+
+// StatsRecorder 写入统计的记录后端
+//
+// 写入器在热路径上同步调用记录器，实现必须是并发安全的，且应尽量廉价（例如原子累加）。
+// 与 StatsSink 按每次写入汇报不同，记录器按统计项分别累加，便于直接对接计数器类型的指标后端；
+// WithBytesCounter 和 WithRequestCounter 的计数器指针就是只实现了其中一项的记录器。
+type StatsRecorder interface {
+	// AddBytes 累加写入器接收的字节数
+	AddBytes(n int64)
+	// AddRequests 累加写入次数；设置了 WithRequestCounterGranularity 时按粒度批量提交
+	AddRequests(n uint64)
+	// AddWait 累加写入等待令牌的时长，只在发生等待时调用
+	AddWait(d time.Duration)
+}
+
+// WithRecorder 追加统计记录器，可多次调用；nil 记录器被忽略
+//
+// 使用示例：
+//
+//	w := ratelimited.NewDiscardWriter(limiters, ratelimited.WithRecorder(metrics))
+func WithRecorder(r StatsRecorder) DiscardWriterOption {
+	return func(w *DiscardWriter) {
+		if r != nil {
+			w.recorders = append(w.recorders, r)
+		}
+	}
+}
+
+// bytesCounter 把字节数累加到计数器指针的记录器，用于 WithBytesCounter
+type bytesCounter struct {
+	p *int64
+}
+
+func (c bytesCounter) AddBytes(n int64)    { atomic.AddInt64(c.p, n) }
+func (bytesCounter) AddRequests(uint64)    {}
+func (bytesCounter) AddWait(time.Duration) {}
+
+// requestCounter 把请求次数累加到计数器指针的记录器，用于 WithRequestCounter
+type requestCounter struct {
+	p *uint64
+}
+
+func (requestCounter) AddBytes(int64)         {}
+func (c requestCounter) AddRequests(n uint64) { atomic.AddUint64(c.p, n) }
+func (requestCounter) AddWait(time.Duration)  {}
+
+// withoutRecorders 返回去掉所有 T 类型记录器后的新切片，不修改 recorders
+func withoutRecorders[T StatsRecorder](recorders []StatsRecorder) []StatsRecorder {
+	var out []StatsRecorder
+	for _, r := range recorders {
+		if _, ok := r.(T); !ok {
+			out = append(out, r)
+		}
+	}
+	return out
+}
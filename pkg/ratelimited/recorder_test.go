@@ -0,0 +1,55 @@
+package ratelimited
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testRecorder 原子累加所有统计项的记录器
+type testRecorder struct {
+	bytes    int64
+	requests uint64
+	waitNs   int64
+}
+
+func (r *testRecorder) AddBytes(n int64)        { atomic.AddInt64(&r.bytes, n) }
+func (r *testRecorder) AddRequests(n uint64)    { atomic.AddUint64(&r.requests, n) }
+func (r *testRecorder) AddWait(d time.Duration) { atomic.AddInt64(&r.waitNs, int64(d)) }
+
+// TestWithRecorder 测试统计记录器与计数器指针并存
+//
+// 测试目标：
+//   - 记录器收到字节数、请求次数和等待时长
+//   - WithBytesCounter 的替换语义只影响字节计数器，不移除记录器
+//   - 请求计数粒度同样适用于记录器
+func TestWithRecorder(t *testing.T) {
+	// Arrange
+	clock := newFakeClock()
+	recorder := &testRecorder{}
+	var replaced, bytesWritten int64
+	var requests uint64
+	w := NewDiscardWriter([]Limiter{&advancingLimiter{clock: clock, delay: 10 * time.Millisecond}},
+		WithClock(clock),
+		WithBatchSize(100),
+		WithBytesCounter(&replaced),
+		WithRecorder(recorder),
+		WithBytesCounter(&bytesWritten),
+		WithRequestCounter(&requests),
+		WithRequestCounterGranularity(2),
+	)
+
+	// Act
+	for range 3 {
+		_, err := w.Write(createTestData(100))
+		assertNoError(t, err, "写入")
+	}
+
+	// Assert
+	assertAtomicEqual(t, 0, &replaced, "被替换的字节计数器不应该再累加")
+	assertAtomicEqual(t, 300, &bytesWritten, "字节计数器")
+	assertAtomicEqual(t, 300, &recorder.bytes, "记录器的字节数")
+	assertEqual(t, uint64(2), atomic.LoadUint64(&requests), "请求计数器按粒度提交")
+	assertEqual(t, uint64(2), atomic.LoadUint64(&recorder.requests), "记录器的请求次数同样按粒度提交")
+	assertAtomicEqual(t, int64(30*time.Millisecond), &recorder.waitNs, "记录器的等待时长")
+}
@@ -0,0 +1,32 @@
+// Package redisrate 基于 Redis 的 ratelimited.Store 实现，让多个进程共享限制器链中的同一层和同一份配额
+//
+// 令牌桶状态保存在 Redis 的一个哈希键中，由 Lua 脚本原子地补充和扣除，
+// 每次 WaitN 只需要一次往返。使用示例：
+//
+//	client := redis.NewClient(&redis.Options{Addr: "localhost:6379"})
+//	fleet := redisrate.NewLimiter(client, "bw:fleet", 100<<20, 1<<20)
+//	limiters := ratelimited.ChainAny(fleet, rate.NewLimiter(10<<20, 1<<20))
+//
+// Quota 提供集群共享的字节预算，通过 ratelimited.WithQuota 接入写入器：
+//
+//	budget := redisrate.NewQuota(client, "quota:daily")
+//	defer budget.Close()
+//	w := ratelimited.NewDiscardWriter(limiters, ratelimited.WithQuota(budget))
+//
+// 降级行为、本地回退限制器和租约大小通过 ratelimited.WithFailMode、
+// ratelimited.WithFallback 和 ratelimited.WithLeaseSize 配置。
+package redisrate
+
+import (
+	"github.com/lwmacct/250918-go-pkg-ratelimited/pkg/ratelimited"
+	"github.com/redis/go-redis/v9"
+	"golang.org/x/time/rate"
+)
+
+// NewLimiter 创建以 key 为令牌桶、速率为 limit、突发容量为 burst 的分布式限制器
+//
+// 共享同一 key 的所有进程共同消耗同一个令牌桶，它们应当使用相同的 limit 和 burst。
+// 等同于 ratelimited.NewStoreLimiter(NewStore(client), key, limit, burst, opts...)。
+func NewLimiter(client redis.Cmdable, key string, limit rate.Limit, burst int, opts ...ratelimited.StoreOption) *ratelimited.StoreLimiter {
+	return ratelimited.NewStoreLimiter(NewStore(client), key, limit, burst, opts...)
+}
@@ -0,0 +1,132 @@
+package redisrate
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/lwmacct/250918-go-pkg-ratelimited/pkg/ratelimited"
+	"github.com/redis/go-redis/v9"
+	"golang.org/x/time/rate"
+)
+
+// newTestClient 启动内存 Redis 并返回连接它的客户端
+func newTestClient(t *testing.T) (*miniredis.Miniredis, *redis.Client) {
+	t.Helper()
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return server, client
+}
+
+// countingLimiter 记录 WaitN 调用次数的本地限制器
+type countingLimiter struct {
+	calls int
+}
+
+func (c *countingLimiter) WaitN(ctx context.Context, n int) error {
+	c.calls++
+	return nil
+}
+
+// TestLimiter_SharedBucket 测试多个限制器实例共享同一个令牌桶
+//
+// 测试目标：
+//   - 突发容量内的申请立即放行
+//   - 不同实例的消耗计入同一个桶，超出后需要等待补充
+//   - 等待会超过上下文 deadline 时立即失败且不扣除令牌
+func TestLimiter_SharedBucket(t *testing.T) {
+	// Arrange: 每秒 1000 个令牌，突发 100
+	_, client := newTestClient(t)
+	first := NewLimiter(client, "bucket", 1000, 100)
+	second := NewLimiter(client, "bucket", 1000, 100)
+	ctx := context.Background()
+
+	// Act & Assert
+	start := time.Now()
+	if err := first.WaitN(ctx, 100); err != nil {
+		t.Fatalf("突发容量内应该立即放行: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 50*time.Millisecond {
+		t.Errorf("突发容量内不应该等待，实际 %v", elapsed)
+	}
+
+	short, cancel := context.WithTimeout(ctx, 5*time.Millisecond)
+	defer cancel()
+	if err := second.WaitN(short, 50); err == nil {
+		t.Error("桶已被另一个实例耗尽，等待超过 deadline 时应该返回错误")
+	}
+
+	start = time.Now()
+	if err := second.WaitN(ctx, 50); err != nil {
+		t.Fatalf("等待补充后应该放行: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 30*time.Millisecond {
+		t.Errorf("另一个实例的消耗应该计入同一个桶，实际只等待了 %v", elapsed)
+	}
+}
+
+// TestLimiter_FailModes 测试 Redis 不可用时的降级行为
+func TestLimiter_FailModes(t *testing.T) {
+	// Arrange: 关闭 Redis 模拟不可用，不重试以免拖慢测试
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr(), MaxRetries: -1})
+	t.Cleanup(func() { client.Close() })
+	server.Close()
+	ctx := context.Background()
+
+	t.Run("FailOpen", func(t *testing.T) {
+		// Act
+		err := NewLimiter(client, "k", 10, 10, ratelimited.WithFailMode(ratelimited.FailOpen)).WaitN(ctx, 5)
+
+		// Assert
+		if err != nil {
+			t.Errorf("FailOpen 应该直接放行，实际 %v", err)
+		}
+	})
+
+	t.Run("FailClosed", func(t *testing.T) {
+		// Act
+		err := NewLimiter(client, "k", 10, 10, ratelimited.WithFailMode(ratelimited.FailClosed)).WaitN(ctx, 5)
+
+		// Assert
+		if !errors.Is(err, ratelimited.ErrStoreUnavailable) {
+			t.Errorf("FailClosed 应该返回 ErrStoreUnavailable，实际 %v", err)
+		}
+	})
+
+	t.Run("FailLocal", func(t *testing.T) {
+		// Arrange
+		fallback := &countingLimiter{}
+
+		// Act
+		err := NewLimiter(client, "k", 10, 10, ratelimited.WithFallback(fallback)).WaitN(ctx, 5)
+
+		// Assert
+		if err != nil {
+			t.Errorf("FailLocal 应该由本地限制器放行，实际 %v", err)
+		}
+		if fallback.calls != 1 {
+			t.Errorf("应该调用本地限制器一次，实际 %d 次", fallback.calls)
+		}
+	})
+}
+
+// TestLimiter_InWriterChain 测试分布式限制器作为写入器链中的一层
+func TestLimiter_InWriterChain(t *testing.T) {
+	// Arrange
+	_, client := newTestClient(t)
+	fleet := NewLimiter(client, "fleet", rate.Inf, 0)
+	local := NewLimiter(client, "local", 1<<20, 64*1024)
+	writer := ratelimited.NewDiscardWriter(ratelimited.ChainAny(fleet, local))
+
+	// Act
+	n, err := writer.Write(make([]byte, 4096))
+
+	// Assert
+	if err != nil || n != 4096 {
+		t.Errorf("写入应该成功，实际 n=%d err=%v", n, err)
+	}
+}
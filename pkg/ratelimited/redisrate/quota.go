@@ -0,0 +1,36 @@
+package redisrate
+
+import (
+	"context"
+
+	"github.com/lwmacct/250918-go-pkg-ratelimited/pkg/ratelimited"
+	"github.com/redis/go-redis/v9"
+)
+
+// Quota 基于 Redis 的分布式字节配额，实现 ratelimited.Quota 接口
+//
+// 整个集群共享 Redis 中一个整数键表示的预算，租用和归还的行为见 ratelimited.StoreQuota。
+type Quota struct {
+	*ratelimited.StoreQuota
+	store *Store
+	key   string
+}
+
+// NewQuota 创建使用 Redis 键 key 作为共享预算的分布式配额
+//
+// 预算由运维方通过 SET 或 SetBudget 设置，本类型只做扣除和归还。
+func NewQuota(client redis.Cmdable, key string, opts ...ratelimited.StoreOption) *Quota {
+	store := NewStore(client)
+	return &Quota{
+		StoreQuota: ratelimited.NewStoreQuota(store, key, opts...),
+		store:      store,
+		key:        key,
+	}
+}
+
+// SetBudget 把集群共享的剩余预算设为 n 字节，通常在每个计费周期开始时由单个进程调用
+func (q *Quota) SetBudget(ctx context.Context, n int64) error {
+	return q.store.SetQuota(ctx, q.key, n)
+}
+
+var _ ratelimited.Quota = (*Quota)(nil)
@@ -0,0 +1,91 @@
+package redisrate
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/lwmacct/250918-go-pkg-ratelimited/pkg/ratelimited"
+	"github.com/redis/go-redis/v9"
+	"golang.org/x/time/rate"
+)
+
+// TestQuota_SharedBudget 测试多个进程的写入器共享 Redis 中的预算
+//
+// 测试目标：
+//   - 写入从本地租约中扣除，不需要每次访问 Redis
+//   - 预算耗尽后写入返回 ErrQuotaExhausted
+//   - Close 把本地未用完的余量归还给 Redis
+func TestQuota_SharedBudget(t *testing.T) {
+	// Arrange: 集群预算 1000 字节，每次租用 300 字节
+	server, client := newTestClient(t)
+	ctx := context.Background()
+	first := NewQuota(client, "budget", ratelimited.WithLeaseSize(300))
+	second := NewQuota(client, "budget", ratelimited.WithLeaseSize(300))
+	if err := first.SetBudget(ctx, 1000); err != nil {
+		t.Fatalf("设置预算失败: %v", err)
+	}
+	limiters := ratelimited.Chain(rate.NewLimiter(rate.Inf, 0))
+	firstWriter := ratelimited.NewDiscardWriter(limiters, ratelimited.WithQuota(first))
+	secondWriter := ratelimited.NewDiscardWriter(limiters, ratelimited.WithQuota(second))
+
+	// Act
+	for range 10 {
+		if _, err := firstWriter.Write(make([]byte, 10)); err != nil {
+			t.Fatalf("预算内的写入应该成功: %v", err)
+		}
+	}
+	remote, _ := server.Get("budget")
+
+	// Assert
+	if remote != "700" {
+		t.Errorf("10 次小写入应该只租用一次，Redis 剩余应为 700，实际 %s", remote)
+	}
+
+	// Act: 第二个进程用完其余预算
+	n, err := secondWriter.Write(make([]byte, 1000))
+
+	// Assert
+	if err != nil || n != 700 {
+		t.Errorf("应该只接收集群剩余的 700 字节，实际 n=%d err=%v", n, err)
+	}
+
+	// Act: 第一个进程归还未用完的租约后，第二个进程可以继续使用
+	if err := first.Close(); err != nil {
+		t.Fatalf("归还本地余量失败: %v", err)
+	}
+	n, err = secondWriter.Write(make([]byte, 1000))
+
+	// Assert
+	if err != nil || n != 200 {
+		t.Errorf("应该接收第一个进程归还的 200 字节，实际 n=%d err=%v", n, err)
+	}
+	if _, err := secondWriter.Write(make([]byte, 1)); !errors.Is(err, ratelimited.ErrQuotaExhausted) {
+		t.Errorf("预算耗尽后应该返回 ErrQuotaExhausted，实际 %v", err)
+	}
+}
+
+// TestQuota_Unavailable 测试 Redis 不可用时的行为
+func TestQuota_Unavailable(t *testing.T) {
+	// Arrange
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr(), MaxRetries: -1})
+	t.Cleanup(func() { client.Close() })
+	server.Close()
+
+	// Act
+	closed := NewQuota(client, "budget")
+	open := NewQuota(client, "budget", ratelimited.WithFailMode(ratelimited.FailOpen))
+
+	// Assert
+	if closed.Acquire(10) {
+		t.Error("默认模式下没有本地余量时应该拒绝")
+	}
+	if closed.Err() == nil {
+		t.Error("应该记录 Redis 错误")
+	}
+	if !open.Acquire(10) {
+		t.Error("FailOpen 模式下应该放行")
+	}
+}
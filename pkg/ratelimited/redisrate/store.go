@@ -0,0 +1,164 @@
+package redisrate
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/lwmacct/250918-go-pkg-ratelimited/pkg/ratelimited"
+	"github.com/redis/go-redis/v9"
+	"golang.org/x/time/rate"
+)
+
+// nowScript 以 Redis 服务器时间（微秒）定义脚本中的 now
+//
+// Redis 5 之前的版本需要先开启按效果复制，才能在调用 TIME 之后执行写命令。
+const nowScript = `
+if redis.replicate_commands then
+  redis.replicate_commands()
+end
+local t = redis.call("TIME")
+local now = tonumber(t[1]) * 1e6 + tonumber(t[2])
+`
+
+// tokenBucketScript 原子地补充并扣除令牌，返回 {是否扣除, 需要等待的微秒数}
+//
+// KEYS[1] 令牌桶哈希键；ARGV: 速率 (令牌/秒)、突发容量、申请数量、最长等待 (微秒)。
+// 当前时间取自 Redis 服务器的 TIME，所有客户端共用同一个时间源，不受各自时钟偏差的影响。
+// 令牌可以被扣为负数，表示已预留给正在等待的申请者；等待超过最长等待时不扣除。
+var tokenBucketScript = redis.NewScript(nowScript + `
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local n = tonumber(ARGV[3])
+local max_wait = tonumber(ARGV[4])
+
+local state = redis.call("HMGET", KEYS[1], "tokens", "ts")
+local tokens = tonumber(state[1]) or burst
+local ts = tonumber(state[2]) or now
+if now > ts then
+  tokens = math.min(burst, tokens + (now - ts) * rate / 1e6)
+  ts = now
+end
+
+local wait = 0
+if tokens < n then
+  wait = math.ceil((n - tokens) * 1e6 / rate)
+end
+if max_wait >= 0 and wait > max_wait then
+  return {0, wait}
+end
+
+tokens = tokens - n
+redis.call("HSET", KEYS[1], "tokens", tostring(tokens), "ts", tostring(ts))
+redis.call("PEXPIRE", KEYS[1], math.ceil((burst - tokens) * 1e3 / rate) + 1000)
+return {1, wait}
+`)
+
+// returnTokensScript 补充令牌后把 ARGV[3] 个令牌归还给令牌桶，令牌数不超过突发容量
+//
+// ARGV 的前两项与 tokenBucketScript 相同；令牌桶不存在（已过期即为满）时不做任何事。
+var returnTokensScript = redis.NewScript(nowScript + `
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local n = tonumber(ARGV[3])
+
+local state = redis.call("HMGET", KEYS[1], "tokens", "ts")
+if not state[1] then
+  return 0
+end
+local tokens = tonumber(state[1])
+local ts = tonumber(state[2]) or now
+if now > ts then
+  tokens = tokens + (now - ts) * rate / 1e6
+  ts = now
+end
+
+tokens = math.min(burst, tokens + n)
+redis.call("HSET", KEYS[1], "tokens", tostring(tokens), "ts", tostring(ts))
+redis.call("PEXPIRE", KEYS[1], math.ceil((burst - tokens) * 1e3 / rate) + 1000)
+return 0
+`)
+
+// leaseScript 从共享预算中扣除至多 ARGV[1] 字节，返回实际扣除量和扣除后的剩余
+//
+// 预算不足时扣走全部剩余；预算键不存在时视为 0。
+var leaseScript = redis.NewScript(`
+local remaining = tonumber(redis.call("GET", KEYS[1]) or "0")
+local want = tonumber(ARGV[1])
+local got = math.min(want, math.max(remaining, 0))
+if got > 0 then
+  remaining = redis.call("DECRBY", KEYS[1], got)
+end
+return {got, remaining}
+`)
+
+// Store 基于 Redis 的 ratelimited.Store 实现
+//
+// 令牌桶保存在哈希键中、由 Lua 脚本按 Redis 服务器时间原子地补充和扣除，空闲后自动过期；
+// 配额保存在整数键中。每次调用只需要一次往返。
+type Store struct {
+	client redis.Cmdable
+}
+
+// NewStore 创建使用 client 的存储后端
+//
+// client 可以是 *redis.Client、*redis.ClusterClient 等任何 redis.Cmdable。
+func NewStore(client redis.Cmdable) *Store {
+	return &Store{client: client}
+}
+
+// TakeTokens 实现 ratelimited.Store 接口
+func (s *Store) TakeTokens(ctx context.Context, key string, limit rate.Limit, burst, n int, maxWait time.Duration) (time.Duration, error) {
+	micros := int64(-1)
+	if maxWait >= 0 {
+		micros = maxWait.Microseconds()
+	}
+	res, err := tokenBucketScript.Run(ctx, s.client, []string{key},
+		float64(limit), burst, n, micros).Int64Slice()
+	if err != nil {
+		return 0, err
+	}
+	wait := time.Duration(res[1]) * time.Microsecond
+	if res[0] == 0 {
+		return 0, &ratelimited.MaxWaitError{Estimated: wait, MaxWait: maxWait}
+	}
+	return wait, nil
+}
+
+// ReturnTokens 实现 ratelimited.Store 接口
+func (s *Store) ReturnTokens(ctx context.Context, key string, limit rate.Limit, burst, n int) error {
+	return returnTokensScript.Run(ctx, s.client, []string{key},
+		float64(limit), burst, n).Err()
+}
+
+// GetQuota 实现 ratelimited.Store 接口
+func (s *Store) GetQuota(ctx context.Context, key string) (int64, error) {
+	n, err := s.client.Get(ctx, key).Int64()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	return n, err
+}
+
+// SetQuota 实现 ratelimited.Store 接口
+func (s *Store) SetQuota(ctx context.Context, key string, n int64) error {
+	return s.client.Set(ctx, key, n, 0).Err()
+}
+
+// TakeQuota 实现 ratelimited.Store 接口，扣除用 Lua 脚本原子完成，归还使用 INCRBY
+func (s *Store) TakeQuota(ctx context.Context, key string, n int64) (int64, int64, error) {
+	if n < 0 {
+		remaining, err := s.client.IncrBy(ctx, key, -n).Result()
+		if err != nil {
+			return 0, 0, err
+		}
+		return n, remaining, nil
+	}
+	res, err := leaseScript.Run(ctx, s.client, []string{key}, n).Int64Slice()
+	if err != nil {
+		return 0, 0, err
+	}
+	return res[0], res[1], nil
+}
+
+var _ ratelimited.Store = (*Store)(nil)
@@ -0,0 +1,80 @@
+package redisrate
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/lwmacct/250918-go-pkg-ratelimited/pkg/ratelimited"
+)
+
+// TestStore 测试 Redis 存储后端的令牌归还和配额操作
+func TestStore(t *testing.T) {
+	// Arrange
+	_, client := newTestClient(t)
+	store := NewStore(client)
+	ctx := context.Background()
+
+	// Act & Assert: 令牌桶
+	_, err := store.TakeTokens(ctx, "bucket", 1000, 100, 100, -1)
+	if err != nil {
+		t.Fatalf("突发容量内的预留失败: %v", err)
+	}
+	_, err = store.TakeTokens(ctx, "bucket", 1000, 100, 50, time.Millisecond)
+	var mwErr *ratelimited.MaxWaitError
+	if !errors.As(err, &mwErr) {
+		t.Errorf("等待超过上限时应该返回 *MaxWaitError，实际 %v", err)
+	}
+	if err := store.ReturnTokens(ctx, "bucket", 1000, 100, 100); err != nil {
+		t.Fatalf("归还令牌失败: %v", err)
+	}
+	if wait, err := store.TakeTokens(ctx, "bucket", 1000, 100, 50, 0); err != nil || wait != 0 {
+		t.Errorf("归还后应该立即放行，实际 wait=%v err=%v", wait, err)
+	}
+
+	// Act & Assert: 配额
+	if got, err := store.GetQuota(ctx, "missing"); err != nil || got != 0 {
+		t.Errorf("不存在的配额应为 0，实际 %d err=%v", got, err)
+	}
+	if err := store.SetQuota(ctx, "budget", 100); err != nil {
+		t.Fatalf("设置配额失败: %v", err)
+	}
+	if taken, remaining, _ := store.TakeQuota(ctx, "budget", 150); taken != 100 || remaining != 0 {
+		t.Errorf("不足时应该扣走全部剩余，实际 taken=%d remaining=%d", taken, remaining)
+	}
+	if _, remaining, _ := store.TakeQuota(ctx, "budget", -30); remaining != 30 {
+		t.Errorf("归还后剩余应为 30，实际 %d", remaining)
+	}
+}
+
+// TestStore_ServerTime 测试令牌桶按 Redis 服务器时间补充，与客户端时钟无关
+//
+// 测试目标：
+//   - 服务器时间不前进时，客户端经过的实际时间不会补充令牌
+//   - 服务器时间前进后按速率补充
+func TestStore_ServerTime(t *testing.T) {
+	// Arrange: 冻结服务器时间，每秒 1000 个令牌
+	server, client := newTestClient(t)
+	server.SetTime(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	store := NewStore(client)
+	ctx := context.Background()
+	if _, err := store.TakeTokens(ctx, "bucket", 1000, 100, 100, -1); err != nil {
+		t.Fatalf("突发容量内的预留失败: %v", err)
+	}
+
+	// Act
+	time.Sleep(20 * time.Millisecond)
+	frozen, err := store.TakeTokens(ctx, "bucket", 1000, 100, 10, 0)
+	server.SetTime(time.Date(2026, 1, 1, 0, 0, 1, 0, time.UTC))
+	advanced, advancedErr := store.TakeTokens(ctx, "bucket", 1000, 100, 10, 0)
+
+	// Assert
+	var mwErr *ratelimited.MaxWaitError
+	if !errors.As(err, &mwErr) || mwErr.Estimated != 10*time.Millisecond {
+		t.Errorf("服务器时间冻结时不应该补充令牌，实际 wait=%v err=%v", frozen, err)
+	}
+	if advancedErr != nil || advanced != 0 {
+		t.Errorf("服务器时间前进后应该立即放行，实际 wait=%v err=%v", advanced, advancedErr)
+	}
+}
@@ -0,0 +1,51 @@
+package ratelimited
+
+import (
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// =============================================================================
+// 令牌归还 - 写入器结束时退还未使用的批次令牌
+// =============================================================================
+
+// TokenReturner 可以归还已发放令牌的限制器
+//
+// 自定义限制器实现该接口后，写入器在 Release 时把未使用的批次令牌交还给它。
+type TokenReturner interface {
+	// ReturnN 归还 n 个此前通过 WaitN 发放、但没有被使用的令牌
+	ReturnN(n int)
+}
+
+// Release 把当前批次中尚未使用的令牌归还给限制器链，返回归还的令牌数
+//
+// 批量申请的令牌在写入器结束时可能剩余至多批次大小减一个，多个写入器共享限制器时
+// 这些令牌会让其他写入器等待更久。Release 清空批次并把剩余令牌交还给链中每一层：
+// 实现了 TokenReturner 的限制器调用 ReturnN；*rate.Limiter 通过负数的 ReserveN
+// 把令牌加回令牌桶（超出突发容量的部分在下次补充时被截断）；其他限制器无法归还，
+// 这部分令牌直接作废。Close 和便利复制函数会自动调用 Release。
+func (w *DiscardWriter) Release() int64 {
+	n := atomic.SwapInt64(&w.remainingTokens, 0)
+	if n <= 0 {
+		return 0
+	}
+	limiters, _ := w.chain()
+	now := w.clock.Now()
+	for _, limiter := range limiters {
+		returnTokens(limiter, int(n), now)
+	}
+	return n
+}
+
+// returnTokens 尽可能把 n 个令牌归还给 limiter，now 为写入器时钟的当前时间
+func returnTokens(limiter Limiter, n int, now time.Time) {
+	switch l := limiter.(type) {
+	case TokenReturner:
+		l.ReturnN(n)
+	case *rate.Limiter:
+		// 与 waitForChain 的预留一样使用写入器的时钟
+		l.ReserveN(now, -n)
+	}
+}
@@ -0,0 +1,90 @@
+package ratelimited
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"golang.org/x/time/rate"
+)
+
+// returningLimiter 记录归还令牌数的限制器
+type returningLimiter struct {
+	countingLimiter
+	returned int64
+}
+
+func (l *returningLimiter) ReturnN(n int) {
+	l.returned += int64(n)
+}
+
+// TestDiscardWriter_Release 测试归还未使用的批次令牌
+//
+// 测试目标：
+//   - 支持归还的限制器收到批次剩余的令牌，批次被清空
+//   - *rate.Limiter 的令牌桶恢复剩余的令牌
+//   - 便利复制函数结束时自动归还
+func TestDiscardWriter_Release(t *testing.T) {
+	t.Run("自定义限制器", func(t *testing.T) {
+		// Arrange
+		limiter := &returningLimiter{}
+		writer := NewDiscardWriter([]Limiter{limiter}, WithBatchSize(1000))
+		_, err := writer.Write(createTestData(100))
+		assertNoError(t, err, "写入应该成功")
+
+		// Act
+		released := writer.Release()
+
+		// Assert
+		assertEqual(t, int64(900), released, "应该归还批次剩余的令牌")
+		assertEqual(t, int64(900), limiter.returned, "限制器应该收到归还的令牌")
+		assertEqual(t, int64(0), writer.Release(), "重复归还应该没有令牌")
+	})
+
+	t.Run("rate.Limiter", func(t *testing.T) {
+		// Arrange: 几乎不补充的令牌桶，令牌数的变化只来自申请与归还
+		limiter := rate.NewLimiter(0.001, 1000)
+		writer := NewDiscardWriter(Chain(limiter), WithBatchSize(1000))
+		_, err := writer.Write(createTestData(100))
+		assertNoError(t, err, "写入应该成功")
+
+		// Act
+		assertNoError(t, writer.Close(), "关闭应该成功")
+
+		// Assert
+		if tokens := limiter.Tokens(); tokens < 899 || tokens > 901 {
+			t.Errorf("令牌桶应该恢复约 900 个令牌，实际为 %.1f", tokens)
+		}
+	})
+
+	t.Run("虚拟时钟", func(t *testing.T) {
+		// Arrange: 按写入器的虚拟时钟预留和归还，令牌数不受实际时间影响
+		clock := newFakeClock()
+		limiter := rate.NewLimiter(10, 1000)
+		writer := NewDiscardWriter(Chain(limiter), WithBatchSize(1000), WithClock(clock))
+		_, err := writer.Write(createTestData(100))
+		assertNoError(t, err, "写入应该成功")
+
+		// Act
+		writer.Release()
+
+		// Assert
+		assertEqual(t, 900.0, limiter.TokensAt(clock.Now()), "应该在虚拟时钟的当前时间归还令牌")
+	})
+
+	t.Run("便利复制函数", func(t *testing.T) {
+		// Arrange
+		limiter := rate.NewLimiter(0.001, 1000)
+
+		// Act
+		copied, err := CopyWithRateLimit(context.Background(), bytes.NewReader(createTestData(100)), Chain(limiter),
+			WithBatchSize(1000))
+
+		// Assert
+		assertNoError(t, err, "复制应该成功")
+		assertEqual(t, int64(100), copied, "应该复制全部字节")
+		if tokens := limiter.Tokens(); tokens < 899 || tokens > 901 {
+			t.Errorf("复制结束后应该归还约 900 个令牌，实际为 %.1f", tokens)
+		}
+	})
+}
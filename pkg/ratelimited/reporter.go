@@ -0,0 +1,79 @@
+package ratelimited
+
+import (
+	"context"
+	"time"
+)
+
+// =============================================================================
+// 后台统计报告 - 定期推送统计快照
+// =============================================================================
+
+// StartReporter 启动后台 goroutine，每隔 interval 以写入器的统计快照调用 fn
+//
+// ctx 结束、写入器 Close 或调用返回的 stop 函数时停止，停止时不再做最后一次报告。
+// stop 可以重复调用，返回时 goroutine 已经退出，之后不会再调用 fn。
+// interval <= 0 时不启动 goroutine，stop 是空操作。需要输出到日志时可以使用 LogStats。
+//
+// 使用示例：
+//
+//	stop := w.StartReporter(ctx, 10*time.Second, ratelimited.LogStats(log.Printf))
+//	defer stop()
+func (w *DiscardWriter) StartReporter(ctx context.Context, interval time.Duration, fn func(WriterStats)) (stop func()) {
+	return startReporter(ctx, w.clock, interval, w.done, func() {
+		fn(w.Stats())
+	})
+}
+
+// StartReporter 启动后台 goroutine，每隔 interval 以注册表的统计快照调用 fn
+//
+// ctx 结束或调用返回的 stop 函数时停止，其余行为与 DiscardWriter.StartReporter 相同。
+func (r *Registry) StartReporter(ctx context.Context, interval time.Duration, fn func(RegistryStats)) (stop func()) {
+	return startReporter(ctx, r.clock, interval, nil, func() {
+		fn(r.Stats())
+	})
+}
+
+// LogStats 返回把写入器统计输出到日志函数的报告函数，可以直接传给 StartReporter
+//
+// logf 的签名与 log.Printf 兼容。
+func LogStats(logf func(format string, args ...any)) func(WriterStats) {
+	return func(s WriterStats) {
+		logf("ratelimited: stats bytes=%d writes=%d wait=%s batches=%d quota_remaining=%d p99_wait=%s",
+			s.Bytes, s.Requests, s.WaitTime, s.Batches, s.QuotaRemaining, s.WaitHistogram.Quantile(0.99))
+	}
+}
+
+// startReporter 按 clock 每隔 interval 调用 report，直到 ctx 结束、done 关闭或 stop 被调用
+func startReporter(ctx context.Context, clock Clock, interval time.Duration, done <-chan struct{}, report func()) (stop func()) {
+	if interval <= 0 {
+		return func() {}
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	exited := make(chan struct{})
+	go func() {
+		defer close(exited)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-done:
+				return
+			case <-clock.After(interval):
+			}
+			select {
+			case <-done:
+				return
+			default:
+			}
+			if ctx.Err() != nil {
+				return
+			}
+			report()
+		}
+	}()
+	return func() {
+		cancel()
+		<-exited
+	}
+}
@@ -0,0 +1,89 @@
+package ratelimited
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestDiscardWriter_StartReporter 测试后台报告按间隔推送快照并在关闭后停止
+//
+// 测试目标：
+//   - 每经过一个间隔报告一次当前统计
+//   - 写入器 Close 后报告 goroutine 退出
+func TestDiscardWriter_StartReporter(t *testing.T) {
+	// Arrange
+	clock := newFakeClock()
+	w := NewDiscardWriter(nil, WithClock(clock))
+	reports := make(chan WriterStats, 4)
+	stop := w.StartReporter(context.Background(), time.Second, func(s WriterStats) { reports <- s })
+	defer stop()
+
+	// Act
+	_, err := w.Write(createTestData(100))
+	assertNoError(t, err, "写入")
+	clock.waitForWaiters(t, 1)
+	clock.Advance(time.Second)
+
+	// Assert
+	select {
+	case s := <-reports:
+		assertEqual(t, int64(100), s.Bytes, "报告应该包含当前字节数")
+	case <-time.After(2 * time.Second):
+		t.Fatal("经过一个间隔后应该报告")
+	}
+
+	assertNoError(t, w.Close(), "关闭")
+	stop()
+	clock.Advance(time.Second)
+	select {
+	case s := <-reports:
+		t.Errorf("关闭后不应该再报告: %+v", s)
+	default:
+	}
+}
+
+// TestRegistry_StartReporter 测试注册表统计的后台报告与 stop
+func TestRegistry_StartReporter(t *testing.T) {
+	// Arrange: 容量为 1，第二个键会淘汰第一个
+	clock := newFakeClock()
+	created := 0
+	r := NewRegistry(newTestTemplate(&created), WithMaxEntries(1), WithRegistryClock(clock))
+	r.Get("a")
+	r.Get("b")
+	reports := make(chan RegistryStats, 4)
+	stop := r.StartReporter(context.Background(), time.Second, func(s RegistryStats) { reports <- s })
+
+	// Act
+	clock.waitForWaiters(t, 1)
+	clock.Advance(time.Second)
+
+	// Assert
+	select {
+	case s := <-reports:
+		assertEqual(t, RegistryStats{Keys: 1, Created: 2, Evicted: 1}, s, "报告应该包含注册表统计")
+	case <-time.After(2 * time.Second):
+		t.Fatal("经过一个间隔后应该报告")
+	}
+	stop()
+	stop()
+}
+
+// TestLogStats 测试日志报告函数输出统计摘要
+func TestLogStats(t *testing.T) {
+	// Arrange
+	var line string
+	report := LogStats(func(format string, args ...any) { line = fmt.Sprintf(format, args...) })
+
+	// Act
+	report(WriterStats{Bytes: 2048, Requests: 3, WaitTime: time.Second, QuotaRemaining: -1})
+
+	// Assert
+	for _, want := range []string{"bytes=2048", "writes=3", "wait=1s", "quota_remaining=-1"} {
+		if !strings.Contains(line, want) {
+			t.Errorf("日志应该包含 %q，实际 %q", want, line)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package ratelimited
+
+import (
+	"context"
+	"fmt"
+)
+
+// =============================================================================
+// 按请求计费 - 限制每秒写入次数而不是字节数
+// =============================================================================
+
+// PerRequestLimiter 按写入次数计费的链层：每次写入从内部限制器申请 1 个令牌，与字节数无关
+//
+// 链中的其他层仍按字节计费，因此一条链可以同时限制带宽和每秒写入次数，
+// 例如 []Limiter{PerRequest(rate.NewLimiter(100, 10)), rate.NewLimiter(1<<20, 64<<10)}
+// 表示每秒最多 100 次写入、且不超过 1 MB/s。请求令牌在每次写入开始时申请，
+// 之后配额、令牌上限或字节令牌的准入失败（包括非阻塞写入的 ErrWouldBlock）时，
+// 请求令牌按 Release 的方式归还给内部限制器，被拒绝的写入不占用请求速率。
+// PerRequestLimiter 自身的 WaitN 总是立即返回，批量字节令牌不会绕过请求计费。
+type PerRequestLimiter struct {
+	limiter Limiter
+}
+
+// PerRequest 把 l 包装为按写入次数计费的链层
+func PerRequest(l Limiter) *PerRequestLimiter {
+	return &PerRequestLimiter{limiter: l}
+}
+
+// WaitN 实现 Limiter 接口，总是立即返回 nil
+//
+// 请求令牌由写入器在每次写入时单独申请，不参与字节令牌的申请。
+func (p *PerRequestLimiter) WaitN(ctx context.Context, n int) error {
+	return nil
+}
+
+// Unwrap 返回内部的限制器
+func (p *PerRequestLimiter) Unwrap() Limiter {
+	return p.limiter
+}
+
+// WithRequestRateChain 设置按写入次数计费的请求速率链，替换之前设置的请求速率链
+//
+// 每次写入从链中每一层申请 1 个令牌，同时仍按字节数向字节限制器链申请令牌，
+// 两者都放行后数据才被接收，从而同时限制每秒请求数和带宽。与在字节链中放入
+// PerRequest 层等价，适合请求速率和带宽由不同配置管理的场景。
+func WithRequestRateChain(limiters []Limiter) DiscardWriterOption {
+	return func(w *DiscardWriter) {
+		w.requestLimiters = limiters
+	}
+}
+
+// writeScoper 可能包装了按写入计费的层的装饰器
+//
+// 按写入计费的层（PerRequest 层和并发限制器）不参与字节令牌的申请。
+// 日志、指标等装饰器实现 WriteScope 并转发到内部限制器，被包装的层因此仍按写入计费，
+// 不会被当作字节层申请 n 个字节令牌。自定义装饰器同样可以实现该方法。
+type writeScoper interface {
+	// WriteScope 返回被包装的按写入计费的层，内部限制器按字节计费时返回 nil
+	WriteScope() Limiter
+}
+
+// writeScope 返回 l 本身或 l 包装的按写入计费的层，l 按字节计费时返回 nil
+func writeScope(l Limiter) Limiter {
+	switch l := l.(type) {
+	case inFlightLimiter, *PerRequestLimiter:
+		return l
+	case writeScoper:
+		return l.WriteScope()
+	}
+	return nil
+}
+
+// perWriteLayer 判断链层是否按写入而不是按字节计费，这些层不参与字节令牌的申请
+func perWriteLayer(l Limiter) bool {
+	return writeScope(l) != nil
+}
+
+// chargeRequests 为请求速率链和字节链中按请求计费的层各申请 1 个令牌
+//
+// 与字节令牌一样同时预留、遵守 WithMaxWait 和非阻塞写入的语义。
+// 成功时返回的 refund 把这些令牌归还给各层，供之后的准入步骤失败时调用。
+func (w *DiscardWriter) chargeRequests(ctx context.Context) (refund func(), err error) {
+	inner, innerNames := w.requestLayers()
+	if len(inner) == 0 {
+		return func() {}, nil
+	}
+	if err := w.waitForChain(ctx, inner, innerNames, false, 1); err != nil {
+		return nil, err
+	}
+	return func() {
+		now := w.clock.Now()
+		for _, limiter := range inner {
+			returnTokens(limiter, 1, now)
+		}
+	}, nil
+}
+
+// requestLayers 返回请求速率链和字节链中按请求计费的层的内部限制器及其名称
+func (w *DiscardWriter) requestLayers() ([]Limiter, []string) {
+	limiters, names := w.chain()
+	var inner []Limiter
+	var innerNames []string
+	for i, limiter := range w.requestLimiters {
+		if limiter == nil {
+			continue
+		}
+		inner = append(inner, limiter)
+		innerNames = append(innerNames, fmt.Sprintf("request[%d]", i))
+	}
+	for i, limiter := range limiters {
+		p, ok := writeScope(limiter).(*PerRequestLimiter)
+		if !ok || p.limiter == nil {
+			continue
+		}
+		inner = append(inner, p.limiter)
+		innerNames = append(innerNames, limiterName(names, i, false))
+	}
+	return inner, innerNames
+}
@@ -0,0 +1,201 @@
+package ratelimited
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// countingRequestLimiter 记录调用次数和申请令牌总数的限制器
+type countingRequestLimiter struct {
+	calls  int64
+	tokens int64
+}
+
+func (c *countingRequestLimiter) WaitN(ctx context.Context, n int) error {
+	atomic.AddInt64(&c.calls, 1)
+	atomic.AddInt64(&c.tokens, int64(n))
+	return nil
+}
+
+// TestPerRequestLimiter_ChargesOnePerWrite 测试每次写入申请 1 个请求令牌，与字节数无关
+//
+// 测试目标：
+//   - 每次写入都申请请求令牌，即使字节令牌来自批次
+//   - 同一条链中的字节层仍按字节计费
+func TestPerRequestLimiter_ChargesOnePerWrite(t *testing.T) {
+	// Arrange
+	requests := &countingRequestLimiter{}
+	bytes := &countingRequestLimiter{}
+	writer := NewDiscardWriter([]Limiter{PerRequest(requests), bytes}, WithBatchSize(1024))
+
+	// Act
+	for range 5 {
+		_, err := writer.Write(createTestData(100))
+		assertNoError(t, err, "写入")
+	}
+
+	// Assert
+	assertAtomicEqual(t, 5, &requests.tokens, "每次写入应该申请 1 个请求令牌")
+	assertAtomicEqual(t, 1024, &bytes.tokens, "字节层应该只申请一个批次")
+}
+
+// TestPerRequestLimiter_Decorated 测试被装饰器包装的按写入计费的层仍按写入计费
+//
+// 测试目标：
+//   - 日志、指标和抖动装饰器包装的 PerRequest 层每次写入申请 1 个请求令牌
+//   - 被包装的并发限制器仍按写入占用名额，不申请字节令牌
+func TestPerRequestLimiter_Decorated(t *testing.T) {
+	decorators := []struct {
+		name     string
+		decorate func(Limiter) Limiter
+	}{
+		{"NewLoggingLimiter", func(l Limiter) Limiter {
+			return NewLoggingLimiter(l, "requests", 1, func(string, ...any) {})
+		}},
+		{"NewSlogLimiter", func(l Limiter) Limiter {
+			return NewSlogLimiter("requests", l, slog.New(slog.NewTextHandler(io.Discard, nil)))
+		}},
+		{"NewMetricsLimiter", func(l Limiter) Limiter {
+			return NewMetricsLimiter("requests", l, NewLayerMetrics())
+		}},
+		{"WithJitter", func(l Limiter) Limiter {
+			return WithJitter(l, 0.5)
+		}},
+	}
+
+	for _, d := range decorators {
+		t.Run(d.name, func(t *testing.T) {
+			// Arrange
+			requests := &countingRequestLimiter{}
+			bytes := &countingRequestLimiter{}
+			writer := NewDiscardWriter([]Limiter{d.decorate(PerRequest(requests)), bytes}, WithBatchSize(1024))
+
+			// Act
+			for range 5 {
+				_, err := writer.Write(createTestData(100))
+				assertNoError(t, err, "写入")
+			}
+
+			// Assert
+			assertAtomicEqual(t, 5, &requests.tokens, "每次写入应该申请 1 个请求令牌")
+			assertAtomicEqual(t, 1024, &bytes.tokens, "字节层应该只申请一个批次")
+		})
+	}
+
+	t.Run("并发限制器", func(t *testing.T) {
+		// Arrange
+		slots := NewConcurrencyLimiter(1)
+		sink := &blockingSink{entered: make(chan struct{}), proceed: make(chan struct{})}
+		writer := NewWriter(sink, []Limiter{NewMetricsLimiter("slots", slots, NewLayerMetrics())})
+
+		// Act: 写入停在下游
+		done := make(chan struct{})
+		go func() { defer close(done); _, _ = writer.Write(createTestData(10)) }()
+		<-sink.entered
+		inFlight := slots.InFlight()
+		sink.proceed <- struct{}{}
+		<-done
+
+		// Assert
+		assertEqual(t, 1, inFlight, "写入期间应该占用被包装的并发限制器的名额")
+		assertEqual(t, 0, slots.InFlight(), "写入完成后应该归还名额")
+	})
+}
+
+// TestPerRequestLimiter_RateLimitsWrites 测试请求速率耗尽时非阻塞写入和最长等待被拒绝
+func TestPerRequestLimiter_RateLimitsWrites(t *testing.T) {
+	// Arrange: 每秒 1 次请求，突发 2 次
+	requests := rate.NewLimiter(1, 2)
+	writer := NewDiscardWriter([]Limiter{PerRequest(requests), rate.NewLimiter(rate.Inf, 0)},
+		WithMaxWait(10*time.Millisecond))
+
+	// Act
+	_, first := writer.TryWrite(createTestData(1 << 10))
+	_, second := writer.Write(createTestData(1 << 10))
+	_, third := writer.TryWrite(createTestData(1))
+	_, fourth := writer.Write(createTestData(1))
+
+	// Assert
+	assertNoError(t, first, "突发内的第一次写入")
+	assertNoError(t, second, "突发内的第二次写入")
+	if !errors.Is(third, ErrWouldBlock) {
+		t.Errorf("请求令牌耗尽时非阻塞写入应该返回 ErrWouldBlock，实际 %v", third)
+	}
+	var mwErr *MaxWaitError
+	if !errors.As(fourth, &mwErr) {
+		t.Errorf("等待请求令牌超过上限时应该返回 *MaxWaitError，实际 %v", fourth)
+	}
+}
+
+// TestWithRequestRateChain 测试请求速率链与字节链同时生效
+//
+// 测试目标：
+//   - 每次写入向请求速率链申请 1 个令牌、向字节链按字节申请令牌
+//   - 请求速率链被配置提取并在重建的写入器中保留
+func TestWithRequestRateChain(t *testing.T) {
+	// Arrange
+	requests := &countingRequestLimiter{}
+	bytes := &countingRequestLimiter{}
+	writer := NewDiscardWriter([]Limiter{bytes},
+		WithRequestRateChain([]Limiter{requests}), WithExplicitCommit(true))
+
+	// Act
+	for range 3 {
+		_, err := writer.Write(createTestData(200))
+		assertNoError(t, err, "写入")
+	}
+	rebuilt := NewFromConfig(writer.Config())
+	_, err := rebuilt.Write(createTestData(200))
+
+	// Assert
+	assertNoError(t, err, "重建的写入器写入")
+	assertAtomicEqual(t, 4, &requests.tokens, "每次写入应该申请 1 个请求令牌")
+	assertAtomicEqual(t, 800, &bytes.tokens, "字节链应该按字节数申请令牌")
+}
+
+// TestPerRequestLimiter_RefundOnRejection 测试后续准入失败时请求令牌被归还
+//
+// 测试目标：
+//   - 字节令牌不足导致的 ErrWouldBlock 不占用请求速率
+//   - 配额耗尽导致的 ErrQuotaExhausted 不占用请求速率
+func TestPerRequestLimiter_RefundOnRejection(t *testing.T) {
+	t.Run("字节令牌不足", func(t *testing.T) {
+		// Arrange: 每秒 1 次请求、突发 1 次，字节层只够 10 字节
+		requests := rate.NewLimiter(1, 1)
+		writer := NewDiscardWriter([]Limiter{PerRequest(requests), rate.NewLimiter(10, 10)},
+			WithBatchSize(1))
+
+		// Act
+		_, rejected := writer.TryWrite(createTestData(20))
+		_, admitted := writer.TryWrite(createTestData(5))
+
+		// Assert
+		if !errors.Is(rejected, ErrWouldBlock) {
+			t.Errorf("字节令牌不足时应该返回 ErrWouldBlock，实际 %v", rejected)
+		}
+		assertNoError(t, admitted, "被拒绝的写入不应该消耗请求令牌")
+	})
+
+	t.Run("配额耗尽", func(t *testing.T) {
+		// Arrange
+		requests := rate.NewLimiter(1, 1)
+		quota := int64(0)
+		writer := NewDiscardWriter([]Limiter{PerRequest(requests)}, WithSharedQuota(&quota))
+
+		// Act
+		_, err := writer.TryWrite(createTestData(5))
+
+		// Assert
+		if !errors.Is(err, ErrQuotaExhausted) {
+			t.Errorf("配额耗尽时应该返回 ErrQuotaExhausted，实际 %v", err)
+		}
+		assertEqual(t, true, requests.Allow(), "配额耗尽的写入不应该消耗请求令牌")
+	})
+}
@@ -0,0 +1,85 @@
+package ratelimited
+
+import (
+	"sync"
+	"time"
+)
+
+// =============================================================================
+// 饱和检测 - 在限制器开始/停止阻塞时回调
+// =============================================================================
+
+const (
+	// defaultSaturationThreshold 超过该等待时间的令牌申请视为阻塞
+	defaultSaturationThreshold = time.Millisecond
+	// defaultSaturationDebounce 状态翻转前需要连续观察到的次数
+	defaultSaturationDebounce = 2
+)
+
+// WithSaturationCallback 设置饱和状态变化回调
+//
+// 只在状态边沿触发：令牌申请在一段时间的立即放行之后开始出现明显等待时以 true 调用，
+// 恢复立即放行时以 false 调用。只有实际向限制器申请令牌的写入参与判断，
+// 复用批次令牌的写入不计入，避免批次内外交替造成抖动。
+// 状态翻转需要连续多次一致的观察（去抖），可通过 WithSaturationThreshold 调整。
+// 回调在写入 goroutine 中同步执行，应尽快返回。
+func WithSaturationCallback(fn func(saturated bool)) DiscardWriterOption {
+	return func(w *DiscardWriter) {
+		w.saturationFn = fn
+	}
+}
+
+// WithSaturationThreshold 设置饱和判断的等待阈值与去抖次数
+//
+// 等待时间超过 wait 的令牌申请视为阻塞；连续 debounce 次观察与当前状态不同才翻转状态。
+// 默认阈值为 1ms，去抖次数为 2。
+func WithSaturationThreshold(wait time.Duration, debounce int) DiscardWriterOption {
+	return func(w *DiscardWriter) {
+		w.saturationThreshold = wait
+		w.saturationDebounce = debounce
+	}
+}
+
+// saturationTracker 跟踪饱和状态并在边沿触发回调
+type saturationTracker struct {
+	fn        func(saturated bool)
+	threshold time.Duration
+	debounce  int
+
+	mu        sync.Mutex
+	saturated bool
+	streak    int // 连续与当前状态不一致的观察次数
+}
+
+// newSaturationTracker 创建饱和状态跟踪器，未设置的参数使用默认值
+func newSaturationTracker(fn func(bool), threshold time.Duration, debounce int) *saturationTracker {
+	if threshold <= 0 {
+		threshold = defaultSaturationThreshold
+	}
+	if debounce < 1 {
+		debounce = defaultSaturationDebounce
+	}
+	return &saturationTracker{fn: fn, threshold: threshold, debounce: debounce}
+}
+
+// observe 记录一次令牌申请的等待时间
+func (s *saturationTracker) observe(waited time.Duration) {
+	blocking := waited > s.threshold
+
+	s.mu.Lock()
+	if blocking == s.saturated {
+		s.streak = 0
+		s.mu.Unlock()
+		return
+	}
+	s.streak++
+	if s.streak < s.debounce {
+		s.mu.Unlock()
+		return
+	}
+	s.streak = 0
+	s.saturated = blocking
+	s.mu.Unlock()
+
+	s.fn(blocking)
+}
@@ -0,0 +1,76 @@
+package ratelimited
+
+import (
+	"testing"
+	"time"
+)
+
+// TestWithSaturationCallback_Transitions 测试饱和回调只在状态边沿触发
+//
+// 测试目标：
+//   - 单次慢速放行不会触发（去抖）
+//   - 连续慢速放行触发 true，连续快速放行触发 false
+//   - 状态不变期间不重复回调
+func TestWithSaturationCallback_Transitions(t *testing.T) {
+	// Arrange: 批次大小等于写入大小，每次写入都申请令牌
+	clock := newFakeClock()
+	limiter := &advancingLimiter{clock: clock}
+	var events []bool
+
+	writer := NewDiscardWriter([]Limiter{limiter},
+		WithClock(clock),
+		WithBatchSize(10),
+		WithSaturationCallback(func(saturated bool) {
+			events = append(events, saturated)
+		}),
+	)
+
+	fast, slow := time.Duration(0), 5*time.Millisecond
+	delays := []time.Duration{
+		fast, fast,
+		slow, fast, // 单次慢速被去抖
+		slow, slow, slow, // 第二次连续慢速时进入饱和
+		fast, slow, // 单次快速被去抖
+		fast, fast, fast, // 第二次连续快速时退出饱和
+	}
+
+	// Act
+	for _, delay := range delays {
+		limiter.delay = delay
+		_, err := writer.Write(createTestData(10))
+		assertNoError(t, err, "写入应该成功")
+	}
+
+	// Assert
+	assertEqual(t, 2, len(events), "应该只在两次边沿上回调")
+	if len(events) == 2 {
+		assertEqual(t, true, events[0], "第一次回调应该是进入饱和")
+		assertEqual(t, false, events[1], "第二次回调应该是退出饱和")
+	}
+}
+
+// TestWithSaturationCallback_BatchReuseIgnored 测试复用批次令牌的写入不参与判断
+func TestWithSaturationCallback_BatchReuseIgnored(t *testing.T) {
+	// Arrange: 每次申请都很慢，但一个批次可以服务 10 次写入
+	clock := newFakeClock()
+	limiter := &advancingLimiter{clock: clock, delay: 5 * time.Millisecond}
+	var events []bool
+
+	writer := NewDiscardWriter([]Limiter{limiter},
+		WithClock(clock),
+		WithBatchSize(100),
+		WithSaturationThreshold(time.Millisecond, 2),
+		WithSaturationCallback(func(saturated bool) {
+			events = append(events, saturated)
+		}),
+	)
+
+	// Act: 两个批次，中间的立即放行不应该打断连续的慢速观察
+	for i := 0; i < 20; i++ {
+		_, err := writer.Write(createTestData(10))
+		assertNoError(t, err, "写入应该成功")
+	}
+
+	// Assert
+	assertEqual(t, 1, len(events), "两次慢速申请应该触发一次饱和回调")
+}
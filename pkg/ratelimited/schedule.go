@@ -0,0 +1,124 @@
+package ratelimited
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// =============================================================================
+// 定时速率 - 按一天中的时间段切换速率
+// =============================================================================
+
+// RateProfile 一个时间段内使用的速率和突发容量
+type RateProfile struct {
+	Window Window     `json:"window"`
+	Limit  rate.Limit `json:"limit"`
+	Burst  int        `json:"burst"`
+}
+
+// ScheduledLimiter 按一天中的时间段自动切换速率的限制器，实现 Limiter 接口
+//
+// 例如备份流量在 00:00–06:00 使用 100MB/s、其余时间使用 10MB/s。
+// 每次 WaitN 时检查当前所处的时间段，时间段变化时调整底层令牌桶的速率和突发容量；
+// 已经开始的等待按切换前的速率完成。时间段按顺序匹配，第一个包含当前时间的生效，
+// 都不包含时使用默认速率。时区取自时钟返回时间的 Location。
+type ScheduledLimiter struct {
+	clock    Clock
+	profiles []RateProfile
+	fallback RateProfile // 默认速率，Window 不使用
+
+	mu      sync.Mutex
+	limiter *rate.Limiter
+	active  int // 当前生效的时间段下标，-1 表示默认速率
+}
+
+// ScheduledOption 定时速率限制器的配置选项
+type ScheduledOption func(*ScheduledLimiter)
+
+// WithScheduleClock 设置判断时间段使用的时钟，默认使用系统时钟
+//
+// 需要按特定时区切换时，传入返回该时区时间的时钟。
+func WithScheduleClock(c Clock) ScheduledOption {
+	return func(s *ScheduledLimiter) {
+		if c != nil {
+			s.clock = c
+		}
+	}
+}
+
+// NewScheduledLimiter 创建按 profiles 切换速率的限制器，不在任何时间段内时使用 limit 和 burst
+func NewScheduledLimiter(limit rate.Limit, burst int, profiles []RateProfile, opts ...ScheduledOption) *ScheduledLimiter {
+	s := &ScheduledLimiter{
+		clock:    systemClock{},
+		profiles: profiles,
+		fallback: RateProfile{Limit: limit, Burst: burst},
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	// 令牌桶以创建时所处时间段的突发容量装满
+	s.active = s.activeAt(s.clock.Now())
+	profile := s.profile(s.active)
+	s.limiter = rate.NewLimiter(profile.Limit, profile.Burst)
+	return s
+}
+
+// WaitN 实现 Limiter 接口，按当前时间段的速率等待 n 个令牌
+func (s *ScheduledLimiter) WaitN(ctx context.Context, n int) error {
+	return waitRate(ctx, s.clock, s.sync(), n)
+}
+
+// Limit 返回当前时间段的速率
+func (s *ScheduledLimiter) Limit() rate.Limit {
+	return s.sync().Limit()
+}
+
+// Burst 返回当前时间段的突发容量
+func (s *ScheduledLimiter) Burst() int {
+	return s.sync().Burst()
+}
+
+// Active 返回当前生效的时间段下标，使用默认速率时返回 -1
+func (s *ScheduledLimiter) Active() int {
+	s.sync()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.active
+}
+
+// sync 按当前时间选择时间段，变化时调整底层令牌桶，返回底层令牌桶
+func (s *ScheduledLimiter) sync() *rate.Limiter {
+	now := s.clock.Now()
+	active := s.activeAt(now)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if active != s.active {
+		profile := s.profile(active)
+		s.limiter.SetLimitAt(now, profile.Limit)
+		s.limiter.SetBurstAt(now, profile.Burst)
+		s.active = active
+	}
+	return s.limiter
+}
+
+// activeAt 返回 t 所在的第一个时间段的下标，都不包含时返回 -1
+func (s *ScheduledLimiter) activeAt(t time.Time) int {
+	for i, p := range s.profiles {
+		if p.Window.Contains(t) {
+			return i
+		}
+	}
+	return -1
+}
+
+// profile 返回下标为 active 的时间段，-1 表示默认速率
+func (s *ScheduledLimiter) profile(active int) RateProfile {
+	if active < 0 {
+		return s.fallback
+	}
+	return s.profiles[active]
+}
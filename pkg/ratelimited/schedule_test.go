@@ -0,0 +1,54 @@
+package ratelimited
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// TestScheduledLimiter_SwitchesByTimeOfDay 测试按时间段切换速率
+//
+// 测试目标：
+//   - 时间段内使用该时间段的速率和突发容量
+//   - 时间段外使用默认速率
+//   - 跨午夜的时间段同样生效
+func TestScheduledLimiter_SwitchesByTimeOfDay(t *testing.T) {
+	// Arrange: 00:00–06:00 为 100MB/s，22:00–次日 00:00 为 50MB/s，其余时间 10MB/s
+	clock := newFakeClock() // 从 00:00 开始
+	limiter := NewScheduledLimiter(10<<20, 64<<10, []RateProfile{
+		{Window: Window{Start: 0, End: 6 * time.Hour}, Limit: 100 << 20, Burst: 1 << 20},
+		{Window: Window{Start: 22 * time.Hour, End: 0}, Limit: 50 << 20, Burst: 512 << 10},
+	}, WithScheduleClock(clock))
+
+	// Act & Assert
+	assertEqual(t, 0, limiter.Active(), "00:00 应该处于第一个时间段")
+	assertEqual(t, rate.Limit(100<<20), limiter.Limit(), "夜间速率")
+	assertEqual(t, 1<<20, limiter.Burst(), "夜间突发容量")
+
+	clock.Advance(6 * time.Hour)
+	assertEqual(t, -1, limiter.Active(), "06:00 应该回到默认速率")
+	assertEqual(t, rate.Limit(10<<20), limiter.Limit(), "默认速率")
+	assertEqual(t, 64<<10, limiter.Burst(), "默认突发容量")
+
+	clock.Advance(17 * time.Hour)
+	assertEqual(t, 1, limiter.Active(), "23:00 应该处于跨午夜的时间段")
+	assertEqual(t, rate.Limit(50<<20), limiter.Limit(), "跨午夜时间段的速率")
+}
+
+// TestScheduledLimiter_WaitN 测试等待使用当前时间段的突发容量
+func TestScheduledLimiter_WaitN(t *testing.T) {
+	// Arrange: 当前时间段的突发容量为 1MB，默认只有 64KB
+	clock := newFakeClock()
+	limiter := NewScheduledLimiter(10<<20, 64<<10, []RateProfile{
+		{Window: Window{Start: 0, End: time.Hour}, Limit: 100 << 20, Burst: 1 << 20},
+	}, WithScheduleClock(clock))
+
+	// Act & Assert
+	assertNoError(t, limiter.WaitN(context.Background(), 512<<10), "时间段内应该按更大的突发容量放行")
+	clock.Advance(time.Hour)
+	if err := limiter.WaitN(context.Background(), 512<<10); err == nil {
+		t.Error("回到默认速率后超过默认突发容量的申请应该失败")
+	}
+}
@@ -0,0 +1,117 @@
+package ratelimited
+
+import (
+	"expvar"
+	"sync"
+	"time"
+)
+
+// =============================================================================
+// 统计汇聚 - 将写入统计同时推送到多个后端
+// =============================================================================
+
+// StatsSink 写入统计的接收端
+//
+// 每次成功写入后，写入器按注册顺序同步调用所有 Sink。
+// 实现必须是并发安全的，且应尽量廉价；开销较大的后端可以用 NewBatchingSink 包装。
+type StatsSink interface {
+	// RecordWrite 记录一次写入：n 为接收的字节数，waited 为本次写入等待令牌的时间
+	RecordWrite(n int, waited time.Duration)
+}
+
+// StatsSinkFunc 将普通函数适配为 StatsSink
+type StatsSinkFunc func(n int, waited time.Duration)
+
+// RecordWrite 实现 StatsSink 接口
+func (f StatsSinkFunc) RecordWrite(n int, waited time.Duration) {
+	f(n, waited)
+}
+
+// WithStatsSinks 注册统计接收端，可多次调用追加
+func WithStatsSinks(sinks ...StatsSink) DiscardWriterOption {
+	return func(w *DiscardWriter) {
+		for _, sink := range sinks {
+			if sink != nil {
+				w.sinks = append(w.sinks, sink)
+			}
+		}
+	}
+}
+
+// NewLogSink 创建将每次写入输出到日志函数的 Sink
+//
+// logf 的签名与 log.Printf 兼容。
+func NewLogSink(logf func(format string, args ...any)) StatsSink {
+	return StatsSinkFunc(func(n int, waited time.Duration) {
+		logf("ratelimited: write %d bytes, waited %s", n, waited)
+	})
+}
+
+// NewExpvarSink 创建将统计累加到 expvar.Map 的 Sink
+//
+// 使用的键为 "bytes"、"writes" 和 "wait_ns"。
+func NewExpvarSink(m *expvar.Map) StatsSink {
+	return StatsSinkFunc(func(n int, waited time.Duration) {
+		m.Add("bytes", int64(n))
+		m.Add("writes", 1)
+		m.Add("wait_ns", int64(waited))
+	})
+}
+
+// BatchingSink 累积若干次写入后一次性转发给内部 Sink
+type BatchingSink struct {
+	inner StatsSink
+	every int
+
+	mu     sync.Mutex
+	count  int
+	bytes  int
+	waited time.Duration
+}
+
+// NewBatchingSink 包装 inner，每累积 every 次写入才转发一次合并后的记录
+//
+// 合并记录的 n 与 waited 分别为这批写入的字节总数和等待总时长。
+// 未满一批的记录可以通过 Flush 手动转发。
+func NewBatchingSink(inner StatsSink, every int) *BatchingSink {
+	if every < 1 {
+		every = 1
+	}
+	return &BatchingSink{inner: inner, every: every}
+}
+
+// RecordWrite 实现 StatsSink 接口
+func (b *BatchingSink) RecordWrite(n int, waited time.Duration) {
+	b.mu.Lock()
+	b.count++
+	b.bytes += n
+	b.waited += waited
+	if b.count < b.every {
+		b.mu.Unlock()
+		return
+	}
+	bytes, total := b.take()
+	b.mu.Unlock()
+
+	b.inner.RecordWrite(bytes, total)
+}
+
+// Flush 立即转发尚未凑满一批的记录
+func (b *BatchingSink) Flush() {
+	b.mu.Lock()
+	if b.count == 0 {
+		b.mu.Unlock()
+		return
+	}
+	bytes, total := b.take()
+	b.mu.Unlock()
+
+	b.inner.RecordWrite(bytes, total)
+}
+
+// take 取出并清零当前累积值，调用方必须持有锁
+func (b *BatchingSink) take() (int, time.Duration) {
+	bytes, total := b.bytes, b.waited
+	b.count, b.bytes, b.waited = 0, 0, 0
+	return bytes, total
+}
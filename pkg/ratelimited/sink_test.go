@@ -0,0 +1,143 @@
+package ratelimited
+
+import (
+	"expvar"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingSink 记录收到的所有写入统计
+type recordingSink struct {
+	mu      sync.Mutex
+	records []sinkRecord
+}
+
+// sinkRecord 一次 RecordWrite 调用的参数
+type sinkRecord struct {
+	n      int
+	waited time.Duration
+}
+
+func (s *recordingSink) RecordWrite(n int, waited time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records = append(s.records, sinkRecord{n: n, waited: waited})
+}
+
+// snapshot 返回已记录统计的副本
+func (s *recordingSink) snapshot() []sinkRecord {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]sinkRecord(nil), s.records...)
+}
+
+// TestWithStatsSinks_MultipleSinks 测试每次写入通知所有接收端
+//
+// 测试目标：
+//   - 两个接收端都收到相同的写入记录
+//   - 等待时间只在申请令牌的写入上非零
+func TestWithStatsSinks_MultipleSinks(t *testing.T) {
+	// Arrange
+	clock := newFakeClock()
+	limiter := &advancingLimiter{clock: clock, delay: 30 * time.Millisecond}
+	first, second := &recordingSink{}, &recordingSink{}
+
+	writer := NewDiscardWriter([]Limiter{limiter},
+		WithClock(clock),
+		WithBatchSize(200),
+		WithStatsSinks(first),
+		WithStatsSinks(second),
+	)
+
+	// Act: 第一次写入申请批次令牌，第二次复用
+	_, err := writer.Write(createTestData(100))
+	assertNoError(t, err, "第一次写入应该成功")
+	_, err = writer.Write(createTestData(50))
+	assertNoError(t, err, "第二次写入应该成功")
+
+	// Assert
+	expected := []sinkRecord{
+		{n: 100, waited: 30 * time.Millisecond},
+		{n: 50, waited: 0},
+	}
+	for name, sink := range map[string]*recordingSink{"first": first, "second": second} {
+		records := sink.snapshot()
+		assertEqual(t, len(expected), len(records), name+" 应该收到所有写入记录")
+		for i := range expected {
+			if i < len(records) {
+				assertEqual(t, expected[i], records[i], name+" 的写入记录应该正确")
+			}
+		}
+	}
+}
+
+// TestWithStatsSinks_SkipsFailedWrites 测试转发失败的写入不通知接收端
+func TestWithStatsSinks_SkipsFailedWrites(t *testing.T) {
+	// Arrange
+	sink := &recordingSink{}
+	writer := NewWriter(failingDestination{}, []Limiter{&countingLimiter{}}, WithStatsSinks(sink))
+
+	// Act
+	_, err := writer.Write(createTestData(100))
+
+	// Assert
+	if err == nil {
+		t.Fatal("下游失败时写入应该返回错误")
+	}
+	assertEqual(t, 0, len(sink.snapshot()), "失败的写入不应该通知接收端")
+}
+
+// TestNewExpvarSink 测试 expvar 接收端累加统计
+func TestNewExpvarSink(t *testing.T) {
+	// Arrange
+	m := new(expvar.Map).Init()
+	sink := NewExpvarSink(m)
+
+	// Act
+	sink.RecordWrite(100, time.Millisecond)
+	sink.RecordWrite(50, 0)
+
+	// Assert
+	assertEqual(t, "150", m.Get("bytes").String(), "字节数应该累加")
+	assertEqual(t, "2", m.Get("writes").String(), "写入次数应该累加")
+	assertEqual(t, "1000000", m.Get("wait_ns").String(), "等待时间应该累加")
+}
+
+// TestNewLogSink 测试日志接收端输出写入信息
+func TestNewLogSink(t *testing.T) {
+	// Arrange
+	var lines []string
+	sink := NewLogSink(func(format string, args ...any) {
+		lines = append(lines, format)
+	})
+
+	// Act
+	sink.RecordWrite(10, 0)
+
+	// Assert
+	assertEqual(t, 1, len(lines), "每次写入应该输出一条日志")
+}
+
+// TestBatchingSink 测试批量转发
+func TestBatchingSink(t *testing.T) {
+	// Arrange
+	inner := &recordingSink{}
+	sink := NewBatchingSink(inner, 3)
+
+	// Act
+	for i := 0; i < 4; i++ {
+		sink.RecordWrite(10, time.Millisecond)
+	}
+
+	// Assert: 满一批转发一次
+	records := inner.snapshot()
+	assertEqual(t, 1, len(records), "满一批应该转发一次")
+	assertEqual(t, sinkRecord{n: 30, waited: 3 * time.Millisecond}, records[0], "合并记录应该累加")
+
+	// Flush 转发剩余记录
+	sink.Flush()
+	records = inner.snapshot()
+	assertEqual(t, 2, len(records), "Flush 应该转发剩余记录")
+	assertEqual(t, sinkRecord{n: 10, waited: time.Millisecond}, records[1], "剩余记录应该正确")
+}
@@ -0,0 +1,116 @@
+package ratelimited
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// =============================================================================
+// 结构化日志装饰器 - 通过 slog 暴露限流行为
+// =============================================================================
+
+// WithLogger 设置写入器记录后台告警使用的 logger，为 nil 时使用 slog.Default()（默认）
+//
+// 用于无法通过返回值报告的情况，例如速率控制通道收到未知层级的更新、
+// 写入器被回收时仍未调用 Close。
+func WithLogger(logger *slog.Logger) DiscardWriterOption {
+	return func(w *DiscardWriter) {
+		w.logger = logger
+	}
+}
+
+// loggerOrDefault 返回 logger，为 nil 时返回 slog.Default()
+func loggerOrDefault(logger *slog.Logger) *slog.Logger {
+	if logger == nil {
+		return slog.Default()
+	}
+	return logger
+}
+
+// SlogLimiter 把令牌申请、长时间等待和错误记录到 slog 的限制器装饰器，实现 Limiter 接口
+//
+// 每次申请以 Debug 级别记录；等待超过阈值时以 Warn 级别记录，便于发现严重的限流；
+// 内部限制器返回错误时以 Warn 级别记录（上下文取消以 Debug 级别记录）。
+// 所有记录都带有层级名称（layer）、申请的令牌数（tokens）和等待时长（waited）属性。
+// 装饰器不改变准入行为，错误原样透传。
+type SlogLimiter struct {
+	inner     Limiter
+	name      string
+	logger    *slog.Logger
+	threshold time.Duration
+	clock     Clock
+}
+
+// SlogOption 结构化日志装饰器的配置选项
+type SlogOption func(*SlogLimiter)
+
+// WithSlowWaitThreshold 设置以 Warn 级别记录的等待时长阈值，默认 1 秒；d <= 0 时不记录长时间等待
+func WithSlowWaitThreshold(d time.Duration) SlogOption {
+	return func(l *SlogLimiter) {
+		l.threshold = d
+	}
+}
+
+// WithSlogClock 设置测量等待时长使用的时钟，默认使用系统时钟
+func WithSlogClock(c Clock) SlogOption {
+	return func(l *SlogLimiter) {
+		if c != nil {
+			l.clock = c
+		}
+	}
+}
+
+// NewSlogLimiter 创建把名为 name 的层级 inner 的申请记录到 logger 的装饰器
+//
+// logger 为 nil 时使用 slog.Default()。
+func NewSlogLimiter(name string, inner Limiter, logger *slog.Logger, opts ...SlogOption) *SlogLimiter {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	l := &SlogLimiter{
+		inner:     inner,
+		name:      name,
+		logger:    logger,
+		threshold: time.Second,
+		clock:     systemClock{},
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
+}
+
+// WaitN 实现 Limiter 接口
+func (l *SlogLimiter) WaitN(ctx context.Context, n int) error {
+	start := l.clock.Now()
+	err := l.inner.WaitN(ctx, n)
+	waited := l.clock.Now().Sub(start)
+
+	attrs := []slog.Attr{
+		slog.String("layer", l.name),
+		slog.Int("tokens", n),
+		slog.Duration("waited", waited),
+	}
+	switch {
+	case err != nil && ctx.Err() != nil:
+		l.logger.LogAttrs(ctx, slog.LevelDebug, "ratelimited: wait canceled", append(attrs, slog.Any("error", err))...)
+	case err != nil:
+		l.logger.LogAttrs(ctx, slog.LevelWarn, "ratelimited: wait failed", append(attrs, slog.Any("error", err))...)
+	case l.threshold > 0 && waited > l.threshold:
+		l.logger.LogAttrs(ctx, slog.LevelWarn, "ratelimited: slow wait", attrs...)
+	default:
+		l.logger.LogAttrs(ctx, slog.LevelDebug, "ratelimited: tokens granted", attrs...)
+	}
+	return err
+}
+
+// Unwrap 返回内部的限制器
+func (l *SlogLimiter) Unwrap() Limiter {
+	return l.inner
+}
+
+// WriteScope 转发内部限制器的计费方式（见 PerRequest 与 ConcurrencyLimiter）
+func (l *SlogLimiter) WriteScope() Limiter {
+	return writeScope(l.inner)
+}
@@ -0,0 +1,65 @@
+package ratelimited
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+// failingWaitLimiter 总是返回指定错误的限制器
+type failingWaitLimiter struct {
+	err error
+}
+
+func (f failingWaitLimiter) WaitN(ctx context.Context, n int) error {
+	return f.err
+}
+
+// TestSlogLimiter 测试按申请结果选择日志级别并附带层级属性
+//
+// 测试目标：
+//   - 普通申请以 Debug 级别记录
+//   - 等待超过阈值以 Warn 级别记录
+//   - 错误以 Warn 级别记录并原样透传
+func TestSlogLimiter(t *testing.T) {
+	// Arrange
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	boom := errors.New("boom")
+	ctx := context.Background()
+
+	// Act
+	fast := NewSlogLimiter("fast", &countingRequestLimiter{}, logger)
+	slow := NewSlogLimiter("slow", sleepyWaitLimiter{d: 5 * time.Millisecond}, logger,
+		WithSlowWaitThreshold(time.Millisecond))
+	failing := NewSlogLimiter("failing", failingWaitLimiter{err: boom}, logger)
+	assertNoError(t, fast.WaitN(ctx, 10), "普通申请")
+	assertNoError(t, slow.WaitN(ctx, 10), "慢申请")
+	err := failing.WaitN(ctx, 10)
+
+	// Assert
+	if !errors.Is(err, boom) {
+		t.Errorf("错误应该原样透传，实际 %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("应该记录 3 条日志，实际 %d 条:\n%s", len(lines), buf.String())
+	}
+	expects := []struct{ level, layer string }{
+		{"level=DEBUG", "layer=fast"},
+		{"level=WARN", "layer=slow"},
+		{"level=WARN", "layer=failing"},
+	}
+	for i, want := range expects {
+		if !strings.Contains(lines[i], want.level) || !strings.Contains(lines[i], want.layer) {
+			t.Errorf("第 %d 条日志应该包含 %s 和 %s，实际 %s", i+1, want.level, want.layer, lines[i])
+		}
+	}
+	if !strings.Contains(lines[2], "error=boom") {
+		t.Errorf("错误日志应该包含错误信息，实际 %s", lines[2])
+	}
+}
@@ -0,0 +1,50 @@
+package ratelimited
+
+import (
+	"errors"
+	"fmt"
+)
+
+// =============================================================================
+// 限制器状态交换 - 分布式协调的基础
+// =============================================================================
+
+// StateExporter 可以导出和合并内部状态的限制器
+//
+// 用于在多个节点之间交换本地消耗，以近似地执行全局上限（例如基于 gossip 的分布式限流）。
+// 状态的编码格式由实现自行决定，只要求同一实现导出的状态可以被其 MergeState 接受。
+// MergeState 应当是幂等且可交换的（例如按节点取最大值的计数器），
+// 这样重复或乱序收到的状态不会导致重复计数。*rate.Limiter 没有可导出的状态。
+type StateExporter interface {
+	// ExportState 返回当前状态的编码
+	ExportState() []byte
+	// MergeState 将另一个节点导出的状态合并到本地
+	MergeState(state []byte) error
+}
+
+// MergeChainStates 将远端链中各层的状态合并到本地链的对应层
+//
+// 两条链按位置一一对应，长度必须相同。只有两侧都实现 StateExporter 的层会被合并，
+// 其余层（如 *rate.Limiter 或 nil）被跳过。某一层合并失败不会中断其他层，
+// 所有错误会带上层级位置合并返回。
+func MergeChainStates(local, remote []Limiter) error {
+	if len(local) != len(remote) {
+		return fmt.Errorf("ratelimited: cannot merge chains of different length (%d vs %d)", len(local), len(remote))
+	}
+
+	var errs []error
+	for i := range local {
+		dst, ok := local[i].(StateExporter)
+		if !ok {
+			continue
+		}
+		src, ok := remote[i].(StateExporter)
+		if !ok {
+			continue
+		}
+		if err := dst.MergeState(src.ExportState()); err != nil {
+			errs = append(errs, fmt.Errorf("limiter[%d]: %w", i, err))
+		}
+	}
+	return errors.Join(errs...)
+}
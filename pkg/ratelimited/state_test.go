@@ -0,0 +1,100 @@
+package ratelimited
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+
+	"golang.org/x/time/rate"
+)
+
+// gossipCounter 按节点记录消耗的限制器，状态为按节点取最大值的计数器（G-Counter）
+type gossipCounter struct {
+	node string
+
+	mu       sync.Mutex
+	consumed map[string]int64
+}
+
+func newGossipCounter(node string) *gossipCounter {
+	return &gossipCounter{node: node, consumed: make(map[string]int64)}
+}
+
+func (c *gossipCounter) WaitN(ctx context.Context, n int) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.consumed[c.node] += int64(n)
+	return nil
+}
+
+func (c *gossipCounter) ExportState() []byte {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	data, _ := json.Marshal(c.consumed)
+	return data
+}
+
+func (c *gossipCounter) MergeState(state []byte) error {
+	var remote map[string]int64
+	if err := json.Unmarshal(state, &remote); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for node, n := range remote {
+		if n > c.consumed[node] {
+			c.consumed[node] = n
+		}
+	}
+	return nil
+}
+
+// total 返回所有节点的消耗总和
+func (c *gossipCounter) total() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var sum int64
+	for _, n := range c.consumed {
+		sum += n
+	}
+	return sum
+}
+
+// TestMergeChainStates_CombinesConsumption 测试合并两个节点的消耗
+//
+// 测试目标：
+//   - 合并后本地状态反映两个节点的消耗总和
+//   - 重复合并是幂等的
+//   - 没有可导出状态的 rate.Limiter 被跳过
+func TestMergeChainStates_CombinesConsumption(t *testing.T) {
+	// Arrange
+	nodeA := newGossipCounter("a")
+	nodeB := newGossipCounter("b")
+	localChain := []Limiter{rate.NewLimiter(rate.Inf, 0), nodeA}
+	remoteChain := []Limiter{rate.NewLimiter(rate.Inf, 0), nodeB}
+
+	_, err := NewDiscardWriter(localChain, WithBatchSize(1)).Write(createTestData(300))
+	assertNoError(t, err, "节点 A 写入应该成功")
+	_, err = NewDiscardWriter(remoteChain, WithBatchSize(1)).Write(createTestData(500))
+	assertNoError(t, err, "节点 B 写入应该成功")
+
+	// Act
+	assertNoError(t, MergeChainStates(localChain, remoteChain), "合并应该成功")
+	assertNoError(t, MergeChainStates(localChain, remoteChain), "重复合并应该成功")
+
+	// Assert
+	assertEqual(t, int64(800), nodeA.total(), "本地应该看到两个节点的总消耗")
+	assertEqual(t, int64(500), nodeB.total(), "远端状态不应该被修改")
+}
+
+// TestMergeChainStates_LengthMismatch 测试长度不同的链拒绝合并
+func TestMergeChainStates_LengthMismatch(t *testing.T) {
+	// Act
+	err := MergeChainStates([]Limiter{newGossipCounter("a")}, nil)
+
+	// Assert
+	if err == nil {
+		t.Error("长度不同的链应该返回错误")
+	}
+}
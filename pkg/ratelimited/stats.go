@@ -0,0 +1,45 @@
+package ratelimited
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// =============================================================================
+// 统计快照 - 写入器内部维护的统计
+// =============================================================================
+
+// WriterStats 写入器统计的快照，可以自由复制
+type WriterStats struct {
+	Bytes          int64         // 写入的字节数
+	Requests       uint64        // 写入次数
+	WaitTime       time.Duration // 等待令牌的累计时长
+	Batches        uint64        // 向限制器链申请令牌的次数
+	QuotaRemaining int64         // 共享配额的剩余字节数，未设置共享配额时为 -1
+	WaitHistogram  WaitHistogram // 每次写入等待令牌时长的分布，用于观察限流带来的尾延迟
+}
+
+// Stats 返回写入器自身统计的快照
+//
+// 统计由写入器内部维护，不需要注入计数器指针；WithBytesCounter 等选项仍然可用，
+// 两者互不影响。各字段分别原子读取，并发写入时快照不保证字段之间严格一致。
+func (w *DiscardWriter) Stats() WriterStats {
+	stats := WriterStats{
+		Bytes:          atomic.LoadInt64(&w.bytesTotal),
+		Requests:       atomic.LoadUint64(&w.requestsTotal),
+		WaitTime:       time.Duration(atomic.LoadInt64(&w.waitTotal)),
+		Batches:        atomic.LoadUint64(&w.batchesTotal),
+		QuotaRemaining: -1,
+		WaitHistogram:  w.waitHist.snapshot(),
+	}
+	if w.quota != nil {
+		stats.QuotaRemaining = w.quota.Remaining()
+	}
+	return stats
+}
+
+// recordWait 记录一次向限制器链申请令牌的等待
+func (w *DiscardWriter) recordWait(waited time.Duration) {
+	atomic.AddUint64(&w.batchesTotal, 1)
+	atomic.AddInt64(&w.waitTotal, int64(waited))
+}
@@ -0,0 +1,61 @@
+package ratelimited
+
+import (
+	"testing"
+	"time"
+)
+
+// TestDiscardWriter_Stats 测试统计快照
+//
+// 测试目标：
+//   - 不注入计数器也能得到字节数、写入次数和申请次数
+//   - 等待时间按时钟累计
+//   - 配额剩余量与共享配额一致，未设置时为 -1
+func TestDiscardWriter_Stats(t *testing.T) {
+	t.Run("带配额", func(t *testing.T) {
+		// Arrange: 每次申请令牌推进虚拟时钟 10ms
+		clock := newFakeClock()
+		quota := int64(1000)
+		writer := NewDiscardWriter([]Limiter{&advancingLimiter{clock: clock, delay: 10 * time.Millisecond}},
+			WithClock(clock),
+			WithSharedQuota(&quota),
+		)
+
+		// Act
+		for range 3 {
+			_, err := writer.Write(createTestData(100))
+			assertNoError(t, err, "写入应该成功")
+		}
+		stats := writer.Stats()
+
+		// Assert
+		assertEqual(t, uint64(3), stats.WaitHistogram.Count(), "等待分布应该记录三次写入")
+		if p50 := stats.WaitHistogram.Quantile(0.5); p50 < 10*time.Millisecond || p50 > 12500*time.Microsecond {
+			t.Errorf("等待分布的中位数应该在 10ms 的桶内，实际 %s", p50)
+		}
+		stats.WaitHistogram = WaitHistogram{}
+		assertEqual(t, WriterStats{
+			Bytes:          300,
+			Requests:       3,
+			WaitTime:       30 * time.Millisecond,
+			Batches:        3,
+			QuotaRemaining: 700,
+		}, stats, "快照应该反映三次写入")
+	})
+
+	t.Run("无配额", func(t *testing.T) {
+		// Arrange
+		writer := NewDiscardWriter([]Limiter{&countingLimiter{}}, WithBatchSize(1000))
+
+		// Act
+		for range 5 {
+			_, _ = writer.Write(createTestData(100))
+		}
+		stats := writer.Stats()
+
+		// Assert
+		assertEqual(t, int64(500), stats.Bytes, "字节数应该累计")
+		assertEqual(t, uint64(1), stats.Batches, "批次内的写入不应该重复申请")
+		assertEqual(t, int64(-1), stats.QuotaRemaining, "未设置配额时为 -1")
+	})
+}
@@ -0,0 +1,508 @@
+package ratelimited
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// =============================================================================
+// 存储后端 - 分布式限制器与配额的可插拔基础
+// =============================================================================
+
+// Store 分布式限制器和配额共享状态的存储后端
+//
+// StoreLimiter 和 StoreQuota 只依赖该接口，Redis、etcd、DynamoDB 或内部服务
+// 实现它即可接入（redisrate 子包提供了 Redis 实现，MemoryStore 用于单进程和测试）。
+// 每个方法都必须对同一个 key 原子执行，并且可以被多个进程并发调用。
+type Store interface {
+	// TakeTokens 在 key 的令牌桶（速率 limit、突发容量 burst）上预留 n 个令牌，返回需要等待的时长
+	//
+	// 令牌可以被预留为负数，调用方等待返回的时长后即可使用。maxWait >= 0 且等待会超过它时
+	// 不预留并返回 *MaxWaitError；maxWait < 0 表示不限制。
+	TakeTokens(ctx context.Context, key string, limit rate.Limit, burst, n int, maxWait time.Duration) (time.Duration, error)
+	// ReturnTokens 把 n 个此前预留的令牌归还给 key 的令牌桶，令牌数不超过突发容量
+	ReturnTokens(ctx context.Context, key string, limit rate.Limit, burst, n int) error
+	// GetQuota 返回 key 的剩余配额，不存在时为 0
+	GetQuota(ctx context.Context, key string) (int64, error)
+	// SetQuota 把 key 的剩余配额设为 n
+	SetQuota(ctx context.Context, key string, n int64) error
+	// TakeQuota 从 key 的配额中扣除至多 n，返回实际扣除量和扣除后的剩余；n 为负数时归还 -n
+	TakeQuota(ctx context.Context, key string, n int64) (taken, remaining int64, err error)
+}
+
+// ErrStoreUnavailable FailClosed 模式下存储后端不可用时返回的错误
+var ErrStoreUnavailable = errors.New("ratelimited: store unavailable")
+
+// FailMode 存储后端不可用时分布式限制器和配额的行为
+type FailMode int
+
+const (
+	// FailLocal 限制器退化为本地限制器（默认），配额只使用已租到本地的余量
+	FailLocal FailMode = iota
+	// FailOpen 直接放行，不做任何限制
+	FailOpen
+	// FailClosed 限制器返回包装了 ErrStoreUnavailable 的错误，配额只使用已租到本地的余量
+	FailClosed
+)
+
+// storeOptions StoreLimiter 与 StoreQuota 共用的配置
+type storeOptions struct {
+	failMode  FailMode
+	fallback  Limiter
+	leaseSize int64
+	timeout   time.Duration
+	clock     Clock
+}
+
+// StoreOption 分布式限制器和配额的配置选项
+type StoreOption func(*storeOptions)
+
+// WithFailMode 设置存储后端不可用时的行为，默认为 FailLocal
+func WithFailMode(mode FailMode) StoreOption {
+	return func(o *storeOptions) {
+		o.failMode = mode
+	}
+}
+
+// WithFallback 设置 FailLocal 模式下限制器使用的本地限制器
+//
+// 默认使用速率和突发容量相同的 *rate.Limiter。后端不可用期间各进程分别按本地限制器放行，
+// 整个集群的总速率可能达到单个进程的若干倍，需要时可以传入按进程数折算的更低速率。
+func WithFallback(fallback Limiter) StoreOption {
+	return func(o *storeOptions) {
+		o.fallback = fallback
+	}
+}
+
+// WithLeaseSize 设置配额每次从后端租用的字节数，默认 1MB
+func WithLeaseSize(n int64) StoreOption {
+	return func(o *storeOptions) {
+		if n > 0 {
+			o.leaseSize = n
+		}
+	}
+}
+
+// WithStoreTimeout 设置配额访问后端的超时，默认 1 秒
+//
+// 作用于 StoreQuota 不受调用方上下文约束的后端请求：写入时的租用和 Close 的归还。
+// 超时的租用按后端不可用处理。
+func WithStoreTimeout(d time.Duration) StoreOption {
+	return func(o *storeOptions) {
+		if d > 0 {
+			o.timeout = d
+		}
+	}
+}
+
+// WithStoreClock 设置限制器等待和 MemoryStore 补充令牌使用的时钟，默认使用系统时钟
+func WithStoreClock(c Clock) StoreOption {
+	return func(o *storeOptions) {
+		if c != nil {
+			o.clock = c
+		}
+	}
+}
+
+const (
+	// defaultLeaseSize 配额每次从后端租用的默认字节数
+	defaultLeaseSize = 1 << 20
+	// defaultStoreTimeout 配额访问后端的默认超时
+	defaultStoreTimeout = time.Second
+)
+
+// newStoreOptions 应用选项并填充默认值
+func newStoreOptions(opts []StoreOption) storeOptions {
+	o := storeOptions{leaseSize: defaultLeaseSize, timeout: defaultStoreTimeout, clock: systemClock{}}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// =============================================================================
+// StoreLimiter - 基于存储后端的分布式令牌桶
+// =============================================================================
+
+// StoreLimiter 令牌桶状态保存在 Store 中的分布式限制器，实现 Limiter 接口
+//
+// 共享同一 key 的所有进程共同消耗同一个令牌桶，让多个进程共享链中的同一层。
+type StoreLimiter struct {
+	store Store
+	key   string
+	limit rate.Limit
+	burst int
+	opts  storeOptions
+}
+
+// NewStoreLimiter 创建以 store 中的 key 为令牌桶、速率为 limit、突发容量为 burst 的分布式限制器
+//
+// 共享同一 key 的所有进程应当使用相同的 limit 和 burst。
+func NewStoreLimiter(store Store, key string, limit rate.Limit, burst int, opts ...StoreOption) *StoreLimiter {
+	o := newStoreOptions(opts)
+	if o.fallback == nil {
+		o.fallback = rate.NewLimiter(limit, burst)
+	}
+	return &StoreLimiter{store: store, key: key, limit: limit, burst: burst, opts: o}
+}
+
+// WaitN 实现 Limiter 接口，阻塞直到后端的令牌桶放行 n 个令牌
+//
+// 等待会超过 ctx 的 deadline 时不预留令牌并立即返回错误，与 *rate.Limiter 的行为一致。
+// 令牌在后端预留后本地等待，ctx 在等待期间结束时把预留的令牌归还给后端。
+// 后端调用失败时按 FailMode 处理。
+func (l *StoreLimiter) WaitN(ctx context.Context, n int) error {
+	if l.limit == rate.Inf {
+		return nil
+	}
+	if n > l.burst {
+		return fmt.Errorf("ratelimited: WaitN(n=%d) exceeds limiter's burst %d", n, l.burst)
+	}
+	if l.limit <= 0 {
+		return fmt.Errorf("ratelimited: WaitN(n=%d) with zero rate would wait forever", n)
+	}
+
+	maxWait := time.Duration(-1)
+	if deadline, ok := ctx.Deadline(); ok {
+		maxWait = max(deadline.Sub(l.opts.clock.Now()), 0)
+	}
+	wait, err := l.store.TakeTokens(ctx, l.key, l.limit, l.burst, n, maxWait)
+	var mwErr *MaxWaitError
+	if errors.As(err, &mwErr) {
+		return fmt.Errorf("ratelimited: WaitN(n=%d) would exceed context deadline: %w", n, context.DeadlineExceeded)
+	}
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		return l.degrade(ctx, n, err)
+	}
+	if wait <= 0 {
+		return nil
+	}
+
+	if err := Sleep(ctx, l.opts.clock, wait); err != nil {
+		// 归还失败时预留的令牌随时间自然补充，不影响本次返回的错误
+		_ = l.store.ReturnTokens(context.Background(), l.key, l.limit, l.burst, n)
+		return err
+	}
+	return nil
+}
+
+// ReturnN 实现 TokenReturner 接口，把未使用的令牌归还给后端
+func (l *StoreLimiter) ReturnN(n int) {
+	if l.limit == rate.Inf || n <= 0 {
+		return
+	}
+	_ = l.store.ReturnTokens(context.Background(), l.key, l.limit, l.burst, n)
+}
+
+// Limit 返回限制器的速率
+func (l *StoreLimiter) Limit() rate.Limit {
+	return l.limit
+}
+
+// Burst 返回限制器的突发容量
+func (l *StoreLimiter) Burst() int {
+	return l.burst
+}
+
+// degrade 按 FailMode 处理后端调用失败
+func (l *StoreLimiter) degrade(ctx context.Context, n int, err error) error {
+	switch l.opts.failMode {
+	case FailOpen:
+		return nil
+	case FailClosed:
+		return errors.Join(ErrStoreUnavailable, err)
+	default:
+		return l.opts.fallback.WaitN(ctx, n)
+	}
+}
+
+// =============================================================================
+// StoreQuota - 基于存储后端的分布式配额
+// =============================================================================
+
+// StoreQuota 集群共享的字节配额，预算保存在 Store 中，实现 Quota 接口
+//
+// 为避免每次写入都访问后端，配额以租约为单位批量扣除到本地，写入从本地余量中扣除；
+// Release 退还到本地余量，Flush / Close 把本地未用完的余量归还给后端。
+// 因此某个进程的本地余量对其他进程暂时不可见，租约越大往返越少、但预算的分配越不均匀。
+// 后端不可用时只能使用已租到的本地余量，FailOpen 模式下则直接放行。
+//
+// 访问后端期间不持有锁：同一时刻最多只有一个租用请求，其他需要租用的写入等待它完成后
+// 重新检查本地余量，不需要租用的写入和 Remaining 等查询不受影响。
+// 租用的超时由 WithStoreTimeout 设置。
+type StoreQuota struct {
+	store Store
+	key   string
+	opts  storeOptions
+
+	mu         sync.Mutex
+	local      int64         // 已租到本地、尚未使用的字节数
+	lastRemote int64         // 最近一次租用后后端中的剩余预算
+	lastErr    error         // 最近一次访问后端的错误
+	leasing    chan struct{} // 正在进行的租用，完成时关闭；nil 表示没有租用
+}
+
+// NewStoreQuota 创建使用 store 中的 key 作为共享预算的分布式配额
+//
+// 预算由运维方通过 Store.SetQuota 设置，本类型只做扣除和归还。
+func NewStoreQuota(store Store, key string, opts ...StoreOption) *StoreQuota {
+	return &StoreQuota{store: store, key: key, opts: newStoreOptions(opts)}
+}
+
+// AcquireUpTo 实现 Quota 接口，本地余量不足时向后端租用
+func (q *StoreQuota) AcquireUpTo(n int64) (int64, int64) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.refill(n)
+	available := q.local + max(q.lastRemote, 0)
+	if q.failOpen() {
+		return n, max(available, n)
+	}
+	if q.local <= 0 {
+		return 0, available
+	}
+	got := min(n, q.local)
+	q.local -= got
+	return got, available
+}
+
+// Acquire 实现 Quota 接口，本地余量加上可租到的部分不足 n 时不扣除
+func (q *StoreQuota) Acquire(n int64) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.refill(n)
+	if q.local < n {
+		return q.failOpen()
+	}
+	q.local -= n
+	return true
+}
+
+// Release 实现 Quota 接口，退还到本地余量；负数表示补扣，从本地余量中扣除
+func (q *StoreQuota) Release(n int64) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.local += n
+}
+
+// Remaining 实现 Quota 接口，返回本地余量加上最近一次观察到的后端剩余预算
+//
+// 为避免往返，后端部分是最近一次租用时的值，其他进程之后的消耗不会反映出来。
+func (q *StoreQuota) Remaining() int64 {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.local + max(q.lastRemote, 0)
+}
+
+// Err 返回最近一次访问后端的错误，成功后被清除
+func (q *StoreQuota) Err() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.lastErr
+}
+
+// Flush 把本地未用完的余量（或补扣产生的欠额）归还给后端
+//
+// 归还期间不持有锁，余量先从本地移出，归还失败时再放回。
+func (q *StoreQuota) Flush(ctx context.Context) error {
+	q.mu.Lock()
+	local := q.local
+	q.local = 0
+	q.mu.Unlock()
+	if local == 0 {
+		return nil
+	}
+
+	_, remaining, err := q.store.TakeQuota(ctx, q.key, -local)
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if err != nil {
+		q.local += local
+		q.lastErr = err
+		return err
+	}
+	q.lastRemote = remaining
+	return nil
+}
+
+// Close 归还本地余量，进程退出前应调用以免预算被本地余量带走
+//
+// 归还最多等待 WithStoreTimeout 设置的超时。
+func (q *StoreQuota) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), q.opts.timeout)
+	defer cancel()
+	return q.Flush(ctx)
+}
+
+// refill 本地余量不足 n 时向后端租用，调用方需持有锁
+//
+// 已有租用在进行时等待它完成：完成后余量足够或该次租用失败时直接返回，
+// 否则再由本调用发起一次租用。
+func (q *StoreQuota) refill(n int64) {
+	leased := false
+	for q.local < n {
+		if done := q.leasing; done != nil {
+			q.mu.Unlock()
+			<-done
+			q.mu.Lock()
+			if q.lastErr != nil {
+				return
+			}
+			continue
+		}
+		if leased {
+			return
+		}
+		q.lease(n - q.local)
+		leased = true
+	}
+}
+
+// lease 从后端租用至少 need 字节（不少于租约大小），调用方需持有锁
+//
+// 请求期间释放锁，并通过 leasing 让其他需要租用的调用方等待本次结果。
+func (q *StoreQuota) lease(need int64) {
+	done := make(chan struct{})
+	q.leasing = done
+	q.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), q.opts.timeout)
+	taken, remaining, err := q.store.TakeQuota(ctx, q.key, max(need, q.opts.leaseSize))
+	cancel()
+
+	q.mu.Lock()
+	q.leasing = nil
+	close(done)
+	if err != nil {
+		q.lastErr = err
+		return
+	}
+	q.lastErr = nil
+	q.local += taken
+	q.lastRemote = remaining
+}
+
+// failOpen 判断是否因后端不可用而直接放行，调用方需持有锁
+func (q *StoreQuota) failOpen() bool {
+	return q.opts.failMode == FailOpen && q.lastErr != nil
+}
+
+// =============================================================================
+// MemoryStore - 进程内的存储后端
+// =============================================================================
+
+// MemoryStore 把状态保存在进程内存中的 Store 实现，并发安全
+//
+// 只能在单个进程内共享，适合测试、示例以及暂不需要分布式后端的部署。
+type MemoryStore struct {
+	mu      sync.Mutex
+	clock   Clock
+	buckets map[string]*memoryBucket
+	quotas  map[string]int64
+}
+
+// memoryBucket 一个 key 的令牌桶状态
+type memoryBucket struct {
+	tokens float64
+	ts     time.Time
+}
+
+// NewMemoryStore 创建空的进程内存储后端
+//
+// 只有 WithStoreClock 选项对 MemoryStore 生效。
+func NewMemoryStore(opts ...StoreOption) *MemoryStore {
+	return &MemoryStore{
+		clock:   newStoreOptions(opts).clock,
+		buckets: make(map[string]*memoryBucket),
+		quotas:  make(map[string]int64),
+	}
+}
+
+// TakeTokens 实现 Store 接口
+func (s *MemoryStore) TakeTokens(ctx context.Context, key string, limit rate.Limit, burst, n int, maxWait time.Duration) (time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b := s.bucket(key, limit, burst)
+	var wait time.Duration
+	if b.tokens < float64(n) {
+		wait = time.Duration(math.Ceil((float64(n) - b.tokens) / float64(limit) * float64(time.Second)))
+	}
+	if maxWait >= 0 && wait > maxWait {
+		return 0, &MaxWaitError{Estimated: wait, MaxWait: maxWait}
+	}
+	b.tokens -= float64(n)
+	return wait, nil
+}
+
+// ReturnTokens 实现 Store 接口
+func (s *MemoryStore) ReturnTokens(ctx context.Context, key string, limit rate.Limit, burst, n int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b := s.bucket(key, limit, burst)
+	b.tokens = min(b.tokens+float64(n), float64(burst))
+	return nil
+}
+
+// GetQuota 实现 Store 接口
+func (s *MemoryStore) GetQuota(ctx context.Context, key string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.quotas[key], nil
+}
+
+// SetQuota 实现 Store 接口
+func (s *MemoryStore) SetQuota(ctx context.Context, key string, n int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.quotas[key] = n
+	return nil
+}
+
+// TakeQuota 实现 Store 接口
+func (s *MemoryStore) TakeQuota(ctx context.Context, key string, n int64) (int64, int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	taken := n
+	if n > 0 {
+		taken = min(n, max(s.quotas[key], 0))
+	}
+	s.quotas[key] -= taken
+	return taken, s.quotas[key], nil
+}
+
+// bucket 返回 key 的令牌桶并按经过的时间补充令牌，调用方需持有锁
+func (s *MemoryStore) bucket(key string, limit rate.Limit, burst int) *memoryBucket {
+	now := s.clock.Now()
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &memoryBucket{tokens: float64(burst), ts: now}
+		s.buckets[key] = b
+	}
+	if now.After(b.ts) {
+		b.tokens = min(float64(burst), b.tokens+now.Sub(b.ts).Seconds()*float64(limit))
+		b.ts = now
+	}
+	return b
+}
+
+var (
+	_ Store         = (*MemoryStore)(nil)
+	_ Limiter       = (*StoreLimiter)(nil)
+	_ TokenReturner = (*StoreLimiter)(nil)
+	_ Quota         = (*StoreQuota)(nil)
+)
@@ -0,0 +1,247 @@
+package ratelimited
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// failingStore 所有调用都失败的存储后端，模拟后端不可用
+type failingStore struct{}
+
+var errStoreDown = errors.New("store down")
+
+func (failingStore) TakeTokens(context.Context, string, rate.Limit, int, int, time.Duration) (time.Duration, error) {
+	return 0, errStoreDown
+}
+func (failingStore) ReturnTokens(context.Context, string, rate.Limit, int, int) error {
+	return errStoreDown
+}
+func (failingStore) GetQuota(context.Context, string) (int64, error) { return 0, errStoreDown }
+func (failingStore) SetQuota(context.Context, string, int64) error   { return errStoreDown }
+func (failingStore) TakeQuota(context.Context, string, int64) (int64, int64, error) {
+	return 0, 0, errStoreDown
+}
+
+// TestMemoryStore_TakeTokens 测试进程内令牌桶的预留、上限和归还
+func TestMemoryStore_TakeTokens(t *testing.T) {
+	// Arrange: 每秒 1000 个令牌，突发 100
+	clock := newFakeClock()
+	store := NewMemoryStore(WithStoreClock(clock))
+	ctx := context.Background()
+
+	// Act & Assert
+	wait, err := store.TakeTokens(ctx, "k", 1000, 100, 100, -1)
+	assertNoError(t, err, "突发容量内的预留")
+	assertEqual(t, time.Duration(0), wait, "突发容量内不应该等待")
+
+	_, err = store.TakeTokens(ctx, "k", 1000, 100, 50, 10*time.Millisecond)
+	var mwErr *MaxWaitError
+	if !errors.As(err, &mwErr) || mwErr.Estimated != 50*time.Millisecond {
+		t.Errorf("等待超过上限时应该返回预计 50ms 的 *MaxWaitError，实际 %v", err)
+	}
+
+	assertNoError(t, store.ReturnTokens(ctx, "k", 1000, 100, 30), "归还令牌")
+	wait, err = store.TakeTokens(ctx, "k", 1000, 100, 50, -1)
+	assertNoError(t, err, "不限制等待的预留")
+	assertEqual(t, 20*time.Millisecond, wait, "归还的 30 个令牌应该抵扣等待")
+
+	clock.Advance(time.Second)
+	wait, _ = store.TakeTokens(ctx, "k", 1000, 100, 100, -1)
+	assertEqual(t, time.Duration(0), wait, "补充后令牌不应超过突发容量并立即放行")
+}
+
+// TestMemoryStore_Quota 测试进程内配额的设置、扣除和归还
+func TestMemoryStore_Quota(t *testing.T) {
+	// Arrange
+	store := NewMemoryStore()
+	ctx := context.Background()
+	assertNoError(t, store.SetQuota(ctx, "q", 100), "设置配额")
+
+	// Act
+	taken, remaining, err := store.TakeQuota(ctx, "q", 150)
+
+	// Assert
+	assertNoError(t, err, "扣除配额")
+	assertEqual(t, int64(100), taken, "不足时应该扣走全部剩余")
+	assertEqual(t, int64(0), remaining, "扣除后的剩余")
+
+	taken, remaining, _ = store.TakeQuota(ctx, "q", -40)
+	assertEqual(t, int64(-40), taken, "负数表示归还")
+	assertEqual(t, int64(40), remaining, "归还后的剩余")
+	got, _ := store.GetQuota(ctx, "q")
+	assertEqual(t, int64(40), got, "GetQuota 应该返回当前剩余")
+}
+
+// TestStoreLimiter 测试基于存储后端的限制器
+//
+// 测试目标：
+//   - 共享同一 key 的限制器消耗同一个令牌桶
+//   - 等待会超过上下文 deadline 时立即失败
+//   - 后端不可用时按 FailMode 降级
+func TestStoreLimiter(t *testing.T) {
+	t.Run("SharedBucket", func(t *testing.T) {
+		// Arrange
+		store := NewMemoryStore()
+		first := NewStoreLimiter(store, "k", 1000, 100)
+		second := NewStoreLimiter(store, "k", 1000, 100)
+		ctx := context.Background()
+
+		// Act
+		assertNoError(t, first.WaitN(ctx, 100), "突发容量内的申请")
+		short, cancel := context.WithTimeout(ctx, 5*time.Millisecond)
+		defer cancel()
+		err := second.WaitN(short, 50)
+
+		// Assert
+		if err == nil {
+			t.Error("桶已被另一个实例耗尽，等待超过 deadline 时应该返回错误")
+		}
+	})
+
+	t.Run("FailModes", func(t *testing.T) {
+		// Arrange
+		ctx := context.Background()
+		fallback := &countingWaitLimiter{}
+
+		// Act
+		openErr := NewStoreLimiter(failingStore{}, "k", 10, 10, WithFailMode(FailOpen)).WaitN(ctx, 5)
+		closedErr := NewStoreLimiter(failingStore{}, "k", 10, 10, WithFailMode(FailClosed)).WaitN(ctx, 5)
+		localErr := NewStoreLimiter(failingStore{}, "k", 10, 10, WithFallback(fallback)).WaitN(ctx, 5)
+
+		// Assert
+		assertNoError(t, openErr, "FailOpen 应该直接放行")
+		if !errors.Is(closedErr, ErrStoreUnavailable) {
+			t.Errorf("FailClosed 应该返回 ErrStoreUnavailable，实际 %v", closedErr)
+		}
+		assertNoError(t, localErr, "FailLocal 应该由本地限制器放行")
+		assertEqual(t, 1, fallback.calls, "应该调用本地限制器一次")
+	})
+}
+
+// countingWaitLimiter 记录 WaitN 调用次数的限制器
+type countingWaitLimiter struct {
+	calls int
+}
+
+func (c *countingWaitLimiter) WaitN(ctx context.Context, n int) error {
+	c.calls++
+	return nil
+}
+
+// TestStoreQuota 测试基于存储后端的配额按租约扣除并在 Close 时归还
+func TestStoreQuota(t *testing.T) {
+	// Arrange: 共享预算 1000 字节，每次租用 300 字节
+	store := NewMemoryStore()
+	ctx := context.Background()
+	assertNoError(t, store.SetQuota(ctx, "budget", 1000), "设置预算")
+	quota := NewStoreQuota(store, "budget", WithLeaseSize(300))
+	writer := NewDiscardWriter(Chain(rate.NewLimiter(rate.Inf, 0)), WithQuota(quota))
+
+	// Act
+	for range 10 {
+		_, err := writer.Write(make([]byte, 10))
+		assertNoError(t, err, "预算内的写入")
+	}
+	afterWrites, _ := store.GetQuota(ctx, "budget")
+	assertNoError(t, quota.Close(), "归还本地余量")
+	afterClose, _ := store.GetQuota(ctx, "budget")
+
+	// Assert
+	assertEqual(t, int64(700), afterWrites, "10 次小写入应该只租用一次")
+	assertEqual(t, int64(900), afterClose, "Close 应该归还未用完的 200 字节")
+	assertEqual(t, int64(900), quota.Remaining(), "剩余应该反映后端的预算")
+}
+
+// TestStoreQuota_Unavailable 测试后端不可用时配额的行为
+func TestStoreQuota_Unavailable(t *testing.T) {
+	// Act
+	closed := NewStoreQuota(failingStore{}, "budget")
+	open := NewStoreQuota(failingStore{}, "budget", WithFailMode(FailOpen))
+
+	// Assert
+	if closed.Acquire(10) {
+		t.Error("默认模式下没有本地余量时应该拒绝")
+	}
+	if !errors.Is(closed.Err(), errStoreDown) {
+		t.Errorf("应该记录后端错误，实际 %v", closed.Err())
+	}
+	if !open.Acquire(10) {
+		t.Error("FailOpen 模式下应该放行")
+	}
+}
+
+// blockingStore TakeQuota 阻塞到 release 被关闭或 ctx 结束的存储后端
+type blockingStore struct {
+	*MemoryStore
+	release chan struct{}
+	calls   atomic.Int64
+}
+
+func (s *blockingStore) TakeQuota(ctx context.Context, key string, n int64) (int64, int64, error) {
+	s.calls.Add(1)
+	select {
+	case <-s.release:
+		return s.MemoryStore.TakeQuota(ctx, key, n)
+	case <-ctx.Done():
+		return 0, 0, ctx.Err()
+	}
+}
+
+// TestStoreQuota_SlowBackend 测试后端缓慢时配额不持有锁访问后端，且租用受超时约束
+//
+// 测试目标：
+//   - 租用进行期间 Remaining 等不需要租用的调用不被阻塞
+//   - 并发的租用需求合并为一次后端请求
+//   - 后端没有响应时租用在超时后失败
+func TestStoreQuota_SlowBackend(t *testing.T) {
+	t.Run("单次租用", func(t *testing.T) {
+		// Arrange
+		store := &blockingStore{MemoryStore: NewMemoryStore(), release: make(chan struct{})}
+		assertNoError(t, store.SetQuota(context.Background(), "budget", 1000), "设置预算")
+		quota := NewStoreQuota(store, "budget", WithLeaseSize(500), WithStoreTimeout(time.Minute))
+
+		// Act: 两个写入同时需要租用
+		var wg sync.WaitGroup
+		results := make([]bool, 2)
+		for i := range results {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				results[i] = quota.Acquire(100)
+			}()
+		}
+		for store.calls.Load() == 0 {
+			time.Sleep(time.Millisecond)
+		}
+		remaining := quota.Remaining() // 租用进行期间不应该阻塞
+		close(store.release)
+		wg.Wait()
+
+		// Assert
+		assertEqual(t, int64(0), remaining, "租用完成前本地没有余量")
+		assertEqual(t, true, results[0] && results[1], "两次申请都应该从同一次租用中扣除")
+		assertEqual(t, int64(1), store.calls.Load(), "并发的租用需求应该只访问一次后端")
+		assertEqual(t, int64(800), quota.Remaining(), "租到 500 字节、扣除 200 字节")
+	})
+
+	t.Run("超时", func(t *testing.T) {
+		// Arrange: 后端永不响应
+		store := &blockingStore{MemoryStore: NewMemoryStore(), release: make(chan struct{})}
+		quota := NewStoreQuota(store, "budget", WithStoreTimeout(10*time.Millisecond))
+
+		// Act
+		ok := quota.Acquire(100)
+
+		// Assert
+		assertEqual(t, false, ok, "超时的租用应该按后端不可用处理")
+		if !errors.Is(quota.Err(), context.DeadlineExceeded) {
+			t.Errorf("应该记录超时错误，实际 %v", quota.Err())
+		}
+	})
+}
@@ -0,0 +1,43 @@
+package ratelimited
+
+import "sync/atomic"
+
+// =============================================================================
+// 软配额阈值 - 配额消耗到一定比例时提前告警
+// =============================================================================
+
+// quotaThreshold 一个软配额阈值及其回调
+type quotaThreshold struct {
+	pct   float64
+	fn    func(remaining int64)
+	fired atomic.Bool
+}
+
+// WithQuotaThreshold 在配额消耗达到 pct 比例时调用一次 fn，例如 0.8 表示用掉 80%
+//
+// 用于在硬性截止之前发出告警，而不是只能通过 ErrQuotaExhausted 发现配额耗尽。
+// 比例以写入器创建时的剩余配额为基准，pct 取值 (0, 1]，超出范围的阈值被忽略。
+// 所有写入器对共享配额的消耗都计入判断，但每个阈值在单个写入器的生命周期内最多触发一次，
+// 由越过阈值的那次写入触发。fn 的参数是越过阈值时的剩余配额，回调在写入 goroutine 中
+// 同步执行，应尽快返回。可以多次调用设置多个阈值；未设置配额时不生效。
+func WithQuotaThreshold(pct float64, fn func(remaining int64)) DiscardWriterOption {
+	return func(w *DiscardWriter) {
+		if pct <= 0 || pct > 1 || fn == nil {
+			return
+		}
+		w.quotaThresholds = append(w.quotaThresholds, &quotaThreshold{pct: pct, fn: fn})
+	}
+}
+
+// checkQuotaThresholds 在扣除配额后检查是否越过了尚未触发的阈值
+func (w *DiscardWriter) checkQuotaThresholds(remaining int64) {
+	if w.quotaBudget <= 0 {
+		return
+	}
+	consumed := float64(w.quotaBudget - remaining)
+	for _, th := range w.quotaThresholds {
+		if consumed >= th.pct*float64(w.quotaBudget) && th.fired.CompareAndSwap(false, true) {
+			th.fn(remaining)
+		}
+	}
+}
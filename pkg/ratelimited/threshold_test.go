@@ -0,0 +1,36 @@
+package ratelimited
+
+import (
+	"testing"
+
+	"golang.org/x/time/rate"
+)
+
+// TestWithQuotaThreshold 测试配额消耗达到阈值时只回调一次
+//
+// 测试目标：
+//   - 越过阈值的写入触发回调，参数为当时的剩余配额
+//   - 之后的写入不再重复触发
+//   - 多个阈值各自独立触发
+func TestWithQuotaThreshold(t *testing.T) {
+	// Arrange
+	quota := int64(100)
+	var warned, critical []int64
+	writer := NewDiscardWriter(Chain(rate.NewLimiter(rate.Inf, 0)),
+		WithSharedQuota(&quota),
+		WithQuotaThreshold(0.5, func(remaining int64) { warned = append(warned, remaining) }),
+		WithQuotaThreshold(0.9, func(remaining int64) { critical = append(critical, remaining) }),
+	)
+
+	// Act
+	for _, size := range []int{40, 20, 10, 25} {
+		_, err := writer.Write(createTestData(size))
+		assertNoError(t, err, "配额内的写入应该成功")
+	}
+
+	// Assert
+	assertEqual(t, 1, len(warned), "50% 阈值应该只触发一次")
+	assertEqual(t, int64(40), warned[0], "应该在越过阈值的写入后回调剩余配额")
+	assertEqual(t, 1, len(critical), "90% 阈值应该只触发一次")
+	assertEqual(t, int64(5), critical[0], "应该在越过阈值的写入后回调剩余配额")
+}
@@ -0,0 +1,82 @@
+package ratelimited
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// =============================================================================
+// 瞬时吞吐量 - 指数加权移动平均
+// =============================================================================
+
+// defaultThroughputWindow 吞吐量移动平均的默认时间常数
+const defaultThroughputWindow = 5 * time.Second
+
+// throughputEMA 按时间衰减的字节速率移动平均
+//
+// 每接收 n 字节，估计值先按距上次更新的时间 dt 衰减为原来的 exp(-dt/τ)，再加上 n/τ。
+// 速率恒定为 r 时估计值收敛到 r；停止写入后估计值按同样的指数规律衰减到 0。
+// 更新和读取都是惰性的，不需要后台 goroutine。
+type throughputEMA struct {
+	mu     sync.Mutex
+	window time.Duration // 时间常数 τ
+	rate   float64       // 最后一次更新时的估计值（字节/秒）
+	last   time.Time     // 最后一次更新的时间
+}
+
+// WithThroughputWindow 设置 Throughput 移动平均的时间常数，默认 5 秒
+//
+// 时间常数越小，估计值对速率变化的反应越快，但抖动也越大。d <= 0 时使用默认值。
+func WithThroughputWindow(d time.Duration) DiscardWriterOption {
+	return func(w *DiscardWriter) {
+		if d > 0 {
+			w.throughput.window = d
+		}
+	}
+}
+
+// Throughput 返回最近的写入吞吐量（字节/秒）的指数加权移动平均
+//
+// 估计值从 0 开始，持续写入约 3 个时间常数后接近实际速率；停止写入后逐渐衰减到 0。
+// 进度条和仪表盘可以直接读取，不需要自行采样计数器并计算差值。
+func (w *DiscardWriter) Throughput() float64 {
+	return w.throughput.at(w.clock.Now())
+}
+
+// add 在 now 时刻记录接收的 n 字节
+func (e *throughputEMA) add(now time.Time, n int) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	window := e.windowSeconds()
+	e.rate = e.decayed(now, window) + float64(n)/window
+	e.last = now
+}
+
+// at 返回 now 时刻的估计值
+func (e *throughputEMA) at(now time.Time) float64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.decayed(now, e.windowSeconds())
+}
+
+// decayed 返回最后一次的估计值衰减到 now 时刻的结果，调用方需持有锁
+func (e *throughputEMA) decayed(now time.Time, window float64) float64 {
+	if e.last.IsZero() {
+		return 0
+	}
+	dt := now.Sub(e.last).Seconds()
+	if dt <= 0 {
+		return e.rate
+	}
+	return e.rate * math.Exp(-dt/window)
+}
+
+// windowSeconds 返回以秒为单位的时间常数
+func (e *throughputEMA) windowSeconds() float64 {
+	if e.window <= 0 {
+		return defaultThroughputWindow.Seconds()
+	}
+	return e.window.Seconds()
+}
@@ -0,0 +1,37 @@
+package ratelimited
+
+import (
+	"testing"
+	"time"
+)
+
+// TestDiscardWriter_Throughput 测试吞吐量移动平均收敛到实际速率并在空闲后衰减
+//
+// 测试目标：
+//   - 没有写入时吞吐量为 0
+//   - 恒定速率写入若干个时间常数后接近实际速率
+//   - 停止写入后按指数规律衰减
+func TestDiscardWriter_Throughput(t *testing.T) {
+	// Arrange: 每 100ms 写入 1000 字节，即 10000 B/s
+	clock := newFakeClock()
+	w := NewDiscardWriter(nil, WithClock(clock), WithThroughputWindow(time.Second))
+	assertEqual(t, 0.0, w.Throughput(), "没有写入时吞吐量应该为 0")
+
+	// Act
+	for i := 0; i < 100; i++ {
+		_, err := w.Write(createTestData(1000))
+		assertNoError(t, err, "写入")
+		clock.Advance(100 * time.Millisecond)
+	}
+	steady := w.Throughput()
+	clock.Advance(5 * time.Second)
+	idle := w.Throughput()
+
+	// Assert
+	if steady < 9000 || steady > 11000 {
+		t.Errorf("稳定写入后吞吐量应该接近 10000 B/s，实际 %.0f", steady)
+	}
+	if idle > steady*0.01 {
+		t.Errorf("空闲 5 个时间常数后吞吐量应该衰减到 1%% 以下，实际 %.0f", idle)
+	}
+}
@@ -0,0 +1,47 @@
+package ratelimited
+
+import (
+	"context"
+	"errors"
+)
+
+// =============================================================================
+// 非阻塞写入 - 不能立即准入时返回而不是等待
+// =============================================================================
+
+// ErrWouldBlock 写入需要等待令牌或维护窗口结束才能准入
+var ErrWouldBlock = errors.New("ratelimited: write would block")
+
+// nonBlockingKey 标记非阻塞写入的上下文键
+type nonBlockingKey struct{}
+
+// withNonBlocking 返回要求令牌申请不得等待的上下文
+func withNonBlocking(ctx context.Context) context.Context {
+	return context.WithValue(ctx, nonBlockingKey{}, true)
+}
+
+// isNonBlocking 判断 ctx 是否要求令牌申请不得等待
+func isNonBlocking(ctx context.Context) bool {
+	v, _ := ctx.Value(nonBlockingKey{}).(bool)
+	return v
+}
+
+// TryWrite 只在当前的令牌和配额允许时写入，永远不阻塞
+//
+// 适合不能挂起 goroutine 的事件循环式调用方。共享配额只够一部分时与 Write 一样
+// 接收前一部分并返回短写计数；批次剩余令牌不足、且限制器链无法立即放行时
+// 返回 0 和 ErrWouldBlock，配额被回滚，调用方可以稍后重试。非阻塞写入只申请
+// 本次缺少的令牌而不是整个批次，避免为批次余量而等待。
+// 写入器暂停或处于维护窗口时同样返回 ErrWouldBlock（维护窗口配置了快速失败时返回 ErrBlackout）。
+//
+// 判断是否需要等待依赖限制器的非阻塞预留：链中存在不支持预留的限制器时，
+// 凡是需要向限制器申请令牌的写入都返回 ErrNotReservable。
+// 分阶段限制（WithPhaseLimiters）的写入器在初始阶段不支持非阻塞写入，同样返回 ErrNotReservable。
+func (w *DiscardWriter) TryWrite(p []byte) (int, error) {
+	if w.inReadPhase() {
+		return 0, ErrNotReservable
+	}
+	n, err := w.admitContext(withNonBlocking(w.ctx), p, len(p), true)
+	w.countSize(n)
+	return n, err
+}
@@ -0,0 +1,62 @@
+package ratelimited
+
+import (
+	"testing"
+
+	"golang.org/x/time/rate"
+)
+
+// TestDiscardWriter_TryWrite 测试非阻塞写入
+//
+// 测试目标：
+//   - 令牌充足时立即写入
+//   - 令牌不足时返回 ErrWouldBlock，配额被回滚
+//   - 配额只够一部分时返回短写计数
+//   - 不支持预留的限制器返回 ErrNotReservable
+func TestDiscardWriter_TryWrite(t *testing.T) {
+	t.Run("令牌充足与不足", func(t *testing.T) {
+		// Arrange: 令牌桶有 100 个令牌，几乎不补充
+		limiter := rate.NewLimiter(0.001, 100)
+		quota := int64(1000)
+		writer := NewDiscardWriter(Chain(limiter), WithSharedQuota(&quota))
+
+		// Act
+		n, err := writer.TryWrite(createTestData(80))
+		assertNoError(t, err, "令牌充足时应该立即写入")
+		assertEqual(t, 80, n, "应该接收全部字节")
+
+		n, err = writer.TryWrite(createTestData(50))
+
+		// Assert
+		assertEqual(t, ErrWouldBlock, err, "令牌不足时应该返回 ErrWouldBlock")
+		assertEqual(t, 0, n, "不应该接收任何字节")
+		assertAtomicEqual(t, 920, &quota, "失败的写入应该回滚配额")
+		if tokens := limiter.Tokens(); tokens < 19.9 || tokens > 20.1 {
+			t.Errorf("失败的写入不应该消耗令牌，实际剩余 %.1f", tokens)
+		}
+	})
+
+	t.Run("配额短写", func(t *testing.T) {
+		// Arrange
+		quota := int64(30)
+		writer := NewDiscardWriter(Chain(rate.NewLimiter(rate.Inf, 0)), WithSharedQuota(&quota))
+
+		// Act
+		n, err := writer.TryWrite(createTestData(50))
+
+		// Assert
+		assertNoError(t, err, "配额短写不应该返回错误")
+		assertEqual(t, 30, n, "应该只接收配额内的字节")
+	})
+
+	t.Run("不支持预留", func(t *testing.T) {
+		// Arrange
+		writer := NewDiscardWriter([]Limiter{&countingLimiter{}})
+
+		// Act
+		_, err := writer.TryWrite(createTestData(10))
+
+		// Assert
+		assertEqual(t, ErrNotReservable, err, "无法判断是否阻塞时应该返回 ErrNotReservable")
+	})
+}
@@ -0,0 +1,241 @@
+package ratelimited
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// =============================================================================
+// 两阶段提交 - 跨多个写入器的全有或全无准入
+// =============================================================================
+
+var (
+	// ErrNotAdmitted 写入无法立即被某个写入器准入（配额不足或需要等待令牌）
+	ErrNotAdmitted = errors.New("ratelimited: write not admitted")
+	// ErrNotReservable 写入器的限制器不支持非阻塞预留
+	ErrNotReservable = errors.New("ratelimited: limiter does not support reservations")
+	// ErrCommitFinished 提交已经完成或已被中止
+	ErrCommitFinished = errors.New("ratelimited: commit already finished")
+)
+
+// reserver 支持非阻塞预留的限制器，*rate.Limiter 实现了该接口
+type reserver interface {
+	ReserveN(t time.Time, n int) *rate.Reservation
+}
+
+// writeReservation 单个写入器上的一次预留
+type writeReservation struct {
+	w            *DiscardWriter
+	size         int
+	at           time.Time
+	reservations []*rate.Reservation
+	quota        int64  // 已从共享配额中预留的字节数
+	ceiling      int64  // 已从令牌上限中预留的令牌数
+	releaseSlots func() // 归还并发名额，nil 表示没有占用
+}
+
+// reserve 为 size 字节在写入器上预留配额和所有限制器的令牌
+//
+// 与 TryWrite 执行相同的准入检查：写入器暂停、处于维护窗口或并发名额用尽时
+// 返回包装了原因的 ErrNotAdmitted（维护窗口配置了快速失败时返回 ErrBlackout），
+// 令牌上限不足时返回 ErrTokenCeilingReached。请求速率层各预留 1 个令牌，
+// 大写入在大写入专用链上预留。只有全部检查和限制器都能立即放行时才成功，
+// 否则撤销已完成的部分预留。并发名额一直占用到提交或中止。
+// 预留按字节计费，不经过成本函数和去重窗口，也不消耗批次令牌。
+func (w *DiscardWriter) reserve(size int) (*writeReservation, error) {
+	res := &writeReservation{w: w, size: size, at: w.clock.Now()}
+	if size <= 0 {
+		return res, nil
+	}
+
+	ctx := withNonBlocking(w.ctx)
+	if err := w.ctx.Err(); err != nil {
+		return nil, err
+	}
+	if err := w.waitResumed(ctx); err != nil {
+		return nil, notAdmitted(err)
+	}
+	if len(w.blackouts) > 0 {
+		if err := w.waitBlackout(ctx); err != nil {
+			return nil, notAdmitted(err)
+		}
+	}
+	release, err := w.acquireSlots(ctx)
+	if err != nil {
+		return nil, notAdmitted(err)
+	}
+	res.releaseSlots = release
+
+	requestLayers, _ := w.requestLayers()
+	if err := res.reserveChain(requestLayers, 1); err != nil {
+		res.cancel()
+		return nil, err
+	}
+
+	if w.quota != nil {
+		if !w.quota.Acquire(int64(size)) {
+			res.cancel()
+			return nil, ErrNotAdmitted
+		}
+		res.quota = int64(size)
+	}
+
+	if !w.reserveCeiling(int64(size)) {
+		res.cancel()
+		return nil, ErrTokenCeilingReached
+	}
+	res.ceiling = int64(size)
+
+	limiters, _ := w.chain()
+	if w.largeThreshold > 0 && size > w.largeThreshold && len(w.largeLimiters) > 0 {
+		limiters = w.largeLimiters
+	}
+	if err := res.reserveChain(limiters, size); err != nil {
+		res.cancel()
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// notAdmitted 把需要等待的准入错误转换为包装了原因的 ErrNotAdmitted，其他错误原样返回
+func notAdmitted(err error) error {
+	if errors.Is(err, ErrWouldBlock) {
+		return fmt.Errorf("%w: %w", ErrNotAdmitted, err)
+	}
+	return err
+}
+
+// reserveChain 在链中每个按字节计费的限制器上非阻塞地预留 n 个令牌
+//
+// 按写入计费的层（并发限制器、按请求计费层）由调用方单独处理。失败时已完成的预留
+// 留在 r 中，由调用方统一撤销。
+func (r *writeReservation) reserveChain(limiters []Limiter, n int) error {
+	for _, limiter := range limiters {
+		if limiter == nil || perWriteLayer(limiter) {
+			continue
+		}
+		rl, ok := limiter.(reserver)
+		if !ok {
+			return ErrNotReservable
+		}
+		res := rl.ReserveN(r.at, n)
+		if !res.OK() {
+			return ErrNotAdmitted
+		}
+		r.reservations = append(r.reservations, res)
+		if res.DelayFrom(r.at) > 0 {
+			return ErrNotAdmitted
+		}
+	}
+	return nil
+}
+
+// cancel 归还预留的令牌、配额、令牌上限和并发名额
+func (r *writeReservation) cancel() {
+	// 按预留的逆序取消，令牌桶才能完整恢复
+	for i := len(r.reservations) - 1; i >= 0; i-- {
+		r.reservations[i].CancelAt(r.at)
+	}
+	r.reservations = nil
+	if r.quota > 0 {
+		r.w.quota.Release(r.quota)
+		r.quota = 0
+	}
+	r.w.releaseCeiling(r.ceiling)
+	r.ceiling = 0
+	r.done()
+}
+
+// done 归还预留占用的并发名额
+func (r *writeReservation) done() {
+	if r.releaseSlots != nil {
+		r.releaseSlots()
+		r.releaseSlots = nil
+	}
+}
+
+// commit 将预留的写入计入写入器的统计，并归还并发名额
+func (r *writeReservation) commit() {
+	w := r.w
+	r.done()
+	if r.size <= 0 {
+		return
+	}
+	w.countSize(r.size)
+	w.recordCounts(r.size)
+	for _, sink := range w.sinks {
+		sink.RecordWrite(r.size, 0)
+	}
+}
+
+// Commit 一组已在所有写入器上预留成功的写入
+//
+// 必须且只能调用 Commit 或 Abort 之一；重复调用返回 ErrCommitFinished。
+type Commit struct {
+	mu           sync.Mutex
+	reservations []*writeReservation
+	done         bool
+}
+
+// PrepareWrite 为多个写入器同时预留写入，全部可以立即准入时才成功
+//
+// writers[i] 预留 sizes[i] 字节：依次从共享配额中预留字节，并通过 ReserveN 在每个限制器上
+// 非阻塞地预留令牌。任何一个写入器无法立即准入时，已完成的预留全部撤销，
+// 返回包装了 ErrNotAdmitted（或 ErrNotReservable）并标明写入器下标的错误。
+//
+// 成功时返回的 Commit 持有全部预留：调用 Commit() 确认写入并更新统计，
+// 调用 Abort() 取消所有预留并归还令牌和配额。所有限制器都必须支持预留
+// （如 *rate.Limiter），否则无法保证全有或全无的语义。
+//
+// 每个写入器上的准入检查与 TryWrite 相同：暂停、维护窗口、并发限制（名额占用到
+// Commit 或 Abort）、请求速率链、共享配额、令牌上限以及大写入专用链都会生效。
+func PrepareWrite(writers []*DiscardWriter, sizes []int) (*Commit, error) {
+	if len(writers) != len(sizes) {
+		return nil, fmt.Errorf("ratelimited: %d writers but %d sizes", len(writers), len(sizes))
+	}
+
+	c := &Commit{reservations: make([]*writeReservation, 0, len(writers))}
+	for i, w := range writers {
+		res, err := w.reserve(sizes[i])
+		if err != nil {
+			c.Abort()
+			return nil, fmt.Errorf("writer %d: %w", i, err)
+		}
+		c.reservations = append(c.reservations, res)
+	}
+	return c, nil
+}
+
+// Commit 确认所有预留的写入，数据被丢弃，统计按预留的字节数更新
+func (c *Commit) Commit() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.done {
+		return ErrCommitFinished
+	}
+	c.done = true
+
+	for _, res := range c.reservations {
+		res.commit()
+	}
+	return nil
+}
+
+// Abort 取消所有预留，归还令牌和配额；已完成的提交上调用是空操作
+func (c *Commit) Abort() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.done {
+		return
+	}
+	c.done = true
+
+	for i := len(c.reservations) - 1; i >= 0; i-- {
+		c.reservations[i].cancel()
+	}
+}
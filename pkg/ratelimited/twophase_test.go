@@ -0,0 +1,192 @@
+package ratelimited
+
+import (
+	"errors"
+	"testing"
+
+	"golang.org/x/time/rate"
+)
+
+// TestPrepareWrite_AllOrNothing 测试任一写入器无法准入时撤销全部预留
+//
+// 测试目标：
+//   - 第二个写入器的令牌不足时 PrepareWrite 失败并标明原因
+//   - 第一个写入器已预留的令牌和配额被归还
+func TestPrepareWrite_AllOrNothing(t *testing.T) {
+	// Arrange: 低速率使测试期间的补充可以忽略
+	okLimiter := rate.NewLimiter(1, 100)
+	tightLimiter := rate.NewLimiter(1, 10)
+	quota := int64(1000)
+	writers := []*DiscardWriter{
+		NewDiscardWriter([]Limiter{okLimiter}, WithSharedQuota(&quota)),
+		NewDiscardWriter([]Limiter{tightLimiter}),
+	}
+
+	// Act
+	commit, err := PrepareWrite(writers, []int{50, 50})
+
+	// Assert
+	if commit != nil || !errors.Is(err, ErrNotAdmitted) {
+		t.Fatalf("期望 ErrNotAdmitted，实际 commit=%v err=%v", commit, err)
+	}
+	if tokens := okLimiter.Tokens(); tokens < 99 {
+		t.Errorf("第一个写入器的令牌应该被归还，剩余 %.1f", tokens)
+	}
+	assertAtomicEqual(t, int64(1000), &quota, "预留的配额应该被归还")
+}
+
+// TestPrepareWrite_Abort 测试中止归还所有预留
+func TestPrepareWrite_Abort(t *testing.T) {
+	// Arrange
+	l1 := rate.NewLimiter(1, 100)
+	l2 := rate.NewLimiter(1, 100)
+	var bytes int64
+	writers := []*DiscardWriter{
+		NewDiscardWriter([]Limiter{l1}, WithBytesCounter(&bytes)),
+		NewDiscardWriter([]Limiter{l2}),
+	}
+	commit, err := PrepareWrite(writers, []int{60, 40})
+	assertNoError(t, err, "准备阶段应该成功")
+
+	// Act
+	commit.Abort()
+
+	// Assert
+	if l1.Tokens() < 99 || l2.Tokens() < 99 {
+		t.Errorf("中止后令牌应该被归还，剩余 %.1f / %.1f", l1.Tokens(), l2.Tokens())
+	}
+	assertAtomicEqual(t, int64(0), &bytes, "中止不应该更新统计")
+	assertEqual(t, ErrCommitFinished, commit.Commit(), "中止后不能再提交")
+}
+
+// TestPrepareWrite_Commit 测试提交消耗令牌并更新统计
+func TestPrepareWrite_Commit(t *testing.T) {
+	// Arrange
+	limiter := rate.NewLimiter(1, 100)
+	var bytes int64
+	var requests uint64
+	writer := NewDiscardWriter([]Limiter{limiter},
+		WithBytesCounter(&bytes), WithRequestCounter(&requests))
+	commit, err := PrepareWrite([]*DiscardWriter{writer}, []int{70})
+	assertNoError(t, err, "准备阶段应该成功")
+
+	// Act
+	err = commit.Commit()
+	commit.Abort()
+
+	// Assert
+	assertNoError(t, err, "提交应该成功")
+	assertAtomicEqual(t, int64(70), &bytes, "应该按预留字节数统计")
+	assertEqual(t, uint64(1), requests, "应该计入一次请求")
+	if tokens := limiter.Tokens(); tokens > 31 {
+		t.Errorf("提交后令牌应该保持消耗，剩余 %.1f", tokens)
+	}
+}
+
+// TestPrepareWrite_NotReservable 测试不支持预留的限制器被拒绝
+func TestPrepareWrite_NotReservable(t *testing.T) {
+	// Arrange
+	writer := NewDiscardWriter([]Limiter{&countingLimiter{}})
+
+	// Act
+	_, err := PrepareWrite([]*DiscardWriter{writer}, []int{10})
+
+	// Assert
+	if !errors.Is(err, ErrNotReservable) {
+		t.Errorf("期望 ErrNotReservable，实际 %v", err)
+	}
+}
+
+// TestPrepareWrite_WriterControls 测试预留遵守写入器的准入控制
+//
+// 测试目标：
+//   - 暂停、并发名额用尽时返回 ErrNotAdmitted，令牌上限不足时返回 ErrTokenCeilingReached
+//   - 请求速率链按次预留，大写入在专用链上预留
+//   - 并发名额一直占用到提交
+func TestPrepareWrite_WriterControls(t *testing.T) {
+	t.Run("暂停", func(t *testing.T) {
+		// Arrange
+		writer := NewDiscardWriter(nil)
+		writer.Pause()
+
+		// Act
+		_, err := PrepareWrite([]*DiscardWriter{writer}, []int{10})
+
+		// Assert
+		if !errors.Is(err, ErrNotAdmitted) || !errors.Is(err, ErrWouldBlock) {
+			t.Errorf("期望包装了 ErrWouldBlock 的 ErrNotAdmitted，实际 %v", err)
+		}
+	})
+
+	t.Run("令牌上限", func(t *testing.T) {
+		// Arrange
+		quota := int64(1000)
+		writer := NewDiscardWriter(nil, WithTokenCeiling(15), WithSharedQuota(&quota))
+
+		// Act
+		first, firstErr := PrepareWrite([]*DiscardWriter{writer}, []int{10})
+		_, secondErr := PrepareWrite([]*DiscardWriter{writer}, []int{10})
+
+		// Assert
+		assertNoError(t, firstErr, "上限内的预留应该成功")
+		assertNoError(t, first.Commit(), "提交")
+		if !errors.Is(secondErr, ErrTokenCeilingReached) {
+			t.Errorf("超出上限时期望 ErrTokenCeilingReached，实际 %v", secondErr)
+		}
+		assertAtomicEqual(t, int64(990), &quota, "失败的预留应该归还配额")
+	})
+
+	t.Run("请求速率链", func(t *testing.T) {
+		// Arrange: 每秒 1 次请求，突发 1 次
+		requests := rate.NewLimiter(1, 1)
+		writer := NewDiscardWriter(nil, WithRequestRateChain([]Limiter{requests}))
+
+		// Act
+		first, firstErr := PrepareWrite([]*DiscardWriter{writer}, []int{10})
+		_, secondErr := PrepareWrite([]*DiscardWriter{writer}, []int{10})
+
+		// Assert
+		assertNoError(t, firstErr, "第一次预留应该成功")
+		first.Abort()
+		if !errors.Is(secondErr, ErrNotAdmitted) {
+			t.Errorf("请求令牌耗尽时期望 ErrNotAdmitted，实际 %v", secondErr)
+		}
+	})
+
+	t.Run("大写入专用链", func(t *testing.T) {
+		// Arrange: 普通链足够，大写入链只有 50 个令牌
+		large := rate.NewLimiter(1, 50)
+		writer := NewDiscardWriter([]Limiter{rate.NewLimiter(1, 1000)},
+			WithLargeWriteThreshold(20),
+			WithLargeWriteLimiters(large),
+		)
+
+		// Act
+		_, err := PrepareWrite([]*DiscardWriter{writer}, []int{100})
+
+		// Assert
+		if !errors.Is(err, ErrNotAdmitted) {
+			t.Errorf("大写入应该在专用链上预留，实际 %v", err)
+		}
+	})
+
+	t.Run("并发名额", func(t *testing.T) {
+		// Arrange
+		slots := NewConcurrencyLimiter(1)
+		writer := NewDiscardWriter([]Limiter{slots})
+
+		// Act
+		first, firstErr := PrepareWrite([]*DiscardWriter{writer}, []int{10})
+		_, secondErr := PrepareWrite([]*DiscardWriter{writer}, []int{10})
+		inFlight := slots.InFlight()
+		assertNoError(t, first.Commit(), "提交")
+
+		// Assert
+		assertNoError(t, firstErr, "第一次预留应该成功")
+		if !errors.Is(secondErr, ErrNotAdmitted) {
+			t.Errorf("名额用尽时期望 ErrNotAdmitted，实际 %v", secondErr)
+		}
+		assertEqual(t, 1, inFlight, "提交前应该占用名额")
+		assertEqual(t, 0, slots.InFlight(), "提交后应该归还名额")
+	})
+}
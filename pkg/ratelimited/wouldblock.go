@@ -0,0 +1,84 @@
+package ratelimited
+
+import (
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// WouldBlock 判断此刻写入 n 字节是否需要等待，以及预计的等待时间
+//
+// 这是在阻塞等待与直接拒绝（例如返回 HTTP 429）之间做决定的规划原语。
+// 判断按默认计费（每字节一个令牌）进行：当前批次剩余令牌足够时不会阻塞；
+// 否则对写入实际会申请的令牌数，在每个支持预留的限制器（如 *rate.Limiter）上
+// ReserveN 后立即取消，取各层延迟的最大值。不支持预留的限制器视为不阻塞。
+// 处于维护窗口且未启用快速失败时，剩余的窗口时间也计入等待。
+//
+// 该方法不消耗令牌，但结果只反映调用时刻的状态：并发的写入或预留可能让
+// 真实的等待变长，取消预留时若有更晚的预留发生，令牌也可能无法完整恢复。
+// 单段申请量（不超过批次大小）超过某层突发容量时永远无法满足，返回 true 和 rate.InfDuration。
+func (w *DiscardWriter) WouldBlock(n int) (bool, time.Duration) {
+	if n <= 0 {
+		return false, 0
+	}
+
+	now := w.clock.Now()
+	var wait time.Duration
+	if len(w.blackouts) > 0 && !w.blackoutFailFast {
+		wait = w.blackoutRemaining(now)
+	}
+
+	need := int64(n) - max(atomic.LoadInt64(&w.remainingTokens), 0)
+	if need > 0 {
+		if d := w.peekDelay(now, w.acquireSize(need)); d > wait {
+			wait = d
+		}
+	}
+
+	return wait > 0, wait
+}
+
+// peekDelay 返回在所有支持预留的限制器上申请 total 个令牌的最长延迟，不消耗令牌
+//
+// 与写入一致按批次大小分段预留，最后一段的延迟即总延迟。
+func (w *DiscardWriter) peekDelay(now time.Time, total int64) time.Duration {
+	chunk := w.batchSize
+	if chunk <= 0 || chunk > total {
+		chunk = total
+	}
+
+	var delay time.Duration
+	limiters, _ := w.chain()
+	for _, limiter := range limiters {
+		rl, ok := limiter.(reserver)
+		if !ok || limiter == nil {
+			continue
+		}
+		if d := peekReserver(rl, now, total, chunk); d > delay {
+			delay = d
+		}
+	}
+	return delay
+}
+
+// peekReserver 在单个限制器上分段预留 total 个令牌后全部取消，返回最后一段的延迟
+func peekReserver(rl reserver, now time.Time, total, chunk int64) time.Duration {
+	var reservations []*rate.Reservation
+	defer func() {
+		for i := len(reservations) - 1; i >= 0; i-- {
+			reservations[i].CancelAt(now)
+		}
+	}()
+
+	var delay time.Duration
+	for reserved := int64(0); reserved < total; reserved += chunk {
+		r := rl.ReserveN(now, int(min(chunk, total-reserved)))
+		if !r.OK() {
+			return rate.InfDuration
+		}
+		reservations = append(reservations, r)
+		delay = r.DelayFrom(now)
+	}
+	return delay
+}
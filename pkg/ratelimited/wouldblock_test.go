@@ -0,0 +1,62 @@
+package ratelimited
+
+import (
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// TestDiscardWriter_WouldBlock 测试根据令牌桶状态判断是否阻塞
+//
+// 测试目标：
+//   - 令牌桶已满时不阻塞
+//   - 令牌桶耗尽时阻塞，预计等待时间与补充速率相符
+//   - 判断本身不消耗令牌
+func TestDiscardWriter_WouldBlock(t *testing.T) {
+	// Arrange: 速率 100 令牌/秒，突发 100
+	clock := newFakeClock()
+	limiter := rate.NewLimiter(100, 100)
+	writer := NewDiscardWriter([]Limiter{limiter}, WithClock(clock), WithBatchSize(50))
+
+	// Act & Assert: 令牌桶已满
+	blocked, wait := writer.WouldBlock(50)
+	assertEqual(t, false, blocked, "令牌桶已满时不应该阻塞")
+	assertEqual(t, time.Duration(0), wait, "不阻塞时等待时间为 0")
+	if tokens := limiter.TokensAt(clock.Now()); tokens < 100 {
+		t.Errorf("判断不应该消耗令牌，剩余 %.1f", tokens)
+	}
+
+	// 耗尽令牌桶
+	limiter.ReserveN(clock.Now(), 100)
+	blocked, wait = writer.WouldBlock(50)
+	assertEqual(t, true, blocked, "令牌桶耗尽时应该阻塞")
+	assertEqual(t, 500*time.Millisecond, wait, "补充 50 个令牌需要 500ms")
+	_, wait = writer.WouldBlock(50)
+	assertEqual(t, 500*time.Millisecond, wait, "重复判断结果应该相同")
+}
+
+// TestDiscardWriter_WouldBlockOpaqueLimiter 测试不支持预留的限制器视为不阻塞
+func TestDiscardWriter_WouldBlockOpaqueLimiter(t *testing.T) {
+	// Arrange
+	writer := NewDiscardWriter([]Limiter{&countingLimiter{}})
+
+	// Act
+	blocked, _ := writer.WouldBlock(1 << 20)
+
+	// Assert
+	assertEqual(t, false, blocked, "无法预留的限制器应该报告不阻塞")
+}
+
+// TestDiscardWriter_WouldBlockExceedsBurst 测试超过突发容量时永远无法满足
+func TestDiscardWriter_WouldBlockExceedsBurst(t *testing.T) {
+	// Arrange
+	writer := NewDiscardWriter([]Limiter{rate.NewLimiter(100, 10)}, WithBatchSize(100))
+
+	// Act
+	blocked, wait := writer.WouldBlock(100)
+
+	// Assert
+	assertEqual(t, true, blocked, "超过突发容量应该阻塞")
+	assertEqual(t, rate.InfDuration, wait, "等待时间应该为无限")
+}
@@ -0,0 +1,34 @@
+package ratelimited
+
+import "io"
+
+// =============================================================================
+// 限流写入器 - 对任意 io.Writer 应用限制器链
+// =============================================================================
+
+// Option 写入器的配置选项，与 DiscardWriterOption 是同一类型
+//
+// DiscardWriter 和 ThrottledWriter 共用全部选项（配额、计数器、上下文、批次大小等）。
+type Option = DiscardWriterOption
+
+// ThrottledWriter 将限流后的数据转发到真实下游（文件、套接字、缓冲区等）的写入器
+//
+// 与 DiscardWriter 共用批量令牌、配额、上下文和统计的全部机制，区别只在于
+// 通过准入的数据被写入 dst 而不是丢弃。下游短写或出错时，未交付字节对应的
+// 令牌和配额会被退还，Write 返回下游实际接受的字节数和错误。
+type ThrottledWriter struct {
+	*DiscardWriter
+}
+
+// NewWriter 创建把数据按限制器链限流后写入 dst 的写入器
+//
+// 使用示例：
+//
+//	w := ratelimited.NewWriter(file, ratelimited.Chain(limiter),
+//	    ratelimited.WithContext(ctx),
+//	    ratelimited.WithBytesCounter(&written),
+//	)
+//	_, err := io.Copy(w, src)
+func NewWriter(dst io.Writer, limiters []Limiter, opts ...Option) *ThrottledWriter {
+	return &ThrottledWriter{DiscardWriter: newForwardingWriter(dst, limiters, opts...)}
+}
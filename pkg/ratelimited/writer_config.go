@@ -0,0 +1,162 @@
+package ratelimited
+
+import "time"
+
+// =============================================================================
+// 写入器配置 - 提取与重建
+// =============================================================================
+
+// QuotaMode 写入器的配额模式
+type QuotaMode int
+
+const (
+	// QuotaUnlimited 不限制总量
+	QuotaUnlimited QuotaMode = iota
+	// QuotaShared 从共享配额指针中扣除
+	QuotaShared
+)
+
+// WriterConfig 写入器的可复用配置
+//
+// 只包含决定限流行为的设置，不包含计数器、统计接收端、等待观察者和上下文等观测或生命周期相关的状态。
+// 转发写入器的下游 dst 也不在配置中：NewFromConfig 总是创建丢弃写入器。
+// 限制器链、共享配额指针和函数类型字段按引用共享：用同一份配置创建的写入器
+// 共同消耗同一组限制器的令牌和同一份配额。标量字段带有 json 标签，可直接序列化保存。
+//
+// 启用分阶段限制时 Limiters 为初始阶段的链，SteadyLimiters 为稳定阶段的链，
+// 重建的写入器从初始阶段重新开始。
+type WriterConfig struct {
+	Limiters           []Limiter              `json:"-"`
+	LimiterNames       []string               `json:"limiter_names,omitempty"`
+	PhaseBoundary      int64                  `json:"phase_boundary,omitempty"`
+	SteadyLimiters     []Limiter              `json:"-"`
+	BatchSize          int64                  `json:"batch_size"`
+	QuotaMode          QuotaMode              `json:"quota_mode"`
+	SharedQuota        *int64                 `json:"-"`
+	Quota              Quota                  `json:"-"`
+	CostFunc           func([]byte) int64     `json:"-"`
+	Clock              Clock                  `json:"-"`
+	Classifier         func([]byte) string    `json:"-"`
+	LargeWriteLimiters []Limiter              `json:"-"`
+	DynamicChain       *DynamicChain          `json:"-"`
+	RequestRateChain   []Limiter              `json:"-"`
+	ErrorReport        func() float64         `json:"-"`
+	ErrorScale         func(float64) float64  `json:"-"`
+	QuotaThresholds    []QuotaThresholdConfig `json:"quota_thresholds,omitempty"`
+
+	RequestCounterGranularity uint64             `json:"request_counter_granularity,omitempty"`
+	IdleRefund                time.Duration      `json:"idle_refund,omitempty"`
+	DedupWindow               int                `json:"dedup_window,omitempty"`
+	TokenCeiling              int64              `json:"token_ceiling,omitempty"`
+	BlackoutWindows           []Window           `json:"blackout_windows,omitempty"`
+	BlackoutFailFast          bool               `json:"blackout_fail_fast,omitempty"`
+	ClassMultipliers          map[string]float64 `json:"class_multipliers,omitempty"`
+	ExplicitCommit            bool               `json:"explicit_commit,omitempty"`
+	LargeWriteThreshold       int                `json:"large_write_threshold,omitempty"`
+	QuotaAwareWait            bool               `json:"quota_aware_wait,omitempty"`
+	FullWrite                 bool               `json:"full_write,omitempty"`
+	MaxWait                   time.Duration      `json:"max_wait,omitempty"`
+	CountAfterWrite           bool               `json:"count_after_write,omitempty"`
+}
+
+// QuotaThresholdConfig 一个软配额阈值，对应 WithQuotaThreshold 的参数
+//
+// 触发状态不属于配置，重建的写入器中每个阈值都可以重新触发一次。
+type QuotaThresholdConfig struct {
+	Pct float64               `json:"pct"`
+	Fn  func(remaining int64) `json:"-"`
+}
+
+// Config 返回写入器当前的配置
+func (w *DiscardWriter) Config() WriterConfig {
+	cfg := WriterConfig{
+		Limiters:                  w.limiters,
+		LimiterNames:              w.names,
+		BatchSize:                 w.batchSize,
+		SharedQuota:               w.sharedRemaining,
+		CostFunc:                  w.costFunc,
+		Clock:                     w.clock,
+		Classifier:                w.classify,
+		ClassMultipliers:          w.classMultipliers,
+		RequestCounterGranularity: w.requestGranularity,
+		IdleRefund:                w.idleRefund,
+		TokenCeiling:              w.tokenCeiling,
+		BlackoutWindows:           w.blackouts,
+		BlackoutFailFast:          w.blackoutFailFast,
+		ExplicitCommit:            w.explicitCommit,
+		LargeWriteThreshold:       w.largeThreshold,
+		LargeWriteLimiters:        w.largeLimiters,
+		DynamicChain:              w.dynamicChain,
+		RequestRateChain:          w.requestLimiters,
+		QuotaAwareWait:            w.quotaAwareWait,
+		FullWrite:                 w.fullWrite,
+		MaxWait:                   w.maxWait,
+		CountAfterWrite:           w.countAfterWrite,
+	}
+	if w.steadyLimiters != nil {
+		cfg.PhaseBoundary = w.phaseBoundary
+		cfg.SteadyLimiters = w.steadyLimiters
+	}
+	if w.errorFeedback != nil {
+		cfg.ErrorReport = w.errorFeedback.report
+		cfg.ErrorScale = w.errorFeedback.scale
+	}
+	for _, th := range w.quotaThresholds {
+		cfg.QuotaThresholds = append(cfg.QuotaThresholds, QuotaThresholdConfig{Pct: th.pct, Fn: th.fn})
+	}
+	if w.quota != nil {
+		cfg.QuotaMode = QuotaShared
+	}
+	if w.sharedRemaining == nil {
+		cfg.Quota = w.quota
+	}
+	if w.dedup != nil {
+		cfg.DedupWindow = len(w.dedup.ring)
+	}
+	return cfg
+}
+
+// NewFromConfig 根据配置创建写入器
+//
+// opts 在配置之后应用，可用于附加计数器或覆盖个别设置。
+// 去重窗口只复用大小，新写入器从空窗口开始。
+func NewFromConfig(cfg WriterConfig, opts ...DiscardWriterOption) *DiscardWriter {
+	allOpts := []DiscardWriterOption{
+		WithLimiterNames(cfg.LimiterNames),
+		WithBatchSize(cfg.BatchSize),
+		WithCostFunc(cfg.CostFunc),
+		WithClock(cfg.Clock),
+		WithRequestCounterGranularity(cfg.RequestCounterGranularity),
+		WithIdleRefund(cfg.IdleRefund),
+		WithDedupCharge(cfg.DedupWindow),
+		WithTokenCeiling(cfg.TokenCeiling),
+		WithBlackoutWindows(cfg.BlackoutWindows),
+		WithBlackoutFailFast(cfg.BlackoutFailFast),
+		WithClassifier(cfg.Classifier, cfg.ClassMultipliers),
+		WithExplicitCommit(cfg.ExplicitCommit),
+		WithLargeWriteThreshold(cfg.LargeWriteThreshold),
+		WithLargeWriteLimiters(cfg.LargeWriteLimiters...),
+		WithQuotaAwareWait(cfg.QuotaAwareWait),
+		WithFullWrite(cfg.FullWrite),
+		WithMaxWait(cfg.MaxWait),
+		WithDynamicChain(cfg.DynamicChain),
+		WithRequestRateChain(cfg.RequestRateChain),
+		WithErrorFeedback(cfg.ErrorReport, cfg.ErrorScale),
+		WithCountAfterWrite(cfg.CountAfterWrite),
+	}
+	if cfg.PhaseBoundary > 0 {
+		allOpts = append(allOpts, WithPhaseLimiters(cfg.PhaseBoundary, cfg.Limiters, cfg.SteadyLimiters))
+	}
+	for _, th := range cfg.QuotaThresholds {
+		allOpts = append(allOpts, WithQuotaThreshold(th.Pct, th.Fn))
+	}
+	if cfg.QuotaMode == QuotaShared && cfg.SharedQuota != nil {
+		allOpts = append(allOpts, WithSharedQuota(cfg.SharedQuota))
+	}
+	if cfg.QuotaMode == QuotaShared && cfg.Quota != nil {
+		allOpts = append(allOpts, WithQuota(cfg.Quota))
+	}
+	allOpts = append(allOpts, opts...)
+
+	return NewDiscardWriter(cfg.Limiters, allOpts...)
+}
@@ -0,0 +1,119 @@
+package ratelimited
+
+import (
+	"encoding/json"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestNewFromConfig_Equivalence 测试从配置重建的写入器行为等价
+//
+// 测试目标：
+//   - Config 完整提取批次、配额、成本等设置
+//   - 重建的写入器共享同一限制器链和配额
+//   - 相同的写入序列产生相同的令牌申请模式
+func TestNewFromConfig_Equivalence(t *testing.T) {
+	// Arrange
+	quota := int64(10000)
+	limiter := &countingLimiter{}
+	original := NewDiscardWriter([]Limiter{limiter},
+		WithBatchSize(256),
+		WithSharedQuota(&quota),
+		WithIdleRefund(time.Minute),
+		WithDedupCharge(8),
+	)
+
+	// Act
+	cfg := original.Config()
+	rebuilt := NewFromConfig(cfg)
+
+	// Assert: 配置字段一致
+	assertEqual(t, int64(256), cfg.BatchSize, "批次大小应该被提取")
+	assertEqual(t, QuotaShared, cfg.QuotaMode, "配额模式应该为共享")
+	assertEqual(t, 8, cfg.DedupWindow, "去重窗口大小应该被提取")
+	rebuiltCfg := rebuilt.Config()
+	assertEqual(t, cfg.BatchSize, rebuiltCfg.BatchSize, "重建后批次大小应该一致")
+	assertEqual(t, cfg.SharedQuota, rebuiltCfg.SharedQuota, "重建后应该共享同一配额指针")
+	assertEqual(t, cfg.IdleRefund, rebuiltCfg.IdleRefund, "重建后空闲作废时长应该一致")
+	assertEqual(t, cfg.DedupWindow, rebuiltCfg.DedupWindow, "重建后去重窗口应该一致")
+	assertEqual(t, cfg.Limiters[0], rebuiltCfg.Limiters[0], "限制器链应该按引用共享")
+
+	// 相同写入序列下两者的令牌申请一致，且落在同一个限制器上
+	for i, writer := range []*DiscardWriter{original, rebuilt} {
+		for j := 0; j < 4; j++ {
+			_, err := writer.Write(createTestData(100 + j))
+			assertNoError(t, err, "写入应该成功")
+		}
+		assertEqual(t, int64(4*(i+1)), limiter.callCount(), "有配额时每次写入应该按需申请")
+		assertEqual(t, int64(406*(i+1)), limiter.tokenCount(), "两个写入器应该申请相同的令牌")
+	}
+	assertEqual(t, int64(10000-2*406), atomic.LoadInt64(&quota), "两个写入器应该扣除同一份配额")
+}
+
+// TestNewFromConfig_RoundTrip 测试名称、分阶段、错误反馈和软配额阈值经配置往返后仍然生效
+//
+// 测试目标：
+//   - Config 提取限制器名称、分阶段链、错误反馈、配额阈值和计数时机
+//   - 重建的写入器从初始阶段开始，按相同的边界切换到稳定阶段
+//   - 错误反馈的缩放和阈值回调在重建的写入器上同样生效
+func TestNewFromConfig_RoundTrip(t *testing.T) {
+	// Arrange
+	quota := int64(1000)
+	readPhase, steady := &countingLimiter{}, &countingLimiter{}
+	var fired atomic.Int64
+	original := NewDiscardWriter(nil,
+		WithPhaseLimiters(100, []Limiter{readPhase}, []Limiter{steady}),
+		WithLimiterNames([]string{"read"}),
+		WithErrorFeedback(func() float64 { return 0.5 }, nil),
+		WithSharedQuota(&quota),
+		WithQuotaThreshold(0.1, func(int64) { fired.Add(1) }),
+		WithCountAfterWrite(true),
+	)
+
+	// Act
+	cfg := original.Config()
+	rebuilt := NewFromConfig(cfg)
+	rebuiltCfg := rebuilt.Config()
+
+	// Assert: 配置字段往返一致
+	assertEqual(t, "read", rebuiltCfg.LimiterNames[0], "限制器名称应该往返一致")
+	assertEqual(t, int64(100), rebuiltCfg.PhaseBoundary, "阶段边界应该往返一致")
+	assertEqual(t, Limiter(readPhase), rebuiltCfg.Limiters[0], "初始阶段链应该往返一致")
+	assertEqual(t, Limiter(steady), rebuiltCfg.SteadyLimiters[0], "稳定阶段链应该往返一致")
+	assertEqual(t, true, rebuiltCfg.ErrorReport != nil, "错误反馈应该往返一致")
+	assertEqual(t, 1, len(rebuiltCfg.QuotaThresholds), "配额阈值应该往返一致")
+	assertEqual(t, true, rebuiltCfg.CountAfterWrite, "计数时机应该往返一致")
+
+	// 两个写入器跨越阶段边界的相同写入应该产生相同的令牌申请
+	var readTokens, steadyTokens [2]int64
+	for i, writer := range []*DiscardWriter{rebuilt, original} {
+		beforeRead, beforeSteady := readPhase.tokenCount(), steady.tokenCount()
+		_, err := writer.Write(createTestData(200))
+		assertNoError(t, err, "写入应该成功")
+		readTokens[i] = readPhase.tokenCount() - beforeRead
+		steadyTokens[i] = steady.tokenCount() - beforeSteady
+	}
+	assertEqual(t, int64(200), readTokens[0], "错误率 0.5 时初始阶段的 100 字节应该申请双倍令牌")
+	assertEqual(t, int64(200), steadyTokens[0], "越过边界的部分应该在稳定阶段链上申请")
+	assertEqual(t, readTokens[0], readTokens[1], "初始阶段的令牌申请应该一致")
+	assertEqual(t, steadyTokens[0], steadyTokens[1], "稳定阶段的令牌申请应该一致")
+	assertEqual(t, int64(2), fired.Load(), "每个写入器都应该各自触发一次阈值")
+}
+
+// TestWriterConfig_JSON 测试配置的标量字段可以序列化
+func TestWriterConfig_JSON(t *testing.T) {
+	// Arrange
+	writer := NewDiscardWriter(nil, WithBatchSize(1024), WithRequestCounterGranularity(8))
+
+	// Act
+	data, err := json.Marshal(writer.Config())
+	assertNoError(t, err, "序列化应该成功")
+
+	var decoded WriterConfig
+	assertNoError(t, json.Unmarshal(data, &decoded), "反序列化应该成功")
+
+	// Assert
+	assertEqual(t, int64(1024), decoded.BatchSize, "批次大小应该往返一致")
+	assertEqual(t, uint64(8), decoded.RequestCounterGranularity, "计数粒度应该往返一致")
+}
@@ -0,0 +1,40 @@
+package ratelimited
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"golang.org/x/time/rate"
+)
+
+// TestNewWriter_ForwardsToDestination 测试限流写入器把数据写入下游
+//
+// 测试目标：
+//   - 通过准入的数据原样写入下游
+//   - 配额和计数器选项与丢弃写入器一致地生效
+func TestNewWriter_ForwardsToDestination(t *testing.T) {
+	// Arrange
+	var dst bytes.Buffer
+	quota := int64(600)
+	var bytesWritten int64
+	writer := NewWriter(&dst, Chain(rate.NewLimiter(rate.Inf, 0)),
+		WithSharedQuota(&quota),
+		WithBytesCounter(&bytesWritten),
+	)
+	source := createTestData(1000)
+
+	// Act
+	copied, err := io.Copy(writer, bytes.NewReader(source))
+
+	// Assert
+	if err != io.ErrShortWrite {
+		t.Fatalf("配额不足时 io.Copy 应该报告短写，实际为 %v", err)
+	}
+	assertEqual(t, int64(600), copied, "应该复制配额内的字节")
+	if !bytes.Equal(source[:600], dst.Bytes()) {
+		t.Error("下游应该收到原样的数据")
+	}
+	assertAtomicEqual(t, 600, &bytesWritten, "字节计数器应该生效")
+	assertAtomicEqual(t, 0, &quota, "配额应该被耗尽")
+}
@@ -0,0 +1,149 @@
+// Package wsmw 提供限制 WebSocket 连接带宽和帧速率的适配器
+//
+// 适配器包装任何提供 ReadMessage / WriteMessage 的连接（如 gorilla/websocket 的 *Conn），
+// 消息负载经过限制器链，还可以额外限制每秒的帧数：
+//
+//	conn := wsmw.Wrap(wsConn, ratelimited.Chain(inbound), ratelimited.Chain(outbound),
+//	    wsmw.WithFrameLimiters(rate.NewLimiter(50, 50), rate.NewLimiter(50, 50)))
+//	_, msg, err := conn.ReadMessage()
+//
+// 只需要限制字节速率时，也可以在握手之前用 ratelimited.WrapConn 包装底层 net.Conn
+// （例如通过拨号器的 NetDial 或服务端 Hijack 得到的连接），此时帧头也计入字节数。
+package wsmw
+
+import (
+	"context"
+	"errors"
+
+	"github.com/lwmacct/250918-go-pkg-ratelimited/pkg/ratelimited"
+)
+
+// MessageConn 按消息读写的 WebSocket 连接
+type MessageConn interface {
+	// ReadMessage 读取下一条消息，返回消息类型和负载
+	ReadMessage() (messageType int, p []byte, err error)
+	// WriteMessage 写入一条消息
+	WriteMessage(messageType int, data []byte) error
+}
+
+// config 适配器的配置
+type config struct {
+	ctx         context.Context
+	readFrames  ratelimited.Limiter
+	writeFrames ratelimited.Limiter
+	writerOpts  []ratelimited.DiscardWriterOption
+}
+
+// Option 适配器的配置选项
+type Option func(*config)
+
+// WithFrameLimiters 按帧限速，每读取或写入一条消息从对应的限制器申请 1 个令牌
+//
+// 传入 nil 的方向不限制帧速率。帧限制与字节限制同时生效。
+func WithFrameLimiters(read, write ratelimited.Limiter) Option {
+	return func(c *config) {
+		c.readFrames = read
+		c.writeFrames = write
+	}
+}
+
+// WithContext 设置等待令牌使用的上下文，通常是连接的生命周期上下文，默认不会取消
+func WithContext(ctx context.Context) Option {
+	return func(c *config) {
+		if ctx != nil {
+			c.ctx = ctx
+		}
+	}
+}
+
+// WithWriterOptions 设置两个方向字节限速使用的写入器选项，例如 WithSharedQuota、WithMaxWait
+func WithWriterOptions(opts ...ratelimited.DiscardWriterOption) Option {
+	return func(c *config) {
+		c.writerOpts = append(c.writerOpts, opts...)
+	}
+}
+
+// Conn 限速的 WebSocket 连接，读写方向分别由独立的限制器链限流
+//
+// 负载字节按消息计费：读取在收到消息之后计费（限速体现为下一次读取之前的等待），
+// 写入在发出消息之前计费。Conn 的读写方法与底层连接一样，不能被多个 goroutine 同时调用同一方向。
+type Conn struct {
+	MessageConn
+	cfg    config
+	reader *ratelimited.DiscardWriter
+	writer *ratelimited.DiscardWriter
+}
+
+// Wrap 包装 c，读取的负载经过 readChain 限流、写入的负载经过 writeChain 限流
+func Wrap(c MessageConn, readChain, writeChain []ratelimited.Limiter, opts ...Option) *Conn {
+	cfg := config{ctx: context.Background()}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	writerOpts := append([]ratelimited.DiscardWriterOption{ratelimited.WithContext(cfg.ctx)}, cfg.writerOpts...)
+	return &Conn{
+		MessageConn: c,
+		cfg:         cfg,
+		reader:      ratelimited.NewDiscardWriter(readChain, writerOpts...),
+		writer:      ratelimited.NewDiscardWriter(writeChain, writerOpts...),
+	}
+}
+
+// ReadMessage 读取下一条消息，并按读方向的帧限制和限制器链计费
+//
+// 计费失败（上下文取消、配额耗尽等）时返回已读取的消息和错误。
+func (c *Conn) ReadMessage() (int, []byte, error) {
+	messageType, p, err := c.MessageConn.ReadMessage()
+	if err != nil {
+		return messageType, p, err
+	}
+	if err := c.waitFrame(c.cfg.readFrames); err != nil {
+		return messageType, p, err
+	}
+	if len(p) > 0 {
+		if err := c.reader.Charge(len(p)); err != nil {
+			return messageType, p, err
+		}
+	}
+	return messageType, p, nil
+}
+
+// WriteMessage 按写方向的帧限制和限制器链计费后写入一条消息
+func (c *Conn) WriteMessage(messageType int, data []byte) error {
+	if err := c.waitFrame(c.cfg.writeFrames); err != nil {
+		return err
+	}
+	if len(data) > 0 {
+		if err := c.writer.Charge(len(data)); err != nil {
+			return err
+		}
+	}
+	return c.MessageConn.WriteMessage(messageType, data)
+}
+
+// BytesRead 返回已读取的负载字节数
+func (c *Conn) BytesRead() int64 {
+	return c.reader.Stats().Bytes
+}
+
+// BytesWritten 返回已写入的负载字节数
+func (c *Conn) BytesWritten() int64 {
+	return c.writer.Stats().Bytes
+}
+
+// Close 关闭两个方向的写入器；底层连接实现了 Close() error 时一并关闭
+func (c *Conn) Close() error {
+	err := errors.Join(c.reader.Close(), c.writer.Close())
+	if closer, ok := c.MessageConn.(interface{ Close() error }); ok {
+		err = errors.Join(err, closer.Close())
+	}
+	return err
+}
+
+// waitFrame 为一帧申请 1 个令牌，limiter 为 nil 时不限制
+func (c *Conn) waitFrame(limiter ratelimited.Limiter) error {
+	if limiter == nil {
+		return nil
+	}
+	return limiter.WaitN(c.cfg.ctx, 1)
+}
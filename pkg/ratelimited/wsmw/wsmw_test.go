@@ -0,0 +1,117 @@
+package wsmw
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync/atomic"
+	"testing"
+
+	"github.com/lwmacct/250918-go-pkg-ratelimited/pkg/ratelimited"
+)
+
+// countingLimiter 记录调用次数和申请令牌总数的限制器
+type countingLimiter struct {
+	calls  int64
+	tokens int64
+}
+
+func (c *countingLimiter) WaitN(ctx context.Context, n int) error {
+	atomic.AddInt64(&c.calls, 1)
+	atomic.AddInt64(&c.tokens, int64(n))
+	return nil
+}
+
+// fakeConn 从队列读取消息、记录写入消息的连接
+type fakeConn struct {
+	incoming [][]byte
+	written  [][]byte
+	closed   bool
+}
+
+func (c *fakeConn) ReadMessage() (int, []byte, error) {
+	if len(c.incoming) == 0 {
+		return 0, nil, io.EOF
+	}
+	p := c.incoming[0]
+	c.incoming = c.incoming[1:]
+	return 1, p, nil
+}
+
+func (c *fakeConn) WriteMessage(messageType int, data []byte) error {
+	c.written = append(c.written, data)
+	return nil
+}
+
+func (c *fakeConn) Close() error {
+	c.closed = true
+	return nil
+}
+
+// TestConn_ThrottlesBytesAndFrames 测试消息负载计入字节链、每条消息计入帧限制器
+//
+// 测试目标：
+//   - 读写两个方向分别统计负载字节数
+//   - 每条消息从帧限制器申请 1 个令牌
+//   - Close 关闭底层连接
+func TestConn_ThrottlesBytesAndFrames(t *testing.T) {
+	// Arrange
+	underlying := &fakeConn{incoming: [][]byte{[]byte("hello"), []byte("world!")}}
+	readFrames := &countingLimiter{}
+	writeFrames := &countingLimiter{}
+	conn := Wrap(underlying,
+		[]ratelimited.Limiter{&countingLimiter{}}, []ratelimited.Limiter{&countingLimiter{}},
+		WithFrameLimiters(readFrames, writeFrames))
+
+	// Act
+	for {
+		if _, _, err := conn.ReadMessage(); err != nil {
+			break
+		}
+	}
+	for range 3 {
+		if err := conn.WriteMessage(1, []byte("pong")); err != nil {
+			t.Fatalf("写入失败: %v", err)
+		}
+	}
+
+	// Assert
+	if got := conn.BytesRead(); got != 11 {
+		t.Errorf("应该读取 11 字节负载，实际 %d", got)
+	}
+	if got := conn.BytesWritten(); got != 12 {
+		t.Errorf("应该写入 12 字节负载，实际 %d", got)
+	}
+	if got := atomic.LoadInt64(&readFrames.tokens); got != 2 {
+		t.Errorf("读方向应该申请 2 帧，实际 %d", got)
+	}
+	if got := atomic.LoadInt64(&writeFrames.tokens); got != 3 {
+		t.Errorf("写方向应该申请 3 帧，实际 %d", got)
+	}
+	if len(underlying.written) != 3 {
+		t.Errorf("应该写出 3 条消息，实际 %d", len(underlying.written))
+	}
+	if err := conn.Close(); err != nil || !underlying.closed {
+		t.Errorf("Close 应该关闭底层连接，err=%v", err)
+	}
+}
+
+// TestConn_QuotaExhausted 测试配额耗尽时写入被拒绝且不写出消息
+func TestConn_QuotaExhausted(t *testing.T) {
+	// Arrange
+	quota := int64(5)
+	underlying := &fakeConn{}
+	conn := Wrap(underlying, nil, []ratelimited.Limiter{&countingLimiter{}},
+		WithWriterOptions(ratelimited.WithSharedQuota(&quota)))
+
+	// Act
+	err := conn.WriteMessage(1, []byte("too long"))
+
+	// Assert
+	if !errors.Is(err, ratelimited.ErrQuotaExhausted) {
+		t.Errorf("配额不足时应该返回 ErrQuotaExhausted，实际 %v", err)
+	}
+	if len(underlying.written) != 0 {
+		t.Error("被拒绝的消息不应该写出")
+	}
+}